@@ -1,18 +1,53 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/netrecon/toolkit/internal/analysis"
+	"github.com/netrecon/toolkit/internal/api"
+	"github.com/netrecon/toolkit/internal/clock"
 	"github.com/netrecon/toolkit/internal/config"
+	"github.com/netrecon/toolkit/internal/coordinator"
 	"github.com/netrecon/toolkit/internal/database"
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/enrich"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/notify"
+	"github.com/netrecon/toolkit/internal/output"
+	"github.com/netrecon/toolkit/internal/remote"
+	"github.com/netrecon/toolkit/internal/rpc"
 	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/sink"
+	"github.com/netrecon/toolkit/internal/tracing"
 	"github.com/netrecon/toolkit/pkg/masscan"
+	"github.com/netrecon/toolkit/pkg/naabu"
 	"github.com/netrecon/toolkit/pkg/nmap"
+	"github.com/netrecon/toolkit/pkg/rustscan"
 )
 
 // Version information - set via ldflags during build
@@ -26,14 +61,30 @@ var (
 var (
 	cfgFile    string
 	verbose    bool
+	noDB       bool
 	configFlag string
 	logger     *logrus.Logger
 	cfg        *config.Config
 	db         *database.DB
 	repo       *database.Repository
 	scanMgr    *scanner.ScannerManager
+	resolver   *enrich.Resolver
+
+	// clk supplies the time to commands, the repository and scanners; tests
+	// replace it with a clock.Mock
+	clk clock.Clock = clock.Real{}
+
+	// lookupCache holds DNS and reputation answers shared by enrichers
+	lookupCache *enrich.Cache
+
+	// shutdownTracing flushes spans still buffered for export
+	shutdownTracing = func(context.Context) error { return nil }
 )
 
+// skipDatabaseAnnotation marks commands that run without a database connection
+// or scanner registration
+const skipDatabaseAnnotation = "netrecon/skip-database"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "netrecon",
@@ -45,7 +96,15 @@ and integrates with popular scanning tools like Nmap and Masscan.`,
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if shutdownErr := shutdownTracing(ctx); shutdownErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to flush traces: %v\n", shutdownErr)
+	}
+	cancel()
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -55,6 +114,7 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.netrecon/config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&noDB, "no-db", false, "run without a database (results are not saved)")
 
 	// Add subcommands
 	rootCmd.AddCommand(
@@ -63,6 +123,16 @@ func init() {
 		newResultCmd(),
 		newConfigCmd(),
 		newServerCmd(),
+		newRPCCmd(),
+		newDBCmd(),
+		newInventoryCmd(),
+		newAuditCmd(),
+		newExclusionCmd(),
+		newReparseCmd(),
+		newSalvageCmd(),
+		newVerifySignatureCmd(),
+		newValidateCmd(),
+		newScannersCmd(),
 		newVersionCmd(),
 	)
 }
@@ -70,6 +140,7 @@ func init() {
 func initializeApp(cmd *cobra.Command, args []string) error {
 	// Initialize logger
 	logger = logrus.New()
+	logger.AddHook(tracing.LogHook{})
 	if verbose {
 		logger.SetLevel(logrus.DebugLevel)
 	}
@@ -86,215 +157,3182 @@ func initializeApp(cmd *cobra.Command, args []string) error {
 		logger.SetLevel(level)
 	}
 
-	// Initialize database connection
-	dbConfig := database.Config{
-		Host:     cfg.Database.Host,
-		Port:     cfg.Database.Port,
-		User:     cfg.Database.User,
-		Password: cfg.Database.Password,
-		DBName:   cfg.Database.DBName,
-		SSLMode:  cfg.Database.SSLMode,
-	}
+	lookupCache = enrich.NewCache(cfg.Enrich.Cache.Size, time.Duration(cfg.Enrich.Cache.TTL)*time.Second)
+	resolver = enrich.NewResolver(nil, lookupCache)
 
-	db, err = database.NewConnection(dbConfig, logger)
+	shutdownTracing, err = tracing.Setup(cmd.Context(), tracing.Config{
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		Version:     version,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
 	if err != nil {
-		logger.Warnf("Database connection failed: %v", err)
-		// Continue without database for some commands
-	} else {
-		// Run migrations
-		if err := db.Migrate("./migrations"); err != nil {
-			logger.Warnf("Migration failed: %v", err)
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	// Some commands must never touch the database
+	if cmd.Annotations[skipDatabaseAnnotation] == "true" {
+		return nil
+	}
+
+	if noDB {
+		if flag := cmd.Flags().Lookup("save-db"); flag != nil && flag.Changed && flag.Value.String() == "true" {
+			return fmt.Errorf("--save-db cannot be combined with --no-db")
+		}
+		logger.Debug("Database disabled with --no-db")
+	} else if err := connectDatabase(); err != nil {
+		// Running against a partly migrated schema could corrupt data, so
+		// this stops every command rather than falling back to no database
+		if errors.Is(err, database.ErrMigrationFailed) {
+			return fmt.Errorf("%w (fix the migration, or set database.ignore_migration_errors to start anyway)", err)
+		}
+		// Saving was requested, so silently dropping results would hide a config error
+		if flag := cmd.Flags().Lookup("save-db"); flag != nil && flag.Value.String() == "true" {
+			return fmt.Errorf("%w (use --no-db to run without saving results)", err)
 		}
-		repo = database.NewRepository(db)
+		logger.Warnf("%v", err)
 	}
 
-	// Initialize scanner manager
-	scanMgr = scanner.NewScannerManager()
+	scanMgr = newLocalScannerManager()
+
+	return nil
+}
+
+// newLocalScannerManager registers the scanners installed on this machine
+func newLocalScannerManager() *scanner.ScannerManager {
+	mgr := scanner.NewScannerManager()
+	mgr.SetClock(clk)
 
-	// Register scanners
 	if nmapScanner, err := nmap.NewScanner(); err == nil {
-		scanMgr.RegisterScanner(nmapScanner)
+		mgr.RegisterScanner(nmapScanner)
 	} else {
 		logger.Warnf("Nmap scanner not available: %v", err)
 	}
 
 	if masscanScanner, err := masscan.NewScanner(); err == nil {
-		scanMgr.RegisterScanner(masscanScanner)
+		mgr.RegisterScanner(masscanScanner)
 	} else {
 		logger.Warnf("Masscan scanner not available: %v", err)
 	}
 
+	if rustscanScanner, err := rustscan.NewScanner(); err == nil {
+		mgr.RegisterScanner(rustscanScanner)
+	} else {
+		logger.Warnf("RustScan scanner not available: %v", err)
+	}
+
+	if naabuScanner, err := naabu.NewScanner(); err == nil {
+		naabuScanner.SetMaxThreads(cfg.Scanner.MaxThreads)
+		mgr.RegisterScanner(naabuScanner)
+	} else {
+		logger.Warnf("Naabu scanner not available: %v", err)
+	}
+
+	return mgr
+}
+
+// maxRetryBackoff caps the doubling delay between database retries
+const maxRetryBackoff = 5 * time.Second
+
+// saveTimeout bounds storing a scan result once the scan is over, which no
+// longer runs under the scan's own deadlines
+const saveTimeout = 2 * time.Minute
+
+// connectDatabase opens the database, runs migrations and sets up the repository
+func connectDatabase() error {
+	dbConfig := database.Config{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+		Retry: database.RetryPolicy{
+			Attempts:   cfg.Database.RetryAttempts,
+			Backoff:    time.Duration(cfg.Database.RetryBackoffMs) * time.Millisecond,
+			MaxBackoff: maxRetryBackoff,
+		},
+		Breaker: database.BreakerPolicy{
+			Threshold: cfg.Database.BreakerThreshold,
+			Cooldown:  time.Duration(cfg.Database.BreakerCooldown) * time.Second,
+		},
+	}
+
+	var err error
+	db, err = database.NewConnection(dbConfig, logger)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+
+	if err := db.Migrate("./migrations"); err != nil {
+		if !cfg.Database.IgnoreMigrationErrors {
+			db.Close()
+			db = nil
+			return err
+		}
+		logger.Errorf("%v (continuing because database.ignore_migration_errors is set)", err)
+	}
+	repo = database.NewRepository(db)
+	repo.SetClock(clk)
 	return nil
 }
 
 // newScanCmd creates the scan command
 func newScanCmd() *cobra.Command {
 	var (
-		scanner      string
-		ports        string
-		timing       string
-		arguments    string
-		outputFile   string
-		outputFormat string
-		saveDB       bool
-		threads      int
+		scannerName   string
+		outputFile    string
+		outputFormat  string
+		saveDB        bool
+		sinkNames     []string
+		via           string
+		digest        bool
+		estimate      bool
+		serviceGroups []string
+		allowLocal    bool
+		correlationID string
+		differential  int
+		expression    string
+		tail          bool
+		internetScale bool
+		shardHosts    uint64
+		shardPorts    int
+		mode          string
+		fields        []string
+		meta          []string
+		repeat        int
+		aggregate     bool
+		flags         scanFlags
 	)
 
 	scanCmd := &cobra.Command{
 		Use:   "scan [target]",
 		Short: "Perform network scan",
-		Long:  "Perform network reconnaissance scan on the specified target",
-		Args:  cobra.ExactArgs(1),
+		Long: `Perform network reconnaissance scan on the specified target. Use - to read newline-separated targets from stdin,
+each optionally followed by its own ports ("10.0.0.0/24 22,80"). Targets
+sharing the same ports are scanned by one scanner process, then saved and
+published per target.
+
+Instead of a target, --expr takes a target expression combining targets,
+exclusions and ports, e.g. "10.0.0.0/24 exclude 10.0.0.1-10 and :22,80,443".
+Ports in the expression replace --ports and --service-group.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			target := args[0]
+			var parsed *scanner.TargetExpression
+			switch {
+			case expression != "" && len(args) > 0:
+				return fmt.Errorf("give either a target or --expr, not both")
+			case expression != "":
+				var err error
+				if parsed, err = scanner.ParseTargetExpression(expression); err != nil {
+					return err
+				}
+			case len(args) == 0:
+				return fmt.Errorf("a target or --expr is required")
+			}
 
-			// Check scanner availability
-			_, exists := scanMgr.GetScanner(scanner)
-			if !exists {
-				fmt.Printf("⚠️  Scanner '%s' not available, using simulation mode\n", scanner)
+			if flags.maxHosts == 0 {
+				flags.maxHosts = cfg.Scanner.MaxHosts
 			}
-			fmt.Printf("🔍 Starting scan of %s with %s...\n", target, scanner)
-			
-			// For demo purposes, let's run nmap directly
-			if scanner == "nmap" {
-				fmt.Printf("📡 Running: nmap -p %s %s\n", ports, target)
+
+			if cmd.Flags().Changed("correlation-id") {
+				if err := scanner.ValidateCorrelationID(correlationID); err != nil {
+					return err
+				}
 			} else {
-				fmt.Printf("📡 Running: %s scan on %s (ports: %s)\n", scanner, target, ports)
+				correlationID = scanner.NewCorrelationID()
 			}
-			
-			// Simulate scan completion
-			fmt.Printf("🎯 Scan completed successfully!\n")
-			fmt.Printf("📍 Target: %s\n", target)
-			fmt.Printf("🔧 Scanner: %s\n", scanner) 
-			fmt.Printf("✅ Status: completed\n")
-			fmt.Printf("⏱️  Duration: 2.5s (simulated)\n")
-			fmt.Printf("🖥️  Hosts found: 1\n")
-			
-			fmt.Printf("\n📋 Discovered Hosts:\n")
-			fmt.Printf("  1. IP: %s - Status: up - Ports: %s\n", target, ports)
 
+			// Service groups replace the default port range but add to explicit --ports
+			if len(serviceGroups) > 0 {
+				explicit := ""
+				if cmd.Flags().Changed("ports") {
+					explicit = flags.ports
+				}
+				ports, err := scanner.CombineServiceGroups(explicit, serviceGroups)
+				if err != nil {
+					return err
+				}
+				flags.ports = ports
+			}
+			if parsed != nil && parsed.Ports != "" {
+				flags.ports = parsed.Ports
+			}
 
-			// Save to database if requested
-			if saveDB && repo != nil {
-				logger.Info("💾 Saving results to database...")
-				// TODO: Implement database saving
+			// Fall back to the configured order unless a scanner was chosen explicitly
+			var fallback []string
+			if !cmd.Flags().Changed("scanner") {
+				scannerName = cfg.Scanner.DefaultScanner
+				fallback = cfg.Scanner.Fallback
 			}
 
-			// Save to file if requested
-			if outputFile != "" {
-				logger.Infof("💾 Saving results to file: %s", outputFile)
-				// TODO: Implement file saving with formatters
+			projection, err := newProjection(fields, outputFormat)
+			if err != nil {
+				return err
+			}
+
+			metadata, err := database.ParseMetadata(meta)
+			if err != nil {
+				return err
+			}
+
+			scripts, err := scanner.ModeScripts(mode, cfg.Scanner.Nmap.VulnScripts)
+			if err != nil {
+				return err
+			}
+			flags.scripts = scripts
+
+			// Scripts only run in nmap, so never fall back to another scanner
+			if len(scripts) > 0 {
+				if cmd.Flags().Changed("scanner") && scannerName != "nmap" {
+					return fmt.Errorf("--mode %s requires the nmap scanner", mode)
+				}
+				scannerName = "nmap"
+				fallback = nil
+			}
+
+			var requested []scanner.TargetPorts
+			switch {
+			case parsed != nil:
+				for _, target := range parsed.Targets {
+					requested = append(requested, scanner.TargetPorts{Target: target})
+				}
+			case args[0] == "-":
+				if requested, err = readStdinTargets(cmd.InOrStdin()); err != nil {
+					return err
+				}
+			default:
+				requested = []scanner.TargetPorts{{Target: args[0]}}
+			}
+
+			if estimate {
+				batches, err := planBatches(requested, flags, 0)
+				if err != nil {
+					return err
+				}
+				for _, batch := range batches {
+					scanConfig := buildScanConfig(flags)
+					scanConfig.Ports = batch.Ports
+					if err := printEstimate(batch.Target(), scannerName, scanConfig); err != nil {
+						return fmt.Errorf("cannot estimate scan of %s: %w", batch.Target(), err)
+					}
+				}
+				return nil
+			}
+
+			summary := &notify.Digest{StartedAt: clk.Now()}
+			if digest {
+				defer func() {
+					summary.FinishedAt = clk.Now()
+					sendDigest(summary)
+				}()
+			}
+
+			if differential < 0 {
+				return fmt.Errorf("--differential must not be negative")
+			}
+			if repeat < 1 {
+				return fmt.Errorf("--repeat must be at least 1")
+			}
+			if aggregate && repeat < 2 {
+				return fmt.Errorf("--aggregate needs --repeat of at least 2")
+			}
+
+			// Aggregated repeats run within one scan; otherwise every target
+			// is scanned once per round
+			runs, merged := repeat, 1
+			if aggregate {
+				runs, merged = 1, repeat
 			}
 
+			if internetScale {
+				if differential > 0 {
+					return fmt.Errorf("--internet-scale cannot be combined with --differential")
+				}
+				if repeat > 1 {
+					return fmt.Errorf("--internet-scale cannot be combined with --repeat")
+				}
+				targets := make([]string, len(requested))
+				for i, pair := range requested {
+					if pair.Ports != "" {
+						return fmt.Errorf("--internet-scale scans every target on --ports; remove the ports given for %s", pair.Target)
+					}
+					targets[i] = pair.Target
+				}
+				return runCampaign(campaignRun{
+					targets:        targets,
+					scanner:        scannerName,
+					fallback:       fallback,
+					config:         buildScanConfig(flags),
+					shardHosts:     shardHosts,
+					shardPorts:     shardPorts,
+					allowLocalhost: allowLocal,
+					correlationID:  correlationID,
+					metadata:       metadata,
+					tail:           tail,
+				})
+			}
+
+			batches, err := planBatches(requested, flags, differential)
+			if err != nil {
+				return err
+			}
+
+			scans := slices.Repeat(batches, runs)
+			for i, batch := range scans {
+				// Several scans write numbered files instead of overwriting one
+				file := outputFile
+				if file != "" && len(scans) > 1 {
+					file = numberedPath(outputFile, i+1)
+				}
+
+				target := batch.Target()
+				scanConfig := buildScanConfig(flags)
+				scanConfig.Ports = batch.Ports
+
+				entry := notify.DigestScan{Target: target, Status: scanner.StatusCompleted, CorrelationID: correlationID}
+				err := runScan(scanRun{
+					target:       target,
+					scanner:      scannerName,
+					fallback:     fallback,
+					config:       scanConfig,
+					outputFile:   file,
+					outputFormat: outputFormat,
+					projection:   projection,
+					saveDB:       saveDB,
+					sinkNames:    sinkNames,
+					via:          via,
+
+					allowLocalhost: allowLocal,
+					correlationID:  correlationID,
+					metadata:       metadata,
+					tail:           tail,
+					repeat:         merged,
+					digest:         &entry,
+				})
+
+				if file != "" && outputFormat == "html" {
+					entry.ReportPath = file
+				}
+				if err != nil {
+					entry.Status = scanner.StatusFailed
+				}
+				summary.Scans = append(summary.Scans, entry)
+
+				if err != nil {
+					return fmt.Errorf("scan of %s failed: %w", target, err)
+				}
+			}
 			return nil
 		},
 	}
 
-	scanCmd.Flags().StringVarP(&scanner, "scanner", "s", "nmap", "Scanner to use (nmap, masscan)")
-	scanCmd.Flags().StringVarP(&ports, "ports", "p", "1-1000", "Port range to scan")
-	scanCmd.Flags().StringVarP(&timing, "timing", "T", "4", "Timing template (0-5 for nmap)")
-	scanCmd.Flags().StringVarP(&arguments, "args", "A", "", "Additional scanner arguments")
+	scanCmd.Flags().StringVarP(&scannerName, "scanner", "s", "", "Scanner to use (nmap, masscan, rustscan, naabu; default from scanner.default_scanner)")
+	scanCmd.Flags().StringVarP(&flags.ports, "ports", "p", "1-1000", "Port range to scan")
+	scanCmd.Flags().StringVar(&mode, "mode", scanner.ModeDefault, "Scan mode: default, or vuln to run the scanner.nmap.vuln_scripts NSE scripts and store their vulnerabilities (nmap)")
+	scanCmd.Flags().StringVar(&expression, "expr", "", "Target expression with exclusions and ports, e.g. \"10.0.0.0/24 exclude 10.0.0.1-10 and :22,80\"")
+	scanCmd.Flags().StringSliceVar(&serviceGroups, "service-group", nil, "Scan the ports of service groups: "+strings.Join(scanner.ServiceGroupNames(), ", ")+" (added to --ports when given)")
+	scanCmd.Flags().StringVarP(&flags.timing, "timing", "T", "4", "Timing template (0-5 for nmap)")
+	scanCmd.Flags().StringVarP(&flags.arguments, "args", "A", "", "Additional scanner arguments")
 	scanCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file")
-	scanCmd.Flags().StringVarP(&outputFormat, "format", "f", "json", "Output format (json, xml, csv, html)")
+	scanCmd.Flags().StringVarP(&outputFormat, "format", "f", "json", "Output format (json, xml, csv, html, cmdb, hosts, iplist, cef, capfilter, markdown, text)")
+	scanCmd.Flags().StringSliceVar(&fields, "fields", nil, "Restrict json or csv output to these fields, as JSON keys with dots for nested ones (e.g. hosts.ip_address,hosts.ports.number)")
 	scanCmd.Flags().BoolVar(&saveDB, "save-db", true, "Save results to database")
-	scanCmd.Flags().IntVar(&threads, "threads", 1000, "Number of threads/rate")
+	scanCmd.Flags().StringSliceVar(&sinkNames, "sink", nil, "Publish results to message queue sinks (nats)")
+	scanCmd.Flags().StringVar(&via, "via", "", "Run the scanner on a jump host (ssh://user@host[:port])")
+	scanCmd.Flags().IntVar(&differential, "differential", 0, "Only scan ports previously seen open on the target, with the full --ports range every N runs")
+	scanCmd.Flags().StringVar(&correlationID, "correlation-id", "", "Key tying the scan to an external workflow, echoed in sinks, digests and the API (default: random UUID)")
+	scanCmd.Flags().StringArrayVar(&meta, "meta", nil, "Attach key=value metadata to the stored scan, e.g. --meta env=prod --meta ticket=OPS-42 (repeatable)")
+	scanCmd.Flags().BoolVar(&allowLocal, "allow-localhost", false, "Allow targets that resolve to loopback or this machine's own addresses")
+	scanCmd.Flags().BoolVar(&tail, "tail", false, "Stream the scanner's raw stdout and stderr to the terminal while it runs")
+	scanCmd.Flags().BoolVar(&estimate, "estimate", false, "Print the estimated duration, packet count and command without scanning")
+	scanCmd.Flags().BoolVar(&internetScale, "internet-scale", false, "Scan in checkpointed shards that resume after an interruption, with --threads as the global rate")
+	scanCmd.Flags().Uint64Var(&shardHosts, "shard-size", coordinator.DefaultShardHosts, "Largest address block per --internet-scale shard")
+	scanCmd.Flags().IntVar(&shardPorts, "shard-ports", 1, "Parts the port range is split into per --internet-scale target block")
+	scanCmd.Flags().IntVar(&repeat, "repeat", 1, "Scan each target N times in a row")
+	scanCmd.Flags().BoolVar(&aggregate, "aggregate", false, "Merge the --repeat runs into one result: ports open in any run are open, with the fraction of runs that saw them as hit_ratio")
+	scanCmd.Flags().BoolVar(&digest, "digest", false, "Email a summary digest when the run finishes (notify.smtp)")
+	scanCmd.Flags().IntVar(&flags.threads, "threads", 1000, "Number of threads/rate (batch size for rustscan, concurrency for naabu)")
+	scanCmd.Flags().IntVar(&flags.maxHosts, "max-hosts", 0, "Maximum hosts to keep from a scan (default from scanner.max_hosts)")
+	scanCmd.Flags().IntVar(&flags.wait, "wait", masscan.DefaultWait, "Seconds masscan waits for late responses")
+	scanCmd.Flags().IntVar(&flags.retries, "retries", masscan.DefaultRetries, "Masscan probe retransmissions")
+	scanCmd.Flags().StringSliceVar(&flags.dnsServers, "dns-servers", nil, "DNS servers nmap resolves through (comma-separated IPs)")
+	scanCmd.Flags().BoolVar(&flags.noDNS, "no-dns", false, "Disable DNS resolution (nmap -n)")
+	scanCmd.Flags().BoolVar(&flags.udp, "udp", false, "Scan UDP ports (nmap -sU with protocol-specific probes); shorthand for --protocol udp")
+	scanCmd.Flags().StringVar(&flags.protocol, "protocol", "", "Protocol to scan: tcp, udp or both (nmap -sU -sS); only nmap scans UDP")
+	scanCmd.Flags().IntVar(&flags.intensity, "version-intensity", 0, "Service detection intensity 1-9 (default: nmap's, or payload-only probes for --udp)")
+	scanCmd.Flags().IntVar(&flags.split, "split", 0, "Split the port range across N concurrent masscan processes sharing the rate")
+	scanCmd.Flags().BoolVar(&flags.adaptiveRate, "adaptive-rate", false, "Scan with masscan in bursts, ramping the rate up to --threads and backing off on packet loss")
+	scanCmd.Flags().BoolVar(&flags.openOnly, "open", scanner.DefaultOpenOnly, "Only report open ports (nmap --open); use --open=false to keep closed/filtered ports")
+	scanCmd.Flags().BoolVar(&flags.countFiltered, "count-filtered", false, "Record how many ports each host filters and report filtering hosts as firewalled (nmap)")
+	scanCmd.Flags().StringSliceVar(&flags.states, "reported-states", scanner.DefaultReportedStates, "Host states to report: up, down, unknown, skipped (down requires --open=false)")
+
+	scanCmd.AddCommand(newScanRerunCmd())
 
 	return scanCmd
 }
 
-// newTargetCmd creates the target management command
-func newTargetCmd() *cobra.Command {
-	targetCmd := &cobra.Command{
-		Use:   "target",
-		Short: "Manage scan targets",
-		Long:  "Add, list, and manage network scan targets",
-	}
+// newScanRerunCmd creates the command repeating a stored scan with its
+// original scanner and configuration
+func newScanRerunCmd() *cobra.Command {
+	var (
+		outputFile    string
+		outputFormat  string
+		saveDB        bool
+		sinkNames     []string
+		allowLocal    bool
+		correlationID string
+		tail          bool
+		fields        []string
+		meta          []string
+	)
 
-	// Add subcommands
-	targetCmd.AddCommand(
-		&cobra.Command{
-			Use:   "add [target] [description]",
-			Short: "Add a new target",
-			Args:  cobra.RangeArgs(1, 2),
-			RunE: func(cmd *cobra.Command, args []string) error {
-				if repo == nil {
-					return fmt.Errorf("database connection required")
-				}
+	rerunCmd := &cobra.Command{
+		Use:   "rerun [scan-id]",
+		Short: "Re-run a previous scan with the same configuration",
+		Long:  "Scan the target of a stored scan again using the scanner and scan configuration recorded with it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
 
-				target := args[0]
-				description := ""
-				if len(args) > 1 {
-					description = args[1]
-				}
+			scanID, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid scan ID: %w", err)
+			}
 
-				// Implementation would go here
-				fmt.Printf("Added target: %s (description: %s)\n", target, description)
-				return nil
-			},
-		},
-		&cobra.Command{
-			Use:   "list",
-			Short: "List all targets",
-			RunE: func(cmd *cobra.Command, args []string) error {
-				if repo == nil {
-					return fmt.Errorf("database connection required")
+			if cmd.Flags().Changed("correlation-id") {
+				if err := scanner.ValidateCorrelationID(correlationID); err != nil {
+					return err
 				}
+			} else {
+				correlationID = scanner.NewCorrelationID()
+			}
 
-				targets, err := repo.ListScanTargets()
-				if err != nil {
-					return fmt.Errorf("failed to list targets: %w", err)
-				}
+			projection, err := newProjection(fields, outputFormat)
+			if err != nil {
+				return err
+			}
 
-				fmt.Printf("Found %d targets:\n", len(targets))
-				for _, target := range targets {
-					fmt.Printf("- %s (%s): %s\n", target.Target, target.Type, target.Description)
-				}
-				return nil
-			},
+			overrides, err := database.ParseMetadata(meta)
+			if err != nil {
+				return err
+			}
+
+			previous, err := repo.GetScanResult(scanID)
+			if err != nil {
+				return fmt.Errorf("failed to load scan %s: %w", scanID, err)
+			}
+			scanConfig, err := storedScanConfig(previous)
+			if err != nil {
+				return err
+			}
+
+			target, err := repo.GetScanTarget(previous.TargetID)
+			if err != nil {
+				return fmt.Errorf("failed to load target of scan %s: %w", scanID, err)
+			}
+
+			// The rerun keeps the original metadata, with --meta entries replacing its keys
+			metadata := make(models.Metadata, len(previous.Metadata)+len(overrides))
+			for key, value := range previous.Metadata {
+				metadata[key] = value
+			}
+			for key, value := range overrides {
+				metadata[key] = value
+			}
+
+			return runScan(scanRun{
+				target:       target.Target,
+				scanner:      previous.ScanType,
+				config:       scanConfig,
+				outputFile:   outputFile,
+				outputFormat: outputFormat,
+				projection:   projection,
+				saveDB:       saveDB,
+				sinkNames:    sinkNames,
+
+				allowLocalhost: allowLocal,
+				correlationID:  correlationID,
+				metadata:       metadata,
+				action:         "scan.rerun",
+				tail:           tail,
+			})
 		},
-	)
+	}
 
-	return targetCmd
+	rerunCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file")
+	rerunCmd.Flags().StringVarP(&outputFormat, "format", "f", "json", "Output format (json, xml, csv, html, cmdb, hosts, iplist, cef, capfilter, markdown, text)")
+	rerunCmd.Flags().StringSliceVar(&fields, "fields", nil, "Restrict json or csv output to these fields, as JSON keys with dots for nested ones (e.g. hosts.ip_address,hosts.ports.number)")
+	rerunCmd.Flags().BoolVar(&saveDB, "save-db", true, "Save results to database")
+	rerunCmd.Flags().StringSliceVar(&sinkNames, "sink", nil, "Publish results to message queue sinks (nats)")
+	rerunCmd.Flags().StringVar(&correlationID, "correlation-id", "", "Key tying the scan to an external workflow (default: random UUID)")
+	rerunCmd.Flags().StringArrayVar(&meta, "meta", nil, "Set key=value metadata on the new scan, over the metadata copied from the original (repeatable)")
+	rerunCmd.Flags().BoolVar(&allowLocal, "allow-localhost", false, "Allow targets that resolve to loopback or this machine's own addresses")
+	rerunCmd.Flags().BoolVar(&tail, "tail", false, "Stream the scanner's raw stdout and stderr to the terminal while it runs")
+
+	return rerunCmd
 }
 
-// newResultCmd creates the result management command
-func newResultCmd() *cobra.Command {
-	resultCmd := &cobra.Command{
-		Use:   "result",
-		Short: "Manage scan results",
-		Long:  "View and export scan results",
+// printEstimate prints the predicted cost and effective command of a scan
+func printEstimate(target, scannerName string, scanConfig *scanner.ScanConfig) error {
+	estimators := map[string]scanner.Estimator{
+		"nmap":    &nmap.Scanner{},
+		"masscan": &masscan.Scanner{},
+	}
+	estimator, ok := estimators[scannerName]
+	if !ok {
+		return fmt.Errorf("unknown scanner %s", scannerName)
+	}
+	if err := estimator.(scanner.Scanner).ValidateConfig(scanConfig); err != nil {
+		return fmt.Errorf("invalid scan configuration: %w", err)
 	}
 
-	// Add subcommands for result management
-	return resultCmd
+	est, err := estimator.Estimate(target, scanConfig)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📐 Estimate for %s with %s (rough)\n", target, scannerName)
+	fmt.Printf("   Hosts:    %d\n", est.Hosts)
+	fmt.Printf("   Ports:    %d\n", est.Ports)
+	fmt.Printf("   Packets:  %d\n", est.Packets)
+	fmt.Printf("   Rate:     %.1f packets/s\n", est.PacketsPerSecond)
+	fmt.Printf("   Duration: %s\n", scanner.HumanDuration(est.Duration.Milliseconds()))
+	fmt.Printf("   Command:  %s\n", est.Command)
+	return nil
 }
 
-// newConfigCmd creates the config management command
-func newConfigCmd() *cobra.Command {
-	configCmd := &cobra.Command{
-		Use:   "config",
-		Short: "Manage configuration",
-		Long:  "View and modify application configuration",
-	}
+// scanRun describes a single scan invocation
+type scanRun struct {
+	target       string
+	scanner      string
+	fallback     []string // Scanners tried when scanner is unavailable
+	config       *scanner.ScanConfig
+	outputFile   string
+	outputFormat string
+	projection   *output.Projection // Fields json and csv output is restricted to, nil for all
+	saveDB       bool
+	sinkNames    []string
+	via          string // ssh://user@host jump host overriding scanner.remote.ssh
 
-	// Add subcommands for config management
-	return configCmd
+	allowLocalhost bool               // Scan targets pointing at the scanning machine itself
+	correlationID  string             // Caller-supplied or generated key recorded on the result
+	metadata       models.Metadata    // Caller-supplied context stored with the result
+	action         string             // Audit log action, "scan" when empty
+	tail           bool               // Stream the scanner's raw output to the terminal
+	repeat         int                // Runs merged into one result, see scanner.Repeat
+	digest         *notify.DigestScan // Filled with the host counts of the result, if set
 }
 
-// newServerCmd creates the server command
-func newServerCmd() *cobra.Command {
-	serverCmd := &cobra.Command{
-		Use:   "server",
-		Short: "Start web server",
-		Long:  "Start the web interface server",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			// Implementation would go here
-			fmt.Printf("Starting server on %s:%d\n", cfg.Server.Host, cfg.Server.Port)
-			return nil
-		},
+// runScan selects a scanner, runs the scan and delivers the result
+func runScan(run scanRun) (err error) {
+	scannerName := run.scanner
+	target := run.target
+
+	ctx, span := tracing.Start(context.Background(), "scan.run",
+		trace.WithAttributes(attribute.String("netrecon.correlation_id", run.correlationID)))
+	defer func() { tracing.End(span, err) }()
+	log := logger.WithContext(ctx)
+	if run.tail {
+		ctx = scanner.WithTail(ctx, os.Stdout)
 	}
 
-	return serverCmd
+	// Unlike the scanner's timeout, this bounds enrichment too. Results are
+	// saved with baseCtx instead, so a partial result is still stored once
+	// the watchdog has cancelled the scan.
+	baseCtx := ctx
+	ctx, cancel := scanner.WithMaxDuration(ctx, time.Duration(cfg.Scanner.MaxTotalDuration)*time.Second)
+	defer cancel()
+
+	// Check the report format now rather than after a long scan
+	var formatterMgr *output.FormatterManager
+	if run.outputFile != "" {
+		if formatterMgr, err = newFormatterManager(false, ""); err != nil {
+			return err
+		}
+		formatter, err := lookupFormatter(formatterMgr, run.outputFormat)
+		if err != nil {
+			return err
+		}
+		if ext := strings.TrimPrefix(filepath.Ext(run.outputFile), "."); ext != "" && !strings.EqualFold(ext, formatter.GetFileExtension()) {
+			log.Warnf("Output file %s is written as %s despite its .%s extension (expected .%s)",
+				run.outputFile, run.outputFormat, ext, formatter.GetFileExtension())
+		}
+		formatterMgr.SetProjection(run.projection)
+	}
+
+	mgr := scanMgr
+	if run.via != "" || cfg.Scanner.Remote.SSH.Host != "" {
+		runner, err := dialJumpHost(run.via)
+		if err != nil {
+			return err
+		}
+		defer runner.Close()
+		mgr = newRemoteScannerManager(runner)
+	}
+
+	if !run.allowLocalhost {
+		if err := checkLocalTarget(target, mgr != scanMgr); err != nil {
+			return err
+		}
+	}
+	if err := applyExclusions(run.config, target); err != nil {
+		return err
+	}
+
+	// Check scanner availability
+	selected, err := mgr.SelectScanner(scannerName, run.fallback)
+	if err != nil && mgr != scanMgr {
+		return fmt.Errorf("scanner not available on jump host: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w; install it or pick an installed scanner with --scanner (see netrecon scanners)", err)
+	}
+	if name := selected.GetName(); name != scannerName {
+		log.Warnf("Scanner %s not available, using %s", scannerName, name)
+		scannerName = name
+	}
+	if err := selected.ValidateConfig(run.config); err != nil {
+		return fmt.Errorf("invalid scan configuration: %w", err)
+	}
+
+	if err := auditScan(run, scannerName); err != nil {
+		return err
+	}
+
+	// Open sinks before scanning so connection problems surface early
+	sinks, err := newSinkRegistry().Open(run.sinkNames)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sink.CloseAll(sinks); err != nil {
+			log.Warnf("%v", err)
+		}
+	}()
+
+	// Ctrl-C stops the scanner; the hosts it reported so far are kept, and
+	// saved with a context the signal does not cancel
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	scanOnce := func(ctx context.Context) (*scanner.ScanResult, error) {
+		if cfg.Scanner.DefaultTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Scanner.DefaultTimeout)*time.Second)
+			defer cancel()
+		}
+		return scanner.Run(ctx, selected, target, run.config)
+	}
+
+	var result *scanner.ScanResult
+	var scanErr error
+	if run.repeat > 1 {
+		fmt.Printf("🔍 Scanning %s %d times with %s...\n", target, run.repeat, scannerName)
+		result, scanErr = scanner.Repeat(ctx, run.repeat, scanOnce)
+	} else {
+		fmt.Printf("🔍 Starting scan of %s with %s...\n", target, scannerName)
+		result, scanErr = scanOnce(ctx)
+	}
+	if result == nil {
+		return scanErr
+	}
+	for _, notice := range result.Notices {
+		log.Warnf("Scan of %s: %s", target, notice)
+	}
+	result.CorrelationID = run.correlationID
+	result.Metadata = run.metadata
+
+	// A scan stopped by Ctrl-C or its timeout is not enriched
+	if ctx.Err() == nil && (result.Status == scanner.StatusCompleted || result.Status == scanner.StatusCompletedWithErrors) {
+		enrichHosts(ctx, result.Hosts)
+	}
+	if result.MarkOverrun(ctx) {
+		log.Warnf("Scan of %s exceeded scanner.max_total_duration, keeping the partial result", target)
+	}
+	result.Findings = analysis.AnalyzeGraph(scanner.ToStored(result, uuid.Nil, clk.Now()))
+
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(baseCtx), saveTimeout)
+	defer cancelSave()
+
+	// A batch is published and stored as one scan per target, each with
+	// its own new host counts
+	parts := scanner.SplitResult(result)
+	countDiscoveries(saveCtx, append([]*scanner.ScanResult{result}, parts...)...)
+	if run.digest != nil {
+		run.digest.HostCount = len(result.Hosts)
+		run.digest.Discovery = result.Discovery
+	}
+
+	text, err := newTerminalTextFormatter().Format(result)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n%s", text)
+
+	for _, s := range sinks {
+		for _, part := range parts {
+			if err := s.Publish(ctx, part); err != nil {
+				log.Warnf("Failed to publish result: %v", err)
+			}
+		}
+	}
+
+	// Save to database if requested; a failed save still leaves the file
+	var saveErr error
+	if run.saveDB && repo != nil {
+		log.Info("💾 Saving results to database...")
+		for _, part := range parts {
+			scanID, err := saveScanResult(saveCtx, run, part)
+			if err != nil {
+				saveErr = errors.Join(saveErr, err)
+				continue
+			}
+			fmt.Printf("💾 Saved %s as scan %s (see netrecon result export %s)\n", part.Target, scanID, scanID)
+		}
+	}
+
+	// Save to file if requested
+	if formatterMgr != nil {
+		if err := formatterMgr.FormatAndSave(result, run.outputFormat, run.outputFile); err != nil {
+			return fmt.Errorf("failed to save results to %s: %w", run.outputFile, err)
+		}
+		log.Infof("💾 Saved results to %s", run.outputFile)
+	}
+
+	switch {
+	case result.Status == scanner.StatusFailed:
+		return scanErr
+	case saveErr != nil:
+		return saveErr
+	case ctx.Err() != nil && !errors.Is(context.Cause(ctx), scanner.ErrMaxDurationExceeded):
+		return fmt.Errorf("scan interrupted")
+	case !result.Complete:
+		log.Warnf("Scan of %s is incomplete (%s): %s", target, result.Status, result.Error)
+	}
+	return nil
+}
+
+// countDiscoveries counts the hosts and open ports of results that no
+// earlier scan saw, from the port inventory recorded before the first
+// result's scan started. Without a database, or when the inventory cannot
+// be read, the counts are left unset.
+func countDiscoveries(ctx context.Context, results ...*scanner.ScanResult) {
+	if repo == nil || len(results) == 0 {
+		return
+	}
+	start, err := time.Parse(time.RFC3339, results[0].StartTime)
+	if err != nil {
+		start = clk.Now()
+	}
+
+	var addresses []string
+	for _, result := range results {
+		for _, host := range result.Hosts {
+			addresses = append(addresses, host.IPAddress)
+		}
+	}
+	known, err := repo.WithContext(ctx).ListPortAssetsBefore(addresses, start)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("New host counts unavailable: %v", err)
+		return
+	}
+	for _, result := range results {
+		result.Discovery = diff.CountDiscoveries(result.Hosts, known)
+	}
+}
+
+// saveScanResult stores a scan result with its hosts, ports and HTTP probes
+// under its target, adding the target on its first scan, and returns the
+// ID of the stored scan
+func saveScanResult(ctx context.Context, run scanRun, result *scanner.ScanResult) (uuid.UUID, error) {
+	graph, err := storedScan(run, result)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := saveScanGraph(ctx, graph, ""); err != nil {
+		return uuid.Nil, err
+	}
+	return graph.ID, nil
+}
+
+// storedScan converts a scan result to the graph saved for it. The
+// configuration is kept for netrecon rerun, see storedScanConfig.
+func storedScan(run scanRun, result *scanner.ScanResult) (*models.FullScanResult, error) {
+	scanConfig, err := json.Marshal(run.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scan configuration: %w", err)
+	}
+
+	graph := scanner.ToStored(result, uuid.Nil, clk.Now())
+	graph.ID = uuid.New()
+	graph.ScanConfig = scanConfig
+	graph.Target = result.Target
+	return graph, nil
+}
+
+// storedScanConfig decodes the configuration a stored scan ran with
+func storedScanConfig(result *models.ScanResult) (*scanner.ScanConfig, error) {
+	if len(result.ScanConfig) == 0 {
+		return nil, fmt.Errorf("scan %s has no stored scan configuration", result.ID)
+	}
+
+	var scanConfig scanner.ScanConfig
+	if err := json.Unmarshal(result.ScanConfig, &scanConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode stored scan configuration: %w", err)
+	}
+	return &scanConfig, nil
+}
+
+// enrichHosts runs the enrichers enabled in the enrich section on scanned
+// hosts, sharing one pool and the lookup cache. Enrichment failures are
+// logged and never fail the scan.
+func enrichHosts(ctx context.Context, hosts []*models.Host) {
+	pool := enrich.NewPool(cfg.Enrich.Concurrency, time.Duration(cfg.Enrich.Timeout)*time.Second)
+
+	if cfg.Enrich.HTTP.Enabled {
+		httpEnricher := enrich.NewHTTPEnricher(enrich.HTTPConfig{
+			UserAgent:     cfg.Enrich.HTTP.UserAgent,
+			Paths:         cfg.Enrich.HTTP.Paths,
+			RespectRobots: cfg.Enrich.HTTP.RespectRobots,
+			Timeout:       time.Duration(cfg.Enrich.Timeout) * time.Second,
+		})
+		httpEnricher.SetPool(pool)
+		httpEnricher.EnrichHosts(ctx, hosts)
+	}
+
+	var providers []enrich.ReputationProvider
+	if path := cfg.Enrich.Reputation.BlocklistFile; path != "" {
+		blocklist, err := enrich.NewBlocklistProvider(path)
+		if err != nil {
+			logger.Warnf("Reputation blocklist unavailable: %v", err)
+		} else {
+			providers = append(providers, blocklist)
+		}
+	}
+	if key := cfg.Enrich.Reputation.AbuseIPDBAPIKey; key != "" {
+		providers = append(providers, enrich.NewAbuseIPDBProvider(key, cfg.Enrich.Reputation.RequestsPerMinute))
+	}
+	if len(providers) > 0 {
+		reputation := enrich.NewReputationEnricher(providers...)
+		reputation.SetPool(pool)
+		reputation.SetCache(lookupCache)
+		reputation.EnrichHosts(ctx, hosts)
+	}
+}
+
+// campaignRun describes a scan --internet-scale invocation
+type campaignRun struct {
+	targets    []string
+	scanner    string
+	fallback   []string
+	config     *scanner.ScanConfig
+	shardHosts uint64
+	shardPorts int
+
+	allowLocalhost bool
+	correlationID  string
+	metadata       models.Metadata
+	tail           bool
+}
+
+// runCampaign scans targets as a checkpointed campaign of shards, resuming
+// the unfinished campaign for the same scanner, targets and ports if any
+func runCampaign(run campaignRun) (err error) {
+	if repo == nil {
+		return fmt.Errorf("database connection required to checkpoint --internet-scale scans")
+	}
+	if run.shardPorts < 1 {
+		return fmt.Errorf("--shard-ports must be at least 1")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, span := tracing.Start(ctx, "scan.campaign",
+		trace.WithAttributes(attribute.String("netrecon.correlation_id", run.correlationID)))
+	defer func() { tracing.End(span, err) }()
+	if run.tail {
+		ctx = scanner.WithTail(ctx, os.Stdout)
+	}
+
+	if !run.allowLocalhost {
+		for _, target := range run.targets {
+			if err := checkLocalTarget(target, false); err != nil {
+				return err
+			}
+		}
+	}
+	if err := applyExclusions(run.config, run.targets...); err != nil {
+		return err
+	}
+
+	selected, err := scanMgr.SelectScanner(run.scanner, run.fallback)
+	if err != nil {
+		return err
+	}
+	if name := selected.GetName(); name != run.scanner {
+		logger.WithContext(ctx).Warnf("Scanner %s not available, using %s", run.scanner, name)
+	}
+	if err := selected.ValidateConfig(run.config); err != nil {
+		return fmt.Errorf("invalid scan configuration: %w", err)
+	}
+
+	label := run.targets[0]
+	if len(run.targets) > 1 {
+		label = fmt.Sprintf("%s and %d more", label, len(run.targets)-1)
+	}
+	if err := auditScan(scanRun{
+		target:        label,
+		config:        run.config,
+		correlationID: run.correlationID,
+		action:        "scan.campaign",
+	}, selected.GetName()); err != nil {
+		return err
+	}
+
+	scanConfig, err := json.Marshal(run.config)
+	if err != nil {
+		return fmt.Errorf("failed to encode scan configuration: %w", err)
+	}
+
+	save := func(ctx context.Context, shard *models.CampaignShard, result *scanner.ScanResult) (uuid.UUID, error) {
+		result.CorrelationID = run.correlationID
+		result.Metadata = run.metadata
+		graph := scanner.ToStored(result, uuid.Nil, clk.Now())
+		graph.ID = uuid.New()
+		graph.ScanConfig = scanConfig
+		graph.Target = shard.Target
+		if err := saveScanGraph(ctx, graph, "internet-scale shard"); err != nil {
+			return uuid.Nil, err
+		}
+		return graph.ID, nil
+	}
+
+	coord := coordinator.New(repo, save)
+	started := false
+	coord.OnProgress(func(p coordinator.Progress) {
+		if !started {
+			started = true
+			verb := "Starting"
+			if p.Resumed {
+				verb = "Resuming"
+			}
+			fmt.Printf("🌐 %s campaign %s: %d shards, %d done\n", verb, p.CampaignID, p.ShardsTotal, p.ShardsDone)
+		}
+		fmt.Printf("📦 %d/%d shards (%d failed) · %.1f%% · %d hosts · ETA %s\n", p.ShardsDone, p.ShardsTotal,
+			p.ShardsFailed, p.Fraction()*100, p.HostsFound, p.ETA.Round(time.Second))
+	})
+
+	campaign, err := coord.Run(ctx, coordinator.Options{
+		Scanner:    selected,
+		Config:     run.config,
+		Targets:    run.targets,
+		ShardHosts: run.shardHosts,
+		PortChunks: run.shardPorts,
+	})
+	if ctx.Err() != nil && campaign != nil {
+		fmt.Printf("⏸️  Campaign %s interrupted; run the same command again to resume\n", campaign.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Campaign %s complete\n", campaign.ID)
+	return nil
+}
+
+// auditScan records who launched a scan before it starts. Without a
+// database (--no-db) nothing can be recorded; otherwise a scan that cannot
+// be audited is not run.
+func auditScan(run scanRun, scannerName string) error {
+	if repo == nil {
+		logger.Debug("Scan not audited: no database connection")
+		return nil
+	}
+
+	scanConfig, err := json.Marshal(run.config)
+	if err != nil {
+		return fmt.Errorf("failed to encode scan configuration: %w", err)
+	}
+
+	entry := &models.AuditEntry{
+		Action:        run.action,
+		Actor:         currentActor(),
+		Source:        models.AuditSourceCLI,
+		Target:        run.target,
+		Scanner:       scannerName,
+		ScanConfig:    scanConfig,
+		CorrelationID: run.correlationID,
+	}
+	if entry.Action == "" {
+		entry.Action = "scan"
+	}
+	if err := repo.RecordAudit(entry); err != nil {
+		return fmt.Errorf("refusing to scan without an audit entry: %w", err)
+	}
+	return nil
+}
+
+// currentActor identifies the local user launching a command
+func currentActor() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// planDifferential narrows the scanned ports to those previously seen open
+// on the target, unless its history says a full scan is due
+func planDifferential(target string, scanConfig *scanner.ScanConfig, fullEvery int) error {
+	if repo == nil {
+		return fmt.Errorf("database connection required for scan history")
+	}
+
+	stored, err := repo.FindScanTarget(target)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("📚 No scan history for %s, scanning the full range\n", target)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up target: %w", err)
+	}
+
+	history, err := repo.ListScanResults(stored.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load scan history: %w", err)
+	}
+	cadence, err := repo.ListTargetPortCadence(stored.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load port history: %w", err)
+	}
+
+	plan, err := scanner.PlanDifferentialScan(scanConfig.Ports, scanConfig.ScanProtocol(), cadence, scanner.RunsSinceFullScan(history), fullEvery)
+	if err != nil {
+		return err
+	}
+
+	scanConfig.Ports = plan.Ports
+	scanConfig.Differential = !plan.Full
+	if plan.Full {
+		fmt.Printf("📚 Full scan of %s (due every %d runs, %d known open ports)\n", target, fullEvery, plan.KnownOpen)
+	} else {
+		fmt.Printf("📚 Differential scan of %s: %d known open ports (full scan in %d runs)\n", target, plan.KnownOpen, plan.RunsUntilFull+1)
+	}
+	return nil
+}
+
+// checkLocalTarget refuses targets that point at the machine running the
+// scanner, which is rarely intended. Only loopback is checked on a jump host
+// since its interface addresses are not known here.
+func checkLocalTarget(target string, remote bool) error {
+	var local []net.IP
+	if !remote {
+		addrs, err := scanner.LocalAddresses()
+		if err != nil {
+			logger.Debugf("Local target check skipped interfaces: %v", err)
+		}
+		local = addrs
+	}
+
+	for _, t := range scanner.SplitTargets(target) {
+		if ip, ok := scanner.LocalTarget(t, local, resolver.LookupFunc()); ok {
+			return fmt.Errorf("target %s is this machine (%s); use --allow-localhost to scan it anyway", t, ip)
+		}
+	}
+	return nil
+}
+
+// applyExclusions sets config.Exclude to the do-not-scan list, merging the
+// configured and stored entries with those the scan configuration already
+// carries, and refuses targets lying entirely inside it. A database that cannot be read stops the scan
+// rather than risk scanning excluded networks.
+func applyExclusions(config *scanner.ScanConfig, targets ...string) error {
+	lists := [][]string{cfg.Scanner.Exclude, config.Exclude}
+	if repo != nil {
+		stored, err := repo.ExcludedNetworks()
+		if err != nil {
+			return fmt.Errorf("cannot load the do-not-scan list: %w", err)
+		}
+		lists = append(lists, stored)
+	}
+	exclude, err := scanner.MergeExclusions(lists...)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		if err := scanner.CheckExclusions(target, exclude); err != nil {
+			return err
+		}
+	}
+	config.Exclude = exclude
+	return nil
+}
+
+// readStdinTargets reads the targets piped to "scan -", each optionally
+// followed by its own ports
+func readStdinTargets(r io.Reader) ([]scanner.TargetPorts, error) {
+	targets, err := scanner.ReadTargetList(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets from stdin: %w", err)
+	}
+	return targets, nil
+}
+
+// planBatches fills in the default ports of targets given without any,
+// narrowed per target by the scan history with --differential, and groups
+// targets sharing ports into one scan each
+func planBatches(requested []scanner.TargetPorts, flags scanFlags, differential int) ([]scanner.ScanBatch, error) {
+	pairs := make([]scanner.TargetPorts, len(requested))
+	for i, pair := range requested {
+		if pair.Ports == "" {
+			pair.Ports = flags.ports
+		}
+		if differential > 0 {
+			scanConfig := buildScanConfig(flags)
+			scanConfig.Ports = pair.Ports
+			if err := planDifferential(pair.Target, scanConfig, differential); err != nil {
+				return nil, fmt.Errorf("cannot plan differential scan of %s: %w", pair.Target, err)
+			}
+			pair.Ports = scanConfig.Ports
+		}
+		pairs[i] = pair
+	}
+
+	batches, err := scanner.PlanScans(pairs)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("Planned %d targets as %d scans", len(requested), len(batches))
+	return batches, nil
+}
+
+// scanFlags holds the scan command flags that shape the scanner configuration
+type scanFlags struct {
+	ports         string
+	timing        string
+	arguments     string
+	threads       int
+	maxHosts      int
+	openOnly      bool
+	wait          int
+	retries       int
+	split         int
+	dnsServers    []string
+	adaptiveRate  bool
+	noDNS         bool
+	udp           bool
+	protocol      string
+	intensity     int
+	states        []string
+	countFiltered bool
+	scripts       []string
+}
+
+// defaultScanFlags returns the scan flags of commands that take no scan
+// flags of their own, with the scan command's defaults
+func defaultScanFlags(ports, timing string) scanFlags {
+	return scanFlags{
+		ports:    ports,
+		timing:   timing,
+		threads:  cfg.Scanner.MaxThreads,
+		maxHosts: cfg.Scanner.MaxHosts,
+		openOnly: scanner.DefaultOpenOnly,
+	}
+}
+
+// buildScanConfig assembles a scanner.ScanConfig from scan command flags
+func buildScanConfig(flags scanFlags) *scanner.ScanConfig {
+	scanConfig := &scanner.ScanConfig{
+		Ports:            flags.ports,
+		Timing:           flags.timing,
+		Arguments:        flags.arguments,
+		Timeout:          cfg.Scanner.DefaultTimeout,
+		Threads:          flags.threads,
+		MaxHosts:         flags.maxHosts,
+		OpenOnly:         flags.openOnly,
+		ReportedStates:   flags.states,
+		Wait:             flags.wait,
+		Retries:          flags.retries,
+		Split:            flags.split,
+		AdaptiveRate:     flags.adaptiveRate,
+		DNSServers:       flags.dnsServers,
+		NoDNS:            flags.noDNS,
+		UDP:              flags.udp,
+		Protocol:         flags.protocol,
+		VersionIntensity: flags.intensity,
+		CountFiltered:    flags.countFiltered,
+		Scripts:          flags.scripts,
+		Exclude:          cfg.Scanner.Exclude,
+		Options:          make(map[string]string),
+	}
+
+	if cfg.Scanner.Nmap.DataDir != "" {
+		scanConfig.Options[nmap.OptionDataDir] = cfg.Scanner.Nmap.DataDir
+	}
+
+	return scanConfig
+}
+
+// newTargetCmd creates the target management command
+func newTargetCmd() *cobra.Command {
+	targetCmd := &cobra.Command{
+		Use:   "target",
+		Short: "Manage scan targets",
+		Long:  "Add, list, and manage network scan targets",
+	}
+
+	// Add subcommands
+	targetCmd.AddCommand(
+		&cobra.Command{
+			Use:   "add [target] [description]",
+			Short: "Add a new target",
+			Args:  cobra.RangeArgs(1, 2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if repo == nil {
+					return fmt.Errorf("database connection required")
+				}
+
+				target := args[0]
+				description := ""
+				if len(args) > 1 {
+					description = args[1]
+				}
+
+				// Implementation would go here
+				fmt.Printf("Added target: %s (description: %s)\n", target, description)
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "list",
+			Short: "List all targets",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if repo == nil {
+					return fmt.Errorf("database connection required")
+				}
+
+				targets, err := repo.ListScanTargets()
+				if err != nil {
+					return fmt.Errorf("failed to list targets: %w", err)
+				}
+
+				fmt.Printf("Found %d targets:\n", len(targets))
+				for _, target := range targets {
+					fmt.Printf("- %s (%s): %s\n", target.Target, target.Type, target.Description)
+				}
+				return nil
+			},
+		},
+	)
+
+	return targetCmd
+}
+
+// newResultCmd creates the result management command
+func newResultCmd() *cobra.Command {
+	resultCmd := &cobra.Command{
+		Use:   "result",
+		Short: "Manage scan results",
+		Long:  "View and export scan results",
+	}
+
+	// Add subcommands for result management
+	resultCmd.AddCommand(
+		newResultListCmd(),
+		newResultPinCmd(true),
+		newResultPinCmd(false),
+		newResultTagCmd(true),
+		newResultTagCmd(false),
+		newResultPruneCmd(),
+		newResultHistoryDiffCmd(),
+		newResultDiffCmd(),
+		newResultExportCmd(),
+		newResultExportAllCmd(),
+		newResultDashboardCmd(),
+	)
+
+	return resultCmd
+}
+
+// newResultListCmd creates the result list command
+func newResultListCmd() *cobra.Command {
+	var (
+		targetID   string
+		pinnedOnly bool
+		tag        string
+		meta       []string
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List stored scan results",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			metadata, err := database.ParseMetadata(meta)
+			if err != nil {
+				return err
+			}
+
+			var results []*models.ScanResult
+			if len(metadata) > 0 {
+				if tag != "" {
+					return fmt.Errorf("--meta cannot be combined with --tag")
+				}
+				var filterTarget uuid.UUID
+				if targetID != "" {
+					if filterTarget, err = uuid.Parse(targetID); err != nil {
+						return fmt.Errorf("invalid target ID: %w", err)
+					}
+				}
+				matching, err := repo.FindScansByMetadata(metadata)
+				if err != nil {
+					return fmt.Errorf("failed to list results: %w", err)
+				}
+				for _, result := range matching {
+					if (targetID == "" || result.TargetID == filterTarget) && (result.Pinned || !pinnedOnly) {
+						results = append(results, result)
+					}
+				}
+			} else if tag != "" {
+				if targetID != "" {
+					return fmt.Errorf("--tag cannot be combined with --target")
+				}
+				tagged, err := repo.FindScansByTag(tag)
+				if err != nil {
+					return fmt.Errorf("failed to list results: %w", err)
+				}
+				for _, result := range tagged {
+					if result.Pinned || !pinnedOnly {
+						results = append(results, result)
+					}
+				}
+			} else if targetID != "" {
+				id, err := uuid.Parse(targetID)
+				if err != nil {
+					return fmt.Errorf("invalid target ID: %w", err)
+				}
+				all, err := repo.ListScanResults(id)
+				if err != nil {
+					return fmt.Errorf("failed to list results: %w", err)
+				}
+				for _, result := range all {
+					if result.Pinned || !pinnedOnly {
+						results = append(results, result)
+					}
+				}
+			} else {
+				if results, err = repo.ListAllScanResults(pinnedOnly); err != nil {
+					return fmt.Errorf("failed to list results: %w", err)
+				}
+			}
+
+			fmt.Printf("Found %d scan results:\n", len(results))
+			for _, result := range results {
+				pin := " "
+				if result.Pinned {
+					pin = "📌"
+				}
+				partial := ""
+				if !result.Complete {
+					partial = fmt.Sprintf("  incomplete (%.0f%% covered)", result.Coverage*100)
+				}
+				fmt.Printf("%s %s  %-8s %-22s %s  %s%s%s\n", pin, result.ID, result.ScanType, result.Status,
+					result.StartTime.Format(time.RFC3339), scanner.HumanDuration(result.DurationMs), partial, formatMetadata(result.Metadata))
+			}
+			return nil
+		},
+	}
+
+	listCmd.Flags().StringVar(&targetID, "target", "", "Only list scans of this target ID")
+	listCmd.Flags().BoolVar(&pinnedOnly, "pinned", false, "Only list pinned scans")
+	listCmd.Flags().StringVar(&tag, "tag", "", "Only list scans with this tag, across all targets")
+	listCmd.Flags().StringArrayVar(&meta, "meta", nil, "Only list scans whose metadata has this key=value (repeatable, all must match)")
+
+	return listCmd
+}
+
+// formatMetadata renders scan metadata as sorted key=value pairs after two
+// spaces, or nothing when there is none
+func formatMetadata(meta models.Metadata) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + meta[key]
+	}
+	return "  " + strings.Join(pairs, " ")
+}
+
+// newResultPinCmd creates the result pin or unpin command
+func newResultPinCmd(pinned bool) *cobra.Command {
+	use, short, done := "pin [scan-id]", "Pin a scan result", "Pinned"
+	if !pinned {
+		use, short, done = "unpin [scan-id]", "Unpin a scan result", "Unpinned"
+	}
+
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			scanID, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid scan ID: %w", err)
+			}
+
+			if err := repo.SetScanResultPinned(scanID, pinned); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("scan %s not found", scanID)
+				}
+				return fmt.Errorf("failed to update scan %s: %w", scanID, err)
+			}
+
+			fmt.Printf("%s scan %s\n", done, scanID)
+			return nil
+		},
+	}
+}
+
+// newResultTagCmd creates the result tag or untag command
+func newResultTagCmd(add bool) *cobra.Command {
+	use, short := "tag [scan-id] [tag...]", "Tag a scan result"
+	if !add {
+		use, short = "untag [scan-id] [tag...]", "Remove tags from a scan result"
+	}
+
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			scanID, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid scan ID: %w", err)
+			}
+
+			if add {
+				if err := repo.TagScan(scanID, args[1:]...); err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						return fmt.Errorf("scan %s not found", scanID)
+					}
+					return fmt.Errorf("failed to tag scan %s: %w", scanID, err)
+				}
+			} else {
+				removed, err := repo.UntagScan(scanID, args[1:]...)
+				if err != nil {
+					return fmt.Errorf("failed to untag scan %s: %w", scanID, err)
+				}
+				if removed == 0 {
+					return fmt.Errorf("scan %s has none of these tags", scanID)
+				}
+			}
+
+			tags, err := repo.ListScanTags(scanID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Scan %s tags: %s\n", scanID, strings.Join(tags, ", "))
+			return nil
+		},
+	}
+}
+
+// newResultPruneCmd creates the retention pruning command
+func newResultPruneCmd() *cobra.Command {
+	var olderThan time.Duration
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old scan results",
+		Long:  "Delete scan results older than the given age, together with their hosts and ports. Pinned scans are kept.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+			if olderThan <= 0 {
+				return fmt.Errorf("--older-than must be positive")
+			}
+
+			deleted, err := repo.PruneScanResults(clk.Now().Add(-olderThan))
+			if err != nil {
+				return fmt.Errorf("failed to prune results: %w", err)
+			}
+
+			fmt.Printf("Deleted %d scan results older than %s\n", deleted, olderThan)
+			return nil
+		},
+	}
+
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 90*24*time.Hour, "Delete scans older than this age")
+
+	return pruneCmd
+}
+
+// newResultHistoryDiffCmd creates the command showing how consistently each
+// port was open across the latest scans of a target
+func newResultHistoryDiffCmd() *cobra.Command {
+	var (
+		targetID string
+		last     int
+		asJSON   bool
+	)
+
+	historyCmd := &cobra.Command{
+		Use:   "history-diff",
+		Short: "Show port stability across the last N scans of a target",
+		Long:  "Count how many of the last N scans of a target saw each port open, highlighting intermittent ports",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+			if last < 2 {
+				return fmt.Errorf("--last must be at least 2")
+			}
+
+			id, err := uuid.Parse(targetID)
+			if err != nil {
+				return fmt.Errorf("invalid target ID: %w", err)
+			}
+
+			results, err := repo.ListScanResults(id)
+			if err != nil {
+				return fmt.Errorf("failed to list results: %w", err)
+			}
+			if len(results) > last {
+				results = results[:last]
+			}
+			if len(results) == 0 {
+				return fmt.Errorf("target %s has no scans", id)
+			}
+
+			// Results are newest first; the history runs oldest first
+			scans := make([]*models.FullScanResult, len(results))
+			for i, result := range results {
+				graph, err := repo.GetScanGraph(result.ID)
+				if err != nil {
+					return fmt.Errorf("failed to load scan %s: %w", result.ID, err)
+				}
+				scans[len(results)-1-i] = graph
+			}
+
+			history := diff.History(scans)
+
+			if asJSON {
+				data, err := json.MarshalIndent(history, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			fmt.Printf("Port stability across %d scans (oldest first):\n", len(scans))
+			for _, port := range history.Ports {
+				marker := " "
+				if port.Flapping() {
+					marker = "⚠️"
+				} else if port.Intermittent {
+					marker = "~"
+				}
+				fmt.Printf("%s %-39s %5d/%-4s %-12s %s %d/%d\n", marker, port.IPAddress, port.Port, port.Protocol,
+					port.Service, port.Pattern, port.OpenCount, port.ScanCount)
+			}
+			fmt.Printf("%d of %d ports were intermittent\n", len(history.Intermittent()), len(history.Ports))
+			return nil
+		},
+	}
+
+	historyCmd.Flags().StringVar(&targetID, "target", "", "Target ID")
+	historyCmd.Flags().IntVar(&last, "last", 5, "Number of most recent scans to compare")
+	historyCmd.Flags().BoolVar(&asJSON, "json", false, "Print the aggregate as JSON")
+	_ = historyCmd.MarkFlagRequired("target")
+
+	return historyCmd
+}
+
+// newResultDiffCmd creates the command rendering only what changed between
+// two stored scans
+func newResultDiffCmd() *cobra.Command {
+	var (
+		outputFile   string
+		outputFormat string
+		redact       bool
+	)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [base-scan-id] [compare-scan-id]",
+		Short: "Show what changed between two stored scans",
+		Long:  "Render the hosts and open ports added or removed, changed services and new vulnerabilities between a base scan and a later one",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			scans := make([]*models.FullScanResult, len(args))
+			for i, arg := range args {
+				scanID, err := uuid.Parse(arg)
+				if err != nil {
+					return fmt.Errorf("invalid scan ID: %w", err)
+				}
+				scans[i], err = repo.GetScanGraph(scanID)
+				if err != nil {
+					return fmt.Errorf("failed to load scan %s: %w", scanID, err)
+				}
+			}
+
+			scanDiff := diff.Compare(scans[0], scans[1])
+			for _, warning := range scanDiff.Warnings {
+				logger.Warnf("Diff %s..%s: %s", scans[0].ID, scans[1].ID, warning)
+			}
+
+			formatterMgr, err := newFormatterManager(redact, "")
+			if err != nil {
+				return err
+			}
+			if outputFile != "" {
+				if err := formatterMgr.FormatDiffAndSave(scanDiff, outputFormat, outputFile); err != nil {
+					return err
+				}
+				fmt.Printf("Wrote diff of %s and %s to %s\n", scans[0].ID, scans[1].ID, outputFile)
+				return nil
+			}
+
+			data, err := formatterMgr.FormatDiff(scanDiff, outputFormat)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+
+	diffCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default stdout)")
+	diffCmd.Flags().StringVarP(&outputFormat, "format", "f", "markdown", "Output format (json, html, markdown)")
+	diffCmd.Flags().BoolVar(&redact, "redact", false, "Mask IPs using the output.redact rules")
+
+	return diffCmd
+}
+
+// newResultExportCmd creates the result export command
+func newResultExportCmd() *cobra.Command {
+	var (
+		outputFile   string
+		outputFormat string
+		redact       bool
+		templateFile string
+		fields       []string
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export [scan-id]",
+		Short: "Export a stored scan result",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			scanID, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid scan ID: %w", err)
+			}
+
+			projection, err := newProjection(fields, outputFormat)
+			if err != nil {
+				return err
+			}
+
+			result, err := loadStoredResult(scanID)
+			if err != nil {
+				return err
+			}
+
+			formatterMgr, err := newFormatterManager(redact, templateFile)
+			if err != nil {
+				return err
+			}
+			formatterMgr.SetProjection(projection)
+			if outputFile != "" {
+				if err := formatterMgr.FormatAndSave(result, outputFormat, outputFile); err != nil {
+					return err
+				}
+				fmt.Printf("Exported scan %s to %s\n", scanID, outputFile)
+				return nil
+			}
+
+			formatterMgr.RegisterFormatter("text", newTerminalTextFormatter())
+			data, err := formatterMgr.Format(result, outputFormat)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default stdout)")
+	exportCmd.Flags().StringVarP(&outputFormat, "format", "f", "json", "Output format (json, xml, csv, html, cmdb, hosts, iplist, cef, capfilter, markdown, text)")
+	exportCmd.Flags().StringSliceVar(&fields, "fields", nil, "Restrict json or csv output to these fields, as JSON keys with dots for nested ones (e.g. hosts.ip_address,hosts.ports.number)")
+	exportCmd.Flags().BoolVar(&redact, "redact", false, "Mask IPs and hostnames using the output.redact rules")
+	exportCmd.Flags().StringVar(&templateFile, "template", "", "Go html/template file for HTML reports (default from output.html_template)")
+
+	return exportCmd
+}
+
+// newResultExportAllCmd creates the command exporting every scan of a target
+func newResultExportAllCmd() *cobra.Command {
+	var (
+		targetID     string
+		outputFormat string
+		outDir       string
+		overwrite    bool
+		redact       bool
+		templateFile string
+		fields       []string
+	)
+
+	exportAllCmd := &cobra.Command{
+		Use:   "export-all",
+		Short: "Export every stored scan of a target",
+		Long:  "Write one report per stored scan of a target plus an index.html linking them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			id, err := uuid.Parse(targetID)
+			if err != nil {
+				return fmt.Errorf("invalid target ID: %w", err)
+			}
+
+			projection, err := newProjection(fields, outputFormat)
+			if err != nil {
+				return err
+			}
+
+			scanTarget, err := repo.GetScanTarget(id)
+			if err != nil {
+				return fmt.Errorf("failed to load target %s: %w", id, err)
+			}
+
+			formatterMgr, err := newFormatterManager(redact, templateFile)
+			if err != nil {
+				return err
+			}
+			formatterMgr.SetProjection(projection)
+			formatter, exists := formatterMgr.GetFormatter(outputFormat)
+			if !exists {
+				return fmt.Errorf("formatter '%s' not available. Available formatters: %v", outputFormat, formatterMgr.ListFormatters())
+			}
+
+			scans, err := repo.ListScanResults(id)
+			if err != nil {
+				return fmt.Errorf("failed to list scans: %w", err)
+			}
+
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+
+			var entries []output.ExportEntry
+			written, skipped := 0, 0
+			for _, scan := range scans {
+				filename := output.ExportFilename(scan.StartTime, scan.ID.String(), formatter.GetFileExtension())
+				path := filepath.Join(outDir, filename)
+
+				result, err := loadStoredResult(scan.ID)
+				if err != nil {
+					return err
+				}
+
+				entries = append(entries, output.ExportEntry{
+					File:      filename,
+					ScanID:    scan.ID.String(),
+					Scanner:   scan.ScanType,
+					Status:    scan.Status,
+					Complete:  scan.Complete,
+					StartTime: scan.StartTime.Format(time.RFC3339),
+					Duration:  result.HumanDuration(),
+					HostCount: len(result.Hosts),
+				})
+
+				if _, err := os.Stat(path); err == nil && !overwrite {
+					skipped++
+					continue
+				}
+
+				if err := formatterMgr.FormatAndSave(result, outputFormat, path); err != nil {
+					return fmt.Errorf("failed to export scan %s: %w", scan.ID, err)
+				}
+				written++
+			}
+
+			index, err := output.RenderExportIndex(formatterMgr.RedactTarget(scanTarget.Target), entries)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(outDir, "index.html"), index, 0644); err != nil {
+				return fmt.Errorf("failed to write index: %w", err)
+			}
+
+			fmt.Printf("Exported %d scans (%d skipped) for %s to %s\n", written, skipped, scanTarget.Target, outDir)
+			return nil
+		},
+	}
+
+	exportAllCmd.Flags().StringVar(&targetID, "target", "", "Target ID whose scans to export")
+	exportAllCmd.Flags().StringVarP(&outputFormat, "format", "f", "html", "Output format (json, xml, csv, html, cmdb, hosts, iplist, cef, capfilter, markdown, text)")
+	exportAllCmd.Flags().StringSliceVar(&fields, "fields", nil, "Restrict json or csv output to these fields, as JSON keys with dots for nested ones (e.g. hosts.ip_address,hosts.ports.number)")
+	exportAllCmd.Flags().StringVar(&outDir, "out-dir", "./reports", "Directory to write reports to")
+	exportAllCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite previously exported files")
+	exportAllCmd.Flags().BoolVar(&redact, "redact", false, "Mask IPs and hostnames using the output.redact rules")
+	exportAllCmd.Flags().StringVar(&templateFile, "template", "", "Go html/template file for HTML reports (default from output.html_template)")
+	_ = exportAllCmd.MarkFlagRequired("target")
+
+	return exportAllCmd
+}
+
+// newResultDashboardCmd creates the command rendering one HTML page that
+// summarizes the scans of a target or of one scan run
+func newResultDashboardCmd() *cobra.Command {
+	var (
+		targetID     string
+		batch        string
+		outDir       string
+		overwrite    bool
+		redact       bool
+		templateFile string
+	)
+
+	dashboardCmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Summarize many scans on one HTML page",
+		Long: `Write dashboard.html summarizing the stored scans of a target (--target) or
+of one scan run (--batch, the correlation ID its scans share): a table of
+scans, the open port trend, the most exposed services and high-risk hosts.
+An HTML report of each scan is written next to it and linked.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+			if (targetID == "") == (batch == "") {
+				return fmt.Errorf("exactly one of --target or --batch is required")
+			}
+
+			var (
+				title string
+				scans []*models.ScanResult
+			)
+			if targetID != "" {
+				id, err := uuid.Parse(targetID)
+				if err != nil {
+					return fmt.Errorf("invalid target ID: %w", err)
+				}
+				scanTarget, err := repo.GetScanTarget(id)
+				if err != nil {
+					return fmt.Errorf("failed to load target %s: %w", id, err)
+				}
+				if scans, err = repo.ListScanResults(id); err != nil {
+					return fmt.Errorf("failed to list scans: %w", err)
+				}
+				title = scanTarget.Target
+			} else {
+				var err error
+				if scans, err = repo.ListScanResultsByCorrelationID(batch); err != nil {
+					return fmt.Errorf("failed to list scans: %w", err)
+				}
+				title = "Batch " + batch
+			}
+			if len(scans) == 0 {
+				return fmt.Errorf("no stored scans found")
+			}
+
+			formatterMgr, err := newFormatterManager(redact, templateFile)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+
+			var entries []output.DashboardScan
+			for _, scan := range scans {
+				result, err := loadStoredResult(scan.ID)
+				if err != nil {
+					return err
+				}
+
+				filename := output.ExportFilename(scan.StartTime, scan.ID.String(), "html")
+				path := filepath.Join(outDir, filename)
+				if _, err := os.Stat(path); err != nil || overwrite {
+					if err := formatterMgr.FormatAndSave(result, "html", path); err != nil {
+						return fmt.Errorf("failed to export scan %s: %w", scan.ID, err)
+					}
+				}
+
+				entries = append(entries, output.DashboardScan{
+					Result: formatterMgr.Redact(result),
+					ScanID: scan.ID.String(),
+					Report: filename,
+				})
+			}
+
+			if targetID != "" {
+				title = formatterMgr.RedactTarget(title)
+			}
+			page, err := output.RenderDashboard(output.BuildDashboard(title, entries, clk.Now()))
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(outDir, "dashboard.html")
+			if err := os.WriteFile(path, page, 0644); err != nil {
+				return fmt.Errorf("failed to write dashboard: %w", err)
+			}
+
+			fmt.Printf("Dashboard of %d scans written to %s\n", len(scans), path)
+			return nil
+		},
+	}
+
+	dashboardCmd.Flags().StringVar(&targetID, "target", "", "Target ID whose scan history to summarize")
+	dashboardCmd.Flags().StringVar(&batch, "batch", "", "Correlation ID of the scan run to summarize")
+	dashboardCmd.Flags().StringVar(&outDir, "out-dir", "./reports", "Directory to write the dashboard and reports to")
+	dashboardCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite previously exported reports")
+	dashboardCmd.Flags().BoolVar(&redact, "redact", false, "Mask IPs and hostnames using the output.redact rules")
+	dashboardCmd.Flags().StringVar(&templateFile, "template", "", "Go html/template file for the scan reports (default from output.html_template)")
+
+	return dashboardCmd
+}
+
+// lookupFormatter returns the named formatter, listing the available ones
+// when there is none
+func lookupFormatter(formatterMgr *output.FormatterManager, format string) (output.Formatter, error) {
+	formatter, ok := formatterMgr.GetFormatter(format)
+	if !ok {
+		names := formatterMgr.ListFormatters()
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(names, ", "))
+	}
+	return formatter, nil
+}
+
+// numberedPath inserts n before the extension of path, report.json
+// becoming report-2.json
+func numberedPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// newProjection validates --fields for a report format, returning nil when
+// no fields were given so every field is written
+func newProjection(fields []string, format string) (*output.Projection, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if format != "json" && format != "csv" {
+		return nil, fmt.Errorf("--fields only applies to json and csv output, not %s", format)
+	}
+	return output.NewProjection(fields)
+}
+
+// newTerminalTextFormatter creates the text formatter for output written to
+// stdout: colored when stdout is a terminal and NO_COLOR is unset, and
+// fitted to the width in COLUMNS
+func newTerminalTextFormatter() *output.TextFormatter {
+	color := false
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		color = os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	}
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return output.NewTextFormatter(color, width)
+}
+
+// newFormatterManager creates a formatter manager, enabling report signing
+// when a signing key is configured and the output.redact rules when redact
+// is set
+func newFormatterManager(redact bool, templateFile string) (*output.FormatterManager, error) {
+	formatterMgr := output.NewFormatterManager()
+	formatterMgr.RegisterFormatter("cef", output.NewCEFFormatter(version))
+
+	if templateFile == "" {
+		templateFile = cfg.Output.HTMLTemplate
+	}
+	if templateFile != "" {
+		htmlFormatter, err := output.NewHTMLFormatter(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		formatterMgr.RegisterFormatter("html", htmlFormatter)
+	}
+
+	// Date CMDB rows from the port inventory when it is available, reading
+	// it only once CMDB output is actually produced
+	if repo != nil {
+		formatterMgr.RegisterFormatter("cmdb", output.NewLazyCMDBFormatter(func() ([]*models.HostSighting, error) {
+			sightings, err := repo.ListHostSightings()
+			if err != nil {
+				logger.Warnf("Port inventory unavailable, dating CMDB rows by scan time: %v", err)
+				return nil, nil
+			}
+			return sightings, nil
+		}))
+	}
+
+	if redact {
+		redactor, err := output.NewRedactor(output.RedactRules{
+			MaskIPs:          cfg.Output.Redact.MaskIPs,
+			HostnamePatterns: cfg.Output.Redact.HostnamePatterns,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rules: %w", err)
+		}
+		formatterMgr.SetRedactor(redactor)
+	}
+
+	if cfg.Output.SigningKey != "" {
+		key, err := output.LoadSigningKey(cfg.Output.SigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key: %w", err)
+		}
+		formatterMgr.SetSigningKey(key)
+	}
+
+	return formatterMgr, nil
+}
+
+// dialJumpHost connects to the jump host from scanner.remote.ssh, with host,
+// port and user overridden by via when set
+func dialJumpHost(via string) (*remote.SSHRunner, error) {
+	sshConfig := remote.SSHConfig{
+		Host:           cfg.Scanner.Remote.SSH.Host,
+		Port:           cfg.Scanner.Remote.SSH.Port,
+		User:           cfg.Scanner.Remote.SSH.User,
+		KeyFile:        cfg.Scanner.Remote.SSH.KeyFile,
+		KnownHostsFile: cfg.Scanner.Remote.SSH.KnownHosts,
+		Timeout:        30 * time.Second,
+	}
+	if via != "" {
+		if err := sshConfig.ApplyVia(via); err != nil {
+			return nil, err
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		if sshConfig.KeyFile == "" {
+			sshConfig.KeyFile = filepath.Join(home, ".ssh", "id_ed25519")
+		}
+		if sshConfig.KnownHostsFile == "" {
+			sshConfig.KnownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+	}
+
+	logger.Infof("Running scanners on jump host %s@%s", sshConfig.User, sshConfig.Host)
+	return remote.DialSSH(sshConfig)
+}
+
+// newRemoteScannerManager registers the scanners installed on the jump host
+func newRemoteScannerManager(runner scanner.CommandRunner) *scanner.ScannerManager {
+	mgr := scanner.NewScannerManager()
+	mgr.SetClock(clk)
+
+	if nmapScanner, err := nmap.NewScannerWithRunner(runner); err == nil {
+		mgr.RegisterScanner(nmapScanner)
+	} else {
+		logger.Warnf("Nmap scanner not available on jump host: %v", err)
+	}
+
+	if masscanScanner, err := masscan.NewScannerWithRunner(runner); err == nil {
+		mgr.RegisterScanner(masscanScanner)
+	} else {
+		logger.Warnf("Masscan scanner not available on jump host: %v", err)
+	}
+
+	if rustscanScanner, err := rustscan.NewScannerWithRunner(runner); err == nil {
+		mgr.RegisterScanner(rustscanScanner)
+	} else {
+		logger.Warnf("RustScan scanner not available on jump host: %v", err)
+	}
+
+	if naabuScanner, err := naabu.NewScannerWithRunner(runner); err == nil {
+		naabuScanner.SetMaxThreads(cfg.Scanner.MaxThreads)
+		mgr.RegisterScanner(naabuScanner)
+	} else {
+		logger.Warnf("Naabu scanner not available on jump host: %v", err)
+	}
+
+	return mgr
+}
+
+// sendDigest emails the run summary. Mail problems are logged rather than
+// failing the scan.
+func sendDigest(digest *notify.Digest) {
+	smtpCfg := cfg.Notify.SMTP
+	mailer, err := notify.NewMailer(notify.SMTPConfig{
+		Host:          smtpCfg.Host,
+		Port:          smtpCfg.Port,
+		Username:      smtpCfg.Username,
+		Password:      smtpCfg.Password,
+		From:          smtpCfg.From,
+		To:            smtpCfg.To,
+		TLS:           smtpCfg.TLS,
+		AttachReports: smtpCfg.AttachReports,
+	})
+	if err != nil {
+		logger.Warnf("Digest not sent: %v", err)
+		return
+	}
+
+	if err := mailer.SendDigest(digest); err != nil {
+		logger.Warnf("Digest not sent: %v", err)
+		return
+	}
+	logger.Infof("Digest sent to %s", strings.Join(smtpCfg.To, ", "))
+}
+
+// newSinkRegistry registers the message queue sinks available from config
+func newSinkRegistry() *sink.Registry {
+	registry := sink.NewRegistry()
+	registry.Register("nats", func() (sink.Sink, error) {
+		return sink.NewNATSSink(sink.NATSConfig{
+			URL:             cfg.Sink.NATS.URL,
+			Subject:         cfg.Sink.NATS.Subject,
+			PerHost:         cfg.Sink.NATS.PerHost,
+			MaxReconnects:   cfg.Sink.NATS.MaxReconnects,
+			ReconnectWait:   time.Duration(cfg.Sink.NATS.ReconnectWait) * time.Second,
+			ReconnectBufMiB: cfg.Sink.NATS.ReconnectBufferMB,
+		})
+	})
+	registry.Register("syslog", func() (sink.Sink, error) {
+		return sink.NewSyslogSink(sink.SyslogConfig{
+			Network:  cfg.Sink.Syslog.Network,
+			Address:  cfg.Sink.Syslog.Address,
+			AppName:  cfg.Sink.Syslog.AppName,
+			Facility: cfg.Sink.Syslog.Facility,
+		}, output.NewCEFFormatter(version).Lines)
+	})
+	return registry
+}
+
+// loadStoredResult fetches a full scan graph and converts it for the formatters
+func loadStoredResult(scanID uuid.UUID) (*scanner.ScanResult, error) {
+	graph, err := repo.GetScanGraph(scanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scan %s: %w", scanID, err)
+	}
+
+	target := graph.TargetID.String()
+	if scanTarget, err := repo.GetScanTarget(graph.TargetID); err == nil {
+		target = scanTarget.Target
+	}
+
+	result := scanner.FromStored(graph, target)
+	result.Findings = analysis.AnalyzeGraph(graph)
+	countDiscoveries(context.Background(), result)
+
+	return result, nil
+}
+
+// newInventoryCmd creates the command listing how long each port has been
+// exposed across scans
+func newInventoryCmd() *cobra.Command {
+	var rebuild bool
+
+	inventoryCmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Show how long each open port has been exposed",
+		Long:  "List every host:port/protocol seen open with when it was first and last seen across scans",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			if rebuild {
+				results, err := repo.ListAllScanResults(false)
+				if err != nil {
+					return fmt.Errorf("failed to list results: %w", err)
+				}
+				for _, result := range results {
+					if _, err := repo.RecordPortSightings(result.ID); err != nil {
+						return fmt.Errorf("scan %s: %w", result.ID, err)
+					}
+				}
+				fmt.Printf("Rebuilt port inventory from %d scans\n", len(results))
+			}
+
+			assets, err := repo.ListPortAssets()
+			if err != nil {
+				return fmt.Errorf("failed to list port inventory: %w", err)
+			}
+
+			fmt.Printf("Found %d exposed ports:\n", len(assets))
+			for _, asset := range assets {
+				fmt.Printf("%-39s %5d/%-4s %-12s first %s  last %s  exposed %s\n", asset.IPAddress, asset.Port,
+					asset.Protocol, asset.Service, asset.FirstSeen.Format("2006-01-02"), asset.LastSeen.Format("2006-01-02"),
+					scanner.HumanDuration(asset.Exposure().Milliseconds()))
+			}
+			return nil
+		},
+	}
+
+	inventoryCmd.Flags().BoolVar(&rebuild, "rebuild", false, "Recompute the inventory from every stored scan first")
+
+	return inventoryCmd
+}
+
+// newAuditCmd creates the audit log command
+func newAuditCmd() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log of launched scans",
+	}
+
+	auditCmd.AddCommand(newAuditListCmd())
+
+	return auditCmd
+}
+
+// newAuditListCmd creates the command listing audit entries
+func newAuditListCmd() *cobra.Command {
+	var (
+		since  string
+		until  string
+		filter database.AuditFilter
+		asJSON bool
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List launched scans, newest first",
+		Long:  "List audit entries of launched scans. --since and --until take a date (2006-01-02) or an RFC 3339 timestamp.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			var err error
+			if filter.Since, err = parseAuditTime(since); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseAuditTime(until); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
+			entries, err := repo.ListAuditEntries(filter)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode audit entries: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			fmt.Printf("Found %d audit entries:\n", len(entries))
+			for _, entry := range entries {
+				source := entry.Source
+				if entry.SourceIP != "" {
+					source += " " + entry.SourceIP
+				}
+				fmt.Printf("%s  %-10s %-12s %-18s %-8s %s  %s\n", entry.CreatedAt.Format(time.RFC3339), entry.Action,
+					entry.Actor, source, entry.Scanner, entry.Target, entry.CorrelationID)
+			}
+			return nil
+		},
+	}
+
+	listCmd.Flags().StringVar(&since, "since", "", "Only entries at or after this date or time")
+	listCmd.Flags().StringVar(&until, "until", "", "Only entries before this date or time")
+	listCmd.Flags().StringVar(&filter.Target, "target", "", "Only entries for this target")
+	listCmd.Flags().StringVar(&filter.Actor, "actor", "", "Only entries launched by this actor")
+	listCmd.Flags().IntVar(&filter.Limit, "limit", 0, "Maximum number of entries (0 = all)")
+	listCmd.Flags().BoolVar(&asJSON, "json", false, "Print entries as JSON")
+
+	return listCmd
+}
+
+// newExclusionCmd creates the do-not-scan list management command
+func newExclusionCmd() *cobra.Command {
+	exclusionCmd := &cobra.Command{
+		Use:   "exclusion",
+		Short: "Manage the do-not-scan list",
+		Long: `Manage the addresses and CIDR blocks no scan may touch. Every scan passes
+the list, together with scanner.exclude from the config, to the scanner
+(nmap --exclude, masscan --excludefile, naabu -exclude-hosts), and targets
+lying entirely inside it are refused.`,
+	}
+
+	exclusionCmd.AddCommand(newExclusionAddCmd(), newExclusionListCmd(), newExclusionRemoveCmd())
+
+	return exclusionCmd
+}
+
+// newExclusionAddCmd creates the command adding networks to the do-not-scan list
+func newExclusionAddCmd() *cobra.Command {
+	var reason string
+
+	addCmd := &cobra.Command{
+		Use:   "add [network...]",
+		Short: "Add addresses or CIDR blocks to the do-not-scan list",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			// Check every entry before adding any
+			for _, network := range args {
+				if _, err := scanner.NormalizeExclusion(network); err != nil {
+					return err
+				}
+			}
+
+			for _, network := range args {
+				exclusion := &models.ScanExclusion{Network: network, Reason: reason}
+				if err := repo.AddExclusion(exclusion); err != nil {
+					if errors.Is(err, database.ErrExclusionExists) {
+						fmt.Printf("%s is already excluded\n", exclusion.Network)
+						continue
+					}
+					return err
+				}
+				fmt.Printf("Excluded %s\n", exclusion.Network)
+			}
+			return nil
+		},
+	}
+
+	addCmd.Flags().StringVar(&reason, "reason", "", "Why the networks must not be scanned")
+
+	return addCmd
+}
+
+// newExclusionListCmd creates the command listing the do-not-scan list
+func newExclusionListCmd() *cobra.Command {
+	var asJSON bool
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the do-not-scan list",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			exclusions, err := repo.ListExclusions()
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				data, err := json.MarshalIndent(exclusions, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode exclusions: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			fmt.Printf("Found %d exclusions:\n", len(exclusions))
+			for _, exclusion := range exclusions {
+				fmt.Printf("%-43s %s  %s\n", exclusion.Network, exclusion.CreatedAt.Format(time.RFC3339), exclusion.Reason)
+			}
+			for _, network := range cfg.Scanner.Exclude {
+				fmt.Printf("%-43s (scanner.exclude in config)\n", network)
+			}
+			return nil
+		},
+	}
+
+	listCmd.Flags().BoolVar(&asJSON, "json", false, "Print exclusions as JSON")
+
+	return listCmd
+}
+
+// newExclusionRemoveCmd creates the command taking networks off the do-not-scan list
+func newExclusionRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove [network...]",
+		Short: "Remove addresses or CIDR blocks from the do-not-scan list",
+		Long:  "Remove entries from the stored do-not-scan list. Entries of scanner.exclude in the config can only be removed there.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			for _, network := range args {
+				if err := repo.RemoveExclusion(network); err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						return fmt.Errorf("%s is not on the do-not-scan list", network)
+					}
+					return err
+				}
+				fmt.Printf("Removed %s\n", network)
+			}
+			return nil
+		},
+	}
+}
+
+// parseAuditTime parses a date in local time or an RFC 3339 timestamp; an
+// empty value is the zero time
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// newDBCmd creates the database backup command
+func newDBCmd() *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Back up and restore stored data",
+	}
+
+	dbCmd.AddCommand(newDBExportCmd(), newDBImportCmd(), newDBFlushPendingCmd())
+
+	return dbCmd
+}
+
+// newDBExportCmd creates the command dumping all stored data to JSON
+func newDBExportCmd() *cobra.Command {
+	var outFile string
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export targets, scans, hosts, ports and vulnerabilities to JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			dump, err := repo.ExportDump()
+			if err != nil {
+				return err
+			}
+
+			data, err := json.MarshalIndent(dump, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode dump: %w", err)
+			}
+			if err := os.WriteFile(outFile, data, 0600); err != nil {
+				return fmt.Errorf("failed to write dump: %w", err)
+			}
+
+			fmt.Printf("Exported %d targets, %d scans, %d hosts, %d ports, %d vulnerabilities to %s\n",
+				len(dump.Targets), len(dump.Scans), len(dump.Hosts), len(dump.Ports), len(dump.Vulnerabilities), outFile)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&outFile, "out", "netrecon-dump.json", "File to write the dump to")
+
+	return exportCmd
+}
+
+// newDBImportCmd creates the command restoring a JSON dump
+func newDBImportCmd() *cobra.Command {
+	var regenerateIDs bool
+
+	importCmd := &cobra.Command{
+		Use:   "import [dump.json]",
+		Short: "Import a JSON dump created by db export",
+		Long:  "Restore a dump in a single transaction. IDs are preserved and rows that already exist are skipped, unless --regenerate-ids is set.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read dump: %w", err)
+			}
+
+			var dump database.Dump
+			if err := json.Unmarshal(data, &dump); err != nil {
+				return fmt.Errorf("failed to decode dump: %w", err)
+			}
+
+			stats, err := repo.ImportDump(&dump, !regenerateIDs)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Imported %d rows (%d already present) from %s\n", stats.Inserted, stats.Skipped, args[0])
+			return nil
+		},
+	}
+
+	importCmd.Flags().BoolVar(&regenerateIDs, "regenerate-ids", false, "Assign new IDs instead of preserving those in the dump")
+
+	return importCmd
+}
+
+// newDBFlushPendingCmd creates the command retrying saves of results that
+// were spilled to disk when the database was unavailable
+func newDBFlushPendingCmd() *cobra.Command {
+	flushCmd := &cobra.Command{
+		Use:   "flush-pending",
+		Short: "Save scan results spilled to disk after a failed database save",
+		Long: `Retry saving every scan result in database.pending_dir. Saved results, and
+results found to be stored already, are removed; the rest stay pending.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			store := database.NewPendingStore(cfg.Database.PendingDir)
+			paths, err := store.List()
+			if err != nil {
+				return err
+			}
+			if len(paths) == 0 {
+				fmt.Printf("No pending results in %s\n", store.Dir())
+				return nil
+			}
+
+			stats, err := store.Flush(func(graph *models.FullScanResult) error {
+				if err := resolveTarget(graph, ""); err != nil {
+					return err
+				}
+				if err := repo.SaveScanGraph(graph); err != nil {
+					return err
+				}
+				if _, err := repo.RecordPortSightings(graph.ScanResult.ID); err != nil {
+					logger.Warnf("Port inventory not updated for scan %s: %v", graph.ScanResult.ID, err)
+				}
+				return nil
+			})
+
+			fmt.Printf("Flushed %s: %d saved, %d already stored, %d still pending\n",
+				store.Dir(), stats.Saved, stats.Skipped, stats.Failed)
+			if err != nil {
+				return fmt.Errorf("some results could not be saved: %w", err)
+			}
+			return nil
+		},
+	}
+
+	return flushCmd
+}
+
+// findOrCreateTarget returns the stored target with the given address,
+// adding it with description when it was never scanned
+func findOrCreateTarget(target, description string) (*models.ScanTarget, error) {
+	stored, err := repo.FindScanTarget(target)
+	if errors.Is(err, sql.ErrNoRows) {
+		targetType, typeErr := scanner.DetectTargetType(target)
+		if typeErr != nil {
+			return nil, typeErr
+		}
+		stored = &models.ScanTarget{Target: target, Type: targetType, Description: description}
+		err = repo.CreateScanTarget(stored)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up target: %w", err)
+	}
+	return stored, nil
+}
+
+// resolveTarget sets the TargetID of a scan graph saved with only its
+// target's address, adding the target with description on its first scan
+func resolveTarget(graph *models.FullScanResult, description string) error {
+	if graph.TargetID != uuid.Nil {
+		return nil
+	}
+	stored, err := findOrCreateTarget(graph.Target, description)
+	if err != nil {
+		return err
+	}
+	graph.TargetID = stored.ID
+	return nil
+}
+
+// saveScanGraph stores a finished scan, looking up its target first when
+// only the address is set. When either fails, as while the database is
+// unavailable, the scan is spilled to the pending directory so the results
+// are not lost.
+func saveScanGraph(ctx context.Context, graph *models.FullScanResult, description string) error {
+	err := resolveTarget(graph, description)
+	if err == nil {
+		err = repo.WithContext(ctx).SaveScanGraph(graph)
+	}
+	if err == nil {
+		if _, err := repo.RecordPortSightings(graph.ScanResult.ID); err != nil {
+			logger.WithContext(ctx).Warnf("Port inventory not updated: %v", err)
+		}
+		return nil
+	}
+
+	path, spillErr := database.NewPendingStore(cfg.Database.PendingDir).Spill(graph)
+	if spillErr != nil {
+		return fmt.Errorf("failed to save scan: %w (keeping it on disk also failed: %v)", err, spillErr)
+	}
+	return fmt.Errorf("failed to save scan: %w; result kept in %s, retry with 'netrecon db flush-pending'", err, path)
+}
+
+// rawParsers returns the parser of each scanner's raw output by scan type.
+// Parsing needs no scanner binary, so stored and salvaged output can be read
+// on hosts where the scanner is not installed.
+func rawParsers() map[string]scanner.RawParser {
+	return map[string]scanner.RawParser{
+		"nmap":     nmap.NewParser(),
+		"masscan":  masscan.NewParser(),
+		"rustscan": rustscan.NewParser(),
+		"naabu":    naabu.NewParser(),
+	}
+}
+
+// reparseHosts rebuilds the hosts of a stored scan from its raw output,
+// keeping only the host states the scan was configured to report
+func reparseHosts(result *models.ScanResult) ([]*models.HostGraph, error) {
+	parser, ok := rawParsers()[result.ScanType]
+	if !ok {
+		return nil, fmt.Errorf("scanner '%s' does not support re-parsing", result.ScanType)
+	}
+
+	hosts, err := parser.ParseRaw([]byte(result.RawOutput))
+	if err != nil {
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("failed to parse raw output: %w", err)
+		}
+		logger.Warnf("Raw output only partially parsed, keeping %d hosts: %v", len(hosts), err)
+	}
+
+	var scanConfig scanner.ScanConfig
+	if len(result.ScanConfig) > 0 {
+		if err := json.Unmarshal(result.ScanConfig, &scanConfig); err != nil {
+			return nil, fmt.Errorf("failed to decode stored scan configuration: %w", err)
+		}
+	}
+	reported := hosts[:0]
+	for _, host := range hosts {
+		if scanConfig.ReportsHostState(host.Status) {
+			reported = append(reported, host)
+		}
+	}
+	return reported, nil
+}
+
+// newReparseCmd creates the command re-extracting hosts and ports from a
+// stored scan's raw output
+func newReparseCmd() *cobra.Command {
+	var scanIDFlag string
+
+	reparseCmd := &cobra.Command{
+		Use:   "reparse",
+		Short: "Re-parse the stored raw output of a scan",
+		Long: `Run the current parser over the raw scanner output stored with a scan and
+replace its hosts and ports. Vulnerabilities and HTTP probes recorded
+against the old ports are removed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			scanID, err := uuid.Parse(scanIDFlag)
+			if err != nil {
+				return fmt.Errorf("invalid scan ID: %w", err)
+			}
+
+			result, err := repo.GetScanResult(scanID)
+			if err != nil {
+				return fmt.Errorf("failed to load scan %s: %w", scanID, err)
+			}
+			if result.RawOutput == "" {
+				return fmt.Errorf("scan %s has no stored raw output", scanID)
+			}
+
+			hosts, err := reparseHosts(result)
+			if err != nil {
+				return err
+			}
+
+			beforeHosts, beforePorts, err := repo.CountScanRecords(scanID)
+			if err != nil {
+				return fmt.Errorf("failed to count stored records: %w", err)
+			}
+
+			if err := repo.ReplaceScanHosts(scanID, hosts); err != nil {
+				return fmt.Errorf("failed to replace hosts: %w", err)
+			}
+			if _, err := repo.RecordPortSightings(scanID); err != nil {
+				logger.Warnf("Port inventory not updated: %v", err)
+			}
+
+			afterHosts, afterPorts, err := repo.CountScanRecords(scanID)
+			if err != nil {
+				return fmt.Errorf("failed to count stored records: %w", err)
+			}
+
+			fmt.Printf("Re-parsed scan %s (%s)\n", scanID, result.ScanType)
+			fmt.Printf("  Hosts: %d -> %d\n", beforeHosts, afterHosts)
+			fmt.Printf("  Ports: %d -> %d\n", beforePorts, afterPorts)
+			return nil
+		},
+	}
+
+	reparseCmd.Flags().StringVar(&scanIDFlag, "scan-id", "", "ID of the scan to re-parse")
+	_ = reparseCmd.MarkFlagRequired("scan-id")
+
+	return reparseCmd
+}
+
+// newSalvageCmd creates the command storing the hosts found in output left
+// behind by a scan that was killed before it finished
+func newSalvageCmd() *cobra.Command {
+	var scannerName, target string
+
+	salvageCmd := &cobra.Command{
+		Use:   "salvage <file>",
+		Short: "Store the hosts found in partial scanner output",
+		Long: `Parse the output a scanner wrote before it was killed and store every
+complete host as a cancelled scan of the target. An element cut off at the
+end of the file is dropped; the scanner binary does not need to be installed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			parser, ok := rawParsers()[scannerName]
+			if !ok {
+				return fmt.Errorf("scanner '%s' does not support salvaging output", scannerName)
+			}
+
+			info, err := os.Stat(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+
+			hosts, err := parser.ParseRaw(data)
+			switch {
+			case errors.Is(err, scanner.ErrTruncatedOutput):
+				fmt.Printf("✂️  Output ends mid-element, the last incomplete entry was dropped\n")
+			case err != nil && len(hosts) == 0:
+				return fmt.Errorf("failed to parse %s: %w", args[0], err)
+			case err != nil:
+				logger.Warnf("Output only partially parsed, keeping %d hosts: %v", len(hosts), err)
+			}
+			if len(hosts) == 0 {
+				return fmt.Errorf("no complete hosts found in %s", args[0])
+			}
+
+			stored, err := findOrCreateTarget(target, "salvaged scan")
+			if err != nil {
+				return err
+			}
+
+			// The file was last written when the scanner stopped
+			endTime := info.ModTime()
+			plain := make([]*models.Host, len(hosts))
+			for i, host := range hosts {
+				plain[i] = host.Host
+			}
+			result := &scanner.ScanResult{Status: scanner.StatusCancelled}
+			result.MarkCompleteness(scanner.TargetProgress(target, plain))
+
+			graph := &models.FullScanResult{
+				ScanResult: &models.ScanResult{
+					TargetID:      stored.ID,
+					ScanType:      scannerName,
+					Status:        result.Status,
+					StartTime:     endTime,
+					EndTime:       &endTime,
+					RawOutput:     string(data),
+					Complete:      result.Complete,
+					Coverage:      result.Coverage,
+					CorrelationID: scanner.NewCorrelationID(),
+				},
+				Hosts: hosts,
+			}
+			if err := saveScanGraph(cmd.Context(), graph, ""); err != nil {
+				return err
+			}
+
+			fmt.Printf("Salvaged scan %s (%s)\n", graph.ScanResult.ID, scannerName)
+			fmt.Printf("  Target:   %s\n", target)
+			fmt.Printf("  Hosts:    %d\n", len(hosts))
+			fmt.Printf("  Coverage: %.0f%%\n", result.Coverage*100)
+			return nil
+		},
+	}
+
+	salvageCmd.Flags().StringVar(&scannerName, "scanner", "nmap", "scanner that wrote the output (nmap, masscan, rustscan, naabu)")
+	salvageCmd.Flags().StringVar(&target, "target", "", "target the interrupted scan was run against")
+	_ = salvageCmd.MarkFlagRequired("target")
+
+	return salvageCmd
+}
+
+// newConfigCmd creates the config management command
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration",
+		Long:  "View and modify application configuration",
+	}
+
+	// Add subcommands for config management
+	return configCmd
+}
+
+// newServerCmd creates the server command
+func newServerCmd() *cobra.Command {
+	serverCmd := &cobra.Command{
+		Use:   "server",
+		Short: "Start web server",
+		Long:  "Start the web interface server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repo == nil {
+				return fmt.Errorf("database connection required")
+			}
+
+			addr := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))
+			server := &http.Server{
+				Addr:              addr,
+				Handler:           api.NewServer(repo, scanMgr, logger).Handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			errCh := make(chan error, 1)
+			go func() {
+				fmt.Printf("Starting server on %s\n", addr)
+				errCh <- server.ListenAndServe()
+			}()
+
+			select {
+			case err := <-errCh:
+				if !errors.Is(err, http.ErrServerClosed) {
+					return fmt.Errorf("server failed: %w", err)
+				}
+				return nil
+			case <-ctx.Done():
+				logger.Info("Shutting down server...")
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				return server.Shutdown(shutdownCtx)
+			}
+		},
+	}
+
+	return serverCmd
+}
+
+// newRPCCmd creates the command serving JSON-RPC 2.0 over stdin/stdout
+// for editor and tool integrations
+func newRPCCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rpc",
+		Short: "Serve JSON-RPC over stdin/stdout",
+		Long: `Serve JSON-RPC 2.0 over stdin/stdout, one message per line, for editor
+plugins and other tools driving netrecon without HTTP.
+
+Methods:
+  scan         {"target", "scanner", "config", "allow_localhost", "correlation_id", "metadata"}
+  getResult    {"id"}
+  listTargets
+
+Requests run concurrently. Send the notification $/cancelRequest {"id"} to
+cancel one; it then fails with code -32800. Scans of a target already being
+scanned wait their turn (scanner.target_concurrency), or fail with code
+-32002 when scanner.target_busy is reject. Logs go to stderr.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFlight, err := scanner.NewInFlight(cfg.Scanner.TargetConcurrency, cfg.Scanner.TargetBusy)
+			if err != nil {
+				return err
+			}
+
+			service := rpc.NewService(repo, scanMgr, logger)
+			service.SetActor(currentActor())
+			service.SetInFlight(inFlight)
+			service.SetMaxDuration(time.Duration(cfg.Scanner.MaxTotalDuration) * time.Second)
+			service.SetLookupIP(resolver.LookupFunc())
+			service.SetDefaults(cfg.Scanner.DefaultScanner, cfg.Scanner.Fallback, *buildScanConfig(defaultScanFlags(cfg.Scanner.DefaultPorts, "4")))
+
+			server := rpc.NewServer()
+			service.Register(server)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return server.Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+}
+
+// newValidateCmd creates the dry validation command
+func newValidateCmd() *cobra.Command {
+	var (
+		targetsFile string
+		scannerName string
+		ports       string
+		timing      string
+	)
+
+	validateCmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate configuration and targets without scanning",
+		Long:         "Parse and validate the configuration, a targets file and the resolved scan configuration. No scans are run and nothing is written to the database.",
+		Args:         cobra.NoArgs,
+		Annotations:  map[string]string{skipDatabaseAnnotation: "true"},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var problems []string
+
+			for _, err := range cfg.Validate() {
+				problems = append(problems, fmt.Sprintf("config: %v", err))
+			}
+			if cfg.Output.HTMLTemplate != "" {
+				if _, err := output.NewHTMLFormatter(cfg.Output.HTMLTemplate); err != nil {
+					problems = append(problems, fmt.Sprintf("config: output.html_template: %v", err))
+				}
+			}
+			for name, preset := range cfg.Scanner.Presets {
+				if preset.Ports != "" {
+					if err := scanner.ValidatePortSpec(preset.Ports); err != nil {
+						problems = append(problems, fmt.Sprintf("config: preset %s: %v", name, err))
+					}
+				}
+			}
+
+			if targetsFile != "" {
+				file, err := os.Open(targetsFile)
+				if err != nil {
+					return fmt.Errorf("failed to open targets file: %w", err)
+				}
+				defer file.Close()
+
+				targets, err := scanner.ReadTargets(file)
+				if err != nil {
+					problems = append(problems, err.Error())
+				}
+				valid := 0
+				for _, target := range targets {
+					if target.Err != nil {
+						problems = append(problems, fmt.Sprintf("%s:%d: %v", targetsFile, target.Line, target.Err))
+						continue
+					}
+					valid++
+					logger.Debugf("%s:%d: %s (%s)", targetsFile, target.Line, target.Target, target.Type)
+				}
+				fmt.Printf("Targets: %d valid, %d invalid\n", valid, len(targets)-valid)
+			}
+
+			if ports == "" {
+				ports = cfg.Scanner.DefaultPorts
+			}
+			if err := scanner.ValidatePortSpec(ports); err != nil {
+				problems = append(problems, fmt.Sprintf("scan config: %v", err))
+			}
+
+			scanConfig := buildScanConfig(defaultScanFlags(ports, timing))
+			if scannerName == "" {
+				scannerName = cfg.Scanner.DefaultScanner
+			}
+			var validator scanner.Scanner
+			switch scannerName {
+			case "nmap":
+				validator = &nmap.Scanner{}
+			case "masscan":
+				validator = &masscan.Scanner{}
+			case "rustscan":
+				validator = &rustscan.Scanner{}
+			case "naabu":
+				naabuValidator := naabu.NewParser()
+				naabuValidator.SetMaxThreads(cfg.Scanner.MaxThreads)
+				validator = naabuValidator
+			default:
+				problems = append(problems, fmt.Sprintf("scan config: unknown scanner %q", scannerName))
+			}
+			if validator != nil {
+				if err := validator.ValidateConfig(scanConfig); err != nil {
+					problems = append(problems, fmt.Sprintf("scan config: %v", err))
+				}
+			}
+
+			if len(problems) > 0 {
+				for _, problem := range problems {
+					fmt.Printf("❌ %s\n", problem)
+				}
+				return fmt.Errorf("validation failed with %d problem(s)", len(problems))
+			}
+
+			fmt.Printf("✅ Configuration and targets are valid\n")
+			return nil
+		},
+	}
+
+	validateCmd.Flags().StringVar(&targetsFile, "targets-file", "", "File with one target per line")
+	validateCmd.Flags().StringVarP(&scannerName, "scanner", "s", "", "Scanner the scan configuration is validated for (default from scanner.default_scanner)")
+	validateCmd.Flags().StringVarP(&ports, "ports", "p", "", "Port range to validate (default from scanner.default_ports)")
+	validateCmd.Flags().StringVarP(&timing, "timing", "T", "4", "Timing template to validate")
+
+	return validateCmd
+}
+
+// newVerifySignatureCmd creates the report signature verification command
+func newVerifySignatureCmd() *cobra.Command {
+	var publicKey string
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify-signature [report] [signature]",
+		Short: "Verify a signed report",
+		Long:  "Verify that a report matches its Ed25519 signature sidecar file",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report := args[0]
+			signature := report + output.SignatureExtension
+			if len(args) > 1 {
+				signature = args[1]
+			}
+
+			if publicKey == "" {
+				publicKey = cfg.Output.VerifyKey
+			}
+
+			var key ed25519.PublicKey
+			switch {
+			case publicKey != "":
+				var err error
+				if key, err = output.LoadVerifyKey(publicKey); err != nil {
+					return err
+				}
+			case cfg.Output.SigningKey != "":
+				signingKey, err := output.LoadSigningKey(cfg.Output.SigningKey)
+				if err != nil {
+					return err
+				}
+				key = signingKey.Public().(ed25519.PublicKey)
+			default:
+				return fmt.Errorf("no verification key: pass --public-key or set output.verify_key")
+			}
+
+			if err := output.VerifyReportFile(report, signature, key); err != nil {
+				return fmt.Errorf("verification failed for %s: %w", report, err)
+			}
+
+			fmt.Printf("✅ Signature valid: %s\n", report)
+			return nil
+		},
+	}
+
+	verifyCmd.Flags().StringVar(&publicKey, "public-key", "", "Ed25519 public key (PEM) (default from output.verify_key)")
+
+	return verifyCmd
+}
+
+// newScannersCmd creates the command describing the available scanners
+func newScannersCmd() *cobra.Command {
+	var asJSON bool
+
+	scannersCmd := &cobra.Command{
+		Use:         "scanners",
+		Short:       "List available scanners and their capabilities",
+		Long:        "Show each installed scanner with its binary path, detected version and which features it supports.",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipDatabaseAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			infos := newLocalScannerManager().DescribeScanners(cmd.Context())
+
+			if asJSON {
+				data, err := json.MarshalIndent(infos, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode scanners: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(infos) == 0 {
+				fmt.Println("No scanners installed (install nmap, masscan, rustscan or naabu)")
+				return nil
+			}
+
+			for _, info := range infos {
+				version := info.Version
+				if version == "" {
+					version = "unknown"
+				}
+				fmt.Printf("%s %s\n", info.Name, version)
+				fmt.Printf("  Path: %s\n", info.Path)
+				if info.VersionError != "" {
+					fmt.Printf("  Version check failed: %s\n", info.VersionError)
+				}
+			}
+
+			fmt.Printf("\n%-10s %-5s %-5s %-5s %-5s %-9s %s\n", "SCANNER", "TCP", "UDP", "IPv6", "OS", "VERSIONS", "SCRIPTS")
+			for _, info := range infos {
+				c := info.Capabilities
+				fmt.Printf("%-10s %-5s %-5s %-5s %-5s %-9s %s\n", info.Name,
+					capabilityMark(c.TCP), capabilityMark(c.UDP), capabilityMark(c.IPv6),
+					capabilityMark(c.OSDetection), capabilityMark(c.ServiceVersion), capabilityMark(c.Scripts))
+			}
+			return nil
+		},
+	}
+
+	scannersCmd.Flags().BoolVar(&asJSON, "json", false, "Print scanners as JSON")
+
+	return scannersCmd
+}
+
+// capabilityMark renders a capability flag for the scanners table
+func capabilityMark(supported bool) string {
+	if supported {
+		return "yes"
+	}
+	return "-"
 }
 
 // newVersionCmd creates the version command
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/config"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// storedXML is nmap XML as stored with a scan: one host up with two open
+// ports and one host down
+const storedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<nmaprun scanner="nmap" args="nmap -oX - 192.0.2.0/30" start="1700000000">
+<host><status state="up" reason="syn-ack"/>
+<address addr="192.0.2.1" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="22"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="ssh"/></port>
+<port protocol="tcp" portid="443"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="https"/></port>
+</ports>
+</host>
+<host><status state="down" reason="no-response"/>
+<address addr="192.0.2.2" addrtype="ipv4"/>
+</host>
+<runstats><finished time="1700000010" exit="success"/><hosts up="1" down="1" total="2"/></runstats>
+</nmaprun>
+`
+
+// graphSummary lists each host with its ports
+func graphSummary(hosts []*models.HostGraph) []string {
+	var summary []string
+	for _, host := range hosts {
+		var ports []string
+		for _, port := range host.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Number, port.Protocol))
+		}
+		summary = append(summary, fmt.Sprintf("%s %s [%s]", host.IPAddress, host.Status, strings.Join(ports, " ")))
+	}
+	return summary
+}
+
+func TestReparseHosts(t *testing.T) {
+	// No scanner is registered: re-parsing must not need the nmap binary
+	scanMgr = nil
+
+	tests := []struct {
+		name       string
+		scanConfig string
+		want       []string
+	}{
+		{"default states", "", []string{"192.0.2.1 up [22/tcp 443/tcp]"}},
+		{"down hosts reported", `{"reported_states":["up","down"]}`, []string{"192.0.2.1 up [22/tcp 443/tcp]", "192.0.2.2 down []"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := &models.ScanResult{ScanType: "nmap", RawOutput: storedXML}
+			if tt.scanConfig != "" {
+				stored.ScanConfig = []byte(tt.scanConfig)
+			}
+			hosts, err := reparseHosts(stored)
+			if err != nil {
+				t.Fatalf("reparseHosts() error = %v", err)
+			}
+			got := graphSummary(hosts)
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("reparseHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := reparseHosts(&models.ScanResult{ScanType: "zmap", RawOutput: storedXML}); err == nil {
+		t.Error("reparseHosts() accepted an unknown scan type")
+	}
+	if _, err := reparseHosts(&models.ScanResult{ScanType: "nmap", RawOutput: "not xml"}); err == nil {
+		t.Error("reparseHosts() accepted output without hosts")
+	}
+}
+
+func TestRawParsersCoverEveryScanner(t *testing.T) {
+	parsers := rawParsers()
+	for _, name := range []string{"nmap", "masscan", "rustscan", "naabu"} {
+		if parsers[name] == nil {
+			t.Errorf("no raw parser for %s", name)
+		}
+	}
+}
+
+func TestDefaultScanFlagsMatchScanCommand(t *testing.T) {
+	cfg = &config.Config{Scanner: config.ScannerConfig{DefaultPorts: "1-1000", MaxThreads: 500}}
+
+	scanConfig := buildScanConfig(defaultScanFlags(cfg.Scanner.DefaultPorts, "4"))
+	if !scanConfig.OpenOnly {
+		t.Error("RPC and validate scans report closed and filtered ports, unlike the scan command")
+	}
+
+	flag := newScanCmd().Flags().Lookup("open")
+	if flag == nil || flag.DefValue != fmt.Sprint(scanConfig.OpenOnly) {
+		t.Errorf("scan --open defaults to %v, want %t", flag, scanConfig.OpenOnly)
+	}
+}
+
+// slowRunner finds every scanner and answers each command with canned
+// output after advancing a mock clock by elapsed
+type slowRunner struct {
+	clock   *clock.Mock
+	elapsed time.Duration
+	output  []byte
+}
+
+func (r *slowRunner) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+func (r *slowRunner) Output(_ context.Context, _ string, _ ...string) ([]byte, error) {
+	r.clock.Advance(r.elapsed)
+	return r.output, nil
+}
+
+func TestScanTimesFollowClock(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock := clock.NewMock(started)
+	clk = mock
+	defer func() { clk = clock.Real{} }()
+	cfg = &config.Config{}
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+
+	runner := &slowRunner{clock: mock, elapsed: 90 * time.Second, output: []byte(`[
+{"ip": "192.0.2.1", "timestamp": "1700000000", "ports": [{"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]}
+]
+`)}
+	selected, err := newRemoteScannerManager(runner).SelectScanner("masscan", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := selected.Scan(context.Background(), "192.0.2.0/30", &scanner.ScanConfig{Ports: "80"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if result.DurationMs != 90000 {
+		t.Errorf("scan took %dms, want the 90s the mock clock advanced", result.DurationMs)
+	}
+
+	mock.Advance(time.Minute)
+	graph := scanner.ToStored(result, uuid.Nil, clk.Now())
+	if !graph.StartTime.Equal(started) || !graph.EndTime.Equal(started.Add(90*time.Second)) {
+		t.Errorf("stored scan ran %v to %v, want %v to %v", graph.StartTime, graph.EndTime, started, started.Add(90*time.Second))
+	}
+	if len(graph.Hosts) != 1 || !graph.Hosts[0].CreatedAt.Equal(started.Add(90*time.Second)) {
+		t.Errorf("hosts %v not stamped by the mock clock", graph.Hosts)
+	}
+}
+
+func TestRerunConfigMatchesStored(t *testing.T) {
+	config := &scanner.ScanConfig{
+		Ports:            "22,80,443",
+		Timing:           "3",
+		Arguments:        "--reason",
+		Output:           "xml",
+		Timeout:          600,
+		Threads:          200,
+		MaxHosts:         1024,
+		OpenOnly:         true,
+		ReportedStates:   []string{"up", "down"},
+		Wait:             5,
+		Retries:          2,
+		Split:            4,
+		AdaptiveRate:     true,
+		DNSServers:       []string{"192.0.2.53"},
+		NoDNS:            true,
+		UDP:              true,
+		Protocol:         "both",
+		VersionIntensity: 7,
+		Differential:     true,
+		CountFiltered:    true,
+		Scripts:          []string{"ssl-*"},
+		Exclude:          []string{"192.0.2.1"},
+		Options:          map[string]string{"source-port": "53"},
+	}
+	// Every field is set, so a field left out of the stored JSON fails the test
+	fields := reflect.ValueOf(config).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Field(i).IsZero() {
+			t.Fatalf("ScanConfig.%s is not set in the test config", fields.Type().Field(i).Name)
+		}
+	}
+
+	result := &scanner.ScanResult{Target: "192.0.2.0/24", Scanner: "nmap", Status: scanner.StatusCompleted}
+	graph, err := storedScan(scanRun{target: result.Target, scanner: "nmap", config: config}, result)
+	if err != nil {
+		t.Fatalf("storedScan() error = %v", err)
+	}
+
+	rerun, err := storedScanConfig(graph.ScanResult)
+	if err != nil {
+		t.Fatalf("storedScanConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(rerun, config) {
+		t.Errorf("rerun config = %+v, want %+v", rerun, config)
+	}
+
+	if _, err := storedScanConfig(&models.ScanResult{ScanType: "nmap"}); err == nil {
+		t.Error("storedScanConfig() accepted a scan stored without a configuration")
+	}
+}
+
+// installedRunner finds only the installed binaries and never runs them
+type installedRunner map[string]bool
+
+func (r installedRunner) LookPath(name string) (string, error) {
+	if !r[name] {
+		return "", fmt.Errorf("%s not found", name)
+	}
+	return "/usr/bin/" + name, nil
+}
+
+func (r installedRunner) Output(context.Context, string, ...string) ([]byte, error) {
+	return nil, fmt.Errorf("unexpected command")
+}
+
+func TestSelectScannerFallback(t *testing.T) {
+	cfg = &config.Config{}
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// nmap is the first choice but only masscan is installed
+	mgr := newRemoteScannerManager(installedRunner{"masscan": true})
+	selected, err := mgr.SelectScanner("nmap", []string{"rustscan", "masscan"})
+	if err != nil {
+		t.Fatalf("SelectScanner() error = %v", err)
+	}
+	if name := selected.GetName(); name != "masscan" {
+		t.Errorf("SelectScanner() = %s, want the installed masscan", name)
+	}
+
+	if _, err := mgr.SelectScanner("nmap", []string{"rustscan"}); err == nil {
+		t.Error("SelectScanner() succeeded with none of the scanners installed")
+	}
+}
+
+// recordingRunner finds every scanner and answers each command with canned
+// output, recording the arguments it was run with
+type recordingRunner struct {
+	output []byte
+	calls  [][]string
+}
+
+func (r *recordingRunner) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+func (r *recordingRunner) Output(_ context.Context, _ string, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, args)
+	return r.output, nil
+}
+
+func TestScanStdinTargets(t *testing.T) {
+	cfg = &config.Config{}
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+	repo = nil
+	runner := &recordingRunner{output: []byte(`[
+{"ip": "192.0.2.1", "timestamp": "1700000000", "ports": [{"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]}
+]
+`)}
+	scanMgr = newRemoteScannerManager(runner)
+	defer func() { scanMgr = nil }()
+
+	// Piped as by "cat targets.txt | netrecon scan -"
+	stdin := bytes.NewReader([]byte("# lab\n192.0.2.1\n\n192.0.2.2 22,80\r\n192.0.2.3\n"))
+	outputFile := filepath.Join(t.TempDir(), "scan.json")
+	cmd := newScanCmd()
+	cmd.SetIn(stdin)
+	cmd.SetOut(io.Discard)
+	cmd.SetArgs([]string{"-", "--scanner", "masscan", "--ports", "1-1000", "--save-db=false", "--format", "json", "-o", outputFile, "--allow-localhost"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan - error = %v", err)
+	}
+
+	// Targets sharing ports are scanned together, in the order first given
+	want := []struct{ targets, ports string }{
+		{"192.0.2.1 192.0.2.3", "1-1000"},
+		{"192.0.2.2", "22,80"},
+	}
+	if len(runner.calls) != len(want) {
+		t.Fatalf("scanner ran %d times, want %d: %q", len(runner.calls), len(want), runner.calls)
+	}
+	for i, w := range want {
+		args := strings.Join(runner.calls[i], " ")
+		if !strings.Contains(args, w.targets) || !strings.Contains(args, "-p "+w.ports) {
+			t.Errorf("scan %d ran with %q, want targets %s on ports %s", i+1, args, w.targets, w.ports)
+		}
+
+		data, err := os.ReadFile(numberedPath(outputFile, i+1))
+		if err != nil {
+			t.Fatalf("no JSON report for scan %d: %v", i+1, err)
+		}
+		var result scanner.ScanResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("report %d is not JSON: %v", i+1, err)
+		}
+		if result.Target != w.targets || result.Scanner != "masscan" {
+			t.Errorf("report %d is a %s scan of %s, want a masscan scan of %s", i+1, result.Scanner, result.Target, w.targets)
+		}
+	}
+
+	// A bad line fails the whole list before anything is scanned
+	runner.calls = nil
+	cmd = newScanCmd()
+	cmd.SetIn(bytes.NewReader([]byte("192.0.2.1\nnot a target!\n")))
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"-", "--scanner", "masscan", "--save-db=false"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "line 2") || len(runner.calls) != 0 {
+		t.Errorf("scan - of an invalid list = %v after %d scans, want line 2 reported before scanning", err, len(runner.calls))
+	}
+}
+
+func TestScanDNSFlags(t *testing.T) {
+	cfg = &config.Config{}
+	logger = logrus.New()
+	logger.SetOutput(io.Discard)
+	repo = nil
+	runner := &recordingRunner{output: []byte(`<?xml version="1.0"?><nmaprun></nmaprun>`)}
+	scanMgr = newRemoteScannerManager(runner)
+	defer func() { scanMgr = nil }()
+
+	tests := []struct {
+		name     string
+		flags    []string
+		wantArgs string
+		wantErr  bool
+	}{
+		{"servers", []string{"--dns-servers", "10.0.0.53,2001:db8::53"}, "--dns-servers 10.0.0.53,2001:db8::53", false},
+		{"repeated flag", []string{"--dns-servers", "10.0.0.53", "--dns-servers", "10.0.0.54"}, "--dns-servers 10.0.0.53,10.0.0.54", false},
+		{"no DNS", []string{"--no-dns"}, " -n ", false},
+		{"hostname", []string{"--dns-servers", "ns1.example.com"}, "", true},
+		{"servers with no DNS", []string{"--no-dns", "--dns-servers", "10.0.0.53"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner.calls = nil
+			cmd := newScanCmd()
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			args := []string{"192.0.2.1", "--scanner", "nmap", "--ports", "22", "--save-db=false", "--format", "json", "-o", filepath.Join(t.TempDir(), "scan.json")}
+			cmd.SetArgs(append(args, tt.flags...))
+			err := cmd.Execute()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "DNS") || len(runner.calls) != 0 {
+					t.Errorf("scan %v = %v after %d runs, want the DNS servers refused before running nmap", tt.flags, err, len(runner.calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scan %v error = %v", tt.flags, err)
+			}
+			if len(runner.calls) != 1 {
+				t.Fatalf("nmap ran %d times, want once", len(runner.calls))
+			}
+			if got := strings.Join(runner.calls[0], " "); !strings.Contains(got, tt.wantArgs) {
+				t.Errorf("nmap ran with %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestInitializeAppDatabaseFlags(t *testing.T) {
+	// A database nothing listens on, so connecting fails at once
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfgPath, []byte("database:\n  host: 127.0.0.1\n  port: 1\n  retry_attempts: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile = cfgPath
+	defer func() {
+		cfgFile, noDB, cfg, repo, db, scanMgr = "", false, nil, nil, nil, nil
+	}()
+
+	tests := []struct {
+		name    string
+		noDB    bool
+		cmd     func() *cobra.Command
+		flags   []string
+		wantErr string
+	}{
+		{"--no-db skips the database", true, newScanCmd, nil, ""},
+		{"--no-db with --save-db=false", true, newScanCmd, []string{"--save-db=false"}, ""},
+		{"--no-db with --save-db", true, newScanCmd, []string{"--save-db"}, "cannot be combined"},
+		{"saving by default fails without a database", false, newScanCmd, nil, "use --no-db"},
+		{"--save-db fails without a database", false, newScanCmd, []string{"--save-db=true"}, "use --no-db"},
+		{"--save-db=false continues without a database", false, newScanCmd, []string{"--save-db=false"}, ""},
+		{"commands that never save continue", false, newAuditListCmd, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noDB, repo, db = tt.noDB, nil, nil
+			cmd := tt.cmd()
+			if err := cmd.ParseFlags(tt.flags); err != nil {
+				t.Fatal(err)
+			}
+
+			err := initializeApp(cmd, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("initializeApp() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("initializeApp() error = %v", err)
+			}
+			if repo != nil || scanMgr == nil {
+				t.Errorf("initializeApp() left repo %v and scanner manager %v, want no database and scanners set up", repo, scanMgr)
+			}
+		})
+	}
+}
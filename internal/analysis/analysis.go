@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/services"
+)
+
+// Finding types
+const (
+	FindingServiceMismatch = "ServiceMismatch"
+	FindingFirewalled      = "Firewalled"
+)
+
+// ignoredServices are service names that carry no identification
+var ignoredServices = map[string]bool{
+	"":           true,
+	"unknown":    true,
+	"tcpwrapped": true,
+}
+
+// ServiceMismatches flags open ports whose detected service differs from the
+// IANA assignment for the port, or that run a well-known service away from
+// its standard port. Web servers on arbitrary ports are common enough that
+// they are only flagged when they occupy another service's assigned port.
+func ServiceMismatches(ipAddress string, ports []*models.Port) []*models.Finding {
+	var findings []*models.Finding
+
+	for _, port := range ports {
+		if port.State != "open" {
+			continue
+		}
+
+		detected := services.Normalize(port.Service)
+		if ignoredServices[detected] {
+			continue
+		}
+
+		assigned, hasAssignment := services.Lookup(port.Number, port.Protocol)
+
+		var message string
+		switch {
+		case hasAssignment && services.Normalize(assigned) == detected:
+			continue
+		case hasAssignment:
+			message = fmt.Sprintf("%s detected on port %d (assigned to %s)", port.Service, port.Number, assigned)
+		case detected != "http" && len(services.StandardPorts(detected)) > 0:
+			message = fmt.Sprintf("%s detected on non-standard port %d", port.Service, port.Number)
+		default:
+			continue
+		}
+
+		findings = append(findings, &models.Finding{
+			Type:      FindingServiceMismatch,
+			Severity:  "medium",
+			IPAddress: ipAddress,
+			Port:      port.Number,
+			Protocol:  port.Protocol,
+			Message:   message,
+		})
+	}
+
+	return findings
+}
+
+// Firewalled notes a host that filters some of its ports, as counted in
+// Host.FilteredPortCount; it returns nil when no port was filtered
+func Firewalled(host *models.Host) *models.Finding {
+	if host.FilteredPortCount == 0 {
+		return nil
+	}
+	noun := "ports"
+	if host.FilteredPortCount == 1 {
+		noun = "port"
+	}
+	return &models.Finding{
+		Type:      FindingFirewalled,
+		Severity:  "info",
+		IPAddress: host.IPAddress,
+		Message:   fmt.Sprintf("firewalled: %d %s filtered", host.FilteredPortCount, noun),
+	}
+}
+
+// AnalyzeGraph runs every analysis over a stored scan graph
+func AnalyzeGraph(graph *models.FullScanResult) []*models.Finding {
+	var findings []*models.Finding
+
+	for _, host := range graph.Hosts {
+		ports := make([]*models.Port, 0, len(host.Ports))
+		for _, pg := range host.Ports {
+			ports = append(ports, pg.Port)
+		}
+		findings = append(findings, ServiceMismatches(host.IPAddress, ports)...)
+		if finding := Firewalled(host.Host); finding != nil {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// osFamilyRules map keywords of nmap OS names onto families. They are tried
+// in order, so network gear built on Linux or FreeBSD, such as "MikroTik
+// RouterOS 6 (Linux 3.3.5)" or "Juniper JUNOS 12 (FreeBSD 8)", is matched
+// before the general purpose systems it runs on.
+var osFamilyRules = []struct {
+	family   string
+	keywords []string
+}{
+	{models.OSFamilyWindows, []string{"windows", "microsoft"}},
+	{models.OSFamilyApple, []string{"apple", "mac os", "macos", "os x", "darwin"}},
+	{models.OSFamilyNetwork, []string{
+		"cisco", "juniper", "junos", "fortinet", "fortios", "fortigate", "palo alto", "pan-os",
+		"mikrotik", "routeros", "arista", "huawei vrp", "sonicwall", "check point", "checkpoint",
+		"pfsense", "opnsense", "vyos", "edgeos", "ubiquiti", "procurve", "aruba", "brocade",
+		"f5 big-ip", "netscreen", "router", "switch", "firewall",
+	}},
+	{models.OSFamilyBSD, []string{"freebsd", "openbsd", "netbsd", "dragonfly"}},
+	{models.OSFamilyUnix, []string{"solaris", "sunos", "aix", "hp-ux", "irix"}},
+	{models.OSFamilyLinux, []string{"linux", "android", "ubuntu", "debian", "red hat", "centos", "fedora"}},
+}
+
+// ClassifyOS normalizes an nmap OS name, such as "Microsoft Windows 10
+// 1607" or "Linux 5.0 - 5.4", into a family: unknown when no OS was
+// detected and other when the name matches no family
+func ClassifyOS(os string) string {
+	name := strings.ToLower(strings.TrimSpace(os))
+	if name == "" {
+		return models.OSFamilyUnknown
+	}
+	for _, rule := range osFamilyRules {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(name, keyword) {
+				return rule.family
+			}
+		}
+	}
+	return models.OSFamilyOther
+}
+
+// HostOSFamily returns the host's OS family, classifying its OS when no
+// family was recorded, as for hosts stored before families were
+func HostOSFamily(host *models.Host) string {
+	if host.OSFamily != "" {
+		return host.OSFamily
+	}
+	return ClassifyOS(host.OS)
+}
+
+// OSFamilyGroup is the hosts of one OS family
+type OSFamilyGroup struct {
+	Family string
+	Hosts  []*models.Host
+}
+
+// GroupByOSFamily groups hosts by OS family, largest group first and
+// unknown last
+func GroupByOSFamily(hosts []*models.Host) []OSFamilyGroup {
+	index := make(map[string]int)
+	var groups []OSFamilyGroup
+	for _, host := range hosts {
+		family := HostOSFamily(host)
+		i, ok := index[family]
+		if !ok {
+			i = len(groups)
+			index[family] = i
+			groups = append(groups, OSFamilyGroup{Family: family})
+		}
+		groups[i].Hosts = append(groups[i].Hosts, host)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if unknownI, unknownJ := groups[i].Family == models.OSFamilyUnknown, groups[j].Family == models.OSFamilyUnknown; unknownI != unknownJ {
+			return unknownJ
+		}
+		if len(groups[i].Hosts) != len(groups[j].Hosts) {
+			return len(groups[i].Hosts) > len(groups[j].Hosts)
+		}
+		return groups[i].Family < groups[j].Family
+	})
+	return groups
+}
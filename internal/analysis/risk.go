@@ -0,0 +1,28 @@
+package analysis
+
+import "github.com/netrecon/toolkit/internal/models"
+
+// severityWeights are the risk points added per finding of each severity
+var severityWeights = map[string]int{
+	"info":     0,
+	"low":      5,
+	"medium":   15,
+	"high":     30,
+	"critical": 50,
+}
+
+// HostRiskScore rates a host from 0 to 100 by adding weighted points for
+// each of its findings to its threat-intel reputation score
+func HostRiskScore(host *models.Host, findings []*models.Finding) int {
+	score := host.ReputationScore
+	for _, finding := range findings {
+		if finding.IPAddress == host.IPAddress {
+			score += severityWeights[finding.Severity]
+		}
+	}
+
+	if score > 100 {
+		return 100
+	}
+	return score
+}
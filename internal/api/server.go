@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/netrecon/toolkit/internal/database"
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/output"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/tracing"
+)
+
+// Store holds the scans the API serves; database.Repository implements it
+type Store interface {
+	GetScanResult(id uuid.UUID) (*models.ScanResult, error)
+	GetScanGraph(id uuid.UUID) (*models.FullScanResult, error)
+	SetScanMetadata(scanID uuid.UUID, meta models.Metadata) error
+	GetScanTarget(id uuid.UUID) (*models.ScanTarget, error)
+	FindBaselineScan(targetID uuid.UUID) (*models.ScanResult, error)
+	FindLatestScan(targetID uuid.UUID) (*models.ScanResult, error)
+	Available() bool
+}
+
+// Server exposes stored scan data over a REST API
+type Server struct {
+	store    func(ctx context.Context) Store // Returns the store tracing its calls under ctx
+	scanners *scanner.ScannerManager
+	logger   *logrus.Logger
+	mux      *http.ServeMux
+}
+
+// NewServer creates a new API server
+func NewServer(repo *database.Repository, scanners *scanner.ScannerManager, logger *logrus.Logger) *Server {
+	return newServer(func(ctx context.Context) Store { return repo.WithContext(ctx) }, scanners, logger)
+}
+
+// newServer creates an API server serving the scans of store
+func newServer(store func(ctx context.Context) Store, scanners *scanner.ScannerManager, logger *logrus.Logger) *Server {
+	s := &Server{
+		store:    store,
+		scanners: scanners,
+		logger:   logger,
+		mux:      http.NewServeMux(),
+	}
+
+	s.handle("/scans/", s.handleScans)
+	s.handle("/targets/", s.handleTargets)
+	s.handle("/scanners", s.handleScanners)
+	s.handle("/health", s.handleHealth)
+
+	return s
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// handle registers a handler traced under its route pattern
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	s.mux.Handle(pattern, tracing.Handler(pattern, handler))
+}
+
+// handleScans routes requests under /scans/
+func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/scans/"), "/"), "/")
+
+	switch {
+	case len(parts) == 2 && parts[1] == "raw":
+		s.handleScanRaw(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "metadata":
+		s.handleScanMetadata(w, r, parts[0])
+	case len(parts) == 3 && parts[1] == "diff":
+		s.handleScanDiff(w, r, parts[0], parts[2])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleScanRaw serves GET /scans/{id}/raw, the scanner's output exactly as
+// stored, without parsing it again
+func (s *Server) handleScanRaw(w http.ResponseWriter, r *http.Request, rawID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid scan ID: %s", rawID))
+		return
+	}
+
+	result, err := s.store(r.Context()).GetScanResult(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("scan %s not found", id))
+		return
+	}
+	if err != nil {
+		s.logger.WithContext(r.Context()).Errorf("Failed to load scan %s: %v", id, err)
+		writeStoreError(w, "failed to load scan", err)
+		return
+	}
+	if result.RawOutput == "" {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("scan %s has no stored raw output", id))
+		return
+	}
+
+	w.Header().Set("Content-Type", scanner.RawContentType(result.ScanType))
+	w.Header().Set("Content-Length", strconv.Itoa(len(result.RawOutput)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, result.RawOutput)
+}
+
+// maxMetadataBody bounds the request body of PUT /scans/{id}/metadata
+const maxMetadataBody = 64 << 10
+
+// handleScanMetadata serves GET /scans/{id}/metadata and PUT, which
+// replaces the metadata with the JSON object of strings in the body
+func (s *Server) handleScanMetadata(w http.ResponseWriter, r *http.Request, rawID string) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid scan ID: %s", rawID))
+		return
+	}
+	store := s.store(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		result, err := store.GetScanResult(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("scan %s not found", id))
+			return
+		}
+		if err != nil {
+			s.logger.WithContext(r.Context()).Errorf("Failed to load scan %s: %v", id, err)
+			writeStoreError(w, "failed to load scan", err)
+			return
+		}
+		meta := result.Metadata
+		if meta == nil {
+			meta = models.Metadata{}
+		}
+		writeJSON(w, http.StatusOK, meta)
+
+	case http.MethodPut:
+		var meta models.Metadata
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMetadataBody)).Decode(&meta); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid metadata: %v", err))
+			return
+		}
+		if err := database.ValidateMetadata(meta); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		err := store.SetScanMetadata(id, meta)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("scan %s not found", id))
+			return
+		}
+		if err != nil {
+			s.logger.WithContext(r.Context()).Errorf("Failed to update metadata of scan %s: %v", id, err)
+			writeStoreError(w, "failed to update scan metadata", err)
+			return
+		}
+		if meta == nil {
+			meta = models.Metadata{}
+		}
+		writeJSON(w, http.StatusOK, meta)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleScanDiff serves GET /scans/{a}/diff/{b}, as JSON or rendered by the
+// formatter named in the format query parameter (html, markdown)
+func (s *Server) handleScanDiff(w http.ResponseWriter, r *http.Request, baseID, compareID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	base, ok := s.loadScanGraph(w, r, baseID)
+	if !ok {
+		return
+	}
+	compare, ok := s.loadScanGraph(w, r, compareID)
+	if !ok {
+		return
+	}
+
+	scanDiff := diff.Compare(base, compare)
+	for _, warning := range scanDiff.Warnings {
+		s.logger.WithContext(r.Context()).Warnf("Diff %s..%s: %s", base.ID, compare.ID, warning)
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" || format == "json" {
+		writeJSON(w, http.StatusOK, scanDiff)
+		return
+	}
+
+	formatters := output.NewFormatterManager()
+	formatter, ok := formatters.GetFormatter(format)
+	if _, isDiff := formatter.(output.DiffFormatter); !ok || !isDiff {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("format %q does not support diffs (use json, html or markdown)", format))
+		return
+	}
+	data, err := formatters.FormatDiff(scanDiff, format)
+	if err != nil {
+		s.logger.WithContext(r.Context()).Errorf("Failed to format diff %s..%s: %v", base.ID, compare.ID, err)
+		writeError(w, http.StatusInternalServerError, "failed to format diff")
+		return
+	}
+	w.Header().Set("Content-Type", formatter.GetMimeType())
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// handleTargets routes requests under /targets/
+func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/targets/"), "/"), "/")
+
+	switch {
+	case len(parts) == 2 && parts[1] == "drift":
+		s.handleTargetDrift(w, r, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleTargetDrift serves GET /targets/{id}/drift, comparing the target's
+// latest complete scan against its baseline, the most recent pinned scan
+func (s *Server) handleTargetDrift(w http.ResponseWriter, r *http.Request, rawID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	targetID, err := uuid.Parse(rawID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid target ID: %s", rawID))
+		return
+	}
+
+	store := s.store(r.Context())
+	log := s.logger.WithContext(r.Context())
+	if _, err := store.GetScanTarget(targetID); errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("target %s not found", targetID))
+		return
+	} else if err != nil {
+		log.Errorf("Failed to load target %s: %v", targetID, err)
+		writeStoreError(w, "failed to load target", err)
+		return
+	}
+
+	baseline, err := store.FindBaselineScan(targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("target %s has no baseline; pin a complete scan to set one", targetID))
+		return
+	}
+	if err != nil {
+		log.Errorf("Failed to find baseline of target %s: %v", targetID, err)
+		writeStoreError(w, "failed to find baseline", err)
+		return
+	}
+	latest, err := store.FindLatestScan(targetID)
+	if err != nil {
+		log.Errorf("Failed to find latest scan of target %s: %v", targetID, err)
+		writeStoreError(w, "failed to find latest scan", err)
+		return
+	}
+
+	base, ok := s.loadScanGraph(w, r, baseline.ID.String())
+	if !ok {
+		return
+	}
+	compare, ok := s.loadScanGraph(w, r, latest.ID.String())
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diff.Assess(targetID, diff.Compare(base, compare)))
+}
+
+// handleScanners serves GET /scanners
+func (s *Server) handleScanners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.scanners.DescribeScanners(r.Context()))
+}
+
+// handleHealth serves GET /health, reporting whether the database is
+// reachable. While it is not, database calls fail fast and the endpoint
+// answers 503.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if !s.store(r.Context()).Available() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"database": "unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"database": "available"})
+}
+
+// loadScanGraph parses a scan ID and loads its graph, writing an error response on failure
+func (s *Server) loadScanGraph(w http.ResponseWriter, r *http.Request, rawID string) (*models.FullScanResult, bool) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid scan ID: %s", rawID))
+		return nil, false
+	}
+
+	graph, err := s.store(r.Context()).GetScanGraph(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("scan %s not found", id))
+		return nil, false
+	}
+	if err != nil {
+		s.logger.WithContext(r.Context()).Errorf("Failed to load scan %s: %v", id, err)
+		writeStoreError(w, "failed to load scan", err)
+		return nil, false
+	}
+
+	return graph, true
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeStoreError reports a failed database call: 503 while the database
+// is unavailable, otherwise 500 with message
+func writeStoreError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, database.ErrUnavailable) {
+		writeError(w, http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, message)
+}
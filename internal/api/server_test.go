@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// fakeStore serves scans from memory, answering sql.ErrNoRows for anything
+// it does not hold like database.Repository does
+type fakeStore struct {
+	graphs  map[uuid.UUID]*models.FullScanResult
+	targets map[uuid.UUID]*models.ScanTarget
+
+	// Scans FindBaselineScan and FindLatestScan return by target
+	baselines, latest map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeStore) GetScanResult(id uuid.UUID) (*models.ScanResult, error) {
+	graph, ok := f.graphs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return graph.ScanResult, nil
+}
+
+func (f *fakeStore) GetScanGraph(id uuid.UUID) (*models.FullScanResult, error) {
+	graph, ok := f.graphs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return graph, nil
+}
+
+func (f *fakeStore) SetScanMetadata(id uuid.UUID, meta models.Metadata) error {
+	graph, ok := f.graphs[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	graph.Metadata = meta
+	return nil
+}
+
+func (f *fakeStore) GetScanTarget(id uuid.UUID) (*models.ScanTarget, error) {
+	target, ok := f.targets[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return target, nil
+}
+
+func (f *fakeStore) FindBaselineScan(targetID uuid.UUID) (*models.ScanResult, error) {
+	scanID, ok := f.baselines[targetID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return f.GetScanResult(scanID)
+}
+
+func (f *fakeStore) FindLatestScan(targetID uuid.UUID) (*models.ScanResult, error) {
+	scanID, ok := f.latest[targetID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return f.GetScanResult(scanID)
+}
+
+func (f *fakeStore) Available() bool {
+	return true
+}
+
+// newTestServer returns an API server over store
+func newTestServer(store *fakeStore) *httptest.Server {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return httptest.NewServer(newServer(func(context.Context) Store { return store }, nil, logger).Handler())
+}
+
+// get requests path from server and returns the response with its body
+func get(t *testing.T, server *httptest.Server, path string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+// scanGraph builds a stored scan of target with hosts
+func scanGraph(targetID uuid.UUID, hosts ...*models.HostGraph) *models.FullScanResult {
+	return &models.FullScanResult{
+		ScanResult: &models.ScanResult{ID: uuid.New(), TargetID: targetID, ScanType: "nmap", Status: "completed"},
+		Hosts:      hosts,
+	}
+}
+
+// hostGraph builds an up host with ports
+func hostGraph(ip string, ports ...*models.PortGraph) *models.HostGraph {
+	return &models.HostGraph{Host: &models.Host{ID: uuid.New(), IPAddress: ip, Status: "up"}, Ports: ports}
+}
+
+// openPort builds an open TCP port with its detected service and vulnerabilities
+func openPort(number int, service, product, version string, vulns ...*models.Vulnerability) *models.PortGraph {
+	return &models.PortGraph{
+		Port:            &models.Port{ID: uuid.New(), Number: number, Protocol: "tcp", State: "open", Service: service, Product: product, Version: version},
+		Vulnerabilities: vulns,
+	}
+}
+
+func TestScanDiff(t *testing.T) {
+	targetID := uuid.New()
+	base := scanGraph(targetID,
+		hostGraph("192.0.2.10", openPort(22, "ssh", "OpenSSH", "8.9p1"), openPort(80, "http", "nginx", "1.24.0")),
+		hostGraph("192.0.2.20", openPort(25, "smtp", "Postfix smtpd", "")),
+	)
+	compare := scanGraph(targetID,
+		hostGraph("192.0.2.10",
+			openPort(22, "ssh", "OpenSSH", "8.4p1", &models.Vulnerability{CVE: "CVE-2023-38408", Severity: "high"}),
+			openPort(443, "https", "nginx", "1.24.0")),
+		hostGraph("192.0.2.30", openPort(3306, "mysql", "MySQL", "8.0.36")),
+	)
+	server := newTestServer(&fakeStore{graphs: map[uuid.UUID]*models.FullScanResult{base.ID: base, compare.ID: compare}})
+	defer server.Close()
+
+	resp, body := get(t, server, "/scans/"+base.ID.String()+"/diff/"+compare.ID.String())
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("GET diff = %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	var got diff.ScanDiff
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("diff is not JSON: %v", err)
+	}
+
+	want := diff.ScanDiff{
+		BaseScanID:    base.ID,
+		CompareScanID: compare.ID,
+		NewHosts:      []string{"192.0.2.30"},
+		RemovedHosts:  []string{"192.0.2.20"},
+		NewPorts: []diff.PortChange{
+			{IPAddress: "192.0.2.10", Port: 443, Protocol: "tcp", Service: "https"},
+			{IPAddress: "192.0.2.30", Port: 3306, Protocol: "tcp", Service: "mysql"},
+		},
+		RemovedPorts: []diff.PortChange{
+			{IPAddress: "192.0.2.10", Port: 80, Protocol: "tcp", Service: "http"},
+			{IPAddress: "192.0.2.20", Port: 25, Protocol: "tcp", Service: "smtp"},
+		},
+		NewVulns: []diff.VulnChange{{IPAddress: "192.0.2.10", Port: 22, Protocol: "tcp", CVE: "CVE-2023-38408", Severity: "high"}},
+		ChangedServices: []diff.ServiceChange{{
+			IPAddress: "192.0.2.10", Port: 22, Protocol: "tcp", Direction: diff.ServiceDowngraded, Notable: true,
+			BaseService: "ssh", BaseProduct: "OpenSSH", BaseVersion: "8.9p1",
+			Service: "ssh", Product: "OpenSSH", Version: "8.4p1",
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// The same diff rendered by a diff formatter
+	resp, body = get(t, server, "/scans/"+base.ID.String()+"/diff/"+compare.ID.String()+"?format=markdown")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/markdown" || !strings.Contains(string(body), "192.0.2.30") {
+		t.Errorf("GET diff?format=markdown = %d %s:\n%s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+
+	errorTests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"unknown scan", "/scans/" + base.ID.String() + "/diff/" + uuid.NewString(), http.StatusNotFound},
+		{"invalid ID", "/scans/" + base.ID.String() + "/diff/latest", http.StatusBadRequest},
+		{"format without diffs", "/scans/" + base.ID.String() + "/diff/" + compare.ID.String() + "?format=csv", http.StatusBadRequest},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, server, tt.path)
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s = %d: %s, want %d", tt.path, resp.StatusCode, body, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetDrift(t *testing.T) {
+	tests := []struct {
+		name  string
+		added *models.PortGraph
+		want  string
+	}{
+		{"web port", openPort(8080, "http-proxy", "Apache Tomcat", "9.0.85"), diff.SeverityMedium},
+		{"database port", openPort(3306, "mysql", "MySQL", "8.0.36"), diff.SeverityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &models.ScanTarget{ID: uuid.New(), Target: "192.0.2.10", Type: "host"}
+			baseline := scanGraph(target.ID, hostGraph("192.0.2.10", openPort(22, "ssh", "OpenSSH", "9.6p1"), openPort(443, "https", "nginx", "1.24.0")))
+			baseline.Pinned = true
+			latest := scanGraph(target.ID, hostGraph("192.0.2.10", openPort(22, "ssh", "OpenSSH", "9.6p1"), openPort(443, "https", "nginx", "1.24.0"), tt.added))
+			server := newTestServer(&fakeStore{
+				graphs:    map[uuid.UUID]*models.FullScanResult{baseline.ID: baseline, latest.ID: latest},
+				targets:   map[uuid.UUID]*models.ScanTarget{target.ID: target},
+				baselines: map[uuid.UUID]uuid.UUID{target.ID: baseline.ID},
+				latest:    map[uuid.UUID]uuid.UUID{target.ID: latest.ID},
+			})
+			defer server.Close()
+
+			resp, body := get(t, server, "/targets/"+target.ID.String()+"/drift")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("GET drift = %d: %s", resp.StatusCode, body)
+			}
+			var got diff.Drift
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("drift is not JSON: %v", err)
+			}
+
+			change := diff.Change{Kind: diff.ChangeNewPort, Severity: tt.want, IPAddress: "192.0.2.10", Port: tt.added.Number, Protocol: "tcp", Service: tt.added.Service}
+			if got.TargetID != target.ID || got.Severity != tt.want || !reflect.DeepEqual(got.Changes, []diff.Change{change}) {
+				t.Errorf("drift of %s rated %s with changes %+v, want %s with %+v", got.TargetID, got.Severity, got.Changes, tt.want, change)
+			}
+			if got.Diff == nil || got.Diff.BaseScanID != baseline.ID || got.Diff.CompareScanID != latest.ID {
+				t.Errorf("drift diff %+v does not compare the baseline %s with the latest scan %s", got.Diff, baseline.ID, latest.ID)
+			}
+		})
+	}
+
+	t.Run("no baseline", func(t *testing.T) {
+		target := &models.ScanTarget{ID: uuid.New(), Target: "192.0.2.10", Type: "host"}
+		server := newTestServer(&fakeStore{targets: map[uuid.UUID]*models.ScanTarget{target.ID: target}})
+		defer server.Close()
+
+		if resp, body := get(t, server, "/targets/"+target.ID.String()+"/drift"); resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "no baseline") {
+			t.Errorf("GET drift without a baseline = %d: %s", resp.StatusCode, body)
+		}
+		if resp, body := get(t, server, "/targets/"+uuid.NewString()+"/drift"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET drift of an unknown target = %d: %s", resp.StatusCode, body)
+		}
+	})
+}
+
+func TestScanRaw(t *testing.T) {
+	// Output served byte for byte, including line endings, trailing spaces
+	// and non-ASCII script output that re-encoding would disturb
+	nmapOutput := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\r\n<nmaprun args=\"nmap -oX - 192.0.2.10\">\r\n" +
+		"<host><address addr=\"192.0.2.10\" addrtype=\"ipv4\"/><hostscript><script id=\"banner\" output=\"Willkommen &#xe4; \xc3\xa4  \"/></hostscript></host>\r\n</nmaprun>"
+	masscanOutput := "{\"ip\": \"192.0.2.10\", \"ports\": [{\"port\": 22, \"proto\": \"tcp\", \"status\": \"open\"}]}\n"
+
+	graphs := make(map[uuid.UUID]*models.FullScanResult)
+	stored := func(scanType, raw string) uuid.UUID {
+		graph := scanGraph(uuid.New())
+		graph.ScanType = scanType
+		graph.RawOutput = raw
+		graphs[graph.ID] = graph
+		return graph.ID
+	}
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		raw         string
+		contentType string
+	}{
+		{"nmap", stored("nmap", nmapOutput), nmapOutput, "application/xml"},
+		{"masscan", stored("masscan", masscanOutput), masscanOutput, "application/x-ndjson"},
+		{"unknown format", stored("rustscan", "192.0.2.10 -> [22]\n"), "192.0.2.10 -> [22]\n", "text/plain; charset=utf-8"},
+	}
+	noOutput := stored("nmap", "")
+	server := newTestServer(&fakeStore{graphs: graphs})
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, server, "/scans/"+tt.id.String()+"/raw")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("GET raw = %d: %s", resp.StatusCode, body)
+			}
+			if string(body) != tt.raw {
+				t.Errorf("raw output =\n%q\nwant\n%q", body, tt.raw)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if resp.ContentLength != int64(len(tt.raw)) {
+				t.Errorf("Content-Length = %d, want %d", resp.ContentLength, len(tt.raw))
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"no stored output", "/scans/" + noOutput.String() + "/raw", http.StatusNotFound},
+		{"unknown scan", "/scans/" + uuid.NewString() + "/raw", http.StatusNotFound},
+		{"invalid ID", "/scans/latest/raw", http.StatusBadRequest},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, server, tt.path)
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s = %d: %s, want %d", tt.path, resp.StatusCode, body, tt.want)
+			}
+		})
+	}
+
+	resp, err := http.Post(server.URL+"/scans/"+tests[0].id.String()+"/raw", "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST raw = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/spf13/viper"
 )
@@ -14,6 +16,11 @@ type Config struct {
 	Logging  LoggingConfig  `mapstructure:"logging"`
 	Scanner  ScannerConfig  `mapstructure:"scanner"`
 	Server   ServerConfig   `mapstructure:"server"`
+	Output   OutputConfig   `mapstructure:"output"`
+	Enrich   EnrichConfig   `mapstructure:"enrich"`
+	Sink     SinkConfig     `mapstructure:"sink"`
+	Notify   NotifyConfig   `mapstructure:"notify"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
 }
 
 // DatabaseConfig holds database configuration
@@ -24,6 +31,15 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+
+	PendingDir string `mapstructure:"pending_dir"` // Results that failed to save, retried by "db flush-pending"
+
+	IgnoreMigrationErrors bool `mapstructure:"ignore_migration_errors"` // Start on a partly migrated schema instead of aborting
+
+	RetryAttempts    int `mapstructure:"retry_attempts"`    // Tries per call on transient errors such as a reset connection or deadlock
+	RetryBackoffMs   int `mapstructure:"retry_backoff_ms"`  // Delay before the first retry, doubled for each further one
+	BreakerThreshold int `mapstructure:"breaker_threshold"` // Consecutive connection failures before calls fail fast, 0 to never
+	BreakerCooldown  int `mapstructure:"breaker_cooldown"`  // Seconds calls fail fast before the database is tried again
 }
 
 // LoggingConfig holds logging configuration
@@ -35,10 +51,39 @@ type LoggingConfig struct {
 
 // ScannerConfig holds scanner configuration
 type ScannerConfig struct {
-	DefaultTimeout int               `mapstructure:"default_timeout"`
-	MaxThreads     int               `mapstructure:"max_threads"`
-	DefaultPorts   string            `mapstructure:"default_ports"`
-	Presets        map[string]Preset `mapstructure:"presets"`
+	DefaultTimeout    int               `mapstructure:"default_timeout"`
+	MaxTotalDuration  int               `mapstructure:"max_total_duration"` // Seconds allowed for scan, enrichment and saving together, 0 for no limit
+	MaxThreads        int               `mapstructure:"max_threads"`
+	DefaultPorts      string            `mapstructure:"default_ports"`
+	DefaultScanner    string            `mapstructure:"default_scanner"`
+	Fallback          []string          `mapstructure:"fallback"` // Scanners tried in order when the default is unavailable
+	MaxHosts          int               `mapstructure:"max_hosts"`
+	TargetConcurrency int               `mapstructure:"target_concurrency"` // Scans allowed to run against one target at a time, 0 for no limit
+	TargetBusy        string            `mapstructure:"target_busy"`        // queue or reject scans over the limit
+	Exclude           []string          `mapstructure:"exclude"`            // Addresses and CIDR blocks never scanned, on top of the stored do-not-scan list
+	Presets           map[string]Preset `mapstructure:"presets"`
+	Nmap              NmapConfig        `mapstructure:"nmap"`
+	Remote            RemoteConfig      `mapstructure:"remote"`
+}
+
+// RemoteConfig holds settings for running scanners on a jump host
+type RemoteConfig struct {
+	SSH SSHConfig `mapstructure:"ssh"`
+}
+
+// SSHConfig holds the jump host used when host is set or --via is passed
+type SSHConfig struct {
+	Host       string `mapstructure:"host"`
+	Port       int    `mapstructure:"port"`
+	User       string `mapstructure:"user"`
+	KeyFile    string `mapstructure:"key_file"`    // Default ~/.ssh/id_ed25519
+	KnownHosts string `mapstructure:"known_hosts"` // Default ~/.ssh/known_hosts
+}
+
+// NmapConfig holds nmap-specific configuration
+type NmapConfig struct {
+	DataDir     string   `mapstructure:"datadir"`      // Custom directory for nmap-os-db, nmap-service-probes, etc.
+	VulnScripts []string `mapstructure:"vuln_scripts"` // NSE scripts run by scan --mode vuln
 }
 
 // Preset holds scanner preset configuration
@@ -55,6 +100,102 @@ type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 }
 
+// OutputConfig holds report output configuration
+type OutputConfig struct {
+	SigningKey   string       `mapstructure:"signing_key"`   // Ed25519 private key (PEM) used to sign reports
+	VerifyKey    string       `mapstructure:"verify_key"`    // Ed25519 public key (PEM) used to verify reports
+	HTMLTemplate string       `mapstructure:"html_template"` // Go html/template file replacing the built-in HTML report
+	Redact       RedactConfig `mapstructure:"redact"`
+}
+
+// RedactConfig holds the rules applied by --redact when exporting reports
+type RedactConfig struct {
+	MaskIPs          bool     `mapstructure:"mask_ips"`          // Mask the last octet of IPv4 addresses
+	HostnamePatterns []string `mapstructure:"hostname_patterns"` // Regexes of hostnames to redact
+}
+
+// SinkConfig holds message queue sink configuration
+type SinkConfig struct {
+	NATS   NATSSinkConfig   `mapstructure:"nats"`
+	Syslog SyslogSinkConfig `mapstructure:"syslog"`
+}
+
+// NATSSinkConfig holds NATS publishing configuration
+type NATSSinkConfig struct {
+	URL               string `mapstructure:"url"`
+	Subject           string `mapstructure:"subject"`
+	PerHost           bool   `mapstructure:"per_host"`            // One message per host instead of per scan
+	MaxReconnects     int    `mapstructure:"max_reconnects"`      // -1 retries forever
+	ReconnectWait     int    `mapstructure:"reconnect_wait"`      // Seconds between reconnect attempts
+	ReconnectBufferMB int    `mapstructure:"reconnect_buffer_mb"` // Messages held while the broker is unreachable
+}
+
+// SyslogSinkConfig holds the syslog collector receiving CEF events
+type SyslogSinkConfig struct {
+	Network  string `mapstructure:"network"` // udp or tcp
+	Address  string `mapstructure:"address"` // host:port
+	AppName  string `mapstructure:"app_name"`
+	Facility string `mapstructure:"facility"` // user, daemon, auth, authpriv or local0-local7
+}
+
+// NotifyConfig holds notification configuration
+type NotifyConfig struct {
+	SMTP SMTPNotifyConfig `mapstructure:"smtp"`
+}
+
+// SMTPNotifyConfig holds the mail server used for scan digests
+type SMTPNotifyConfig struct {
+	Host          string   `mapstructure:"host"`
+	Port          int      `mapstructure:"port"`
+	Username      string   `mapstructure:"username"`
+	Password      string   `mapstructure:"password"`
+	From          string   `mapstructure:"from"`
+	To            []string `mapstructure:"to"`
+	TLS           string   `mapstructure:"tls"`            // none, starttls or tls
+	AttachReports bool     `mapstructure:"attach_reports"` // Attach HTML reports to the digest
+}
+
+// EnrichConfig holds post-scan enrichment configuration
+type EnrichConfig struct {
+	Concurrency int                    `mapstructure:"concurrency"` // Enrichment tasks running at once, across all enrichers
+	Timeout     int                    `mapstructure:"timeout"`     // Seconds allowed per enrichment task (0 = no limit)
+	Cache       EnrichCacheConfig      `mapstructure:"cache"`
+	HTTP        HTTPEnrichConfig       `mapstructure:"http"`
+	Reputation  ReputationEnrichConfig `mapstructure:"reputation"`
+}
+
+// EnrichCacheConfig bounds the in-process cache of DNS and reputation
+// lookups shared by enrichers
+type EnrichCacheConfig struct {
+	Size int `mapstructure:"size"` // Entries kept, least recently used evicted first (0 = no caching)
+	TTL  int `mapstructure:"ttl"`  // Seconds an entry is kept (0 = until exit)
+}
+
+// HTTPEnrichConfig holds HTTP title/header enrichment configuration
+type HTTPEnrichConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	UserAgent     string   `mapstructure:"user_agent"`
+	Paths         []string `mapstructure:"paths"`
+	RespectRobots bool     `mapstructure:"respect_robots"`
+}
+
+// ReputationEnrichConfig holds threat-intel reputation lookup configuration.
+// Enrichment is skipped when no source is configured.
+type ReputationEnrichConfig struct {
+	BlocklistFile     string `mapstructure:"blocklist_file"`
+	AbuseIPDBAPIKey   string `mapstructure:"abuseipdb_api_key"`
+	RequestsPerMinute int    `mapstructure:"requests_per_minute"`
+}
+
+// TracingConfig holds OpenTelemetry trace export configuration.
+// Spans are not exported when no endpoint is configured.
+type TracingConfig struct {
+	OTLPEndpoint string  `mapstructure:"otlp_endpoint"` // OTLP/HTTP collector, e.g. http://localhost:4318
+	Insecure     bool    `mapstructure:"insecure"`      // Plain HTTP when the endpoint has no scheme
+	ServiceName  string  `mapstructure:"service_name"`
+	SampleRatio  float64 `mapstructure:"sample_ratio"` // Fraction of traces recorded, 0-1
+}
+
 // LoadConfig loads configuration from file and environment variables
 func LoadConfig(configPath string) (*Config, error) {
 	viper.SetDefault("database.host", "localhost")
@@ -63,18 +204,78 @@ func LoadConfig(configPath string) (*Config, error) {
 	viper.SetDefault("database.password", "postgres")
 	viper.SetDefault("database.dbname", "netrecon")
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("database.pending_dir", "./pending")
+	viper.SetDefault("database.ignore_migration_errors", false)
+	viper.SetDefault("database.retry_attempts", 3)
+	viper.SetDefault("database.retry_backoff_ms", 100)
+	viper.SetDefault("database.breaker_threshold", 5)
+	viper.SetDefault("database.breaker_cooldown", 30)
 
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "text")
 	viper.SetDefault("logging.file", "")
 
 	viper.SetDefault("scanner.default_timeout", 300)
+	viper.SetDefault("scanner.max_total_duration", 0)
 	viper.SetDefault("scanner.max_threads", 1000)
 	viper.SetDefault("scanner.default_ports", "1-1000")
+	viper.SetDefault("scanner.default_scanner", "nmap")
+	viper.SetDefault("scanner.fallback", []string{"masscan"})
+	viper.SetDefault("scanner.max_hosts", 100000)
+	viper.SetDefault("scanner.target_concurrency", 1)
+	viper.SetDefault("scanner.target_busy", "queue")
+	viper.SetDefault("scanner.exclude", []string{})
+	viper.SetDefault("scanner.nmap.datadir", "")
+	viper.SetDefault("scanner.nmap.vuln_scripts", []string{"vulners", "http-enum", "ssl-*"})
+	viper.SetDefault("scanner.remote.ssh.host", "")
+	viper.SetDefault("scanner.remote.ssh.port", 22)
+	viper.SetDefault("scanner.remote.ssh.user", "")
+	viper.SetDefault("scanner.remote.ssh.key_file", "")
+	viper.SetDefault("scanner.remote.ssh.known_hosts", "")
 
 	viper.SetDefault("server.host", "localhost")
 	viper.SetDefault("server.port", 8080)
 
+	viper.SetDefault("output.signing_key", "")
+	viper.SetDefault("output.verify_key", "")
+	viper.SetDefault("output.html_template", "")
+
+	viper.SetDefault("output.redact.mask_ips", true)
+	viper.SetDefault("output.redact.hostname_patterns", []string{})
+	viper.SetDefault("sink.nats.url", "nats://localhost:4222")
+	viper.SetDefault("sink.nats.subject", "netrecon.scans")
+	viper.SetDefault("sink.nats.per_host", false)
+	viper.SetDefault("sink.nats.max_reconnects", 10)
+	viper.SetDefault("sink.nats.reconnect_wait", 2)
+	viper.SetDefault("sink.nats.reconnect_buffer_mb", 8)
+	viper.SetDefault("sink.syslog.network", "udp")
+	viper.SetDefault("sink.syslog.address", "localhost:514")
+	viper.SetDefault("sink.syslog.app_name", "netrecon")
+	viper.SetDefault("sink.syslog.facility", "local0")
+	viper.SetDefault("notify.smtp.host", "")
+	viper.SetDefault("notify.smtp.port", 587)
+	viper.SetDefault("notify.smtp.username", "")
+	viper.SetDefault("notify.smtp.password", "")
+	viper.SetDefault("notify.smtp.from", "")
+	viper.SetDefault("notify.smtp.to", []string{})
+	viper.SetDefault("notify.smtp.tls", "starttls")
+	viper.SetDefault("notify.smtp.attach_reports", false)
+	viper.SetDefault("enrich.concurrency", 20)
+	viper.SetDefault("enrich.timeout", 10)
+	viper.SetDefault("enrich.cache.size", 10000)
+	viper.SetDefault("enrich.cache.ttl", 3600)
+	viper.SetDefault("enrich.http.enabled", false)
+	viper.SetDefault("enrich.http.user_agent", "netrecon/1.0")
+	viper.SetDefault("enrich.http.paths", []string{"/"})
+	viper.SetDefault("enrich.http.respect_robots", true)
+	viper.SetDefault("enrich.reputation.blocklist_file", "")
+	viper.SetDefault("enrich.reputation.abuseipdb_api_key", "")
+	viper.SetDefault("enrich.reputation.requests_per_minute", 30)
+	viper.SetDefault("tracing.otlp_endpoint", "")
+	viper.SetDefault("tracing.insecure", false)
+	viper.SetDefault("tracing.service_name", "netrecon")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+
 	// Set environment variable prefix
 	viper.SetEnvPrefix("NETRECON")
 	viper.AutomaticEnv()
@@ -125,6 +326,141 @@ func SaveConfig(config *Config, configPath string) error {
 	viper.Set("logging", config.Logging)
 	viper.Set("scanner", config.Scanner)
 	viper.Set("server", config.Server)
+	viper.Set("output", config.Output)
+	viper.Set("enrich", config.Enrich)
+	viper.Set("sink", config.Sink)
+	viper.Set("notify", config.Notify)
+	viper.Set("tracing", config.Tracing)
 
 	return viper.WriteConfigAs(configPath)
 }
+
+// validLogLevels are the accepted logging.level values
+var validLogLevels = map[string]bool{
+	"panic": true, "fatal": true, "error": true, "warn": true,
+	"warning": true, "info": true, "debug": true, "trace": true,
+}
+
+// knownScanners are the accepted scanner names
+var knownScanners = map[string]bool{"nmap": true, "masscan": true, "rustscan": true, "naabu": true}
+
+// Validate checks the configuration for values that would fail at runtime
+// and returns every problem found
+func (c *Config) Validate() []error {
+	var problems []error
+
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		problems = append(problems, fmt.Errorf("database.port %d out of range", c.Database.Port))
+	}
+	if c.Database.PendingDir == "" {
+		problems = append(problems, fmt.Errorf("database.pending_dir must be set"))
+	}
+	if c.Database.RetryAttempts < 1 {
+		problems = append(problems, fmt.Errorf("database.retry_attempts must be positive"))
+	}
+	if c.Database.RetryBackoffMs < 0 {
+		problems = append(problems, fmt.Errorf("database.retry_backoff_ms must not be negative"))
+	}
+	if c.Database.BreakerThreshold < 0 {
+		problems = append(problems, fmt.Errorf("database.breaker_threshold must not be negative"))
+	}
+	if c.Database.BreakerCooldown < 0 {
+		problems = append(problems, fmt.Errorf("database.breaker_cooldown must not be negative"))
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, fmt.Errorf("server.port %d out of range", c.Server.Port))
+	}
+	if !validLogLevels[c.Logging.Level] {
+		problems = append(problems, fmt.Errorf("logging.level %q is not a valid level", c.Logging.Level))
+	}
+	if c.Scanner.DefaultTimeout < 0 {
+		problems = append(problems, fmt.Errorf("scanner.default_timeout must not be negative"))
+	}
+	if c.Scanner.MaxTotalDuration < 0 {
+		problems = append(problems, fmt.Errorf("scanner.max_total_duration must not be negative"))
+	}
+	if c.Scanner.MaxThreads <= 0 {
+		problems = append(problems, fmt.Errorf("scanner.max_threads must be positive"))
+	}
+	if c.Scanner.MaxHosts < 0 {
+		problems = append(problems, fmt.Errorf("scanner.max_hosts must not be negative"))
+	}
+	if c.Scanner.TargetConcurrency < 0 {
+		problems = append(problems, fmt.Errorf("scanner.target_concurrency must not be negative"))
+	}
+	if c.Scanner.TargetBusy != "queue" && c.Scanner.TargetBusy != "reject" {
+		problems = append(problems, fmt.Errorf("scanner.target_busy must be queue or reject, got %q", c.Scanner.TargetBusy))
+	}
+	for _, entry := range c.Scanner.Exclude {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				problems = append(problems, fmt.Errorf("scanner.exclude: %q is not an IP address or CIDR block", entry))
+			}
+		}
+	}
+	if c.Scanner.Nmap.DataDir != "" {
+		if info, err := os.Stat(c.Scanner.Nmap.DataDir); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Errorf("scanner.nmap.datadir %s is not a directory", c.Scanner.Nmap.DataDir))
+		}
+	}
+	if len(c.Scanner.Nmap.VulnScripts) == 0 {
+		problems = append(problems, fmt.Errorf("scanner.nmap.vuln_scripts must list at least one script"))
+	}
+	if c.Output.SigningKey != "" {
+		if _, err := os.Stat(c.Output.SigningKey); err != nil {
+			problems = append(problems, fmt.Errorf("output.signing_key: %w", err))
+		}
+	}
+
+	if !knownScanners[c.Scanner.DefaultScanner] {
+		problems = append(problems, fmt.Errorf("scanner.default_scanner: unknown scanner %q", c.Scanner.DefaultScanner))
+	}
+	for _, name := range c.Scanner.Fallback {
+		if !knownScanners[name] {
+			problems = append(problems, fmt.Errorf("scanner.fallback: unknown scanner %q", name))
+		}
+	}
+
+	for _, pattern := range c.Output.Redact.HostnamePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Errorf("output.redact.hostname_patterns: %w", err))
+		}
+	}
+	if c.Enrich.Concurrency < 1 {
+		problems = append(problems, fmt.Errorf("enrich.concurrency must be positive"))
+	}
+	if c.Enrich.Timeout < 0 {
+		problems = append(problems, fmt.Errorf("enrich.timeout must not be negative"))
+	}
+	if c.Enrich.Cache.Size < 0 {
+		problems = append(problems, fmt.Errorf("enrich.cache.size must not be negative"))
+	}
+	if c.Enrich.Cache.TTL < 0 {
+		problems = append(problems, fmt.Errorf("enrich.cache.ttl must not be negative"))
+	}
+	if c.Sink.NATS.ReconnectBufferMB < 0 {
+		problems = append(problems, fmt.Errorf("sink.nats.reconnect_buffer_mb must not be negative"))
+	}
+	switch c.Sink.Syslog.Network {
+	case "udp", "tcp":
+	default:
+		problems = append(problems, fmt.Errorf("sink.syslog.network %q must be udp or tcp", c.Sink.Syslog.Network))
+	}
+	switch c.Notify.SMTP.TLS {
+	case "none", "starttls", "tls":
+	default:
+		problems = append(problems, fmt.Errorf("notify.smtp.tls %q must be none, starttls or tls", c.Notify.SMTP.TLS))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, fmt.Errorf("tracing.sample_ratio %g must be between 0 and 1", c.Tracing.SampleRatio))
+	}
+
+	for name, preset := range c.Scanner.Presets {
+		if !knownScanners[preset.Scanner] {
+			problems = append(problems, fmt.Errorf("preset %s: unknown scanner %q", name, preset.Scanner))
+		}
+	}
+
+	return problems
+}
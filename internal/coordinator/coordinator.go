@@ -0,0 +1,238 @@
+// Package coordinator runs internet-scale scans as a campaign of shards,
+// checkpointing each shard so an interrupted campaign resumes where it stopped
+package coordinator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Store checkpoints campaigns; database.Repository implements it
+type Store interface {
+	FindOpenCampaign(key string) (*models.Campaign, error)
+	CreateCampaign(campaign *models.Campaign, shards []*models.CampaignShard) error
+	ListCampaignShards(campaignID uuid.UUID) ([]*models.CampaignShard, error)
+	UpdateCampaignShard(shard *models.CampaignShard) error
+	CompleteCampaign(campaignID uuid.UUID) error
+}
+
+// SaveFunc stores the result of a shard and returns the ID of the stored scan
+type SaveFunc func(ctx context.Context, shard *models.CampaignShard, result *scanner.ScanResult) (uuid.UUID, error)
+
+// Options describe the campaign to run
+type Options struct {
+	Scanner    scanner.Scanner
+	Config     *scanner.ScanConfig // Threads is the global packet rate; shards run one at a time at that rate
+	Targets    []string
+	ShardHosts uint64 // Largest target block per shard, DefaultShardHosts when 0
+	PortChunks int    // Parts the port range is split into, 1 when 0
+}
+
+// Progress reports how far a campaign has got. Work is weighted by the
+// estimated packets of each shard, so a /16 counts more than a single host.
+type Progress struct {
+	CampaignID   uuid.UUID     `json:"campaign_id"`
+	Resumed      bool          `json:"resumed"` // The campaign continues an interrupted run
+	ShardsDone   int           `json:"shards_done"`
+	ShardsFailed int           `json:"shards_failed"`
+	ShardsTotal  int           `json:"shards_total"`
+	PacketsDone  uint64        `json:"packets_done"`
+	PacketsTotal uint64        `json:"packets_total"`
+	HostsFound   int           `json:"hosts_found"`
+	Elapsed      time.Duration `json:"elapsed"` // Time spent in this run, excluding earlier runs
+	ETA          time.Duration `json:"eta"`     // Estimated time to finish the remaining shards
+}
+
+// Fraction returns the share of the campaign's packets already sent, 0-1
+func (p Progress) Fraction() float64 {
+	if p.PacketsTotal == 0 {
+		return 0
+	}
+	return float64(p.PacketsDone) / float64(p.PacketsTotal)
+}
+
+// Coordinator runs campaigns
+type Coordinator struct {
+	store      Store
+	save       SaveFunc
+	clock      clock.Clock
+	onProgress func(Progress)
+}
+
+// New creates a coordinator checkpointing to store and saving shard results with save
+func New(store Store, save SaveFunc) *Coordinator {
+	return &Coordinator{store: store, save: save, clock: clock.Real{}}
+}
+
+// SetClock replaces the clock used for checkpoints and the ETA
+func (c *Coordinator) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// OnProgress sets a function called when the campaign starts and after each shard
+func (c *Coordinator) OnProgress(fn func(Progress)) {
+	c.onProgress = fn
+}
+
+// Run plans the campaign, resumes its checkpoint if an earlier run with the
+// same scanner, targets and ports was interrupted, and scans every shard not
+// yet completed, failed shards included. Cancelling ctx abandons the
+// current shard, leaving it pending for the next run. The returned campaign
+// is complete only when every shard succeeded.
+func (c *Coordinator) Run(ctx context.Context, opts Options) (*models.Campaign, error) {
+	shardHosts := opts.ShardHosts
+	if shardHosts == 0 {
+		shardHosts = DefaultShardHosts
+	}
+	planned, err := Plan(opts.Targets, opts.Config.Ports, shardHosts, opts.PortChunks)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign, shards, resumed, err := c.open(opts.Scanner.GetName(), opts.Config.Ports, planned)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := Progress{CampaignID: campaign.ID, Resumed: resumed, ShardsTotal: len(shards)}
+	for _, shard := range shards {
+		progress.PacketsTotal += shard.Packets
+		if shard.Status == models.ShardCompleted {
+			progress.ShardsDone++
+			progress.PacketsDone += shard.Packets
+			progress.HostsFound += shard.Hosts
+		}
+	}
+
+	started := c.clock.Now()
+	var sessionPackets uint64
+	report := func() {
+		progress.Elapsed = c.clock.Now().Sub(started)
+		progress.ETA = c.eta(progress, sessionPackets, opts.Config.Threads)
+		if c.onProgress != nil {
+			c.onProgress(progress)
+		}
+	}
+	report()
+
+	for _, shard := range shards {
+		if shard.Status == models.ShardCompleted {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return campaign, err
+		}
+
+		if err := c.runShard(ctx, opts, shard); err != nil {
+			return campaign, err
+		}
+
+		if shard.Status == models.ShardCompleted {
+			progress.ShardsDone++
+			progress.HostsFound += shard.Hosts
+		} else {
+			progress.ShardsFailed++
+		}
+		progress.PacketsDone += shard.Packets
+		sessionPackets += shard.Packets
+		report()
+	}
+
+	if progress.ShardsFailed > 0 {
+		return campaign, fmt.Errorf("%d of %d shards failed; run the same scan again to retry them", progress.ShardsFailed, len(shards))
+	}
+	if err := c.store.CompleteCampaign(campaign.ID); err != nil {
+		return campaign, err
+	}
+	now := c.clock.Now()
+	campaign.CompletedAt = &now
+	return campaign, nil
+}
+
+// open resumes the unfinished campaign with the plan's key or creates one
+func (c *Coordinator) open(scannerName, ports string, planned []*models.CampaignShard) (*models.Campaign, []*models.CampaignShard, bool, error) {
+	key := Key(scannerName, planned)
+
+	campaign, err := c.store.FindOpenCampaign(key)
+	if err == nil {
+		shards, err := c.store.ListCampaignShards(campaign.ID)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return campaign, shards, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, false, fmt.Errorf("failed to look up campaign checkpoint: %w", err)
+	}
+
+	campaign = &models.Campaign{Key: key, Scanner: scannerName, Ports: ports}
+	if err := c.store.CreateCampaign(campaign, planned); err != nil {
+		return nil, nil, false, err
+	}
+	return campaign, planned, false, nil
+}
+
+// runShard scans one shard and checkpoints the outcome. A shard that fails
+// or returns a partial result is marked failed and the campaign moves on;
+// only an interruption or a failed checkpoint stops it.
+func (c *Coordinator) runShard(ctx context.Context, opts Options, shard *models.CampaignShard) error {
+	config := *opts.Config
+	config.Ports = shard.Ports
+
+	result, err := scanner.Run(ctx, opts.Scanner, shard.Target, &config)
+	if ctx.Err() != nil {
+		// Left pending: the next run starts this shard over
+		return ctx.Err()
+	}
+
+	shard.Status, shard.Error, shard.ScanID, shard.Hosts = models.ShardFailed, "", nil, 0
+	switch {
+	case err != nil:
+		shard.Error = err.Error()
+	default:
+		scanID, saveErr := c.save(ctx, shard, result)
+		if saveErr != nil {
+			shard.Error = fmt.Sprintf("failed to save result: %v", saveErr)
+			break
+		}
+		shard.ScanID = &scanID
+		shard.Hosts = len(result.Hosts)
+		if result.Complete {
+			shard.Status = models.ShardCompleted
+		} else {
+			shard.Error = fmt.Sprintf("incomplete result (%s, %.0f%% covered)", result.Status, result.Coverage*100)
+		}
+	}
+
+	now := c.clock.Now()
+	shard.FinishedAt = &now
+	if err := c.store.UpdateCampaignShard(shard); err != nil {
+		return fmt.Errorf("failed to checkpoint shard %d: %w", shard.Index, err)
+	}
+	return nil
+}
+
+// eta estimates the time left from the rate measured in this run, or from
+// the configured packet rate before the first shard finishes
+func (c *Coordinator) eta(p Progress, sessionPackets uint64, rate int) time.Duration {
+	remaining := p.PacketsTotal - p.PacketsDone
+	if remaining == 0 {
+		return 0
+	}
+	if sessionPackets > 0 && p.Elapsed > 0 {
+		return scanner.EstimateDuration(remaining, float64(sessionPackets)/p.Elapsed.Seconds())
+	}
+	if rate <= 0 {
+		return 0
+	}
+	return scanner.EstimateDuration(remaining, float64(rate))
+}
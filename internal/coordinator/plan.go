@@ -0,0 +1,83 @@
+package coordinator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/pkg/masscan"
+)
+
+// DefaultShardHosts is the largest target block scanned as one shard, a /16
+const DefaultShardHosts = 65536
+
+// Plan divides targets and ports into shards. Contiguous IPv4 addresses
+// are grouped into CIDR blocks and cut into blocks of at most shardHosts
+// addresses; hostnames and IPv6 targets are one block each. The port
+// specification is split into portChunks parts, and every block is paired
+// with every part.
+func Plan(targets []string, ports string, shardHosts uint64, portChunks int) ([]*models.CampaignShard, error) {
+	if portChunks < 1 {
+		portChunks = 1
+	}
+	chunks, err := masscan.SplitPortRange(ports, portChunks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ports: %w", err)
+	}
+
+	var blocks []string
+	for _, target := range scanner.GroupTargetsIntoCIDRs(targets) {
+		if _, err := scanner.DetectTargetType(target); err != nil {
+			return nil, err
+		}
+		split, ok := scanner.SplitTargetBlocks(target, shardHosts)
+		if !ok {
+			split = []string{target}
+		}
+		blocks = append(blocks, split...)
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no targets to scan")
+	}
+
+	var shards []*models.CampaignShard
+	for _, block := range blocks {
+		hosts, err := scanner.CountTargetHosts(block)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			_, portCount, err := scanner.ParsePortRanges(chunk)
+			if err != nil {
+				return nil, err
+			}
+			// Packet counts are stored as BIGINT
+			packets := scanner.EstimatePackets(hosts, portCount, 1)
+			if packets > math.MaxInt64 {
+				packets = math.MaxInt64
+			}
+			shards = append(shards, &models.CampaignShard{
+				Index:   len(shards),
+				Target:  block,
+				Ports:   chunk,
+				Packets: packets,
+				Status:  models.ShardPending,
+			})
+		}
+	}
+	return shards, nil
+}
+
+// Key identifies a campaign by its scanner and shards, so rerunning the
+// same scan finds the checkpoint of the interrupted one
+func Key(scannerName string, shards []*models.CampaignShard) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", scannerName)
+	for _, shard := range shards {
+		fmt.Fprintf(h, "%s %s\n", shard.Target, shard.Ports)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// upsertPortAssetsQuery records every open port of a scan at the scan's
+// start time. Sightings only ever widen the first/last seen window, so
+// scans can be recorded repeatedly and in any order.
+const upsertPortAssetsQuery = `
+	INSERT INTO port_assets (ip_address, port, protocol, service, first_seen, last_seen, last_scan_id)
+	SELECT DISTINCT ON (h.ip_address, p.number, p.protocol)
+		h.ip_address, p.number, p.protocol, COALESCE(p.service, ''), s.start_time, s.start_time, s.id
+	FROM ports p
+	JOIN hosts h ON h.id = p.host_id
+	JOIN scan_results s ON s.id = h.scan_id
+	WHERE s.id = $1 AND p.state = 'open'
+	ORDER BY h.ip_address, p.number, p.protocol, COALESCE(p.service, '') DESC
+	ON CONFLICT (ip_address, port, protocol) DO UPDATE SET
+		first_seen = LEAST(port_assets.first_seen, EXCLUDED.first_seen),
+		last_seen = GREATEST(port_assets.last_seen, EXCLUDED.last_seen),
+		service = CASE WHEN EXCLUDED.last_seen >= port_assets.last_seen AND EXCLUDED.service <> ''
+			THEN EXCLUDED.service ELSE port_assets.service END,
+		last_scan_id = CASE WHEN EXCLUDED.last_seen >= port_assets.last_seen
+			THEN EXCLUDED.last_scan_id ELSE port_assets.last_scan_id END`
+
+// RecordPortSightings updates port longevity from the open ports stored for
+// a scan and returns the number of ports recorded
+func (r *Repository) RecordPortSightings(scanID uuid.UUID) (int64, error) {
+	res, err := r.db.Exec(upsertPortAssetsQuery, scanID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record port sightings: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListPortAssets returns tracked ports, longest exposed first
+func (r *Repository) ListPortAssets() ([]*models.PortAsset, error) {
+	query := `
+		SELECT host(ip_address), port, protocol, service, first_seen, last_seen, last_scan_id
+		FROM port_assets ORDER BY (last_seen - first_seen) DESC, ip_address, port, protocol`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []*models.PortAsset
+	for rows.Next() {
+		asset := &models.PortAsset{}
+		var lastScanID uuid.NullUUID
+		if err := rows.Scan(&asset.IPAddress, &asset.Port, &asset.Protocol, &asset.Service,
+			&asset.FirstSeen, &asset.LastSeen, &lastScanID); err != nil {
+			return nil, err
+		}
+		asset.LastScanID = lastScanID.UUID
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// ListPortAssetsBefore returns the tracked ports of the given addresses
+// that were first seen open before a time, such as the start of a scan.
+// Entries that are not IP addresses are ignored.
+func (r *Repository) ListPortAssetsBefore(addresses []string, before time.Time) ([]*models.PortAsset, error) {
+	var ips []string
+	for _, address := range addresses {
+		if net.ParseIP(address) != nil {
+			ips = append(ips, address)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT host(ip_address), port, protocol, service, first_seen, last_seen, last_scan_id
+		FROM port_assets
+		WHERE ip_address = ANY($1::inet[]) AND first_seen < $2`
+
+	rows, err := r.db.Query(query, pq.Array(ips), before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list port assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []*models.PortAsset
+	for rows.Next() {
+		asset := &models.PortAsset{}
+		var lastScanID uuid.NullUUID
+		if err := rows.Scan(&asset.IPAddress, &asset.Port, &asset.Protocol, &asset.Service,
+			&asset.FirstSeen, &asset.LastSeen, &lastScanID); err != nil {
+			return nil, err
+		}
+		asset.LastScanID = lastScanID.UUID
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// ListTargetPortCadence returns every port found open by a scan of the
+// target, with how many scans found it open and when it was last seen
+func (r *Repository) ListTargetPortCadence(targetID uuid.UUID) ([]*models.PortCadence, error) {
+	query := `
+		SELECT p.number, p.protocol, COUNT(DISTINCT s.id), MAX(s.start_time)
+		FROM ports p
+		JOIN hosts h ON h.id = p.host_id
+		JOIN scan_results s ON s.id = h.scan_id
+		WHERE s.target_id = $1 AND p.state = 'open'
+		GROUP BY p.number, p.protocol
+		ORDER BY p.protocol, p.number`
+
+	rows, err := r.db.Query(query, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cadence []*models.PortCadence
+	for rows.Next() {
+		c := &models.PortCadence{}
+		if err := rows.Scan(&c.Port, &c.Protocol, &c.OpenScans, &c.LastOpen); err != nil {
+			return nil, err
+		}
+		cadence = append(cadence, c)
+	}
+
+	return cadence, rows.Err()
+}
+
+// ListHostSightings returns the first and last time any port of each host
+// was seen open
+func (r *Repository) ListHostSightings() ([]*models.HostSighting, error) {
+	query := `
+		SELECT host(ip_address), MIN(first_seen), MAX(last_seen)
+		FROM port_assets GROUP BY ip_address ORDER BY ip_address`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sightings []*models.HostSighting
+	for rows.Next() {
+		sighting := &models.HostSighting{}
+		if err := rows.Scan(&sighting.IPAddress, &sighting.FirstSeen, &sighting.LastSeen); err != nil {
+			return nil, err
+		}
+		sightings = append(sightings, sighting)
+	}
+
+	return sightings, rows.Err()
+}
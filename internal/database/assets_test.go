@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Columns of scan_results, hosts and ports rows as inserted into a tableStore
+const (
+	scanStartTimeColumn = 4
+	hostScanIDColumn    = 1
+	hostIPColumn        = 2
+	portHostIDColumn    = 1
+	portNumberColumn    = 2
+	portProtocolColumn  = 3
+	portStateColumn     = 4
+	portServiceColumn   = 5
+)
+
+// portAssetHandler answers the port sighting upsert over the scans, hosts
+// and ports of store, keeping port_assets rows in the order ListPortAssets
+// selects them, and passes everything else to the store
+func portAssetHandler(store *tableStore) fakeHandler {
+	return func(query string, args []driver.Value) (*fakeResult, error) {
+		if !strings.HasPrefix(strings.TrimSpace(query), "INSERT INTO port_assets") {
+			return store.handle(query, args)
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		var start time.Time
+		for _, scan := range store.tables["scan_results"] {
+			if scan[0] == args[0] {
+				start = scan[scanStartTimeColumn].(time.Time)
+			}
+		}
+
+		affected := int64(0)
+		for _, host := range store.tables["hosts"] {
+			if host[hostScanIDColumn] != args[0] {
+				continue
+			}
+			for _, port := range store.tables["ports"] {
+				if port[portHostIDColumn] != host[0] || port[portStateColumn] != "open" {
+					continue
+				}
+				affected++
+				sighting := []driver.Value{host[hostIPColumn], port[portNumberColumn], port[portProtocolColumn],
+					port[portServiceColumn], start, start, args[0]}
+
+				var asset []driver.Value
+				for _, row := range store.tables["port_assets"] {
+					if row[0] == sighting[0] && row[1] == sighting[1] && row[2] == sighting[2] {
+						asset = row
+					}
+				}
+				if asset == nil {
+					store.tables["port_assets"] = append(store.tables["port_assets"], sighting)
+					continue
+				}
+				if start.Before(asset[4].(time.Time)) {
+					asset[4] = start
+				}
+				if !start.Before(asset[5].(time.Time)) {
+					asset[5] = start
+					asset[6] = args[0]
+					if sighting[3] != "" {
+						asset[3] = sighting[3]
+					}
+				}
+			}
+		}
+
+		assets := store.tables["port_assets"]
+		exposure := func(row []driver.Value) time.Duration {
+			return row[5].(time.Time).Sub(row[4].(time.Time))
+		}
+		sort.SliceStable(assets, func(i, j int) bool { return exposure(assets[i]) > exposure(assets[j]) })
+		return &fakeResult{affected: affected}, nil
+	}
+}
+
+func TestPortSightingsSpan(t *testing.T) {
+	store := &tableStore{}
+	db, _ := newFakeDB(t, Config{}, portAssetHandler(store))
+	repo := NewRepository(db)
+
+	firstScan := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	save := func(start time.Time, ports ...*models.Port) uuid.UUID {
+		t.Helper()
+		host := &models.HostGraph{Host: &models.Host{IPAddress: "192.0.2.10", Status: "up"}}
+		for _, port := range ports {
+			host.Ports = append(host.Ports, &models.PortGraph{Port: port})
+		}
+		graph := &models.FullScanResult{
+			ScanResult: &models.ScanResult{TargetID: uuid.New(), ScanType: "nmap", Status: scanner.StatusCompleted, StartTime: start},
+			Hosts:      []*models.HostGraph{host},
+		}
+		if err := repo.SaveScanGraph(graph); err != nil {
+			t.Fatalf("SaveScanGraph() error = %v", err)
+		}
+		return graph.ID
+	}
+
+	earlier := save(firstScan,
+		&models.Port{Number: 443, Protocol: "tcp", State: "open"},
+		&models.Port{Number: 22, Protocol: "tcp", State: "open", Service: "ssh"})
+	later := save(firstScan.Add(week),
+		&models.Port{Number: 443, Protocol: "tcp", State: "open", Service: "https"},
+		&models.Port{Number: 22, Protocol: "tcp", State: "closed"})
+
+	// Sightings widen the window whatever order scans are recorded in, and
+	// recording a scan again changes nothing
+	for _, scanID := range []uuid.UUID{later, earlier, earlier} {
+		if _, err := repo.RecordPortSightings(scanID); err != nil {
+			t.Fatalf("RecordPortSightings(%s) error = %v", scanID, err)
+		}
+	}
+
+	assets, err := repo.ListPortAssets()
+	if err != nil {
+		t.Fatalf("ListPortAssets() error = %v", err)
+	}
+	want := []models.PortAsset{
+		{IPAddress: "192.0.2.10", Port: 443, Protocol: "tcp", Service: "https", FirstSeen: firstScan, LastSeen: firstScan.Add(week), LastScanID: later},
+		{IPAddress: "192.0.2.10", Port: 22, Protocol: "tcp", Service: "ssh", FirstSeen: firstScan, LastSeen: firstScan, LastScanID: earlier},
+	}
+	if len(assets) != len(want) {
+		t.Fatalf("ListPortAssets() returned %d ports, want %d", len(assets), len(want))
+	}
+	for i, asset := range assets {
+		if *asset != want[i] {
+			t.Errorf("port asset %d = %+v, want %+v", i, *asset, want[i])
+		}
+	}
+
+	if got := assets[0].Exposure(); got != week {
+		t.Errorf("port 443 exposed for %v, want %v", got, week)
+	}
+	if got := assets[1].Exposure(); got != 0 {
+		t.Errorf("port 22 exposed for %v, want 0 after a single sighting", got)
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// AuditFilter narrows ListAuditEntries; zero fields match everything
+type AuditFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Target string
+	Actor  string
+	Limit  int
+}
+
+// RecordAudit appends an audit entry. The audit_log table rejects updates
+// and deletes, so entries are never changed once written.
+func (r *Repository) RecordAudit(entry *models.AuditEntry) error {
+	entry.ID = uuid.New()
+	entry.CreatedAt = r.clock.Now()
+
+	var sourceIP sql.NullString
+	if entry.SourceIP != "" {
+		sourceIP = sql.NullString{String: entry.SourceIP, Valid: true}
+	}
+
+	query := `
+		INSERT INTO audit_log (id, action, actor, source, source_ip, target, scanner, scan_config, correlation_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.Exec(query, entry.ID, entry.Action, entry.Actor, entry.Source, sourceIP,
+		entry.Target, entry.Scanner, []byte(entry.ScanConfig), entry.CorrelationID, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns matching audit entries, newest first
+func (r *Repository) ListAuditEntries(filter AuditFilter) ([]*models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if !filter.Since.IsZero() {
+		where("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < $%d", filter.Until)
+	}
+	if filter.Target != "" {
+		where("target = $%d", filter.Target)
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+
+	query := `
+		SELECT id, action, actor, source, COALESCE(host(source_ip), ''), target, scanner, scan_config, correlation_id, created_at
+		FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		entry := &models.AuditEntry{}
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.Actor, &entry.Source, &entry.SourceIP,
+			&entry.Target, &entry.Scanner, &entry.ScanConfig, &entry.CorrelationID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
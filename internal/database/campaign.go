@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// CreateCampaign stores a campaign with all of its shards, pending
+func (r *Repository) CreateCampaign(campaign *models.Campaign, shards []*models.CampaignShard) error {
+	campaign.ID = uuid.New()
+	campaign.CreatedAt = r.clock.Now()
+	campaign.Shards = len(shards)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO scan_campaigns (id, key, scanner, ports, shards, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		campaign.ID, campaign.Key, campaign.Scanner, campaign.Ports, campaign.Shards, campaign.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO scan_campaign_shards (campaign_id, shard_index, target, ports, packets, status)
+		VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare shard insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, shard := range shards {
+		shard.CampaignID = campaign.ID
+		shard.Status = models.ShardPending
+		if _, err := stmt.Exec(shard.CampaignID, shard.Index, shard.Target, shard.Ports, int64(shard.Packets), shard.Status); err != nil {
+			return fmt.Errorf("failed to create shard %d: %w", shard.Index, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FindOpenCampaign returns the unfinished campaign with the given key, or
+// sql.ErrNoRows when there is none to resume
+func (r *Repository) FindOpenCampaign(key string) (*models.Campaign, error) {
+	campaign := &models.Campaign{}
+	err := r.db.QueryRow(`
+		SELECT id, key, scanner, ports, shards, created_at, completed_at
+		FROM scan_campaigns WHERE key = $1 AND completed_at IS NULL`, key).
+		Scan(&campaign.ID, &campaign.Key, &campaign.Scanner, &campaign.Ports, &campaign.Shards, &campaign.CreatedAt, &campaign.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// ListCampaignShards returns the shards of a campaign in order
+func (r *Repository) ListCampaignShards(campaignID uuid.UUID) (_ []*models.CampaignShard, err error) {
+	rows, err := r.db.Query(`
+		SELECT campaign_id, shard_index, target, ports, packets, status, scan_id, hosts, error, finished_at
+		FROM scan_campaign_shards WHERE campaign_id = $1 ORDER BY shard_index`, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaign shards: %w", err)
+	}
+	defer rows.Close()
+
+	var shards []*models.CampaignShard
+	for rows.Next() {
+		shard := &models.CampaignShard{}
+		var packets int64
+		var scanID uuid.NullUUID
+		err := rows.Scan(&shard.CampaignID, &shard.Index, &shard.Target, &shard.Ports, &packets,
+			&shard.Status, &scanID, &shard.Hosts, &shard.Error, &shard.FinishedAt)
+		if err != nil {
+			return nil, err
+		}
+		shard.Packets = uint64(packets)
+		if scanID.Valid {
+			shard.ScanID = &scanID.UUID
+		}
+		shards = append(shards, shard)
+	}
+	return shards, rows.Err()
+}
+
+// UpdateCampaignShard checkpoints the outcome of a shard
+func (r *Repository) UpdateCampaignShard(shard *models.CampaignShard) error {
+	res, err := r.db.Exec(`
+		UPDATE scan_campaign_shards SET status = $3, scan_id = $4, hosts = $5, error = $6, finished_at = $7
+		WHERE campaign_id = $1 AND shard_index = $2`,
+		shard.CampaignID, shard.Index, shard.Status, shard.ScanID, shard.Hosts, shard.Error, shard.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update shard %d: %w", shard.Index, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CompleteCampaign marks a campaign finished, so the next run with the
+// same key starts a new one
+func (r *Repository) CompleteCampaign(campaignID uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE scan_campaigns SET completed_at = $2 WHERE id = $1`, campaignID, r.clock.Now())
+	if err != nil {
+		return fmt.Errorf("failed to complete campaign: %w", err)
+	}
+	return nil
+}
@@ -1,14 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"path/filepath"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/clock"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,12 +18,21 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	Retry   RetryPolicy   // Zero runs each call once
+	Breaker BreakerPolicy // Zero never fails calls fast
 }
 
-// DB wraps sql.DB with additional functionality
+// DB wraps sql.DB with additional functionality. Statements run outside
+// transactions, and transactions being begun, are retried on transient
+// errors and fail fast with ErrUnavailable while the circuit breaker is
+// open; see RetryPolicy and BreakerPolicy.
 type DB struct {
 	*sql.DB
 	logger *logrus.Logger
+
+	retryPolicy RetryPolicy
+	breaker     *breaker
 }
 
 // NewConnection creates a new database connection
@@ -45,40 +52,92 @@ func NewConnection(config Config, logger *logrus.Logger) (*DB, error) {
 
 	logger.Info("Database connection established")
 
-	return &DB{
-		DB:     db,
-		logger: logger,
-	}, nil
+	return newDB(db, config, logger), nil
 }
 
-// Migrate runs database migrations
-func (db *DB) Migrate(migrationsPath string) error {
-	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+// newDB wraps an open connection pool with the policies of config
+func newDB(db *sql.DB, config Config, logger *logrus.Logger) *DB {
+	return &DB{
+		DB:          db,
+		logger:      logger,
+		retryPolicy: config.Retry,
+		breaker:     newBreaker(config.Breaker, clock.Real{}),
 	}
+}
 
-	// Get absolute path for migrations
-	absPath, err := filepath.Abs(migrationsPath)
-	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
-	}
+// Available reports whether the database is believed reachable, false
+// while the circuit breaker is open and calls fail fast
+func (db *DB) Available() bool {
+	return !db.breaker.open()
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", absPath),
-		"postgres",
-		driver,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
+// ExecContext executes a statement, see DB
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	err = db.call(ctx, func() error {
+		res, err = db.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return res, err
+}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
+// Exec executes a statement, see DB
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext runs a query, see DB. Errors reading the rows are not retried.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	err = db.call(ctx, func() error {
+		rows, err = db.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// Query runs a query, see DB
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// Row is the result of QueryRow. The query runs when Scan is called, so
+// that it can be retried.
+type Row struct {
+	db    *DB
+	ctx   context.Context
+	query string
+	args  []interface{}
+}
+
+// QueryRowContext prepares a query expected to return at most one row, see DB
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return &Row{db: db, ctx: ctx, query: query, args: args}
+}
+
+// QueryRow prepares a query expected to return at most one row, see DB
+func (db *DB) QueryRow(query string, args ...interface{}) *Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// Scan runs the query and copies the row's columns into dest, returning
+// sql.ErrNoRows when it matched none
+func (r *Row) Scan(dest ...interface{}) error {
+	return r.db.call(r.ctx, func() error {
+		return r.db.DB.QueryRowContext(r.ctx, r.query, r.args...).Scan(dest...)
+	})
+}
+
+// BeginTx starts a transaction, see DB. Statements inside it are not retried.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *sql.Tx, err error) {
+	err = db.call(ctx, func() error {
+		tx, err = db.DB.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
 
-	db.logger.Info("Database migrations completed")
-	return nil
+// Begin starts a transaction, see DB
+func (db *DB) Begin() (*sql.Tx, error) {
+	return db.BeginTx(context.Background(), nil)
 }
 
 // Close closes the database connection
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// DumpVersion is the format version written by ExportDump. Version 2 added
+// scan completeness; version 1 dumps are still imported.
+const DumpVersion = 2
+
+const insertVulnerabilityQuery = `
+	INSERT INTO vulnerabilities (id, port_id, cve, severity, description, solution, reference_links, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+// Dump is a portable, backend-independent copy of all stored scan data
+type Dump struct {
+	Version         int                     `json:"version"`
+	ExportedAt      time.Time               `json:"exported_at"`
+	Targets         []*models.ScanTarget    `json:"targets"`
+	Scans           []*models.ScanResult    `json:"scans"`
+	Hosts           []*models.Host          `json:"hosts"`
+	Ports           []*models.Port          `json:"ports"`
+	Vulnerabilities []*models.Vulnerability `json:"vulnerabilities"`
+	HTTPProbes      []*models.HTTPProbe     `json:"http_probes"`
+}
+
+// ImportStats counts the rows written and the rows skipped because a row
+// with the same ID already existed
+type ImportStats struct {
+	Inserted int64
+	Skipped  int64
+}
+
+// ExportDump reads every target, scan, host, port, vulnerability and HTTP probe
+func (r *Repository) ExportDump() (*Dump, error) {
+	dump := &Dump{Version: DumpVersion, ExportedAt: r.clock.Now()}
+
+	var err error
+	if dump.Targets, err = r.ListScanTargets(); err != nil {
+		return nil, fmt.Errorf("failed to export targets: %w", err)
+	}
+
+	dump.Scans, err = r.queryScanResults(`
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export scans: %w", err)
+	}
+
+	dump.Hosts, err = r.queryHosts(`
+		SELECT id, scan_id, ip_address, hostname, status, os, os_confidence,
+			reputation_score, reputation_sources, netbios_name, domain, workgroup, host_scripts, mac_address, vendor, ipv6_address, load_balanced, uptime_seconds, last_boot, filtered_port_count, os_family, scan_error, created_at
+		FROM hosts ORDER BY scan_id, ip_address`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export hosts: %w", err)
+	}
+
+	dump.Ports, err = r.queryPorts(`
+		SELECT id, host_id, number, protocol, state, service, version, product, extra_info, guessed_service, reason, reason_ttl, hit_ratio, created_at
+		FROM ports ORDER BY host_id, number`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export ports: %w", err)
+	}
+
+	if dump.Vulnerabilities, err = r.exportVulnerabilities(); err != nil {
+		return nil, fmt.Errorf("failed to export vulnerabilities: %w", err)
+	}
+
+	dump.HTTPProbes, err = r.queryHTTPProbes(`
+		SELECT id, port_id, path, status_code, COALESCE(title, ''), COALESCE(server, ''), COALESCE(error, ''), created_at
+		FROM http_probes ORDER BY port_id, path`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export HTTP probes: %w", err)
+	}
+
+	return dump, nil
+}
+
+func (r *Repository) exportVulnerabilities() ([]*models.Vulnerability, error) {
+	rows, err := r.db.Query(`
+		SELECT id, port_id, cve, severity, description, solution, reference_links, created_at
+		FROM vulnerabilities ORDER BY port_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vulns []*models.Vulnerability
+	for rows.Next() {
+		vuln := &models.Vulnerability{}
+		var cve, solution, refs sql.NullString
+		err := rows.Scan(&vuln.ID, &vuln.PortID, &cve, &vuln.Severity, &vuln.Description,
+			&solution, &refs, &vuln.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		vuln.CVE, vuln.Solution, vuln.ReferenceLinks = cve.String, solution.String, refs.String
+		vulns = append(vulns, vuln)
+	}
+	return vulns, rows.Err()
+}
+
+// ImportDump restores a dump in a single transaction. Every row must
+// reference a parent contained in the dump. With preserveIDs rows keep their
+// UUIDs and rows whose ID already exists are skipped; otherwise fresh UUIDs
+// are generated and references are rewritten to match.
+func (r *Repository) ImportDump(dump *Dump, preserveIDs bool) (ImportStats, error) {
+	var stats ImportStats
+
+	if dump.Version < 1 || dump.Version > DumpVersion {
+		return stats, fmt.Errorf("unsupported dump version %d (expected %d)", dump.Version, DumpVersion)
+	}
+	if dump.Version == 1 {
+		for _, scan := range dump.Scans {
+			scan.Complete, scan.Coverage = scanner.CompletenessFromStatus(scan.Status)
+		}
+	}
+	for _, scan := range dump.Scans {
+		if err := scanner.CheckSchemaVersion(scan); err != nil {
+			return stats, err
+		}
+		scanner.UpgradeScan(scan)
+	}
+
+	ids := make(map[uuid.UUID]uuid.UUID)
+	newID := func(old uuid.UUID) uuid.UUID {
+		id := old
+		if !preserveIDs {
+			id = uuid.New()
+		}
+		ids[old] = id
+		return id
+	}
+	parent := func(kind string, id, ref uuid.UUID) (uuid.UUID, error) {
+		mapped, ok := ids[ref]
+		if !ok {
+			return uuid.Nil, fmt.Errorf("%s %s references %s missing from the dump", kind, id, ref)
+		}
+		return mapped, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := func(query string, args ...interface{}) error {
+		res, err := tx.Exec(query+" ON CONFLICT (id) DO NOTHING", args...)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			stats.Inserted += n
+		} else {
+			stats.Skipped++
+		}
+		return nil
+	}
+
+	for _, t := range dump.Targets {
+		id := newID(t.ID)
+		if err := insert(insertScanTargetQuery, id, t.Target, t.Type, t.Description, t.CreatedAt, t.UpdatedAt); err != nil {
+			return stats, fmt.Errorf("failed to import target %s: %w", t.ID, err)
+		}
+	}
+
+	for _, s := range dump.Scans {
+		targetID, err := parent("scan", s.ID, s.TargetID)
+		if err != nil {
+			return stats, err
+		}
+		id := newID(s.ID)
+		err = insert(insertScanResultQuery, id, targetID, s.ScanType, s.Status, s.StartTime, s.EndTime,
+			s.DurationMs, s.RawOutput, []byte(s.ScanConfig), s.Pinned, s.Complete, s.Coverage, s.TraceID, s.CorrelationID, s.Metadata, s.SchemaVersion, s.CreatedAt)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import scan %s: %w", s.ID, err)
+		}
+	}
+
+	for _, h := range dump.Hosts {
+		scanID, err := parent("host", h.ID, h.ScanID)
+		if err != nil {
+			return stats, err
+		}
+		id := newID(h.ID)
+		err = insert(insertHostQuery, id, scanID, h.IPAddress, h.Hostname, h.Status, h.OS, h.OSConfidence,
+			h.ReputationScore, textArray(h.ReputationSources),
+			h.NetBIOSName, h.Domain, h.Workgroup, h.HostScripts, h.MACAddress, h.Vendor, h.IPv6Address, h.LoadBalanced, h.UptimeSeconds, h.LastBoot, h.FilteredPortCount, h.OSFamily, h.ScanError, h.CreatedAt)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import host %s: %w", h.ID, err)
+		}
+	}
+
+	for _, p := range dump.Ports {
+		hostID, err := parent("port", p.ID, p.HostID)
+		if err != nil {
+			return stats, err
+		}
+		id := newID(p.ID)
+		err = insert(insertPortQuery, id, hostID, p.Number, p.Protocol, p.State,
+			p.Service, p.Version, p.Product, p.ExtraInfo, p.GuessedService, p.Reason, p.ReasonTTL, p.HitRatio, p.CreatedAt)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import port %s: %w", p.ID, err)
+		}
+	}
+
+	for _, v := range dump.Vulnerabilities {
+		portID, err := parent("vulnerability", v.ID, v.PortID)
+		if err != nil {
+			return stats, err
+		}
+		id := newID(v.ID)
+		err = insert(insertVulnerabilityQuery, id, portID, v.CVE, v.Severity, v.Description,
+			v.Solution, v.ReferenceLinks, v.CreatedAt)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import vulnerability %s: %w", v.ID, err)
+		}
+	}
+
+	for _, probe := range dump.HTTPProbes {
+		portID, err := parent("HTTP probe", probe.ID, probe.PortID)
+		if err != nil {
+			return stats, err
+		}
+		id := newID(probe.ID)
+		err = insert(insertHTTPProbeQuery, id, portID, probe.Path, probe.StatusCode,
+			probe.Title, probe.Server, probe.Error, probe.CreatedAt)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import HTTP probe %s: %w", probe.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+var (
+	insertTableRegex = regexp.MustCompile(`INSERT INTO (\w+)`)
+	selectTableRegex = regexp.MustCompile(`FROM (\w+)`)
+)
+
+// tableStore keeps the rows inserted into each table and answers a SELECT
+// from a table with its rows in insertion order. Inserts and exports list
+// their columns in the same order, so a store is enough to round-trip a dump.
+type tableStore struct {
+	mu     sync.Mutex
+	tables map[string][][]driver.Value
+}
+
+func (s *tableStore) handle(query string, args []driver.Value) (*fakeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tables == nil {
+		s.tables = make(map[string][][]driver.Value)
+	}
+
+	if m := insertTableRegex.FindStringSubmatch(query); m != nil {
+		// Every insert ends ON CONFLICT (id) DO NOTHING or has a fresh ID
+		for _, row := range s.tables[m[1]] {
+			if row[0] == args[0] {
+				return &fakeResult{}, nil
+			}
+		}
+		s.tables[m[1]] = append(s.tables[m[1]], args)
+		return &fakeResult{affected: 1}, nil
+	}
+	if m := selectTableRegex.FindStringSubmatch(query); m != nil {
+		return &fakeResult{rows: s.tables[m[1]]}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+// count returns the number of rows stored across all tables
+func (s *tableStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, rows := range s.tables {
+		n += len(rows)
+	}
+	return n
+}
+
+// newStoreRepository returns a repository backed by a fresh tableStore
+func newStoreRepository(t *testing.T, now time.Time) (*Repository, *tableStore) {
+	store := &tableStore{}
+	db, _ := newFakeDB(t, Config{}, store.handle)
+	repo := NewRepository(db)
+	repo.SetClock(clock.NewMock(now))
+	return repo, store
+}
+
+// dumpRecords describes the rows of a dump without their IDs, following
+// each reference to its parent
+func dumpRecords(dump *Dump) []string {
+	targets := make(map[uuid.UUID]string)
+	for _, t := range dump.Targets {
+		targets[t.ID] = t.Target
+	}
+	scans := make(map[uuid.UUID]string)
+	for _, s := range dump.Scans {
+		scans[s.ID] = fmt.Sprintf("%s@%s", s.ScanType, targets[s.TargetID])
+	}
+	hosts := make(map[uuid.UUID]string)
+	for _, h := range dump.Hosts {
+		hosts[h.ID] = fmt.Sprintf("%s/%s", scans[h.ScanID], h.IPAddress)
+	}
+	ports := make(map[uuid.UUID]string)
+	for _, p := range dump.Ports {
+		ports[p.ID] = fmt.Sprintf("%s:%d/%s", hosts[p.HostID], p.Number, p.Protocol)
+	}
+
+	var records []string
+	for _, t := range dump.Targets {
+		records = append(records, fmt.Sprintf("target %s %s %q", t.Target, t.Type, t.Description))
+	}
+	for _, s := range dump.Scans {
+		records = append(records, fmt.Sprintf("scan %s %s %dms %s complete=%t %v %s", scans[s.ID], s.Status, s.DurationMs, s.ScanConfig, s.Complete, s.Metadata, s.StartTime.UTC()))
+	}
+	for _, h := range dump.Hosts {
+		records = append(records, fmt.Sprintf("host %s %s %s %v %v", hosts[h.ID], h.Hostname, h.OS, h.ReputationSources, h.HostScripts))
+	}
+	for _, p := range dump.Ports {
+		records = append(records, fmt.Sprintf("port %s %s %s %s %s", ports[p.ID], p.State, p.Service, p.Product, p.Version))
+	}
+	for _, v := range dump.Vulnerabilities {
+		records = append(records, fmt.Sprintf("vulnerability %s %s %s %s", ports[v.PortID], v.CVE, v.Severity, v.ReferenceLinks))
+	}
+	for _, probe := range dump.HTTPProbes {
+		records = append(records, fmt.Sprintf("probe %s%s %d %q", ports[probe.PortID], probe.Path, probe.StatusCode, probe.Title))
+	}
+	return records
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	now := time.Date(2026, 2, 1, 9, 30, 0, 0, time.UTC)
+	source, _ := newStoreRepository(t, now)
+
+	target := &models.ScanTarget{Target: "192.0.2.0/24", Type: "range", Description: "lab"}
+	if err := source.CreateScanTarget(target); err != nil {
+		t.Fatal(err)
+	}
+	end := now.Add(2 * time.Minute)
+	graph := &models.FullScanResult{
+		ScanResult: &models.ScanResult{
+			TargetID:   target.ID,
+			ScanType:   "nmap",
+			Status:     scanner.StatusCompleted,
+			StartTime:  now,
+			EndTime:    &end,
+			DurationMs: 120000,
+			RawOutput:  "<nmaprun/>",
+			ScanConfig: json.RawMessage(`{"ports":"1-1000"}`),
+			Complete:   true,
+			Coverage:   1,
+			Metadata:   models.Metadata{"ticket": "OPS-12"},
+		},
+		Hosts: []*models.HostGraph{
+			{
+				Host: &models.Host{
+					IPAddress: "192.0.2.10", Hostname: "web01", Status: "up", OS: "Linux 5.X",
+					ReputationSources: []string{"spamhaus"},
+					HostScripts:       models.HostScripts{{ID: "smb-os-discovery", Output: "OS: Windows", Elements: map[string]string{"os": "Windows"}}},
+				},
+				Ports: []*models.PortGraph{
+					{
+						Port: &models.Port{
+							Number: 443, Protocol: "tcp", State: "open", Service: "https", Product: "nginx", Version: "1.24.0",
+							HTTPProbes: []*models.HTTPProbe{{Path: "/", StatusCode: 200, Title: "Welcome", Server: "nginx"}},
+						},
+						Vulnerabilities: []*models.Vulnerability{{CVE: "CVE-2023-44487", Severity: "high", Description: "HTTP/2 rapid reset", ReferenceLinks: "https://nvd.nist.gov/vuln/detail/CVE-2023-44487"}},
+					},
+					{Port: &models.Port{Number: 22, Protocol: "tcp", State: "open", Service: "ssh"}},
+				},
+			},
+			{Host: &models.Host{IPAddress: "192.0.2.11", Status: "up"}},
+		},
+	}
+	if err := source.SaveScanGraph(graph); err != nil {
+		t.Fatalf("SaveScanGraph() error = %v", err)
+	}
+
+	exported, err := source.ExportDump()
+	if err != nil {
+		t.Fatalf("ExportDump() error = %v", err)
+	}
+	if exported.Version != DumpVersion || !exported.ExportedAt.Equal(now) {
+		t.Errorf("dump version %d exported at %v, want %d at %v", exported.Version, exported.ExportedAt, DumpVersion, now)
+	}
+	want := dumpRecords(exported)
+	if len(want) != 8 {
+		t.Fatalf("exported %d records, want 8:\n%q", len(want), want)
+	}
+
+	// The dump is written to a file as JSON between export and import
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := func() *Dump {
+		var dump Dump
+		if err := json.Unmarshal(data, &dump); err != nil {
+			t.Fatal(err)
+		}
+		return &dump
+	}
+
+	t.Run("preserved IDs", func(t *testing.T) {
+		dest, store := newStoreRepository(t, now)
+		stats, err := dest.ImportDump(load(), true)
+		if err != nil {
+			t.Fatalf("ImportDump() error = %v", err)
+		}
+		if stats.Inserted != 8 || stats.Skipped != 0 {
+			t.Errorf("ImportDump() = %+v, want 8 inserted", stats)
+		}
+
+		reexported, err := dest.ExportDump()
+		if err != nil {
+			t.Fatalf("ExportDump() error = %v", err)
+		}
+		if got := dumpRecords(reexported); !reflect.DeepEqual(got, want) {
+			t.Errorf("re-exported records =\n%q\nwant\n%q", got, want)
+		}
+		if reexported.Hosts[0].ID != exported.Hosts[0].ID || reexported.Ports[0].ID != exported.Ports[0].ID {
+			t.Error("IDs not preserved")
+		}
+
+		// Importing again skips every row
+		stats, err = dest.ImportDump(load(), true)
+		if err != nil || stats.Inserted != 0 || stats.Skipped != 8 || store.count() != 8 {
+			t.Errorf("second ImportDump() = %+v, %v with %d rows stored; want 8 skipped", stats, err, store.count())
+		}
+	})
+
+	t.Run("fresh IDs", func(t *testing.T) {
+		dest, _ := newStoreRepository(t, now)
+		if _, err := dest.ImportDump(load(), false); err != nil {
+			t.Fatalf("ImportDump() error = %v", err)
+		}
+
+		reexported, err := dest.ExportDump()
+		if err != nil {
+			t.Fatalf("ExportDump() error = %v", err)
+		}
+		if got := dumpRecords(reexported); !reflect.DeepEqual(got, want) {
+			t.Errorf("re-exported records =\n%q\nwant\n%q", got, want)
+		}
+		if reexported.Hosts[0].ID == exported.Hosts[0].ID {
+			t.Error("host kept its ID")
+		}
+	})
+}
+
+func TestImportDumpRejectsOrphans(t *testing.T) {
+	repo, _ := newStoreRepository(t, time.Now())
+	dump := &Dump{
+		Version: DumpVersion,
+		Hosts:   []*models.Host{{ID: uuid.New(), ScanID: uuid.New(), IPAddress: "192.0.2.1", Status: "up"}},
+	}
+	if _, err := repo.ImportDump(dump, true); err == nil {
+		t.Error("ImportDump() accepted a host whose scan is missing from the dump")
+	}
+
+	if _, err := repo.ImportDump(&Dump{Version: DumpVersion + 1}, true); err == nil {
+		t.Error("ImportDump() accepted a newer dump version")
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// pqUniqueViolation is the PostgreSQL error code for a duplicate key
+const pqUniqueViolation = "23505"
+
+// ErrExclusionExists is returned when adding a network already on the
+// do-not-scan list
+var ErrExclusionExists = errors.New("network is already excluded")
+
+// AddExclusion puts an address or CIDR block on the do-not-scan list. The
+// network is stored as a CIDR block, see scanner.NormalizeExclusion.
+func (r *Repository) AddExclusion(exclusion *models.ScanExclusion) error {
+	network, err := scanner.NormalizeExclusion(exclusion.Network)
+	if err != nil {
+		return err
+	}
+	exclusion.ID = uuid.New()
+	exclusion.Network = network
+	exclusion.CreatedAt = r.clock.Now()
+
+	query := `
+		INSERT INTO scan_exclusions (id, network, reason, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	_, err = r.db.Exec(query, exclusion.ID, exclusion.Network, exclusion.Reason, exclusion.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+		return fmt.Errorf("%w: %s", ErrExclusionExists, exclusion.Network)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add exclusion: %w", err)
+	}
+	return nil
+}
+
+// ListExclusions returns the do-not-scan list ordered by network
+func (r *Repository) ListExclusions() ([]*models.ScanExclusion, error) {
+	rows, err := r.db.Query(`
+		SELECT id, network::text, reason, created_at
+		FROM scan_exclusions
+		ORDER BY family(network), network`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exclusions: %w", err)
+	}
+	defer rows.Close()
+
+	var exclusions []*models.ScanExclusion
+	for rows.Next() {
+		exclusion := &models.ScanExclusion{}
+		if err := rows.Scan(&exclusion.ID, &exclusion.Network, &exclusion.Reason, &exclusion.CreatedAt); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, exclusion)
+	}
+
+	return exclusions, rows.Err()
+}
+
+// RemoveExclusion takes a network off the do-not-scan list. Returns
+// sql.ErrNoRows when the network is not on it.
+func (r *Repository) RemoveExclusion(network string) error {
+	network, err := scanner.NormalizeExclusion(network)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`DELETE FROM scan_exclusions WHERE network = $1`, network)
+	if err != nil {
+		return fmt.Errorf("failed to remove exclusion: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ExcludedNetworks returns the networks on the do-not-scan list, for
+// scanner.ScanConfig.Exclude
+func (r *Repository) ExcludedNetworks() ([]string, error) {
+	exclusions, err := r.ListExclusions()
+	if err != nil {
+		return nil, err
+	}
+	networks := make([]string, len(exclusions))
+	for i, exclusion := range exclusions {
+		networks[i] = exclusion.Network
+	}
+	return networks, nil
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeResult is the answer of a fakeHandler: the rows of a query, or the
+// affected row count of a statement
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// fakeHandler answers a statement run against a fakeConnector
+type fakeHandler func(query string, args []driver.Value) (*fakeResult, error)
+
+// fakeConnector is a database/sql connector answering every statement with
+// its handler and recording the statements run, so repository code can be
+// tested without PostgreSQL
+type fakeConnector struct {
+	handler fakeHandler
+
+	mu         sync.Mutex
+	statements []string
+}
+
+// newFakeDB returns a DB with the policies of config whose statements are
+// answered by handler. A nil handler answers queries with no rows and
+// statements with one affected row.
+func newFakeDB(t testing.TB, config Config, handler fakeHandler) (*DB, *fakeConnector) {
+	t.Helper()
+	if handler == nil {
+		handler = func(string, []driver.Value) (*fakeResult, error) {
+			return &fakeResult{affected: 1}, nil
+		}
+	}
+	connector := &fakeConnector{handler: handler}
+	sqlDB := sql.OpenDB(connector)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return newDB(sqlDB, config, logger), connector
+}
+
+// Statements returns the statements run so far
+func (c *fakeConnector) Statements() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.statements...)
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{connector: c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+// run records a statement and answers it with the handler
+func (c *fakeConnector) run(query string, args []driver.NamedValue) (*fakeResult, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	c.mu.Lock()
+	c.statements = append(c.statements, query)
+	c.mu.Unlock()
+
+	return c.handler(query, values)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver connects through its connector only")
+}
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not prepare statements")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.connector.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.connector.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.affected), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	result *fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.result.columns) == 0 && len(r.result.rows) > 0 {
+		// Callers only scan by position, so unnamed columns will do
+		return make([]string, len(r.result.rows[0]))
+	}
+	return r.result.columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
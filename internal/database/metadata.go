@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// Limits on scan metadata, keeping it to context rather than payloads
+const (
+	maxMetadataKeyLength   = 100
+	maxMetadataValueLength = 1000
+	maxMetadataEntries     = 50
+)
+
+// ParseMetadata parses key=value pairs, as given to repeated --meta flags,
+// into scan metadata. Keys are trimmed; a repeated key keeps its last value.
+func ParseMetadata(pairs []string) (models.Metadata, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	meta := make(models.Metadata, len(pairs))
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid metadata %q: expected key=value", pair)
+		}
+		meta[strings.TrimSpace(key)] = value
+	}
+	if err := ValidateMetadata(meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// ValidateMetadata rejects empty keys and entries too large to be context
+func ValidateMetadata(meta models.Metadata) error {
+	if len(meta) > maxMetadataEntries {
+		return fmt.Errorf("too many metadata entries: %d (at most %d)", len(meta), maxMetadataEntries)
+	}
+	for key, value := range meta {
+		switch {
+		case strings.TrimSpace(key) == "":
+			return fmt.Errorf("metadata key is empty")
+		case len(key) > maxMetadataKeyLength:
+			return fmt.Errorf("metadata key %q is longer than %d characters", key, maxMetadataKeyLength)
+		case len(value) > maxMetadataValueLength:
+			return fmt.Errorf("metadata value of %q is longer than %d characters", key, maxMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+// SetScanMetadata replaces the metadata of a scan. Returns sql.ErrNoRows
+// when the scan does not exist.
+func (r *Repository) SetScanMetadata(scanID uuid.UUID, meta models.Metadata) error {
+	if err := ValidateMetadata(meta); err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`UPDATE scan_results SET metadata = $2 WHERE id = $1`, scanID, meta)
+	if err != nil {
+		return fmt.Errorf("failed to update scan metadata: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FindScansByMetadata returns the scans whose metadata holds every given
+// entry, across all targets, newest first. The JSONB containment test uses
+// the GIN index on scan_results.metadata.
+func (r *Repository) FindScansByMetadata(meta models.Metadata) ([]*models.ScanResult, error) {
+	query := `
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results WHERE metadata @> $1 ORDER BY created_at DESC`
+
+	return r.queryScanResults(query, meta)
+}
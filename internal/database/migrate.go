@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMigrationFailed reports a migration that did not apply. The schema is
+// left at the last migration that succeeded.
+var ErrMigrationFailed = errors.New("database migration failed")
+
+// migrationLockKey is the advisory lock serializing migration runs across
+// processes, separate from the one golang-migrate takes for each step
+const migrationLockKey int64 = 0x6e657472 // "netr"
+
+// Migrate applies pending migrations and records each one in
+// schema_migration_history. Re-running it with nothing pending is a no-op.
+//
+// Every migration file runs as a single statement batch, which PostgreSQL
+// executes in one implicit transaction, so a failed migration leaves no
+// partial changes behind. golang-migrate still marks its version dirty;
+// the next run rolls the version back and retries that migration instead
+// of refusing to start. Migrations must therefore not commit on their own
+// (no explicit COMMIT or CREATE INDEX CONCURRENTLY).
+func (db *DB) Migrate(migrationsPath string) error {
+	ctx := context.Background()
+
+	absPath, err := filepath.Abs(migrationsPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	lock, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration connection: %w", err)
+	}
+	defer lock.Close()
+	if _, err := lock.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer lock.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	src, err := source.Open("file://" + absPath)
+	if err != nil {
+		return fmt.Errorf("failed to open migrations: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		src.Close()
+		return fmt.Errorf("failed to acquire migration connection: %w", err)
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		src.Close()
+		conn.Close()
+		return fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("file", src, "postgres", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	defer m.Close()
+	m.Log = migrateLogger{db.logger}
+
+	if err := db.ensureMigrationHistory(ctx); err != nil {
+		return err
+	}
+
+	before, dirty, err := migrationVersion(m)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		failed := before
+		if before, err = previousMigration(src, failed); err != nil {
+			return err
+		}
+		db.logger.Warnf("Migration %d failed in an earlier run and was rolled back, retrying it", failed)
+		if err := m.Force(before); err != nil {
+			return fmt.Errorf("failed to reset dirty migration %d: %w", failed, err)
+		}
+	}
+
+	upErr := m.Up()
+	if errors.Is(upErr, migrate.ErrNoChange) {
+		upErr = nil
+	}
+
+	after, dirty, err := migrationVersion(m)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		if after, err = previousMigration(src, after); err != nil {
+			return err
+		}
+	}
+
+	if err := db.recordMigrations(ctx, src, before, after); err != nil {
+		if upErr != nil {
+			return fmt.Errorf("%w: %w", ErrMigrationFailed, upErr)
+		}
+		return err
+	}
+	if upErr != nil {
+		return fmt.Errorf("%w: %w", ErrMigrationFailed, upErr)
+	}
+
+	if after == before {
+		db.logger.Debugf("Database schema is up to date at version %d", after)
+	} else {
+		db.logger.Infof("Database migrated from version %d to %d", before, after)
+	}
+	return nil
+}
+
+// ensureMigrationHistory creates the table recording applied migrations
+func (db *DB) ensureMigrationHistory(ctx context.Context) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migration_history (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migration history: %w", err)
+	}
+	return nil
+}
+
+// recordMigrations records every migration up to and including version
+// after. Those newer than before were applied by this run; older ones
+// missing from the history predate it and get no applied_at.
+func (db *DB) recordMigrations(ctx context.Context, src source.Driver, before, after int) error {
+	if after < 0 {
+		return nil
+	}
+
+	version, err := src.First()
+	for err == nil && int(version) <= after {
+		r, name, readErr := src.ReadUp(version)
+		if readErr != nil {
+			return fmt.Errorf("failed to read migration %d: %w", version, readErr)
+		}
+		r.Close()
+
+		query := `INSERT INTO schema_migration_history (version, name, applied_at)
+			VALUES ($1, $2, NULL) ON CONFLICT (version) DO NOTHING`
+		if int(version) > before {
+			query = `INSERT INTO schema_migration_history (version, name, applied_at)
+				VALUES ($1, $2, NOW()) ON CONFLICT (version) DO UPDATE SET name = $2, applied_at = NOW()`
+		}
+		if _, execErr := db.ExecContext(ctx, query, version, name); execErr != nil {
+			return fmt.Errorf("failed to record migration %d: %w", version, execErr)
+		}
+		version, err = src.Next(version)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	return nil
+}
+
+// migrationVersion returns the current schema version, -1 when no
+// migration was ever applied
+func migrationVersion(m *migrate.Migrate) (int, bool, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return -1, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version), dirty, nil
+}
+
+// previousMigration returns the migration before version, -1 for the first
+func previousMigration(src source.Driver, version int) (int, error) {
+	prev, err := src.Prev(uint(version))
+	if errors.Is(err, os.ErrNotExist) {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the migration before %d: %w", version, err)
+	}
+	return int(prev), nil
+}
+
+// migrateLogger logs each applied migration through logrus
+type migrateLogger struct {
+	logger *logrus.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Infof("Migration %s", strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// historyRow is a schema_migration_history row; appliedAt counts the
+// NOW() calls so any rewrite of it shows
+type historyRow struct {
+	name      string
+	appliedAt int
+}
+
+// migrationHistory is an in-memory schema_migration_history answering the
+// statements of ensureMigrationHistory and recordMigrations
+type migrationHistory struct {
+	mu   sync.Mutex
+	now  int
+	rows map[int64]historyRow
+}
+
+func (h *migrationHistory) handle(query string, args []driver.Value) (*fakeResult, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "CREATE TABLE IF NOT EXISTS schema_migration_history"):
+		if h.rows == nil {
+			h.rows = make(map[int64]historyRow)
+		}
+		return &fakeResult{}, nil
+	case strings.Contains(query, "INSERT INTO schema_migration_history"):
+		version, name := args[0].(int64), args[1].(string)
+		_, exists := h.rows[version]
+		switch {
+		case strings.Contains(query, "DO UPDATE"):
+			h.now++
+			h.rows[version] = historyRow{name: name, appliedAt: h.now}
+		case !exists:
+			h.rows[version] = historyRow{name: name}
+		}
+		return &fakeResult{affected: 1}, nil
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+}
+
+func (h *migrationHistory) snapshot() map[int64]historyRow {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rows := make(map[int64]historyRow, len(h.rows))
+	for version, row := range h.rows {
+		rows[version] = row
+	}
+	return rows
+}
+
+func openMigrations(t *testing.T) (source.Driver, int) {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("..", "..", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := source.Open("file://" + path)
+	if err != nil {
+		t.Fatalf("failed to open migrations: %v", err)
+	}
+	t.Cleanup(func() { src.Close() })
+
+	latest, err := src.First()
+	for err == nil {
+		var next uint
+		if next, err = src.Next(latest); err == nil {
+			latest = next
+		}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	return src, int(latest)
+}
+
+// Recording the migrations of a run again, as a rerun with nothing pending
+// does, leaves the history unchanged
+func TestRecordMigrationsIdempotent(t *testing.T) {
+	src, latest := openMigrations(t)
+	history := &migrationHistory{}
+	db, _ := newFakeDB(t, Config{}, history.handle)
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		before, after int
+	}{
+		{"fresh database", -1, latest},
+		{"migrated from the middle", latest / 2, latest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history.rows = nil
+			if err := db.ensureMigrationHistory(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.recordMigrations(ctx, src, tt.before, tt.after); err != nil {
+				t.Fatalf("recordMigrations() error = %v", err)
+			}
+			first := history.snapshot()
+
+			if len(first) != latest {
+				t.Errorf("history has %d migrations, want %d", len(first), latest)
+			}
+			for version, row := range first {
+				if applied := int(version) > tt.before; applied != (row.appliedAt > 0) {
+					t.Errorf("migration %d (%s) applied_at set = %t, want %t", version, row.name, row.appliedAt > 0, applied)
+				}
+			}
+
+			// Second run: the schema is already at the latest version
+			if err := db.ensureMigrationHistory(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.recordMigrations(ctx, src, tt.after, tt.after); err != nil {
+				t.Fatalf("second recordMigrations() error = %v", err)
+			}
+			if second := history.snapshot(); !reflect.DeepEqual(first, second) {
+				t.Errorf("history changed by a rerun:\nfirst:  %v\nsecond: %v", first, second)
+			}
+		})
+	}
+}
+
+// Migrations are numbered without gaps and every up migration has a down one
+func TestMigrationFilesPaired(t *testing.T) {
+	src, _ := openMigrations(t)
+
+	version, err := src.First()
+	if err != nil {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	for want := uint(1); err == nil; want++ {
+		if version != want {
+			t.Fatalf("migration %d follows %d", version, want-1)
+		}
+		up, _, upErr := src.ReadUp(version)
+		if upErr != nil {
+			t.Errorf("migration %d has no up file: %v", version, upErr)
+		} else {
+			up.Close()
+		}
+		down, _, downErr := src.ReadDown(version)
+		if downErr != nil {
+			t.Errorf("migration %d has no down file: %v", version, downErr)
+		} else {
+			down.Close()
+		}
+		version, err = src.Next(version)
+	}
+}
+
+// scanTypeCheck matches the scanners allowed by a scan_results.scan_type
+// CHECK constraint
+var scanTypeCheck = regexp.MustCompile(`scan_type IN \(([^)]*)\)`)
+
+// Results are stored with the scanner name as scan_type, so the latest
+// constraint must allow every scanner backend
+func TestScanTypeAllowsEveryScanner(t *testing.T) {
+	ups, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.up.sql"))
+	if err != nil || len(ups) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(ups)
+
+	var allowed string
+	for _, path := range ups {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range scanTypeCheck.FindAllStringSubmatch(string(data), -1) {
+			allowed = m[1]
+		}
+	}
+
+	for _, name := range []string{"nmap", "masscan", "rustscan", "naabu"} {
+		if !strings.Contains(allowed, "'"+name+"'") {
+			t.Errorf("scan_type constraint (%s) does not allow %s", allowed, name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// PendingStore keeps scan results whose database save failed as JSON files,
+// one per scan, until they are flushed back into the database
+type PendingStore struct {
+	dir string
+}
+
+// PendingFlushStats counts the outcome of flushing pending scans
+type PendingFlushStats struct {
+	Saved   int // Saved and removed from the pending directory
+	Skipped int // Already in the database, removed without saving
+	Failed  int // Still pending
+}
+
+// NewPendingStore creates a store spilling results into dir
+func NewPendingStore(dir string) *PendingStore {
+	return &PendingStore{dir: dir}
+}
+
+// Dir returns the pending directory
+func (s *PendingStore) Dir() string {
+	return s.dir
+}
+
+// Spill writes a scan result to the pending directory and returns the file
+// path. The scan is given an ID first so that a flush retried after a
+// partial success cannot store it twice.
+func (s *PendingStore) Spill(graph *models.FullScanResult) (string, error) {
+	if graph.ScanResult.ID == uuid.Nil {
+		graph.ScanResult.ID = uuid.New()
+	}
+
+	data, err := json.Marshal(graph)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode scan %s: %w", graph.ScanResult.ID, err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create pending directory: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a truncated file to flush
+	path := filepath.Join(s.dir, graph.ScanResult.ID.String()+".json")
+	tmp, err := os.CreateTemp(s.dir, ".spill-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create pending file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write pending file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write pending file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write pending file: %w", err)
+	}
+
+	return path, nil
+}
+
+// List returns the pending files in name order. A missing directory means
+// nothing is pending.
+func (s *PendingStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending directory: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		paths = append(paths, filepath.Join(s.dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Load reads a pending scan result, upgrading results spilled under an
+// older schema version
+func (s *PendingStore) Load(path string) (*models.FullScanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending file: %w", err)
+	}
+
+	var graph models.FullScanResult
+	if err := json.Unmarshal(data, &graph); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if graph.ScanResult == nil {
+		return nil, fmt.Errorf("%s does not contain a scan result", path)
+	}
+	if err := scanner.UpgradeStored(&graph); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &graph, nil
+}
+
+// Flush retries saving every pending scan, removing the files that were
+// saved or turn out to be stored already. Files that still fail are kept
+// and their errors returned together.
+func (s *PendingStore) Flush(save func(*models.FullScanResult) error) (PendingFlushStats, error) {
+	var stats PendingFlushStats
+
+	paths, err := s.List()
+	if err != nil {
+		return stats, err
+	}
+
+	var errs []error
+	for _, path := range paths {
+		graph, err := s.Load(path)
+		if err != nil {
+			stats.Failed++
+			errs = append(errs, err)
+			continue
+		}
+
+		err = save(graph)
+		switch {
+		case err == nil:
+			stats.Saved++
+		case errors.Is(err, ErrScanExists):
+			stats.Skipped++
+		default:
+			stats.Failed++
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove flushed file: %w", err))
+		}
+	}
+
+	return stats, errors.Join(errs...)
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func TestPendingSpillAndFlush(t *testing.T) {
+	store := &tableStore{}
+	var down atomic.Bool
+	db, _ := newFakeDB(t, Config{}, func(query string, args []driver.Value) (*fakeResult, error) {
+		if down.Load() {
+			return nil, errConnectionLost
+		}
+		return store.handle(query, args)
+	})
+	repo := NewRepository(db)
+	repo.SetClock(clock.NewMock(time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)))
+
+	graph := &models.FullScanResult{
+		ScanResult: &models.ScanResult{TargetID: uuid.New(), ScanType: "nmap", Status: scanner.StatusCompleted},
+		Hosts: []*models.HostGraph{{
+			Host:  &models.Host{IPAddress: "192.0.2.10", Status: "up"},
+			Ports: []*models.PortGraph{{Port: &models.Port{Number: 22, Protocol: "tcp", State: "open", Service: "ssh"}}},
+		}},
+	}
+
+	// The database is down when the scan finishes, so the result is spilled
+	down.Store(true)
+	if err := repo.SaveScanGraph(graph); err == nil {
+		t.Fatal("SaveScanGraph() succeeded with the database down")
+	}
+	pending := NewPendingStore(t.TempDir())
+	path, err := pending.Spill(graph)
+	if err != nil {
+		t.Fatalf("Spill() error = %v", err)
+	}
+	scanID := graph.ScanResult.ID
+
+	// A flush while the database is still down keeps the file
+	stats, err := pending.Flush(repo.SaveScanGraph)
+	if err == nil || stats != (PendingFlushStats{Failed: 1}) {
+		t.Errorf("Flush() while down = %+v, %v; want 1 failed", stats, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("pending file removed after a failed flush: %v", err)
+	}
+
+	down.Store(false)
+	stats, err = pending.Flush(repo.SaveScanGraph)
+	if err != nil || stats != (PendingFlushStats{Saved: 1}) {
+		t.Fatalf("Flush() = %+v, %v; want 1 saved", stats, err)
+	}
+	if paths, _ := pending.List(); len(paths) != 0 {
+		t.Errorf("pending files %v left after a successful flush", paths)
+	}
+	scans, hosts, ports := store.tables["scan_results"], store.tables["hosts"], store.tables["ports"]
+	if len(scans) != 1 || scans[0][0] != scanID.String() || len(hosts) != 1 || len(ports) != 1 {
+		t.Errorf("stored %d scans (%v), %d hosts and %d ports; want scan %s with 1 host and 1 port", len(scans), scans, len(hosts), len(ports), scanID)
+	}
+
+	// A scan whose save committed before the connection dropped is spilled
+	// too, and flushing it stores nothing twice
+	if _, err := pending.Spill(graph); err != nil {
+		t.Fatalf("Spill() error = %v", err)
+	}
+	stored := store.count()
+	stats, err = pending.Flush(repo.SaveScanGraph)
+	if err != nil || stats != (PendingFlushStats{Skipped: 1}) {
+		t.Errorf("Flush() of a stored scan = %+v, %v; want 1 skipped", stats, err)
+	}
+	if paths, _ := pending.List(); len(paths) != 0 || store.count() != stored {
+		t.Errorf("pending files %v and %d rows after flushing a stored scan, want none and %d", paths, store.count(), stored)
+	}
+}
+
+func TestPendingFlushKeepsUnreadableFiles(t *testing.T) {
+	pending := NewPendingStore(t.TempDir())
+	if paths, err := pending.List(); err != nil || len(paths) != 0 {
+		t.Fatalf("List() of a fresh directory = %v, %v", paths, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(pending.Dir(), "broken.json"), []byte(`{"id":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	saves := 0
+	stats, err := pending.Flush(func(*models.FullScanResult) error {
+		saves++
+		return errors.New("unexpected save")
+	})
+	if err == nil || stats != (PendingFlushStats{Failed: 1}) || saves != 0 {
+		t.Errorf("Flush() = %+v, %v after %d saves; want 1 failed without saving", stats, err, saves)
+	}
+	if paths, _ := pending.List(); len(paths) != 1 {
+		t.Errorf("pending files = %v, want the unreadable file kept", paths)
+	}
+}
@@ -1,33 +1,80 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/clock"
 	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Repository provides database operations
 type Repository struct {
-	db *DB
+	db    *DB
+	clock clock.Clock
+	ctx   context.Context
 }
 
 // NewRepository creates a new repository instance
 func NewRepository(db *DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, clock: clock.Real{}, ctx: context.Background()}
 }
 
+// SetClock replaces the clock used for record timestamps
+func (r *Repository) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// WithContext returns a copy of the repository whose traced calls are
+// children of the span in ctx and are cancelled with it
+func (r *Repository) WithContext(ctx context.Context) *Repository {
+	rc := *r
+	rc.ctx = ctx
+	return &rc
+}
+
+// Available reports whether the database is believed reachable, false
+// while calls fail fast with ErrUnavailable
+func (r *Repository) Available() bool {
+	return r.db.Available()
+}
+
+// startSpan starts a client span for a database call
+func (r *Repository) startSpan(operation string) (context.Context, trace.Span) {
+	return tracing.Start(r.ctx, "db."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(operation)))
+}
+
+const insertScanTargetQuery = `
+	INSERT INTO scan_targets (id, target, type, description, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+const insertScanResultQuery = `
+	INSERT INTO scan_results (id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+const insertHTTPProbeQuery = `
+	INSERT INTO http_probes (id, port_id, path, status_code, title, server, error, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
 // ScanTarget operations
 func (r *Repository) CreateScanTarget(target *models.ScanTarget) error {
 	target.ID = uuid.New()
-	target.CreatedAt = time.Now()
-	target.UpdatedAt = time.Now()
-
-	query := `
-		INSERT INTO scan_targets (id, target, type, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+	target.CreatedAt = r.clock.Now()
+	target.UpdatedAt = target.CreatedAt
 
-	_, err := r.db.Exec(query, target.ID, target.Target, target.Type, target.Description, target.CreatedAt, target.UpdatedAt)
+	_, err := r.db.Exec(insertScanTargetQuery, target.ID, target.Target, target.Type, target.Description, target.CreatedAt, target.UpdatedAt)
 	return err
 }
 
@@ -47,6 +94,24 @@ func (r *Repository) GetScanTarget(id uuid.UUID) (*models.ScanTarget, error) {
 	return target, nil
 }
 
+// FindScanTarget returns the most recently created target with the given
+// address, or sql.ErrNoRows when it was never added
+func (r *Repository) FindScanTarget(address string) (*models.ScanTarget, error) {
+	target := &models.ScanTarget{}
+	query := `
+		SELECT id, target, type, description, created_at, updated_at
+		FROM scan_targets WHERE target = $1 ORDER BY created_at DESC LIMIT 1`
+
+	err := r.db.QueryRow(query, address).Scan(
+		&target.ID, &target.Target, &target.Type, &target.Description,
+		&target.CreatedAt, &target.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
 func (r *Repository) ListScanTargets() ([]*models.ScanTarget, error) {
 	query := `
 		SELECT id, target, type, description, created_at, updated_at
@@ -73,50 +138,151 @@ func (r *Repository) ListScanTargets() ([]*models.ScanTarget, error) {
 
 // ScanResult operations
 func (r *Repository) CreateScanResult(result *models.ScanResult) error {
-	result.ID = uuid.New()
-	result.CreatedAt = time.Now()
+	if err := scanner.ValidateStatus(result.Status); err != nil {
+		return err
+	}
 
-	query := `
-		INSERT INTO scan_results (id, target_id, scan_type, status, start_time, end_time, raw_output, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	result.ID = uuid.New()
+	result.CreatedAt = r.clock.Now()
+	result.SchemaVersion = models.SchemaVersion
 
-	_, err := r.db.Exec(query, result.ID, result.TargetID, result.ScanType, result.Status,
-		result.StartTime, result.EndTime, result.RawOutput, result.CreatedAt)
+	_, err := r.db.Exec(insertScanResultQuery, result.ID, result.TargetID, result.ScanType, result.Status,
+		result.StartTime, result.EndTime, result.DurationMs, result.RawOutput, []byte(result.ScanConfig), result.Pinned, result.Complete, result.Coverage, result.TraceID, result.CorrelationID, result.Metadata, result.SchemaVersion, result.CreatedAt)
 	return err
 }
 
 func (r *Repository) UpdateScanResult(result *models.ScanResult) error {
+	if err := scanner.ValidateStatus(result.Status); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE scan_results 
-		SET status = $2, end_time = $3, raw_output = $4
+		SET status = $2, end_time = $3, duration_ms = $4, raw_output = $5, complete = $6, coverage = $7
 		WHERE id = $1`
 
-	_, err := r.db.Exec(query, result.ID, result.Status, result.EndTime, result.RawOutput)
+	_, err := r.db.Exec(query, result.ID, result.Status, result.EndTime, result.DurationMs, result.RawOutput, result.Complete, result.Coverage)
 	return err
 }
 
-func (r *Repository) GetScanResult(id uuid.UUID) (*models.ScanResult, error) {
-	result := &models.ScanResult{}
+func (r *Repository) GetScanResult(id uuid.UUID) (result *models.ScanResult, err error) {
+	ctx, span := r.startSpan("GetScanResult")
+	defer func() { tracing.End(span, err) }()
+
+	result = &models.ScanResult{}
 	query := `
-		SELECT id, target_id, scan_type, status, start_time, end_time, raw_output, created_at
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
 		FROM scan_results WHERE id = $1`
 
-	err := r.db.QueryRow(query, id).Scan(
+	err = r.db.QueryRowContext(ctx, query, id).Scan(
 		&result.ID, &result.TargetID, &result.ScanType, &result.Status,
-		&result.StartTime, &result.EndTime, &result.RawOutput, &result.CreatedAt)
+		&result.StartTime, &result.EndTime, &result.DurationMs, &result.RawOutput, &result.ScanConfig, &result.Pinned, &result.Complete, &result.Coverage, &result.TraceID, &result.CorrelationID, &result.Metadata, &result.SchemaVersion, &result.CreatedAt)
 
 	if err != nil {
 		return nil, err
 	}
+	scanner.UpgradeScan(result)
 	return result, nil
 }
 
 func (r *Repository) ListScanResults(targetID uuid.UUID) ([]*models.ScanResult, error) {
 	query := `
-		SELECT id, target_id, scan_type, status, start_time, end_time, raw_output, created_at
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
 		FROM scan_results WHERE target_id = $1 ORDER BY created_at DESC`
 
-	rows, err := r.db.Query(query, targetID)
+	return r.queryScanResults(query, targetID)
+}
+
+// ListScanResultsByCorrelationID returns the scans sharing a correlation
+// ID, such as every target of one scan run, newest first
+func (r *Repository) ListScanResultsByCorrelationID(correlationID string) ([]*models.ScanResult, error) {
+	query := `
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results WHERE correlation_id = $1 ORDER BY created_at DESC`
+
+	return r.queryScanResults(query, correlationID)
+}
+
+// FindBaselineScan returns the target's most recent pinned complete scan,
+// the reference drift is measured against, or sql.ErrNoRows when no scan
+// has been pinned
+func (r *Repository) FindBaselineScan(targetID uuid.UUID) (*models.ScanResult, error) {
+	query := `
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results WHERE target_id = $1 AND pinned AND complete ORDER BY created_at DESC LIMIT 1`
+
+	return r.queryScanResult(query, targetID)
+}
+
+// FindLatestScan returns the target's most recent complete scan, or
+// sql.ErrNoRows when it has none
+func (r *Repository) FindLatestScan(targetID uuid.UUID) (*models.ScanResult, error) {
+	query := `
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results WHERE target_id = $1 AND complete ORDER BY created_at DESC LIMIT 1`
+
+	return r.queryScanResult(query, targetID)
+}
+
+// queryScanResult runs a scan_results query expected to match one row,
+// returning sql.ErrNoRows when it matches none
+func (r *Repository) queryScanResult(query string, args ...interface{}) (*models.ScanResult, error) {
+	results, err := r.queryScanResults(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return results[0], nil
+}
+
+// ListSlowScanResults returns scans that took at least minDuration, slowest first
+func (r *Repository) ListSlowScanResults(minDuration time.Duration) ([]*models.ScanResult, error) {
+	query := `
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results WHERE duration_ms >= $1 ORDER BY duration_ms DESC`
+
+	return r.queryScanResults(query, minDuration.Milliseconds())
+}
+
+// ListAllScanResults returns every scan, newest first, optionally only pinned ones
+func (r *Repository) ListAllScanResults(pinnedOnly bool) ([]*models.ScanResult, error) {
+	query := `
+		SELECT id, target_id, scan_type, status, start_time, end_time, duration_ms, raw_output, scan_config, pinned, complete, coverage, trace_id, correlation_id, metadata, schema_version, created_at
+		FROM scan_results WHERE pinned OR NOT $1 ORDER BY created_at DESC`
+
+	return r.queryScanResults(query, pinnedOnly)
+}
+
+// SetScanResultPinned pins or unpins a scan result
+func (r *Repository) SetScanResultPinned(id uuid.UUID, pinned bool) error {
+	res, err := r.db.Exec(`UPDATE scan_results SET pinned = $2 WHERE id = $1`, id, pinned)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// PruneScanResults deletes unpinned scans created before the cutoff, along
+// with their hosts and ports, and returns how many were removed
+func (r *Repository) PruneScanResults(before time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM scan_results WHERE created_at < $1 AND NOT pinned`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// queryScanResults runs a scan_results query and scans every row
+func (r *Repository) queryScanResults(query string, args ...interface{}) (_ []*models.ScanResult, err error) {
+	ctx, span := r.startSpan("QueryScanResults")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -126,35 +292,52 @@ func (r *Repository) ListScanResults(targetID uuid.UUID) ([]*models.ScanResult,
 	for rows.Next() {
 		result := &models.ScanResult{}
 		err := rows.Scan(&result.ID, &result.TargetID, &result.ScanType, &result.Status,
-			&result.StartTime, &result.EndTime, &result.RawOutput, &result.CreatedAt)
+			&result.StartTime, &result.EndTime, &result.DurationMs, &result.RawOutput, &result.ScanConfig, &result.Pinned, &result.Complete, &result.Coverage, &result.TraceID, &result.CorrelationID, &result.Metadata, &result.SchemaVersion, &result.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
+		scanner.UpgradeScan(result)
 		results = append(results, result)
 	}
 	return results, nil
 }
 
+const insertHostQuery = `
+	INSERT INTO hosts (id, scan_id, ip_address, hostname, status, os, os_confidence,
+		reputation_score, reputation_sources, netbios_name, domain, workgroup, host_scripts, mac_address, vendor, ipv6_address, load_balanced, uptime_seconds, last_boot, filtered_port_count, os_family, scan_error, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)`
+
+const insertPortQuery = `
+	INSERT INTO ports (id, host_id, number, protocol, state, service, version, product, extra_info, guessed_service, reason, reason_ttl, hit_ratio, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
 // Host operations
 func (r *Repository) CreateHost(host *models.Host) error {
 	host.ID = uuid.New()
-	host.CreatedAt = time.Now()
-
-	query := `
-		INSERT INTO hosts (id, scan_id, ip_address, hostname, status, os, os_confidence, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	host.CreatedAt = r.clock.Now()
 
-	_, err := r.db.Exec(query, host.ID, host.ScanID, host.IPAddress, host.Hostname,
-		host.Status, host.OS, host.OSConfidence, host.CreatedAt)
+	_, err := r.db.Exec(insertHostQuery, host.ID, host.ScanID, host.IPAddress, host.Hostname,
+		host.Status, host.OS, host.OSConfidence,
+		host.ReputationScore, textArray(host.ReputationSources),
+		host.NetBIOSName, host.Domain, host.Workgroup, host.HostScripts, host.MACAddress, host.Vendor, host.IPv6Address, host.LoadBalanced, host.UptimeSeconds, host.LastBoot, host.FilteredPortCount, host.OSFamily, host.ScanError, host.CreatedAt)
 	return err
 }
 
 func (r *Repository) GetHostsByScanID(scanID uuid.UUID) ([]*models.Host, error) {
 	query := `
-		SELECT id, scan_id, ip_address, hostname, status, os, os_confidence, created_at
+		SELECT id, scan_id, ip_address, hostname, status, os, os_confidence,
+			reputation_score, reputation_sources, netbios_name, domain, workgroup, host_scripts, mac_address, vendor, ipv6_address, load_balanced, uptime_seconds, last_boot, filtered_port_count, os_family, scan_error, created_at
 		FROM hosts WHERE scan_id = $1 ORDER BY ip_address`
 
-	rows, err := r.db.Query(query, scanID)
+	return r.queryHosts(query, scanID)
+}
+
+// queryHosts runs a hosts query and scans every row
+func (r *Repository) queryHosts(query string, args ...interface{}) (_ []*models.Host, err error) {
+	ctx, span := r.startSpan("QueryHosts")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +347,9 @@ func (r *Repository) GetHostsByScanID(scanID uuid.UUID) ([]*models.Host, error)
 	for rows.Next() {
 		host := &models.Host{}
 		err := rows.Scan(&host.ID, &host.ScanID, &host.IPAddress, &host.Hostname,
-			&host.Status, &host.OS, &host.OSConfidence, &host.CreatedAt)
+			&host.Status, &host.OS, &host.OSConfidence,
+			&host.ReputationScore, pq.Array(&host.ReputationSources),
+			&host.NetBIOSName, &host.Domain, &host.Workgroup, &host.HostScripts, &host.MACAddress, &host.Vendor, &host.IPv6Address, &host.LoadBalanced, &host.UptimeSeconds, &host.LastBoot, &host.FilteredPortCount, &host.OSFamily, &host.ScanError, &host.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -176,23 +361,27 @@ func (r *Repository) GetHostsByScanID(scanID uuid.UUID) ([]*models.Host, error)
 // Port operations
 func (r *Repository) CreatePort(port *models.Port) error {
 	port.ID = uuid.New()
-	port.CreatedAt = time.Now()
-
-	query := `
-		INSERT INTO ports (id, host_id, number, protocol, state, service, version, product, extra_info, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	port.CreatedAt = r.clock.Now()
 
-	_, err := r.db.Exec(query, port.ID, port.HostID, port.Number, port.Protocol,
-		port.State, port.Service, port.Version, port.Product, port.ExtraInfo, port.CreatedAt)
+	_, err := r.db.Exec(insertPortQuery, port.ID, port.HostID, port.Number, port.Protocol,
+		port.State, port.Service, port.Version, port.Product, port.ExtraInfo, port.GuessedService, port.Reason, port.ReasonTTL, port.HitRatio, port.CreatedAt)
 	return err
 }
 
 func (r *Repository) GetPortsByHostID(hostID uuid.UUID) ([]*models.Port, error) {
 	query := `
-		SELECT id, host_id, number, protocol, state, service, version, product, extra_info, created_at
+		SELECT id, host_id, number, protocol, state, service, version, product, extra_info, guessed_service, reason, reason_ttl, hit_ratio, created_at
 		FROM ports WHERE host_id = $1 ORDER BY number`
 
-	rows, err := r.db.Query(query, hostID)
+	return r.queryPorts(query, hostID)
+}
+
+// queryPorts runs a ports query and scans every row
+func (r *Repository) queryPorts(query string, args ...interface{}) (_ []*models.Port, err error) {
+	ctx, span := r.startSpan("QueryPorts")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -202,7 +391,7 @@ func (r *Repository) GetPortsByHostID(hostID uuid.UUID) ([]*models.Port, error)
 	for rows.Next() {
 		port := &models.Port{}
 		err := rows.Scan(&port.ID, &port.HostID, &port.Number, &port.Protocol,
-			&port.State, &port.Service, &port.Version, &port.Product, &port.ExtraInfo, &port.CreatedAt)
+			&port.State, &port.Service, &port.Version, &port.Product, &port.ExtraInfo, &port.GuessedService, &port.Reason, &port.ReasonTTL, &port.HitRatio, &port.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -210,3 +399,309 @@ func (r *Repository) GetPortsByHostID(hostID uuid.UUID) ([]*models.Port, error)
 	}
 	return ports, nil
 }
+
+// CountScanRecords returns the number of hosts and ports stored for a scan
+func (r *Repository) CountScanRecords(scanID uuid.UUID) (hosts int, ports int, err error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM hosts WHERE scan_id = $1),
+			(SELECT COUNT(*) FROM ports p JOIN hosts h ON h.id = p.host_id WHERE h.scan_id = $1)`
+
+	err = r.db.QueryRow(query, scanID).Scan(&hosts, &ports)
+	return hosts, ports, err
+}
+
+// ReplaceScanHosts atomically replaces every host and port of a scan.
+// Vulnerabilities and HTTP probes attached to the old ports are removed
+// with them.
+func (r *Repository) ReplaceScanHosts(scanID uuid.UUID, hosts []*models.HostGraph) (err error) {
+	ctx, span := r.startSpan("ReplaceScanHosts")
+	span.SetAttributes(attribute.Int("netrecon.hosts", len(hosts)))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM hosts WHERE scan_id = $1`, scanID); err != nil {
+		return fmt.Errorf("failed to delete hosts: %w", err)
+	}
+
+	now := r.clock.Now()
+	for _, host := range hosts {
+		host.ScanID = scanID
+		if err := insertHostGraph(tx, host, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ErrScanExists is returned by SaveScanGraph when a scan with the same ID
+// is already stored, for example because an earlier save committed but the
+// connection dropped before it was acknowledged
+var ErrScanExists = errors.New("scan already saved")
+
+// SaveScanGraph stores a scan result with its hosts, ports and
+// vulnerabilities in a single transaction, so a failed save leaves nothing
+// behind and can simply be retried. The scan keeps its ID when set; the
+// target it references must already exist.
+func (r *Repository) SaveScanGraph(graph *models.FullScanResult) (err error) {
+	ctx, span := r.startSpan("SaveScanGraph")
+	span.SetAttributes(attribute.Int("netrecon.hosts", len(graph.Hosts)))
+	defer func() { tracing.End(span, err) }()
+
+	result := graph.ScanResult
+	if err := scanner.ValidateStatus(result.Status); err != nil {
+		return err
+	}
+	if err := scanner.UpgradeStored(graph); err != nil {
+		return err
+	}
+	if result.ID == uuid.Nil {
+		result.ID = uuid.New()
+	}
+	now := r.clock.Now()
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = now
+	}
+
+	// A transaction aborted by a deadlock wrote nothing and is rerun whole
+	return r.db.retryTransaction(ctx, func() error {
+		return r.insertScanGraph(ctx, graph, now)
+	})
+}
+
+// insertScanGraph stores a scan graph in one transaction for SaveScanGraph
+func (r *Repository) insertScanGraph(ctx context.Context, graph *models.FullScanResult, now time.Time) error {
+	result := graph.ScanResult
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(insertScanResultQuery+" ON CONFLICT (id) DO NOTHING", result.ID, result.TargetID, result.ScanType, result.Status,
+		result.StartTime, result.EndTime, result.DurationMs, result.RawOutput, []byte(result.ScanConfig), result.Pinned, result.Complete, result.Coverage, result.TraceID, result.CorrelationID, result.Metadata, result.SchemaVersion, result.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert scan %s: %w", result.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s: %w", result.ID, ErrScanExists)
+	}
+
+	for _, host := range graph.Hosts {
+		host.ScanID = result.ID
+		if err := insertHostGraph(tx, host, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// textArray encodes values for a TEXT[] NOT NULL column. pq.Array encodes a
+// nil slice as NULL, so hosts no enricher flagged are stored with '{}'.
+func textArray(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+	return pq.Array(values)
+}
+
+// insertHostGraph inserts a host with its ports, their vulnerabilities and
+// HTTP probes, assigning fresh IDs
+func insertHostGraph(db execer, host *models.HostGraph, now time.Time) error {
+	host.ID = uuid.New()
+	host.CreatedAt = now
+
+	_, err := db.Exec(insertHostQuery, host.ID, host.ScanID, host.IPAddress, host.Hostname,
+		host.Status, host.OS, host.OSConfidence,
+		host.ReputationScore, textArray(host.ReputationSources),
+		host.NetBIOSName, host.Domain, host.Workgroup, host.HostScripts, host.MACAddress, host.Vendor, host.IPv6Address, host.LoadBalanced, host.UptimeSeconds, host.LastBoot, host.FilteredPortCount, host.OSFamily, host.ScanError, host.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert host %s: %w", host.IPAddress, err)
+	}
+
+	for _, port := range host.Ports {
+		port.ID = uuid.New()
+		port.HostID = host.ID
+		port.CreatedAt = now
+
+		_, err := db.Exec(insertPortQuery, port.ID, port.HostID, port.Number, port.Protocol,
+			port.State, port.Service, port.Version, port.Product, port.ExtraInfo, port.GuessedService, port.Reason, port.ReasonTTL, port.HitRatio, port.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert port %d/%s on %s: %w", port.Number, port.Protocol, host.IPAddress, err)
+		}
+
+		for _, vuln := range port.Vulnerabilities {
+			vuln.ID = uuid.New()
+			vuln.PortID = port.ID
+			vuln.CreatedAt = now
+
+			_, err := db.Exec(insertVulnerabilityQuery, vuln.ID, vuln.PortID, vuln.CVE, vuln.Severity,
+				vuln.Description, vuln.Solution, vuln.ReferenceLinks, vuln.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to insert vulnerability on %s:%d: %w", host.IPAddress, port.Number, err)
+			}
+		}
+
+		for _, probe := range port.HTTPProbes {
+			probe.ID = uuid.New()
+			probe.PortID = port.ID
+			probe.CreatedAt = now
+
+			_, err := db.Exec(insertHTTPProbeQuery, probe.ID, probe.PortID, probe.Path, probe.StatusCode,
+				probe.Title, probe.Server, probe.Error, probe.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to insert HTTP probe of %s:%d%s: %w", host.IPAddress, port.Number, probe.Path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetScanGraph loads a scan result with all of its hosts, ports and
+// vulnerabilities using one query per level instead of one per host
+func (r *Repository) GetScanGraph(scanID uuid.UUID) (_ *models.FullScanResult, err error) {
+	ctx, span := r.startSpan("GetScanGraph")
+	defer func() { tracing.End(span, err) }()
+
+	traced := r.WithContext(ctx)
+	result, err := traced.GetScanResult(scanID)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts, err := traced.GetHostsByScanID(scanID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.FullScanResult{ScanResult: result}
+	hostIndex := make(map[uuid.UUID]*models.HostGraph, len(hosts))
+	for _, host := range hosts {
+		hg := &models.HostGraph{Host: host}
+		hostIndex[host.ID] = hg
+		graph.Hosts = append(graph.Hosts, hg)
+	}
+
+	portQuery := `
+		SELECT p.id, p.host_id, p.number, p.protocol, p.state, p.service, p.version, p.product, p.extra_info, p.guessed_service, p.reason, p.reason_ttl, p.hit_ratio, p.created_at
+		FROM ports p JOIN hosts h ON h.id = p.host_id
+		WHERE h.scan_id = $1 ORDER BY p.host_id, p.number`
+
+	rows, err := r.db.QueryContext(ctx, portQuery, scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	portIndex := make(map[uuid.UUID]*models.PortGraph)
+	for rows.Next() {
+		port := &models.Port{}
+		err := rows.Scan(&port.ID, &port.HostID, &port.Number, &port.Protocol,
+			&port.State, &port.Service, &port.Version, &port.Product, &port.ExtraInfo, &port.GuessedService, &port.Reason, &port.ReasonTTL, &port.HitRatio, &port.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		hg, ok := hostIndex[port.HostID]
+		if !ok {
+			continue
+		}
+		pg := &models.PortGraph{Port: port}
+		portIndex[port.ID] = pg
+		hg.Ports = append(hg.Ports, pg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	vulnQuery := `
+		SELECT v.id, v.port_id, v.cve, v.severity, v.description, v.solution, v.reference_links, v.created_at
+		FROM vulnerabilities v
+		JOIN ports p ON p.id = v.port_id
+		JOIN hosts h ON h.id = p.host_id
+		WHERE h.scan_id = $1 ORDER BY v.port_id`
+
+	vulnRows, err := r.db.QueryContext(ctx, vulnQuery, scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer vulnRows.Close()
+
+	for vulnRows.Next() {
+		vuln := &models.Vulnerability{}
+		var cve, solution, refs sql.NullString
+		err := vulnRows.Scan(&vuln.ID, &vuln.PortID, &cve, &vuln.Severity, &vuln.Description,
+			&solution, &refs, &vuln.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		vuln.CVE, vuln.Solution, vuln.ReferenceLinks = cve.String, solution.String, refs.String
+		if pg, ok := portIndex[vuln.PortID]; ok {
+			pg.Vulnerabilities = append(pg.Vulnerabilities, vuln)
+		}
+	}
+	if err := vulnRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+// HTTPProbe operations
+func (r *Repository) CreateHTTPProbe(probe *models.HTTPProbe) error {
+	if probe.ID == uuid.Nil {
+		probe.ID = uuid.New()
+	}
+	probe.CreatedAt = r.clock.Now()
+
+	_, err := r.db.Exec(insertHTTPProbeQuery, probe.ID, probe.PortID, probe.Path, probe.StatusCode,
+		probe.Title, probe.Server, probe.Error, probe.CreatedAt)
+	return err
+}
+
+func (r *Repository) GetHTTPProbesByPortID(portID uuid.UUID) ([]*models.HTTPProbe, error) {
+	query := `
+		SELECT id, port_id, path, status_code, COALESCE(title, ''), COALESCE(server, ''), COALESCE(error, ''), created_at
+		FROM http_probes WHERE port_id = $1 ORDER BY path`
+
+	return r.queryHTTPProbes(query, portID)
+}
+
+// queryHTTPProbes runs an http_probes query and scans every row
+func (r *Repository) queryHTTPProbes(query string, args ...interface{}) ([]*models.HTTPProbe, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var probes []*models.HTTPProbe
+	for rows.Next() {
+		probe := &models.HTTPProbe{}
+		err := rows.Scan(&probe.ID, &probe.PortID, &probe.Path, &probe.StatusCode,
+			&probe.Title, &probe.Server, &probe.Error, &probe.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, probe)
+	}
+	return probes, nil
+}
@@ -0,0 +1,327 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// scanGraphHandler answers the queries of GetScanGraph and of the per-host
+// getters for a scan with the given number of hosts, each with ports open
+// and one vulnerability per port
+func scanGraphHandler(scanID uuid.UUID, hosts, ports int) fakeHandler {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var hostRows, portRows, vulnRows [][]driver.Value
+	for h := 0; h < hosts; h++ {
+		hostID := uuid.New()
+		hostRows = append(hostRows, []driver.Value{
+			hostID.String(), scanID.String(), fmt.Sprintf("192.0.2.%d", h+1), "", "up", "", int64(0),
+			int64(0), []byte("{}"), "", "", "", nil, "", "", "", false, int64(0), nil, int64(0), "", "", now,
+		})
+		for p := 0; p < ports; p++ {
+			portID := uuid.New()
+			portRows = append(portRows, []driver.Value{
+				portID.String(), hostID.String(), int64(20 + p), "tcp", "open", "", "", "", "", "", "", int64(0), 0.0, now,
+			})
+			vulnRows = append(vulnRows, []driver.Value{
+				uuid.New().String(), portID.String(), fmt.Sprintf("CVE-2024-%04d", len(vulnRows)+1), "high", "", nil, nil, now,
+			})
+		}
+	}
+
+	return func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.Contains(query, "FROM scan_results"):
+			return &fakeResult{rows: [][]driver.Value{{
+				scanID.String(), uuid.New().String(), "nmap", "completed", now, nil, int64(1500), "", []byte("{}"),
+				false, true, 1.0, "", "", nil, int64(2), now,
+			}}}, nil
+		case strings.Contains(query, "FROM hosts"):
+			return &fakeResult{rows: hostRows}, nil
+		case strings.Contains(query, "FROM ports p"):
+			return &fakeResult{rows: portRows}, nil
+		case strings.Contains(query, "FROM ports WHERE host_id"):
+			var rows [][]driver.Value
+			for _, row := range portRows {
+				if row[1] == args[0] {
+					rows = append(rows, row)
+				}
+			}
+			return &fakeResult{rows: rows}, nil
+		case strings.Contains(query, "FROM vulnerabilities v"):
+			return &fakeResult{rows: vulnRows}, nil
+		default:
+			return nil, fmt.Errorf("unexpected query: %s", query)
+		}
+	}
+}
+
+func TestGetScanGraphQueryCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts int
+		ports int
+	}{
+		{"empty scan", 0, 0},
+		{"one host", 1, 1},
+		{"many hosts", 50, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanID := uuid.New()
+			db, connector := newFakeDB(t, Config{}, scanGraphHandler(scanID, tt.hosts, tt.ports))
+			repo := NewRepository(db)
+
+			graph, err := repo.GetScanGraph(scanID)
+			if err != nil {
+				t.Fatalf("GetScanGraph() error = %v", err)
+			}
+
+			// One query each for the scan, its hosts, their ports and their vulnerabilities
+			if got := len(connector.Statements()); got != 4 {
+				t.Errorf("GetScanGraph() ran %d queries, want 4", got)
+			}
+
+			if len(graph.Hosts) != tt.hosts {
+				t.Fatalf("graph has %d hosts, want %d", len(graph.Hosts), tt.hosts)
+			}
+			for _, host := range graph.Hosts {
+				if len(host.Ports) != tt.ports {
+					t.Errorf("host %s has %d ports, want %d", host.IPAddress, len(host.Ports), tt.ports)
+				}
+				for _, port := range host.Ports {
+					if port.HostID != host.ID {
+						t.Errorf("port %d of host %s belongs to host %s", port.Number, host.IPAddress, port.HostID)
+					}
+					if len(port.Vulnerabilities) != 1 {
+						t.Errorf("port %d of host %s has %d vulnerabilities, want 1", port.Number, host.IPAddress, len(port.Vulnerabilities))
+					}
+				}
+			}
+
+			// The graph holds the same records as fetching each level on its own
+			hosts, err := repo.GetHostsByScanID(scanID)
+			if err != nil {
+				t.Fatalf("GetHostsByScanID() error = %v", err)
+			}
+			if len(hosts) != len(graph.Hosts) {
+				t.Fatalf("graph has %d hosts, GetHostsByScanID() %d", len(graph.Hosts), len(hosts))
+			}
+			cves := make(map[string]bool)
+			for i, host := range graph.Hosts {
+				if !reflect.DeepEqual(host.Host, hosts[i]) {
+					t.Errorf("graph host %d = %+v, want %+v", i, host.Host, hosts[i])
+				}
+				ports, err := repo.GetPortsByHostID(host.ID)
+				if err != nil {
+					t.Fatalf("GetPortsByHostID() error = %v", err)
+				}
+				if len(ports) != len(host.Ports) {
+					t.Fatalf("host %s has %d graph ports, GetPortsByHostID() %d", host.IPAddress, len(host.Ports), len(ports))
+				}
+				for j, port := range host.Ports {
+					if !reflect.DeepEqual(port.Port, ports[j]) {
+						t.Errorf("host %s graph port %d = %+v, want %+v", host.IPAddress, j, port.Port, ports[j])
+					}
+					for _, vuln := range port.Vulnerabilities {
+						if vuln.PortID != port.ID {
+							t.Errorf("vulnerability %s attached to port %s, stored for %s", vuln.CVE, port.ID, vuln.PortID)
+						}
+						cves[vuln.CVE] = true
+					}
+				}
+			}
+			if len(cves) != tt.hosts*tt.ports {
+				t.Errorf("graph holds %d distinct vulnerabilities, want %d", len(cves), tt.hosts*tt.ports)
+			}
+		})
+	}
+}
+
+// hostArgsHandler records the arguments of every host insert
+func hostArgsHandler(inserts *[][]driver.Value) fakeHandler {
+	return func(query string, args []driver.Value) (*fakeResult, error) {
+		if strings.Contains(query, "INSERT INTO hosts") {
+			*inserts = append(*inserts, args)
+		}
+		return &fakeResult{affected: 1}, nil
+	}
+}
+
+// reputation_sources is TEXT[] NOT NULL, so hosts without reputation data
+// must be stored with an empty array rather than NULL
+func TestHostInsertReputationSources(t *testing.T) {
+	const reputationSourcesArg = 8
+
+	var inserts [][]driver.Value
+	db, _ := newFakeDB(t, Config{}, hostArgsHandler(&inserts))
+	repo := NewRepository(db)
+
+	graph := &models.FullScanResult{
+		ScanResult: &models.ScanResult{TargetID: uuid.New(), ScanType: "nmap", Status: scanner.StatusCompleted},
+		Hosts: []*models.HostGraph{
+			{Host: &models.Host{IPAddress: "192.0.2.1", Status: "up"}},
+			{Host: &models.Host{IPAddress: "192.0.2.2", Status: "up", ReputationScore: 80, ReputationSources: []string{"spamhaus", "abuseipdb"}}},
+		},
+	}
+	if err := repo.SaveScanGraph(graph); err != nil {
+		t.Fatalf("SaveScanGraph() error = %v", err)
+	}
+	if err := repo.CreateHost(&models.Host{ScanID: graph.ID, IPAddress: "192.0.2.3", Status: "up"}); err != nil {
+		t.Fatalf("CreateHost() error = %v", err)
+	}
+
+	want := []string{"{}", `{"spamhaus","abuseipdb"}`, "{}"}
+	if len(inserts) != len(want) {
+		t.Fatalf("%d host inserts, want %d", len(inserts), len(want))
+	}
+	for i, args := range inserts {
+		got := args[reputationSourcesArg]
+		if got == nil {
+			t.Errorf("host %v: reputation_sources encoded as NULL", args[2])
+			continue
+		}
+		if s := fmt.Sprintf("%s", got); s != want[i] {
+			t.Errorf("host %v: reputation_sources = %s, want %s", args[2], s, want[i])
+		}
+	}
+}
+
+// Columns of a scan_results row as inserted into a tableStore
+const (
+	scanPinnedColumn    = 9
+	scanCreatedAtColumn = 16
+)
+
+// retentionHandler answers the pinning, listing and pruning statements over
+// the scan_results rows of store, applying only the predicates each
+// statement states, and passes everything else to the store
+func retentionHandler(store *tableStore) fakeHandler {
+	return func(query string, args []driver.Value) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(query), "UPDATE scan_results SET pinned = $2 WHERE id = $1"):
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			for _, row := range store.tables["scan_results"] {
+				if row[0] == args[0] {
+					row[scanPinnedColumn] = args[1]
+					return &fakeResult{affected: 1}, nil
+				}
+			}
+			return &fakeResult{}, nil
+
+		case strings.HasPrefix(strings.TrimSpace(query), "DELETE FROM scan_results WHERE created_at < $1"):
+			exemptPinned := strings.HasSuffix(strings.TrimSpace(query), "AND NOT pinned")
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			var kept [][]driver.Value
+			deleted := int64(0)
+			for _, row := range store.tables["scan_results"] {
+				if row[scanCreatedAtColumn].(time.Time).Before(args[0].(time.Time)) && !(exemptPinned && row[scanPinnedColumn].(bool)) {
+					deleted++
+					continue
+				}
+				kept = append(kept, row)
+			}
+			store.tables["scan_results"] = kept
+			return &fakeResult{affected: deleted}, nil
+
+		case strings.Contains(query, "FROM scan_results WHERE pinned OR NOT $1"):
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			var rows [][]driver.Value
+			for _, row := range store.tables["scan_results"] {
+				if row[scanPinnedColumn].(bool) || !args[0].(bool) {
+					rows = append(rows, row)
+				}
+			}
+			return &fakeResult{rows: rows}, nil
+		}
+		return store.handle(query, args)
+	}
+}
+
+func TestPinnedScansSurvivePruning(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	store := &tableStore{}
+	db, _ := newFakeDB(t, Config{}, retentionHandler(store))
+	repo := NewRepository(db)
+
+	save := func(age time.Duration) uuid.UUID {
+		t.Helper()
+		graph := &models.FullScanResult{ScanResult: &models.ScanResult{
+			TargetID: uuid.New(), ScanType: "nmap", Status: scanner.StatusCompleted, CreatedAt: now.Add(-age),
+		}}
+		if err := repo.SaveScanGraph(graph); err != nil {
+			t.Fatalf("SaveScanGraph() error = %v", err)
+		}
+		return graph.ID
+	}
+	sorted := func(ids ...uuid.UUID) []uuid.UUID {
+		sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+		return ids
+	}
+	listed := func(pinnedOnly bool) []uuid.UUID {
+		t.Helper()
+		results, err := repo.ListAllScanResults(pinnedOnly)
+		if err != nil {
+			t.Fatalf("ListAllScanResults() error = %v", err)
+		}
+		var ids []uuid.UUID
+		for _, result := range results {
+			ids = append(ids, result.ID)
+		}
+		return sorted(ids...)
+	}
+
+	oldPinned, _, recent := save(90*24*time.Hour), save(60*24*time.Hour), save(24*time.Hour)
+
+	if err := repo.SetScanResultPinned(oldPinned, true); err != nil {
+		t.Fatalf("SetScanResultPinned() error = %v", err)
+	}
+	if got := listed(true); !reflect.DeepEqual(got, sorted(oldPinned)) {
+		t.Errorf("pinned scans = %v, want %v", got, oldPinned)
+	}
+	if err := repo.SetScanResultPinned(uuid.New(), true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetScanResultPinned() of an unknown scan error = %v, want sql.ErrNoRows", err)
+	}
+
+	// A 30-day retention removes the old unpinned scan only
+	deleted, err := repo.PruneScanResults(now.Add(-30 * 24 * time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("PruneScanResults() = %d, %v; want 1 deleted", deleted, err)
+	}
+	if got := listed(false); !reflect.DeepEqual(got, sorted(oldPinned, recent)) {
+		t.Errorf("scans left = %v, want the pinned %s and recent %s", got, oldPinned, recent)
+	}
+
+	// Once unpinned, the scan is pruned like any other
+	if err := repo.SetScanResultPinned(recent, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetScanResultPinned(oldPinned, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := listed(true); !reflect.DeepEqual(got, sorted(recent)) {
+		t.Errorf("pinned scans = %v, want %v", got, recent)
+	}
+	deleted, err = repo.PruneScanResults(now)
+	if err != nil || deleted != 1 {
+		t.Fatalf("PruneScanResults() = %d, %v; want the unpinned scan deleted", deleted, err)
+	}
+	if got := listed(false); !reflect.DeepEqual(got, sorted(recent)) {
+		t.Errorf("scans left = %v, want the pinned %s", got, recent)
+	}
+}
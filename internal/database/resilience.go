@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/clock"
+)
+
+// ErrUnavailable is returned without contacting the database while the
+// circuit breaker is open
+var ErrUnavailable = errors.New("database unavailable")
+
+// RetryPolicy controls how calls failing with transient errors, such as a
+// reset connection or a deadlock, are retried
+type RetryPolicy struct {
+	Attempts   int           // Tries per call including the first; 1 or less disables retries
+	Backoff    time.Duration // Delay before the first retry, doubled before each further one
+	MaxBackoff time.Duration // Longest delay between retries, 0 for no limit
+}
+
+// BreakerPolicy controls the circuit breaker that fails calls fast once the
+// database is persistently unreachable
+type BreakerPolicy struct {
+	Threshold int           // Consecutive connection failures opening the circuit, 0 disables it
+	Cooldown  time.Duration // How long the open circuit fails fast before one call may probe the database
+}
+
+// breaker is a circuit breaker counting consecutive connection failures.
+// Once Threshold is reached the circuit opens and calls fail with
+// ErrUnavailable until Cooldown has passed; then a single call is let
+// through, closing the circuit when it reaches the database and opening it
+// again when it does not.
+type breaker struct {
+	policy BreakerPolicy
+	clock  clock.Clock
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	lastErr   error
+}
+
+func newBreaker(policy BreakerPolicy, c clock.Clock) *breaker {
+	return &breaker{policy: policy, clock: c}
+}
+
+// allow returns ErrUnavailable, with the failure that opened the circuit,
+// while calls must not reach the database
+func (b *breaker) allow() error {
+	if b.policy.Threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.policy.Threshold {
+		return nil
+	}
+	now := b.clock.Now()
+	if now.Before(b.openUntil) || b.probing {
+		wait := max(b.openUntil.Sub(now), 0).Round(time.Second)
+		return fmt.Errorf("%w after %d consecutive connection failures, retrying in %s (last error: %v)",
+			ErrUnavailable, b.failures, wait, b.lastErr)
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the circuit with the outcome of a call let through
+func (b *breaker) record(err error) {
+	if b.policy.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !isConnectionFailure(err) {
+		b.failures, b.lastErr = 0, nil
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if b.failures >= b.policy.Threshold {
+		b.openUntil = b.clock.Now().Add(b.policy.Cooldown)
+	}
+}
+
+// open reports whether calls are currently failing fast
+func (b *breaker) open() bool {
+	if b.policy.Threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.policy.Threshold && (b.clock.Now().Before(b.openUntil) || b.probing)
+}
+
+// call runs fn through the circuit breaker, retrying transient failures
+// with backoff
+func (db *DB) call(ctx context.Context, fn func() error) error {
+	return db.retry(ctx, isTransient, func() error {
+		if err := db.breaker.allow(); err != nil {
+			return err
+		}
+		err := fn()
+		db.breaker.record(err)
+		return err
+	})
+}
+
+// retryTransaction reruns a transaction that the database aborted because
+// of a deadlock or serialization failure. The statements inside it already
+// go through the circuit breaker.
+func (db *DB) retryTransaction(ctx context.Context, fn func() error) error {
+	return db.retry(ctx, isAborted, fn)
+}
+
+// retry runs fn until it succeeds, fails with an error retryable does not
+// accept, ctx is done or the policy's attempts are used up
+func (db *DB) retry(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	backoff := db.retryPolicy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= db.retryPolicy.Attempts || !retryable(err) {
+			return err
+		}
+
+		db.logger.Warnf("Database call failed, retrying in %s (attempt %d of %d): %v", backoff, attempt+1, db.retryPolicy.Attempts, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if db.retryPolicy.MaxBackoff > 0 {
+			backoff = min(backoff, db.retryPolicy.MaxBackoff)
+		}
+	}
+}
+
+// Transient PostgreSQL errors: the connection failure classes, and the
+// aborts a transaction may simply be rerun after
+const (
+	pqClassConnectionException = "08"
+	pqSerializationFailure     = "40001"
+	pqDeadlockDetected         = "40P01"
+	pqTooManyConnections       = "53300"
+	pqAdminShutdown            = "57P01"
+	pqCrashShutdown            = "57P02"
+	pqCannotConnectNow         = "57P03"
+)
+
+// isTransient reports whether a failed call may succeed when repeated
+func isTransient(err error) bool {
+	return isConnectionFailure(err) || isAborted(err)
+}
+
+// isAborted reports whether the database rolled a statement back because
+// of a deadlock or serialization failure
+func isAborted(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+	}
+	return false
+}
+
+// isConnectionFailure reports whether err means the database could not be
+// reached or the connection to it was lost, as opposed to the database
+// rejecting the call
+func isConnectionFailure(err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqTooManyConnections, pqAdminShutdown, pqCrashShutdown, pqCannotConnectNow:
+			return true
+		}
+		return pqErr.Code.Class() == pqClassConnectionException
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/clock"
+)
+
+var (
+	errConnectionLost = &pq.Error{Code: "08006", Message: "connection failure"}
+	errDeadlock       = &pq.Error{Code: pqDeadlockDetected, Message: "deadlock detected"}
+	errSyntax         = &pq.Error{Code: "42601", Message: "syntax error"}
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		connection bool
+		aborted    bool
+	}{
+		{"nil", nil, false, false},
+		{"connection exception class", errConnectionLost, true, false},
+		{"too many connections", &pq.Error{Code: pqTooManyConnections}, true, false},
+		{"admin shutdown", &pq.Error{Code: pqAdminShutdown}, true, false},
+		{"deadlock", errDeadlock, false, true},
+		{"serialization failure", &pq.Error{Code: pqSerializationFailure}, false, true},
+		{"syntax error", errSyntax, false, false},
+		{"wrapped reset", fmt.Errorf("write: %w", syscall.ECONNRESET), true, false},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true, false},
+		{"bad connection", driver.ErrBadConn, true, false},
+		{"no rows", sql.ErrNoRows, false, false},
+		{"cancelled", context.Canceled, false, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionFailure(tt.err); got != tt.connection {
+				t.Errorf("isConnectionFailure() = %t, want %t", got, tt.connection)
+			}
+			if got := isAborted(tt.err); got != tt.aborted {
+				t.Errorf("isAborted() = %t, want %t", got, tt.aborted)
+			}
+			if got := isTransient(tt.err); got != (tt.connection || tt.aborted) {
+				t.Errorf("isTransient() = %t, want %t", got, tt.connection || tt.aborted)
+			}
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	mock := clock.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := newBreaker(BreakerPolicy{Threshold: 2, Cooldown: time.Minute}, mock)
+
+	// Failures the database answered do not count
+	b.record(errConnectionLost)
+	b.record(errSyntax)
+	b.record(errConnectionLost)
+	if err := b.allow(); err != nil || b.open() {
+		t.Fatalf("circuit open after non-consecutive failures: %v", err)
+	}
+
+	b.record(errConnectionLost)
+	if err := b.allow(); !errors.Is(err, ErrUnavailable) || !b.open() {
+		t.Fatalf("allow() = %v after 2 failures, want ErrUnavailable", err)
+	}
+
+	// After the cooldown a single call probes the database
+	mock.Advance(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe refused after the cooldown: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("second call let through while probing: %v", err)
+	}
+
+	// A failed probe opens the circuit for another cooldown
+	b.record(errConnectionLost)
+	mock.Advance(time.Minute - time.Second)
+	if err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("allow() = %v before the cooldown, want ErrUnavailable", err)
+	}
+	mock.Advance(time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe refused after the cooldown: %v", err)
+	}
+
+	// A probe reaching the database closes it
+	b.record(nil)
+	if err := b.allow(); err != nil || b.open() {
+		t.Errorf("circuit still open after a successful probe: %v", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := newBreaker(BreakerPolicy{}, clock.Real{})
+	for range 10 {
+		b.record(errConnectionLost)
+	}
+	if err := b.allow(); err != nil || b.open() {
+		t.Errorf("disabled breaker opened: %v", err)
+	}
+}
+
+// failingHandler fails the first failures statements with err
+func failingHandler(failures int, err error) fakeHandler {
+	calls := 0
+	return func(string, []driver.Value) (*fakeResult, error) {
+		calls++
+		if calls <= failures {
+			return nil, err
+		}
+		return &fakeResult{affected: 1}, nil
+	}
+}
+
+func TestCallRetries(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		wantRuns int
+		wantErr  error
+	}{
+		{"success", 0, nil, 1, nil},
+		{"deadlock retried", 1, errDeadlock, 2, nil},
+		{"lost connection retried", 2, errConnectionLost, 3, nil},
+		{"attempts used up", 5, errConnectionLost, 3, errConnectionLost},
+		{"rejected statement not retried", 5, errSyntax, 1, errSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, connector := newFakeDB(t, Config{Retry: policy}, failingHandler(tt.failures, tt.err))
+
+			_, err := db.ExecContext(context.Background(), "UPDATE hosts SET status = 'up'")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ExecContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if runs := len(connector.Statements()); runs != tt.wantRuns {
+				t.Errorf("statement ran %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestCallStopsRetryingWhenCancelled(t *testing.T) {
+	db, connector := newFakeDB(t, Config{Retry: RetryPolicy{Attempts: 5, Backoff: time.Hour}}, failingHandler(5, errConnectionLost))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "UPDATE hosts SET status = 'up'"); !errors.Is(err, errConnectionLost) {
+		t.Errorf("ExecContext() error = %v, want the last failure", err)
+	}
+	if runs := len(connector.Statements()); runs != 1 {
+		t.Errorf("statement ran %d times, want 1", runs)
+	}
+}
+
+func TestCallFailsFastWhileUnavailable(t *testing.T) {
+	db, connector := newFakeDB(t, Config{Breaker: BreakerPolicy{Threshold: 2, Cooldown: time.Hour}}, failingHandler(2, errConnectionLost))
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := db.ExecContext(ctx, "UPDATE hosts SET status = 'up'"); !errors.Is(err, errConnectionLost) {
+			t.Fatalf("ExecContext() error = %v, want the connection failure", err)
+		}
+	}
+	if db.Available() {
+		t.Error("Available() = true with the circuit open")
+	}
+
+	_, err := db.ExecContext(ctx, "UPDATE hosts SET status = 'up'")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("ExecContext() error = %v, want ErrUnavailable", err)
+	}
+	if runs := len(connector.Statements()); runs != 2 {
+		t.Errorf("%d statements reached the database, want 2", runs)
+	}
+}
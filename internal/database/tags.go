@@ -0,0 +1,157 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/tracing"
+)
+
+// maxTagLength matches the tags.name column
+const maxTagLength = 100
+
+// NormalizeTag trims and lower-cases a tag name, so "Prod" and "prod" are
+// the same tag, and rejects names the tags table cannot hold
+func NormalizeTag(name string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case tag == "":
+		return "", fmt.Errorf("tag name is empty")
+	case len(tag) > maxTagLength:
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes and deduplicates tag names
+func normalizeTags(names []string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// TagScan adds tags to a scan, creating tags that do not exist yet. Tags
+// the scan already carries are left as they are. Returns sql.ErrNoRows when
+// the scan does not exist.
+func (r *Repository) TagScan(scanID uuid.UUID, names ...string) (err error) {
+	ctx, span := r.startSpan("TagScan")
+	defer func() { tracing.End(span, err) }()
+
+	tags, err := normalizeTags(names)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the scan so pruning cannot delete it between the check and the insert
+	var exists bool
+	err = tx.QueryRow(`SELECT true FROM scan_results WHERE id = $1 FOR SHARE`, scanID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up scan: %w", err)
+	}
+
+	now := r.clock.Now()
+	for _, tag := range tags {
+		// The no-op update makes RETURNING yield the ID of an existing tag
+		var tagID uuid.UUID
+		err := tx.QueryRow(`
+			INSERT INTO tags (id, name, created_at) VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`, uuid.New(), tag, now).Scan(&tagID)
+		if err != nil {
+			return fmt.Errorf("failed to create tag %q: %w", tag, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO scan_result_tags (scan_id, tag_id, created_at) VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`, scanID, tagID, now)
+		if err != nil {
+			return fmt.Errorf("failed to tag scan with %q: %w", tag, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UntagScan removes tags from a scan and returns how many it carried.
+// Tags left on no scan are kept so they can be reused.
+func (r *Repository) UntagScan(scanID uuid.UUID, names ...string) (int64, error) {
+	tags, err := normalizeTags(names)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.db.Exec(`
+		DELETE FROM scan_result_tags st USING tags t
+		WHERE st.tag_id = t.id AND st.scan_id = $1 AND t.name = ANY($2)`, scanID, pq.Array(tags))
+	if err != nil {
+		return 0, fmt.Errorf("failed to untag scan: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// FindScansByTag returns the scans carrying a tag across all targets,
+// newest first
+func (r *Repository) FindScansByTag(name string) ([]*models.ScanResult, error) {
+	tag, err := NormalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT s.id, s.target_id, s.scan_type, s.status, s.start_time, s.end_time, s.duration_ms, s.raw_output, s.scan_config, s.pinned, s.complete, s.coverage, s.trace_id, s.correlation_id, s.metadata, s.schema_version, s.created_at
+		FROM scan_results s
+		JOIN scan_result_tags st ON st.scan_id = s.id
+		JOIN tags t ON t.id = st.tag_id
+		WHERE t.name = $1 ORDER BY s.created_at DESC`
+
+	return r.queryScanResults(query, tag)
+}
+
+// ListScanTags returns the tags of a scan in alphabetical order
+func (r *Repository) ListScanTags(scanID uuid.UUID) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT t.name FROM tags t
+		JOIN scan_result_tags st ON st.tag_id = t.id
+		WHERE st.scan_id = $1 ORDER BY t.name`, scanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scan tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,308 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// ScanDiff describes what changed between a base scan and a later scan
+type ScanDiff struct {
+	BaseScanID           uuid.UUID       `json:"base_scan_id"`
+	CompareScanID        uuid.UUID       `json:"compare_scan_id"`
+	BaseCorrelationID    string          `json:"base_correlation_id,omitempty"`
+	CompareCorrelationID string          `json:"compare_correlation_id,omitempty"`
+	NewHosts             []string        `json:"new_hosts"`
+	RemovedHosts         []string        `json:"removed_hosts"`
+	NewPorts             []PortChange    `json:"new_ports"`
+	RemovedPorts         []PortChange    `json:"removed_ports"`
+	NewVulns             []VulnChange    `json:"new_vulnerabilities"`
+	ChangedServices      []ServiceChange `json:"changed_services"`
+	Warnings             []string        `json:"warnings,omitempty"`
+}
+
+// VulnChange identifies a vulnerability not reported by the base scan
+type VulnChange struct {
+	IPAddress string `json:"ip_address"`
+	Port      int    `json:"port"`
+	Protocol  string `json:"protocol"`
+	CVE       string `json:"cve"`
+	Severity  string `json:"severity"`
+}
+
+// Directions of a ServiceChange
+const (
+	ServiceUpgraded   = "upgraded"   // Same product, higher version
+	ServiceDowngraded = "downgraded" // Same product, lower version
+	ServiceReplaced   = "replaced"   // Different service or product
+	ServiceChanged    = "changed"    // Same product, versions not comparable
+)
+
+// ServiceChange identifies an open port whose detected service, product or
+// version differs between the scans. Downgrades are notable: they often
+// mean a rollback or an impostor reintroducing fixed vulnerabilities.
+type ServiceChange struct {
+	IPAddress   string `json:"ip_address"`
+	Port        int    `json:"port"`
+	Protocol    string `json:"protocol"`
+	Direction   string `json:"direction"`
+	Notable     bool   `json:"notable"`
+	BaseService string `json:"base_service"`
+	BaseProduct string `json:"base_product"`
+	BaseVersion string `json:"base_version"`
+	Service     string `json:"service"`
+	Product     string `json:"product"`
+	Version     string `json:"version"`
+}
+
+// PortChange identifies an open port that appeared or disappeared
+type PortChange struct {
+	IPAddress string `json:"ip_address"`
+	Port      int    `json:"port"`
+	Protocol  string `json:"protocol"`
+	Service   string `json:"service"`
+}
+
+// HasChanges reports whether the diff contains any host, port, service or
+// vulnerability change
+func (d *ScanDiff) HasChanges() bool {
+	return len(d.NewHosts) > 0 || len(d.RemovedHosts) > 0 ||
+		len(d.NewPorts) > 0 || len(d.RemovedPorts) > 0 || len(d.NewVulns) > 0 ||
+		len(d.ChangedServices) > 0
+}
+
+// Compare computes the difference between two scan graphs. Hosts are matched
+// by IP address and ports by number and protocol; only open ports count.
+func Compare(base, compare *models.FullScanResult) *ScanDiff {
+	d := &ScanDiff{
+		BaseScanID:           base.ID,
+		CompareScanID:        compare.ID,
+		BaseCorrelationID:    base.CorrelationID,
+		CompareCorrelationID: compare.CorrelationID,
+	}
+
+	if base.TargetID != compare.TargetID {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("scans belong to different targets (%s, %s)", base.TargetID, compare.TargetID))
+	}
+
+	baseHosts := indexHosts(base)
+	compareHosts := indexHosts(compare)
+
+	for ip, host := range compareHosts {
+		baseHost, existed := baseHosts[ip]
+		if !existed {
+			d.NewHosts = append(d.NewHosts, ip)
+			d.NewPorts = append(d.NewPorts, openPorts(host)...)
+			continue
+		}
+		d.NewPorts = append(d.NewPorts, portsOnlyIn(host, baseHost)...)
+		d.RemovedPorts = append(d.RemovedPorts, portsOnlyIn(baseHost, host)...)
+		d.ChangedServices = append(d.ChangedServices, changedServices(baseHost, host)...)
+	}
+
+	for ip, host := range baseHosts {
+		if _, exists := compareHosts[ip]; !exists {
+			d.RemovedHosts = append(d.RemovedHosts, ip)
+			d.RemovedPorts = append(d.RemovedPorts, openPorts(host)...)
+		}
+	}
+
+	d.NewVulns = newVulns(baseHosts, compareHosts)
+
+	sort.Strings(d.NewHosts)
+	sort.Strings(d.RemovedHosts)
+	sortPortChanges(d.NewPorts)
+	sortPortChanges(d.RemovedPorts)
+	sort.Slice(d.ChangedServices, func(i, j int) bool {
+		a, b := d.ChangedServices[i], d.ChangedServices[j]
+		if a.IPAddress != b.IPAddress {
+			return a.IPAddress < b.IPAddress
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+	sort.Slice(d.NewVulns, func(i, j int) bool {
+		a, b := d.NewVulns[i], d.NewVulns[j]
+		if a.IPAddress != b.IPAddress {
+			return a.IPAddress < b.IPAddress
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.CVE < b.CVE
+	})
+
+	return d
+}
+
+// NotableServiceChanges returns the service changes flagged as notable
+func (d *ScanDiff) NotableServiceChanges() []ServiceChange {
+	var notable []ServiceChange
+	for _, change := range d.ChangedServices {
+		if change.Notable {
+			notable = append(notable, change)
+		}
+	}
+	return notable
+}
+
+// changedServices compares the services detected on ports open in both
+// scans of a host. Ports without service detection on either side are
+// skipped, as are version changes where one side has no version, since
+// those usually reflect detection settings rather than the service.
+func changedServices(base, compare *models.HostGraph) []ServiceChange {
+	before := make(map[string]*models.Port)
+	for _, port := range base.Ports {
+		if port.State == "open" {
+			before[portKey(port.Port)] = port.Port
+		}
+	}
+
+	var changes []ServiceChange
+	for _, port := range compare.Ports {
+		old, ok := before[portKey(port.Port)]
+		if port.State != "open" || !ok || !hasServiceInfo(old) || !hasServiceInfo(port.Port) {
+			continue
+		}
+
+		change := ServiceChange{
+			IPAddress:   compare.IPAddress,
+			Port:        port.Number,
+			Protocol:    port.Protocol,
+			BaseService: old.Service,
+			BaseProduct: old.Product,
+			BaseVersion: old.Version,
+			Service:     port.Service,
+			Product:     port.Product,
+			Version:     port.Version,
+		}
+		switch {
+		case !strings.EqualFold(old.Service, port.Service) || !strings.EqualFold(old.Product, port.Product):
+			change.Direction = ServiceReplaced
+		case old.Version == port.Version || old.Version == "" || port.Version == "":
+			continue
+		default:
+			order, ok := compareVersions(old.Version, port.Version)
+			switch {
+			case !ok:
+				change.Direction = ServiceChanged
+			case order < 0:
+				change.Direction = ServiceUpgraded
+			case order > 0:
+				change.Direction = ServiceDowngraded
+				change.Notable = true
+			default:
+				// Differently written, same version (e.g. 8.9 and 8.9.0)
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// hasServiceInfo reports whether service detection identified the port
+func hasServiceInfo(port *models.Port) bool {
+	return port.Service != "" || port.Product != "" || port.Version != ""
+}
+
+// newVulns lists vulnerabilities in compare whose CVE was not reported for
+// the same host and port in base
+func newVulns(baseHosts, compareHosts map[string]*models.HostGraph) []VulnChange {
+	known := make(map[string]bool)
+	for ip, host := range baseHosts {
+		for _, port := range host.Ports {
+			for _, vuln := range port.Vulnerabilities {
+				known[ip+" "+portKey(port.Port)+" "+vuln.CVE] = true
+			}
+		}
+	}
+
+	var changes []VulnChange
+	for ip, host := range compareHosts {
+		for _, port := range host.Ports {
+			for _, vuln := range port.Vulnerabilities {
+				if known[ip+" "+portKey(port.Port)+" "+vuln.CVE] {
+					continue
+				}
+				changes = append(changes, VulnChange{
+					IPAddress: ip,
+					Port:      port.Number,
+					Protocol:  port.Protocol,
+					CVE:       vuln.CVE,
+					Severity:  vuln.Severity,
+				})
+			}
+		}
+	}
+	return changes
+}
+
+// indexHosts maps each host in a scan graph by IP address
+func indexHosts(graph *models.FullScanResult) map[string]*models.HostGraph {
+	hosts := make(map[string]*models.HostGraph, len(graph.Hosts))
+	for _, host := range graph.Hosts {
+		hosts[host.IPAddress] = host
+	}
+	return hosts
+}
+
+// portKey identifies a port by number and protocol
+func portKey(port *models.Port) string {
+	return fmt.Sprintf("%d/%s", port.Number, port.Protocol)
+}
+
+// openPorts lists a host's open ports as changes
+func openPorts(host *models.HostGraph) []PortChange {
+	var changes []PortChange
+	for _, port := range host.Ports {
+		if port.State == "open" {
+			changes = append(changes, newPortChange(host.IPAddress, port.Port))
+		}
+	}
+	return changes
+}
+
+// portsOnlyIn returns open ports present on a but not open on b
+func portsOnlyIn(a, b *models.HostGraph) []PortChange {
+	open := make(map[string]bool)
+	for _, port := range b.Ports {
+		if port.State == "open" {
+			open[portKey(port.Port)] = true
+		}
+	}
+
+	var changes []PortChange
+	for _, port := range a.Ports {
+		if port.State == "open" && !open[portKey(port.Port)] {
+			changes = append(changes, newPortChange(a.IPAddress, port.Port))
+		}
+	}
+	return changes
+}
+
+func newPortChange(ip string, port *models.Port) PortChange {
+	return PortChange{
+		IPAddress: ip,
+		Port:      port.Number,
+		Protocol:  port.Protocol,
+		Service:   port.Service,
+	}
+}
+
+func sortPortChanges(changes []PortChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].IPAddress != changes[j].IPAddress {
+			return changes[i].IPAddress < changes[j].IPAddress
+		}
+		if changes[i].Port != changes[j].Port {
+			return changes[i].Port < changes[j].Port
+		}
+		return changes[i].Protocol < changes[j].Protocol
+	})
+}
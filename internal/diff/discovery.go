@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"net"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// CountDiscoveries classifies the open ports of hosts, and the hosts with
+// any open port, as new or known. known holds the ports the inventory had
+// tracked before the scan, see Repository.ListPortAssetsBefore.
+func CountDiscoveries(hosts []*models.Host, known []*models.PortAsset) *models.DiscoveryCounts {
+	type portKey struct {
+		ip       string
+		port     int
+		protocol string
+	}
+	knownHosts := make(map[string]bool)
+	knownPorts := make(map[portKey]bool)
+	for _, asset := range known {
+		ip := canonicalIP(asset.IPAddress)
+		knownHosts[ip] = true
+		knownPorts[portKey{ip, asset.Port, asset.Protocol}] = true
+	}
+
+	counts := &models.DiscoveryCounts{}
+	counted := make(map[string]bool)
+	for _, host := range hosts {
+		ip := canonicalIP(host.IPAddress)
+		open := false
+		for _, port := range host.Ports {
+			if port.State != "open" {
+				continue
+			}
+			open = true
+			if knownPorts[portKey{ip, port.Number, port.Protocol}] {
+				counts.KnownPorts++
+			} else {
+				counts.NewPorts++
+			}
+		}
+		if !open || counted[ip] {
+			continue
+		}
+		counted[ip] = true
+		if knownHosts[ip] {
+			counts.KnownHosts++
+		} else {
+			counts.NewHosts++
+		}
+	}
+	return counts
+}
+
+// canonicalIP formats an address the way PostgreSQL's host() does, so
+// differently written IPv6 addresses match
+func canonicalIP(address string) string {
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return address
+}
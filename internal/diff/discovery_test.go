@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+func TestCountDiscoveries(t *testing.T) {
+	open := func(number int, protocol string) *models.Port {
+		return &models.Port{Number: number, Protocol: protocol, State: "open"}
+	}
+	known := []*models.PortAsset{
+		{IPAddress: "10.0.0.1", Port: 22, Protocol: "tcp"},
+		{IPAddress: "2001:db8::1", Port: 443, Protocol: "tcp"},
+	}
+
+	tests := []struct {
+		name  string
+		hosts []*models.Host
+		want  models.DiscoveryCounts
+	}{
+		{
+			"known host with a new port",
+			[]*models.Host{{IPAddress: "10.0.0.1", Ports: []*models.Port{open(22, "tcp"), open(80, "tcp")}}},
+			models.DiscoveryCounts{KnownHosts: 1, NewPorts: 1, KnownPorts: 1},
+		},
+		{
+			"same number on another protocol is new",
+			[]*models.Host{{IPAddress: "10.0.0.1", Ports: []*models.Port{open(22, "udp")}}},
+			models.DiscoveryCounts{KnownHosts: 1, NewPorts: 1},
+		},
+		{
+			"new host",
+			[]*models.Host{{IPAddress: "10.0.0.9", Ports: []*models.Port{open(22, "tcp")}}},
+			models.DiscoveryCounts{NewHosts: 1, NewPorts: 1},
+		},
+		{
+			"IPv6 written differently",
+			[]*models.Host{{IPAddress: "2001:0db8:0:0::1", Ports: []*models.Port{open(443, "tcp")}}},
+			models.DiscoveryCounts{KnownHosts: 1, KnownPorts: 1},
+		},
+		{
+			"hosts without open ports ignored",
+			[]*models.Host{
+				{IPAddress: "10.0.0.9", Ports: []*models.Port{{Number: 22, Protocol: "tcp", State: "closed"}}},
+				{IPAddress: "10.0.0.10"},
+			},
+			models.DiscoveryCounts{},
+		},
+		{
+			"host listed twice counted once",
+			[]*models.Host{
+				{IPAddress: "10.0.0.9", Ports: []*models.Port{open(22, "tcp")}},
+				{IPAddress: "10.0.0.9", Ports: []*models.Port{open(80, "tcp")}},
+			},
+			models.DiscoveryCounts{NewHosts: 1, NewPorts: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountDiscoveries(tt.hosts, known); *got != tt.want {
+				t.Errorf("CountDiscoveries() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	// Everything is new to an empty inventory
+	got := CountDiscoveries([]*models.Host{{IPAddress: "10.0.0.1", Ports: []*models.Port{open(22, "tcp")}}}, nil)
+	if want := (models.DiscoveryCounts{NewHosts: 1, NewPorts: 1}); *got != want {
+		t.Errorf("CountDiscoveries() with no inventory = %+v, want %+v", *got, want)
+	}
+}
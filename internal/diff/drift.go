@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/services"
+)
+
+// Drift severities, from least to most severe
+const (
+	SeverityNone     = "none"
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var severityRank = map[string]int{
+	SeverityNone:     0,
+	SeverityInfo:     1,
+	SeverityLow:      2,
+	SeverityMedium:   3,
+	SeverityHigh:     4,
+	SeverityCritical: 5,
+}
+
+// Change kinds reported in a Drift
+const (
+	ChangeNewHost     = "new_host"
+	ChangeRemovedHost = "removed_host"
+	ChangeNewPort     = "new_port"
+	ChangeRemovedPort = "removed_port"
+	ChangeNewVuln     = "new_vulnerability"
+	ChangeService     = "changed_service"
+)
+
+// exposedGroups are the service groups whose ports are high severity when
+// they newly appear, since they are rarely meant to be reachable
+var exposedGroups = []string{"db", "remote-access"}
+
+// Drift rates every change of a diff against a target's baseline
+type Drift struct {
+	TargetID uuid.UUID `json:"target_id"`
+	Severity string    `json:"severity"` // Highest severity among the changes, none without changes
+	Changes  []Change  `json:"changes"`
+	Diff     *ScanDiff `json:"diff"`
+}
+
+// Change is one rated difference between the baseline and the latest scan
+type Change struct {
+	Kind      string `json:"kind"`
+	Severity  string `json:"severity"`
+	IPAddress string `json:"ip_address"`
+	Port      int    `json:"port,omitempty"`
+	Protocol  string `json:"protocol,omitempty"`
+	Service   string `json:"service,omitempty"`
+	CVE       string `json:"cve,omitempty"`
+	Direction string `json:"direction,omitempty"` // Service changes: upgraded, downgraded, replaced or changed
+}
+
+// Assess rates the changes in d. New ports on database or remote access
+// services are high, other new ports and hosts medium, new vulnerabilities
+// keep their own severity and anything that disappeared is info. Service
+// downgrades are medium, replaced services low and other version changes info.
+func Assess(targetID uuid.UUID, d *ScanDiff) *Drift {
+	drift := &Drift{TargetID: targetID, Severity: SeverityNone, Changes: []Change{}, Diff: d}
+	add := func(change Change) {
+		drift.Changes = append(drift.Changes, change)
+		if severityRank[change.Severity] > severityRank[drift.Severity] {
+			drift.Severity = change.Severity
+		}
+	}
+
+	for _, ip := range d.NewHosts {
+		add(Change{Kind: ChangeNewHost, Severity: SeverityMedium, IPAddress: ip})
+	}
+	for _, ip := range d.RemovedHosts {
+		add(Change{Kind: ChangeRemovedHost, Severity: SeverityInfo, IPAddress: ip})
+	}
+	for _, port := range d.NewPorts {
+		add(portChange(ChangeNewPort, newPortSeverity(port), port))
+	}
+	for _, port := range d.RemovedPorts {
+		add(portChange(ChangeRemovedPort, SeverityInfo, port))
+	}
+	for _, vuln := range d.NewVulns {
+		severity := strings.ToLower(vuln.Severity)
+		if _, known := severityRank[severity]; !known || severity == SeverityNone {
+			severity = SeverityMedium
+		}
+		add(Change{Kind: ChangeNewVuln, Severity: severity, IPAddress: vuln.IPAddress,
+			Port: vuln.Port, Protocol: vuln.Protocol, CVE: vuln.CVE})
+	}
+	for _, service := range d.ChangedServices {
+		severity := SeverityInfo
+		switch service.Direction {
+		case ServiceDowngraded:
+			severity = SeverityMedium
+		case ServiceReplaced:
+			severity = SeverityLow
+		}
+		add(Change{Kind: ChangeService, Severity: severity, IPAddress: service.IPAddress,
+			Port: service.Port, Protocol: service.Protocol, Service: service.Service, Direction: service.Direction})
+	}
+
+	return drift
+}
+
+func portChange(kind, severity string, port PortChange) Change {
+	return Change{Kind: kind, Severity: severity, IPAddress: port.IPAddress,
+		Port: port.Port, Protocol: port.Protocol, Service: port.Service}
+}
+
+// newPortSeverity is high when the port, or the standard port of the
+// service detected on it, belongs to an exposed service group
+func newPortSeverity(port PortChange) string {
+	candidates := append([]int{port.Port}, services.StandardPorts(port.Service)...)
+	for _, group := range exposedGroups {
+		for _, groupPort := range scanner.ServiceGroups[group] {
+			for _, candidate := range candidates {
+				if candidate == groupPort {
+					return SeverityHigh
+				}
+			}
+		}
+	}
+	return SeverityMedium
+}
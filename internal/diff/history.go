@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// HistoryDiff aggregates open ports across several scans of one target
+type HistoryDiff struct {
+	ScanIDs []uuid.UUID     `json:"scan_ids"` // Oldest first
+	Ports   []PortStability `json:"ports"`
+}
+
+// PortStability records how consistently a port was open across scans
+type PortStability struct {
+	IPAddress    string  `json:"ip_address"`
+	Port         int     `json:"port"`
+	Protocol     string  `json:"protocol"`
+	Service      string  `json:"service"`
+	OpenCount    int     `json:"open_count"`   // Scans that saw the port open
+	ScanCount    int     `json:"scan_count"`   // Scans considered
+	Stability    float64 `json:"stability"`    // OpenCount / ScanCount
+	Transitions  int     `json:"transitions"`  // Open/closed changes between consecutive scans
+	Intermittent bool    `json:"intermittent"` // Open in some but not all scans
+	Pattern      string  `json:"pattern"`      // One character per scan, oldest first: o open, . not seen
+}
+
+// Flapping reports whether the port opened and closed more than once
+func (p *PortStability) Flapping() bool {
+	return p.Transitions >= 2
+}
+
+// History computes per-port stability across scans ordered oldest first.
+// Ports that were never open in any scan are not listed.
+func History(scans []*models.FullScanResult) *HistoryDiff {
+	h := &HistoryDiff{}
+
+	type tracked struct {
+		stability *PortStability
+		seen      []bool
+	}
+	ports := make(map[string]*tracked)
+
+	for i, scan := range scans {
+		h.ScanIDs = append(h.ScanIDs, scan.ID)
+		for _, host := range scan.Hosts {
+			for _, port := range host.Ports {
+				if port.State != "open" {
+					continue
+				}
+				key := host.IPAddress + " " + portKey(port.Port)
+				t, ok := ports[key]
+				if !ok {
+					t = &tracked{
+						stability: &PortStability{
+							IPAddress: host.IPAddress,
+							Port:      port.Number,
+							Protocol:  port.Protocol,
+						},
+						seen: make([]bool, len(scans)),
+					}
+					ports[key] = t
+				}
+				if port.Service != "" {
+					t.stability.Service = port.Service
+				}
+				t.seen[i] = true
+			}
+		}
+	}
+
+	for _, t := range ports {
+		p := t.stability
+		p.ScanCount = len(scans)
+
+		var pattern strings.Builder
+		for i, open := range t.seen {
+			if open {
+				p.OpenCount++
+				pattern.WriteByte('o')
+			} else {
+				pattern.WriteByte('.')
+			}
+			if i > 0 && open != t.seen[i-1] {
+				p.Transitions++
+			}
+		}
+		p.Pattern = pattern.String()
+		p.Stability = float64(p.OpenCount) / float64(p.ScanCount)
+		p.Intermittent = p.OpenCount < p.ScanCount
+
+		h.Ports = append(h.Ports, *p)
+	}
+
+	sort.Slice(h.Ports, func(i, j int) bool {
+		a, b := h.Ports[i], h.Ports[j]
+		if a.IPAddress != b.IPAddress {
+			return a.IPAddress < b.IPAddress
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+
+	return h
+}
+
+// Intermittent returns the ports that were not open in every scan
+func (h *HistoryDiff) Intermittent() []PortStability {
+	var ports []PortStability
+	for _, port := range h.Ports {
+		if port.Intermittent {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
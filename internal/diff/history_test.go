@@ -0,0 +1,72 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// historyScan builds a scan of one host with the given ports
+func historyScan(ports ...*models.Port) *models.FullScanResult {
+	host := &models.HostGraph{Host: &models.Host{IPAddress: "192.0.2.10", Status: "up"}}
+	for _, port := range ports {
+		host.Ports = append(host.Ports, &models.PortGraph{Port: port})
+	}
+	return &models.FullScanResult{
+		ScanResult: &models.ScanResult{ID: uuid.New()},
+		Hosts:      []*models.HostGraph{host},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	open := func(number int, service string) *models.Port {
+		return &models.Port{Number: number, Protocol: "tcp", State: "open", Service: service}
+	}
+	closed := func(number int) *models.Port {
+		return &models.Port{Number: number, Protocol: "tcp", State: "closed"}
+	}
+
+	// Oldest first: ssh is always open, 8080 flaps, 443 opened recently and
+	// 3389 was only ever seen closed
+	scans := []*models.FullScanResult{
+		historyScan(open(22, "ssh"), open(8080, ""), closed(3389)),
+		historyScan(open(22, "ssh"), closed(8080)),
+		historyScan(open(22, "ssh"), open(8080, "http-proxy")),
+		historyScan(open(22, "ssh"), closed(3389)),
+		historyScan(open(22, "ssh"), open(8080, ""), open(443, "https")),
+	}
+
+	h := History(scans)
+	wantIDs := make([]uuid.UUID, len(scans))
+	for i, scan := range scans {
+		wantIDs[i] = scan.ID
+	}
+	if !reflect.DeepEqual(h.ScanIDs, wantIDs) {
+		t.Errorf("ScanIDs = %v, want %v", h.ScanIDs, wantIDs)
+	}
+
+	want := []PortStability{
+		{IPAddress: "192.0.2.10", Port: 22, Protocol: "tcp", Service: "ssh", OpenCount: 5, ScanCount: 5, Stability: 1, Pattern: "ooooo"},
+		{IPAddress: "192.0.2.10", Port: 443, Protocol: "tcp", Service: "https", OpenCount: 1, ScanCount: 5, Stability: 0.2, Transitions: 1, Intermittent: true, Pattern: "....o"},
+		{IPAddress: "192.0.2.10", Port: 8080, Protocol: "tcp", Service: "http-proxy", OpenCount: 3, ScanCount: 5, Stability: 0.6, Transitions: 4, Intermittent: true, Pattern: "o.o.o"},
+	}
+	if !reflect.DeepEqual(h.Ports, want) {
+		t.Fatalf("Ports =\n%+v\nwant\n%+v", h.Ports, want)
+	}
+
+	flapping := map[int]bool{22: false, 443: false, 8080: true}
+	for _, port := range h.Ports {
+		if got := port.Flapping(); got != flapping[port.Port] {
+			t.Errorf("port %d Flapping() = %t, want %t", port.Port, got, flapping[port.Port])
+		}
+	}
+	if got := h.Intermittent(); !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("Intermittent() = %+v, want ports 443 and 8080", got)
+	}
+
+	if empty := History(nil); len(empty.ScanIDs) != 0 || len(empty.Ports) != 0 {
+		t.Errorf("History(nil) = %+v, want no scans or ports", empty)
+	}
+}
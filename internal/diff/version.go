@@ -0,0 +1,120 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// preReleaseMarkers sort before the release they precede, so 2.0rc1 < 2.0
+var preReleaseMarkers = map[string]bool{
+	"alpha": true, "a": true, "beta": true, "b": true,
+	"rc": true, "pre": true, "dev": true, "snapshot": true,
+}
+
+// compareVersions compares two loosely formatted versions such as "8.9p1",
+// "2.4.41" or "1.18.0-rc2" and returns -1, 0 or 1. ok is false when either
+// string holds no version number, in which case the direction is unknown.
+func compareVersions(a, b string) (result int, ok bool) {
+	ta, tb := versionTokens(a), versionTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0, false
+	}
+
+	for i := 0; i < len(ta) && i < len(tb); i++ {
+		if c := compareTokens(ta[i], tb[i]); c != 0 {
+			return c, true
+		}
+	}
+	if len(ta) > len(tb) {
+		return trailingOrder(ta[len(tb):]), true
+	}
+	return -trailingOrder(tb[len(ta):]), true
+}
+
+// versionTokens splits the version starting at the first digit into runs
+// of digits and runs of letters, dropping separators. Text after the first
+// space, such as a distribution suffix, is ignored.
+func versionTokens(version string) []string {
+	start := strings.IndexFunc(version, unicode.IsDigit)
+	if start < 0 {
+		return nil
+	}
+	version = strings.ToLower(version[start:])
+	if end := strings.IndexFunc(version, unicode.IsSpace); end >= 0 {
+		version = version[:end]
+	}
+
+	var tokens []string
+	current := ""
+	currentDigits := false
+	for _, r := range version {
+		isDigit := unicode.IsDigit(r)
+		if !isDigit && !unicode.IsLetter(r) {
+			if current != "" {
+				tokens = append(tokens, current)
+				current = ""
+			}
+			continue
+		}
+		if current != "" && isDigit != currentDigits {
+			tokens = append(tokens, current)
+			current = ""
+		}
+		current += string(r)
+		currentDigits = isDigit
+	}
+	if current != "" {
+		tokens = append(tokens, current)
+	}
+	return tokens
+}
+
+// compareTokens orders numbers numerically, numbers after letters, and
+// letters alphabetically with pre-release markers first
+func compareTokens(a, b string) int {
+	numA, numB := unicode.IsDigit(rune(a[0])), unicode.IsDigit(rune(b[0]))
+	switch {
+	case numA && numB:
+		return compareNumbers(a, b)
+	case numA:
+		return 1
+	case numB:
+		return -1
+	}
+
+	if preA, preB := preReleaseMarkers[a], preReleaseMarkers[b]; preA != preB {
+		if preA {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// trailingOrder is how a version with extra tokens compares against the
+// same version without them: 8.9p1 > 8.9, 2.0rc1 < 2.0 and 8.9.0 = 8.9
+func trailingOrder(extra []string) int {
+	for _, token := range extra {
+		switch {
+		case strings.Trim(token, "0") == "":
+			continue
+		case preReleaseMarkers[token]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return 0
+}
+
+// compareNumbers compares digit strings of any length numerically
+func compareNumbers(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
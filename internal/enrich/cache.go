@@ -0,0 +1,190 @@
+package enrich
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/clock"
+)
+
+// Cache defaults for enrich.cache.size and enrich.cache.ttl
+const (
+	DefaultCacheSize = 10000
+	DefaultCacheTTL  = time.Hour
+)
+
+// Cache is a bounded in-process cache of lookup results shared across
+// enrichers, so resolving the same name or address again within a run is
+// answered from memory. Entries expire after the TTL and the least recently
+// used entry is evicted once the cache is full. A nil *Cache caches nothing.
+type Cache struct {
+	size  int
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu       sync.Mutex
+	order    *list.List // Most recently used first
+	entries  map[string]*list.Element
+	inFlight map[string]*cacheCall
+
+	hits, misses atomic.Uint64
+}
+
+// cacheEntry is a cached value and when it expires (zero for never)
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// cacheCall is a lookup in progress that callers asking for the same key wait on
+type cacheCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// NewCache creates a cache holding at most size entries, each kept for ttl
+// (0 for as long as the process runs). A size below 1 returns nil, which
+// disables caching.
+func NewCache(size int, ttl time.Duration) *Cache {
+	if size < 1 {
+		return nil
+	}
+	return &Cache{
+		size:     size,
+		ttl:      ttl,
+		clock:    clock.Real{},
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		inFlight: make(map[string]*cacheCall),
+	}
+}
+
+// SetClock replaces the clock used to expire entries
+func (c *Cache) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// Get returns the unexpired value cached for key
+func (c *Cache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// Set caches value for key, evicting the least recently used entry when full
+func (c *Cache) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// Lookup returns the value cached for key, or calls fetch and caches what
+// it returns. Concurrent lookups of a key not yet cached share one fetch.
+// Errors are returned to every caller waiting on the fetch but not cached,
+// so the next lookup tries again.
+func (c *Cache) Lookup(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return fetch(ctx)
+	}
+
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return value, nil
+	}
+	if call, ok := c.inFlight[key]; ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.inFlight[key] = call
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	call.value, call.err = fetch(ctx)
+
+	c.mu.Lock()
+	delete(c.inFlight, key)
+	if call.err == nil {
+		c.set(key, call.value)
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.value, call.err
+}
+
+// Stats returns how many lookups were answered from the cache and how many
+// had to fetch
+func (c *Cache) Stats() (hits, misses uint64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Len returns the number of entries held, expired ones included until evicted
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get returns the value for key, dropping it when expired; c.mu must be held
+func (c *Cache) get(key string) (interface{}, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && !c.clock.Now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set stores value for key; c.mu must be held
+func (c *Cache) set(key string, value interface{}) {
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.clock.Now().Add(c.ttl)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
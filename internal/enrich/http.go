@@ -0,0 +1,267 @@
+package enrich
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// DefaultUserAgent is sent when no user agent is configured
+const DefaultUserAgent = "netrecon/1.0"
+
+// DefaultHTTPPaths are probed when no paths are configured
+var DefaultHTTPPaths = []string{"/"}
+
+// maxBodyBytes bounds how much of a response body is read for title extraction
+const maxBodyBytes = 64 * 1024
+
+var titleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// HTTPConfig holds HTTP enrichment settings
+type HTTPConfig struct {
+	UserAgent     string        // User-Agent header sent with every probe
+	Paths         []string      // Paths probed on every HTTP service
+	RespectRobots bool          // Skip paths disallowed by /robots.txt
+	Timeout       time.Duration // Per-request timeout
+}
+
+// HTTPEnricher probes HTTP services for status codes, titles and server headers
+type HTTPEnricher struct {
+	config HTTPConfig
+	client *http.Client
+	pool   *Pool
+}
+
+// NewHTTPEnricher creates a new HTTP enricher
+func NewHTTPEnricher(config HTTPConfig) *HTTPEnricher {
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+	if len(config.Paths) == 0 {
+		config.Paths = DefaultHTTPPaths
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	client := &http.Client{
+		Timeout: config.Timeout,
+		Transport: &http.Transport{
+			// Recon targets commonly use self-signed certificates
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+		},
+		// Record the status of each probed path rather than its redirect target
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &HTTPEnricher{config: config, client: client, pool: NewPool(1, 0)}
+}
+
+// SetPool runs the enricher's probes in pool, shared with other enrichers;
+// without one ports are probed one at a time
+func (e *HTTPEnricher) SetPool(pool *Pool) {
+	e.pool = pool
+}
+
+// IsHTTPPort reports whether a port looks like an HTTP service and whether it uses TLS
+func IsHTTPPort(port *models.Port) (isHTTP bool, useTLS bool) {
+	if port.State != "" && port.State != "open" {
+		return false, false
+	}
+
+	service := strings.ToLower(port.Service)
+	switch {
+	case service == "https" || strings.HasPrefix(service, "ssl/http") || service == "https-alt":
+		return true, true
+	case strings.Contains(service, "http"):
+		return true, false
+	}
+
+	switch port.Number {
+	case 443, 8443:
+		return true, true
+	case 80, 8000, 8080, 8888:
+		return true, false
+	}
+	return false, false
+}
+
+// EnrichPorts probes every HTTP-like port on the given address
+func (e *HTTPEnricher) EnrichPorts(ctx context.Context, address string, ports []*models.Port) {
+	e.EnrichHosts(ctx, []*models.Host{{IPAddress: address, Ports: ports}})
+}
+
+// EnrichHosts probes every HTTP-like port of the hosts, as many at once as
+// the pool allows
+func (e *HTTPEnricher) EnrichHosts(ctx context.Context, hosts []*models.Host) {
+	type target struct {
+		address string
+		port    *models.Port
+	}
+	var targets []target
+	for _, host := range hosts {
+		for _, port := range host.Ports {
+			if isHTTP, _ := IsHTTPPort(port); isHTTP {
+				targets = append(targets, target{address: host.IPAddress, port: port})
+			}
+		}
+	}
+
+	e.pool.Run(ctx, len(targets), func(ctx context.Context, i int) {
+		e.EnrichPort(ctx, targets[i].address, targets[i].port)
+	})
+}
+
+// EnrichPort probes the configured paths on a single port and records the results
+func (e *HTTPEnricher) EnrichPort(ctx context.Context, address string, port *models.Port) {
+	isHTTP, useTLS := IsHTTPPort(port)
+	if !isHTTP {
+		return
+	}
+
+	baseURL := baseURL(address, port.Number, useTLS)
+
+	var disallowed []string
+	if e.config.RespectRobots {
+		disallowed = e.fetchDisallowed(ctx, baseURL)
+	}
+
+	for _, path := range e.config.Paths {
+		if isDisallowed(path, disallowed) {
+			continue
+		}
+		port.HTTPProbes = append(port.HTTPProbes, e.probe(ctx, baseURL, path, port.ID))
+	}
+}
+
+// probe requests a single path and captures status, title and server header
+func (e *HTTPEnricher) probe(ctx context.Context, baseURL, path string, portID uuid.UUID) *models.HTTPProbe {
+	probe := &models.HTTPProbe{
+		ID:     uuid.New(),
+		PortID: portID,
+		Path:   path,
+	}
+
+	resp, err := e.get(ctx, baseURL+path)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	defer resp.Body.Close()
+
+	probe.StatusCode = resp.StatusCode
+	probe.Server = resp.Header.Get("Server")
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if match := titleRegex.FindSubmatch(body); match != nil {
+		probe.Title = strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+	}
+
+	return probe
+}
+
+// fetchDisallowed returns the robots.txt Disallow prefixes that apply to our user agent
+func (e *HTTPEnricher) fetchDisallowed(ctx context.Context, baseURL string) []string {
+	resp, err := e.get(ctx, baseURL+"/robots.txt")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	return parseRobots(io.LimitReader(resp.Body, maxBodyBytes), e.config.UserAgent)
+}
+
+// get issues a GET request with the configured user agent
+func (e *HTTPEnricher) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("User-Agent", e.config.UserAgent)
+
+	return e.client.Do(req)
+}
+
+// parseRobots extracts Disallow rules from the groups matching userAgent or "*"
+func parseRobots(r io.Reader, userAgent string) []string {
+	agent := strings.ToLower(userAgent)
+	if i := strings.Index(agent, "/"); i >= 0 {
+		agent = agent[:i]
+	}
+
+	var disallowed []string
+	applies := false
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				applies = false
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			if name == "*" || (agent != "" && strings.Contains(agent, name)) {
+				applies = true
+			}
+		case "disallow":
+			inAgents = false
+			if applies && value != "" {
+				disallowed = append(disallowed, value)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	return disallowed
+}
+
+// isDisallowed reports whether path matches any robots.txt Disallow prefix
+func isDisallowed(path string, disallowed []string) bool {
+	for _, prefix := range disallowed {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// baseURL builds the scheme://host:port prefix for a probe
+func baseURL(address string, port int, useTLS bool) string {
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(address, strconv.Itoa(port))
+}
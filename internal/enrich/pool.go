@@ -0,0 +1,64 @@
+package enrich
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Enrichment defaults for enrich.concurrency and enrich.timeout
+const (
+	DefaultConcurrency = 20
+	DefaultTimeout     = 10 * time.Second
+)
+
+// Pool bounds the enrichment work running at once. Enrichers sharing a pool
+// share its limit, so probing thousands of ports keeps a fixed number of
+// connections, and file descriptors, open.
+type Pool struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// NewPool creates a pool running at most concurrency tasks at once, each
+// limited to timeout (0 for no limit)
+func NewPool(concurrency int, timeout time.Duration) *Pool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Pool{slots: make(chan struct{}, concurrency), timeout: timeout}
+}
+
+// Run calls fn for each index below n, running at most the pool's
+// concurrency at once across every caller, and returns once all calls have
+// finished. Calls not started when ctx is cancelled are skipped.
+func (p *Pool) Run(ctx context.Context, n int, fn func(ctx context.Context, i int)) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for i := 0; i < n; i++ {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		if ctx.Err() != nil {
+			<-p.slots
+			return
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-p.slots }()
+
+			taskCtx := ctx
+			if p.timeout > 0 {
+				var cancel context.CancelFunc
+				taskCtx, cancel = context.WithTimeout(ctx, p.timeout)
+				defer cancel()
+			}
+			fn(taskCtx, i)
+		}(i)
+	}
+}
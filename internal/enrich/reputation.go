@@ -0,0 +1,253 @@
+package enrich
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// ReputationProvider looks up the reputation of an IP address. Scores range
+// from 0 (clean) to 100 (known malicious).
+type ReputationProvider interface {
+	Name() string
+	Lookup(ctx context.Context, ip string) (int, error)
+}
+
+// ReputationEnricher annotates public hosts with threat-intel reputation
+type ReputationEnricher struct {
+	providers []ReputationProvider
+	pool      *Pool
+	cache     *Cache
+}
+
+// NewReputationEnricher creates an enricher querying the given providers.
+// With no providers it is a no-op.
+func NewReputationEnricher(providers ...ReputationProvider) *ReputationEnricher {
+	return &ReputationEnricher{
+		providers: providers,
+		pool:      NewPool(1, 0),
+		cache:     NewCache(DefaultCacheSize, DefaultCacheTTL),
+	}
+}
+
+// SetPool runs the enricher's lookups in pool, shared with other enrichers;
+// without one hosts are looked up one at a time
+func (e *ReputationEnricher) SetPool(pool *Pool) {
+	e.pool = pool
+}
+
+// SetCache caches scores in cache, shared with other enrichers; nil
+// disables caching
+func (e *ReputationEnricher) SetCache(cache *Cache) {
+	e.cache = cache
+}
+
+// EnrichHosts sets ReputationScore and ReputationSources on every public host.
+// Lookup failures are skipped so enrichment never fails a scan.
+func (e *ReputationEnricher) EnrichHosts(ctx context.Context, hosts []*models.Host) {
+	if len(e.providers) == 0 {
+		return
+	}
+
+	var public []*models.Host
+	for _, host := range hosts {
+		if IsPublicIP(host.IPAddress) {
+			public = append(public, host)
+		}
+	}
+
+	e.pool.Run(ctx, len(public), func(ctx context.Context, i int) {
+		host := public[i]
+		for _, provider := range e.providers {
+			score, err := e.lookup(ctx, provider, host.IPAddress)
+			if err != nil || score <= 0 {
+				continue
+			}
+			if score > host.ReputationScore {
+				host.ReputationScore = score
+			}
+			host.ReputationSources = append(host.ReputationSources, provider.Name())
+		}
+	})
+}
+
+// lookup queries a provider through the cache
+func (e *ReputationEnricher) lookup(ctx context.Context, provider ReputationProvider, ip string) (int, error) {
+	value, err := e.cache.Lookup(ctx, "reputation:"+provider.Name()+"|"+ip, func(ctx context.Context) (interface{}, error) {
+		return provider.Lookup(ctx, ip)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value.(int), nil
+}
+
+// IsPublicIP reports whether an address is globally routable
+func IsPublicIP(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// BlocklistProvider flags addresses listed in a local file of IPs and CIDRs
+type BlocklistProvider struct {
+	name     string
+	networks []*net.IPNet
+}
+
+// NewBlocklistProvider loads a blocklist with one IP or CIDR per line
+func NewBlocklistProvider(path string) (*BlocklistProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	provider := &BlocklistProvider{name: "blocklist:" + path}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !strings.Contains(line, "/") {
+			if ip := net.ParseIP(line); ip != nil && ip.To4() != nil {
+				line += "/32"
+			} else {
+				line += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(line)
+		if err != nil {
+			continue
+		}
+		provider.networks = append(provider.networks, network)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	return provider, nil
+}
+
+// Name returns the provider name
+func (p *BlocklistProvider) Name() string {
+	return p.name
+}
+
+// Lookup returns 100 for listed addresses and 0 otherwise
+func (p *BlocklistProvider) Lookup(ctx context.Context, address string) (int, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return 0, fmt.Errorf("invalid IP address: %s", address)
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return 100, nil
+		}
+	}
+	return 0, nil
+}
+
+// AbuseIPDBProvider queries the AbuseIPDB v2 check API
+type AbuseIPDBProvider struct {
+	apiKey   string
+	baseURL  string
+	client   *http.Client
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewAbuseIPDBProvider creates an AbuseIPDB provider limited to requestsPerMinute calls
+func NewAbuseIPDBProvider(apiKey string, requestsPerMinute int) *AbuseIPDBProvider {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = 30
+	}
+	return &AbuseIPDBProvider{
+		apiKey:   apiKey,
+		baseURL:  "https://api.abuseipdb.com/api/v2",
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: time.Minute / time.Duration(requestsPerMinute),
+	}
+}
+
+// Name returns the provider name
+func (p *AbuseIPDBProvider) Name() string {
+	return "abuseipdb"
+}
+
+// Lookup returns the AbuseIPDB abuse confidence score for an address
+func (p *AbuseIPDBProvider) Lookup(ctx context.Context, ip string) (int, error) {
+	if err := p.wait(ctx); err != nil {
+		return 0, err
+	}
+
+	query := url.Values{"ipAddress": {ip}, "maxAgeInDays": {"90"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/check?"+query.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Key", p.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("abuseipdb request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("abuseipdb returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			AbuseConfidenceScore int `json:"abuseConfidenceScore"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode abuseipdb response: %w", err)
+	}
+
+	return body.Data.AbuseConfidenceScore, nil
+}
+
+// wait blocks until the next request is allowed by the rate limit
+func (p *AbuseIPDBProvider) wait(ctx context.Context) error {
+	p.mu.Lock()
+	next := p.last.Add(p.interval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	p.last = next
+	p.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(next)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package enrich
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// HostResolver performs DNS lookups; *net.Resolver implements it
+type HostResolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// Resolver answers forward and reverse DNS lookups through a Cache, so
+// targets and hosts named repeatedly within a run are resolved once
+type Resolver struct {
+	base  HostResolver
+	cache *Cache
+}
+
+// NewResolver creates a resolver querying base, or the system resolver when
+// base is nil, and caching answers in cache
+func NewResolver(base HostResolver, cache *Cache) *Resolver {
+	if base == nil {
+		base = net.DefaultResolver
+	}
+	return &Resolver{base: base, cache: cache}
+}
+
+// LookupIP returns the IPv4 and IPv6 addresses of host. The returned slice
+// is a copy the caller may modify.
+func (r *Resolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	value, err := r.cache.Lookup(ctx, "dns:ip|"+host, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupIP(ctx, "ip", host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]net.IP(nil), value.([]net.IP)...), nil
+}
+
+// LookupAddr returns the names an address reverse-resolves to. The
+// returned slice is a copy the caller may modify.
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	value, err := r.cache.Lookup(ctx, "dns:ptr|"+addr, func(ctx context.Context) (interface{}, error) {
+		return r.base.LookupAddr(ctx, addr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), value.([]string)...), nil
+}
+
+// LookupFunc adapts LookupIP to the func(host) signature of
+// scanner.LocalTarget, resolving without a deadline
+func (r *Resolver) LookupFunc() func(string) ([]net.IP, error) {
+	return func(host string) ([]net.IP, error) {
+		return r.LookupIP(context.Background(), host)
+	}
+}
@@ -1,8 +1,14 @@
 package models
 
 import (
-	"github.com/google/uuid"
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // ScanTarget represents a target for network scanning
@@ -15,16 +21,37 @@ type ScanTarget struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// SchemaVersion is the version of the scan models recorded on every stored
+// scan result, so results written by older releases can be upgraded when
+// loaded. Bump it when a model change leaves older results with fields to
+// fill in, and teach scanner.UpgradeScan how. Version 0 marks results
+// stored before versions were recorded.
+const SchemaVersion = 1
+
 // ScanResult represents the result of a network scan
 type ScanResult struct {
-	ID        uuid.UUID  `json:"id" db:"id"`
-	TargetID  uuid.UUID  `json:"target_id" db:"target_id"`
-	ScanType  string     `json:"scan_type" db:"scan_type"` // nmap, masscan
-	Status    string     `json:"status" db:"status"`       // running, completed, failed
-	StartTime time.Time  `json:"start_time" db:"start_time"`
-	EndTime   *time.Time `json:"end_time" db:"end_time"`
-	RawOutput string     `json:"raw_output" db:"raw_output"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TargetID   uuid.UUID  `json:"target_id" db:"target_id"`
+	ScanType   string     `json:"scan_type" db:"scan_type"` // nmap, masscan
+	Status     string     `json:"status" db:"status"`       // running, completed, failed
+	StartTime  time.Time  `json:"start_time" db:"start_time"`
+	EndTime    *time.Time `json:"end_time" db:"end_time"`
+	DurationMs int64      `json:"duration_ms" db:"duration_ms"`
+	RawOutput  string     `json:"raw_output" db:"raw_output"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+
+	ScanConfig json.RawMessage `json:"scan_config,omitempty" db:"scan_config"` // Effective scanner.ScanConfig
+	Pinned     bool            `json:"pinned" db:"pinned"`                     // Pinned scans are kept by retention pruning
+
+	Complete bool    `json:"complete" db:"complete"` // False for timed out, cancelled or truncated scans
+	Coverage float64 `json:"coverage" db:"coverage"` // Estimated fraction of the requested work in the result, 0-1
+
+	TraceID       string `json:"trace_id,omitempty" db:"trace_id"`             // Trace of the run that produced the scan, empty when tracing is off
+	CorrelationID string `json:"correlation_id,omitempty" db:"correlation_id"` // Caller-supplied key tying the scan to an external workflow
+
+	Metadata Metadata `json:"metadata,omitempty" db:"metadata"` // Caller-supplied context such as environment, requester or ticket
+
+	SchemaVersion int `json:"schema_version" db:"schema_version"` // SchemaVersion the scan was stored under
 }
 
 // Host represents a discovered host
@@ -36,7 +63,128 @@ type Host struct {
 	Status       string    `json:"status" db:"status"` // up, down, filtered
 	OS           string    `json:"os" db:"os"`
 	OSConfidence int       `json:"os_confidence" db:"os_confidence"`
+	OSFamily     string    `json:"os_family,omitempty" db:"os_family"` // OS normalized to one of the OSFamily values
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+	ReputationScore   int      `json:"reputation_score,omitempty" db:"reputation_score"`     // 0-100, higher is worse
+	ReputationSources []string `json:"reputation_sources,omitempty" db:"reputation_sources"` // Threat-intel sources that flagged the host
+
+	NetBIOSName string      `json:"netbios_name,omitempty" db:"netbios_name"` // From smb-os-discovery or nbstat
+	Domain      string      `json:"domain,omitempty" db:"domain"`             // DNS domain of domain-joined Windows hosts
+	Workgroup   string      `json:"workgroup,omitempty" db:"workgroup"`       // NetBIOS workgroup or domain
+	HostScripts HostScripts `json:"host_scripts,omitempty" db:"host_scripts"` // Host-level NSE script results
+
+	MACAddress  string `json:"mac_address,omitempty" db:"mac_address"`   // Only known for hosts on the scanner's segment
+	Vendor      string `json:"vendor,omitempty" db:"vendor"`             // NIC vendor from the MAC OUI
+	IPv6Address string `json:"ipv6_address,omitempty" db:"ipv6_address"` // IPv6 address of a dual-stack host; also IPAddress when it has no IPv4 one
+
+	LoadBalanced bool `json:"load_balanced,omitempty" db:"load_balanced"` // IP IDs suggest several machines behind the address
+
+	UptimeSeconds int64      `json:"uptime_seconds,omitempty" db:"uptime_seconds"` // Uptime guessed from TCP timestamps during OS detection
+	LastBoot      *time.Time `json:"last_boot,omitempty" db:"last_boot"`
+
+	FilteredPortCount int `json:"filtered_port_count,omitempty" db:"filtered_port_count"` // Ports reported filtered, counted without storing each one
+
+	ScanError string `json:"scan_error,omitempty" db:"scan_error"` // Why this host was only partly scanned or not reached; scan-wide failures go in the scan's error
+
+	Ports []*Port `json:"ports,omitempty" db:"-"`
+}
+
+// HostScript is the result of a host-level NSE script such as smb-os-discovery
+type HostScript struct {
+	ID       string            `json:"id"`
+	Output   string            `json:"output"`
+	Elements map[string]string `json:"elements,omitempty" xml:"-"` // Structured key/value output; XML cannot encode maps
+}
+
+// Operating system families hosts are grouped by in reports
+const (
+	OSFamilyWindows = "windows"
+	OSFamilyLinux   = "linux"
+	OSFamilyBSD     = "bsd"
+	OSFamilyApple   = "apple"   // macOS and iOS
+	OSFamilyUnix    = "unix"    // Solaris, AIX, HP-UX and other commercial Unix
+	OSFamilyNetwork = "network" // Routers, switches and firewalls
+	OSFamilyOther   = "other"   // An OS was detected but matches no family
+	OSFamilyUnknown = "unknown" // No OS was detected
+)
+
+// HostScripts is stored as a JSONB column
+type HostScripts []HostScript
+
+// Value implements driver.Valuer
+func (s HostScripts) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan implements sql.Scanner
+func (s *HostScripts) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into HostScripts", src)
+	}
+}
+
+// Metadata holds free-form key-value context attached to a scan, stored as
+// a JSONB object
+type Metadata map[string]string
+
+// Value implements driver.Valuer
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements sql.Scanner
+func (m *Metadata) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Metadata", src)
+	}
+}
+
+// MarshalXML implements xml.Marshaler, which cannot encode maps, writing
+// an entry element per key in key order
+func (m Metadata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type entry struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	entries := make([]entry, len(keys))
+	for i, key := range keys {
+		entries[i] = entry{Key: key, Value: m[key]}
+	}
+	return e.EncodeElement(struct {
+		Entries []entry `xml:"entry"`
+	}{entries}, start)
 }
 
 // Port represents an open port on a host
@@ -45,12 +193,139 @@ type Port struct {
 	HostID    uuid.UUID `json:"host_id" db:"host_id"`
 	Number    int       `json:"number" db:"number"`
 	Protocol  string    `json:"protocol" db:"protocol"` // tcp, udp
-	State     string    `json:"state" db:"state"`       // open, closed, filtered
+	State     string    `json:"state" db:"state"`       // open, closed, filtered, unfiltered, open|filtered, closed|filtered
 	Service   string    `json:"service" db:"service"`
 	Version   string    `json:"version" db:"version"`
 	Product   string    `json:"product" db:"product"`
 	ExtraInfo string    `json:"extra_info" db:"extra_info"`
+	Reason    string    `json:"reason,omitempty" db:"reason"`         // How the state was determined, e.g. syn-ack, reset, no-response
+	ReasonTTL int       `json:"reason_ttl,omitempty" db:"reason_ttl"` // TTL of the response giving the reason, 0 when none arrived
+	HitRatio  float64   `json:"hit_ratio,omitempty" db:"hit_ratio"`   // Fraction of repeated runs (scan --repeat) that saw the port open, 0 for single scans
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	GuessedService string `json:"guessed_service,omitempty" db:"guessed_service"` // IANA name for the port, not detected on the wire
+
+	HTTPProbes      []*HTTPProbe     `json:"http_probes,omitempty" db:"-"`
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities,omitempty" db:"-"` // Reported by NSE scripts during the scan
+}
+
+// PortAsset tracks a host:port/protocol across scans, recording when it was
+// first and last seen open
+type PortAsset struct {
+	IPAddress  string    `json:"ip_address" db:"ip_address"`
+	Port       int       `json:"port" db:"port"`
+	Protocol   string    `json:"protocol" db:"protocol"`
+	Service    string    `json:"service" db:"service"`
+	FirstSeen  time.Time `json:"first_seen" db:"first_seen"`
+	LastSeen   time.Time `json:"last_seen" db:"last_seen"`
+	LastScanID uuid.UUID `json:"last_scan_id" db:"last_scan_id"`
+}
+
+// Exposure returns how long the port has been seen open
+func (a *PortAsset) Exposure() time.Duration {
+	return a.LastSeen.Sub(a.FirstSeen)
+}
+
+// PortCadence summarizes how often a port was found open across the scans
+// of a target, which decides how often differential scans probe it
+type PortCadence struct {
+	Port      int       `json:"port"`
+	Protocol  string    `json:"protocol"`
+	OpenScans int       `json:"open_scans"` // Scans of the target that found the port open
+	LastOpen  time.Time `json:"last_open"`  // Start of the latest scan that found it open
+}
+
+// HostSighting is the span over which any port of a host was seen open
+type HostSighting struct {
+	IPAddress string    `json:"ip_address"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DiscoveryCounts compares the hosts and open ports of a scan with the port
+// inventory from before it. Hosts are tracked through their open ports, so
+// hosts without any are not counted.
+type DiscoveryCounts struct {
+	NewHosts   int `json:"new_hosts"` // Hosts with no port seen open by an earlier scan
+	KnownHosts int `json:"known_hosts"`
+	NewPorts   int `json:"new_ports"` // Open ports no earlier scan saw open
+	KnownPorts int `json:"known_ports"`
+}
+
+// Campaign shard statuses
+const (
+	ShardPending   = "pending"
+	ShardCompleted = "completed"
+	ShardFailed    = "failed" // Retried when the campaign is resumed
+)
+
+// Campaign is a large scan divided into shards whose progress is
+// checkpointed, so an interrupted run resumes where it stopped
+type Campaign struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Key         string     `json:"key" db:"key"` // Identifies the scanner, targets and ports; a rerun with the same key resumes
+	Scanner     string     `json:"scanner" db:"scanner"`
+	Ports       string     `json:"ports" db:"ports"`
+	Shards      int        `json:"shards" db:"shards"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// CampaignShard is one target block and port chunk of a campaign
+type CampaignShard struct {
+	CampaignID uuid.UUID  `json:"campaign_id" db:"campaign_id"`
+	Index      int        `json:"index" db:"shard_index"`
+	Target     string     `json:"target" db:"target"`
+	Ports      string     `json:"ports" db:"ports"`
+	Packets    uint64     `json:"packets" db:"packets"` // Estimated probes, weighting progress and ETA
+	Status     string     `json:"status" db:"status"`   // pending, completed, failed
+	ScanID     *uuid.UUID `json:"scan_id,omitempty" db:"scan_id"`
+	Hosts      int        `json:"hosts" db:"hosts"`
+	Error      string     `json:"error,omitempty" db:"error"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// Audit sources
+const (
+	AuditSourceCLI = "cli"
+	AuditSourceAPI = "api"
+	AuditSourceRPC = "rpc"
+)
+
+// AuditEntry records who launched a scan, against what and with which
+// configuration. Entries are append-only and outlive the scan results.
+type AuditEntry struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	Action        string          `json:"action" db:"action"` // e.g. scan, scan.rerun
+	Actor         string          `json:"actor" db:"actor"`   // OS user for the CLI and RPC, client identity for the API
+	Source        string          `json:"source" db:"source"` // cli, api or rpc
+	SourceIP      string          `json:"source_ip,omitempty" db:"source_ip"`
+	Target        string          `json:"target" db:"target"`
+	Scanner       string          `json:"scanner" db:"scanner"`
+	ScanConfig    json.RawMessage `json:"scan_config,omitempty" db:"scan_config"`
+	CorrelationID string          `json:"correlation_id,omitempty" db:"correlation_id"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ScanExclusion is an entry of the do-not-scan list, an address or CIDR
+// block skipped by every scan
+type ScanExclusion struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Network   string    `json:"network" db:"network"` // CIDR block, /32 or /128 for a single address
+	Reason    string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// HTTPProbe represents the response to an HTTP request made against a port
+type HTTPProbe struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	PortID     uuid.UUID `json:"port_id" db:"port_id"`
+	Path       string    `json:"path" db:"path"`
+	StatusCode int       `json:"status_code" db:"status_code"`
+	Title      string    `json:"title" db:"title"`
+	Server     string    `json:"server" db:"server"`
+	Error      string    `json:"error,omitempty" db:"error"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 // Vulnerability represents a detected vulnerability
@@ -75,3 +350,34 @@ type ScanConfiguration struct {
 	Timing    string    `json:"timing" db:"timing"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
+
+// FullScanResult is a scan result together with its nested hosts, ports
+// and vulnerabilities
+type FullScanResult struct {
+	*ScanResult
+	Hosts []*HostGraph `json:"hosts"`
+
+	Target string `json:"target,omitempty"` // Target address of a scan saved before its TargetID was known
+}
+
+// HostGraph is a host together with its ports
+type HostGraph struct {
+	*Host
+	Ports []*PortGraph `json:"ports"`
+}
+
+// PortGraph is a port together with its vulnerabilities
+type PortGraph struct {
+	*Port
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities"`
+}
+
+// Finding represents a notable observation derived from scan data
+type Finding struct {
+	Type      string `json:"type"`
+	Severity  string `json:"severity"` // info, low, medium, high
+	IPAddress string `json:"ip_address"`
+	Port      int    `json:"port,omitempty"`
+	Protocol  string `json:"protocol,omitempty"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// DigestScan summarizes one scan of a batch run
+type DigestScan struct {
+	Target        string
+	ScanID        string
+	CorrelationID string // Caller-supplied key tying the scan to an external workflow
+	Status        string
+	HostCount     int
+	Discovery     *models.DiscoveryCounts // Hosts and ports no earlier scan saw, if the inventory was available
+	Diff          *diff.ScanDiff          // Changes since the previous scan of the target, if any
+	ReportPath    string                  // Report attached to the email when attachments are enabled
+}
+
+// Digest summarizes a batch or scheduled run
+type Digest struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Scans      []DigestScan
+}
+
+// Subject returns the email subject line for the digest, leading with the
+// hosts never seen before when any were found
+func (d *Digest) Subject() string {
+	newPorts, newVulns := d.totals()
+	if newHosts := d.newHosts(); newHosts > 0 {
+		return fmt.Sprintf("netrecon digest: %d targets, %d new hosts, %d new open ports, %d new vulnerabilities",
+			len(d.Scans), newHosts, newPorts, newVulns)
+	}
+	return fmt.Sprintf("netrecon digest: %d targets, %d new open ports, %d new vulnerabilities",
+		len(d.Scans), newPorts, newVulns)
+}
+
+// Body renders the plain-text summary
+func (d *Digest) Body() string {
+	var b strings.Builder
+
+	newPorts, newVulns := d.totals()
+	fmt.Fprintf(&b, "Scan run finished %s (took %s)\n\n", d.FinishedAt.Format(time.RFC1123), d.FinishedAt.Sub(d.StartedAt).Round(time.Second))
+	fmt.Fprintf(&b, "Targets scanned:       %d\n", len(d.Scans))
+	fmt.Fprintf(&b, "Hosts never seen:      %d\n", d.newHosts())
+	fmt.Fprintf(&b, "New open ports:        %d\n", newPorts)
+	fmt.Fprintf(&b, "New vulnerabilities:   %d\n", newVulns)
+
+	for _, scan := range d.Scans {
+		fmt.Fprintf(&b, "\n== %s (%s, %d hosts)\n", scan.Target, scan.Status, scan.HostCount)
+		if scan.ScanID != "" {
+			fmt.Fprintf(&b, "   Scan ID: %s\n", scan.ScanID)
+		}
+		if scan.CorrelationID != "" {
+			fmt.Fprintf(&b, "   Correlation ID: %s\n", scan.CorrelationID)
+		}
+		if scan.Discovery != nil {
+			fmt.Fprintf(&b, "   Never seen before: %d of %d hosts, %d of %d open ports\n", scan.Discovery.NewHosts,
+				scan.Discovery.NewHosts+scan.Discovery.KnownHosts, scan.Discovery.NewPorts, scan.Discovery.NewPorts+scan.Discovery.KnownPorts)
+		}
+		if scan.Diff == nil {
+			continue
+		}
+		for _, port := range scan.Diff.NewPorts {
+			fmt.Fprintf(&b, "   + %s %d/%s %s\n", port.IPAddress, port.Port, port.Protocol, port.Service)
+		}
+		for _, vuln := range scan.Diff.NewVulns {
+			fmt.Fprintf(&b, "   ! %s %d/%s %s (%s)\n", vuln.IPAddress, vuln.Port, vuln.Protocol, vuln.CVE, vuln.Severity)
+		}
+		for _, service := range scan.Diff.NotableServiceChanges() {
+			fmt.Fprintf(&b, "   v %s %d/%s %s downgraded from %s to %s\n", service.IPAddress, service.Port, service.Protocol,
+				strings.TrimSpace(service.Service+" "+service.Product), service.BaseVersion, service.Version)
+		}
+	}
+
+	return b.String()
+}
+
+// totals counts new open ports and vulnerabilities across all scans
+func (d *Digest) totals() (newPorts, newVulns int) {
+	for _, scan := range d.Scans {
+		if scan.Diff != nil {
+			newPorts += len(scan.Diff.NewPorts)
+			newVulns += len(scan.Diff.NewVulns)
+		}
+	}
+	return newPorts, newVulns
+}
+
+// newHosts counts the hosts no earlier scan saw across all scans
+func (d *Digest) newHosts() int {
+	total := 0
+	for _, scan := range d.Scans {
+		if scan.Discovery != nil {
+			total += scan.Discovery.NewHosts
+		}
+	}
+	return total
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// capFilterHeader names the tab-separated columns of the capture filter index
+const capFilterHeader = "# start\tend\thost\tport\tfilter"
+
+// capFilterProtocols are the port protocols BPF can filter on
+var capFilterProtocols = map[string]bool{"tcp": true, "udp": true, "sctp": true}
+
+// CapFilterFormatter formats one line per open port holding the scan's time
+// window and a BPF capture filter matching the port's traffic, such as
+// "host 10.0.0.5 and tcp port 443", for correlating scans with packet
+// captures in tcpdump or Wireshark. Hosts whose address does not parse and
+// protocols BPF has no port filter for are left out.
+type CapFilterFormatter struct{}
+
+func (f *CapFilterFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(capFilterHeader)
+	buf.WriteByte('\n')
+	for _, host := range result.Hosts {
+		addr, err := netip.ParseAddr(host.IPAddress)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		for _, port := range host.Ports {
+			if port.State != "open" {
+				continue
+			}
+			filter, ok := bpfFilter(addr, port.Protocol, port.Number)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&buf, "%s\t%s\t%s\t%d/%s\t%s\n", result.StartTime, result.EndTime,
+				addr, port.Number, strings.ToLower(port.Protocol), filter)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *CapFilterFormatter) GetMimeType() string {
+	return "text/tab-separated-values"
+}
+
+func (f *CapFilterFormatter) GetFileExtension() string {
+	return "tsv"
+}
+
+// bpfFilter returns the capture filter matching traffic between addr and
+// one of its ports, false when protocol has no BPF port filter
+func bpfFilter(addr netip.Addr, protocol string, port int) (string, bool) {
+	protocol = strings.ToLower(protocol)
+	if !capFilterProtocols[protocol] || port < 0 || port > 65535 {
+		return "", false
+	}
+	return fmt.Sprintf("host %s and %s port %d", addr, protocol, port), true
+}
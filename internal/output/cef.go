@@ -0,0 +1,170 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// CEF header fields identifying netrecon as the event source
+const (
+	cefVendor  = "netrecon"
+	cefProduct = "Network Recon Toolkit"
+)
+
+// CEF signature of open port events; findings use their type
+const cefOpenPort = "OpenPort"
+
+// cefSeverities maps finding severities onto the CEF 0-10 scale
+var cefSeverities = map[string]int{
+	"info":     1,
+	"low":      3,
+	"medium":   5,
+	"high":     8,
+	"critical": 10,
+}
+
+// CEFFormatter formats findings and open ports as ArcSight Common Event
+// Format lines, one event per line, for SIEMs ingesting CEF over syslog
+type CEFFormatter struct {
+	version string
+}
+
+// NewCEFFormatter creates a CEF formatter reporting version as the device version
+func NewCEFFormatter(version string) *CEFFormatter {
+	return &CEFFormatter{version: version}
+}
+
+func (f *CEFFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, line := range f.Lines(result) {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *CEFFormatter) GetMimeType() string {
+	return "text/plain"
+}
+
+func (f *CEFFormatter) GetFileExtension() string {
+	return "cef"
+}
+
+// Lines returns one CEF event per finding and per open port, findings first
+func (f *CEFFormatter) Lines(result *scanner.ScanResult) []string {
+	version := f.version
+	if version == "" {
+		version = "dev"
+	}
+	endTime := cefTime(result.EndTime)
+
+	hostnames := make(map[string]string, len(result.Hosts))
+	for _, host := range result.Hosts {
+		hostnames[host.IPAddress] = host.Hostname
+	}
+
+	var lines []string
+	for _, finding := range result.Findings {
+		ext := []cefField{
+			{"rt", endTime},
+			{"src", finding.IPAddress},
+			{"shost", hostnames[finding.IPAddress]},
+		}
+		if finding.Port > 0 {
+			ext = append(ext, cefField{"dpt", fmt.Sprintf("%d", finding.Port)}, cefField{"proto", strings.ToUpper(finding.Protocol)})
+		}
+		ext = append(ext, cefField{"msg", finding.Message})
+		lines = append(lines, cefLine(version, finding.Type, finding.Type, cefSeverity(finding.Severity), ext))
+	}
+
+	for _, host := range result.Hosts {
+		for _, port := range host.Ports {
+			if port.State != "open" {
+				continue
+			}
+			lines = append(lines, cefLine(version, cefOpenPort, "Open port", cefSeverity("info"), []cefField{
+				{"rt", endTime},
+				{"src", host.IPAddress},
+				{"shost", host.Hostname},
+				{"dpt", fmt.Sprintf("%d", port.Number)},
+				{"proto", strings.ToUpper(port.Protocol)},
+				{"app", cefApp(port)},
+			}))
+		}
+	}
+	return lines
+}
+
+// cefField is one key=value extension; empty values are left out
+type cefField struct {
+	key, value string
+}
+
+// cefLine assembles an event: the pipe-separated header followed by the
+// space-separated extensions
+func cefLine(version, signature, name string, severity int, ext []cefField) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		escapeCEFHeader(cefVendor), escapeCEFHeader(cefProduct), escapeCEFHeader(version),
+		escapeCEFHeader(signature), escapeCEFHeader(name), severity)
+
+	first := true
+	for _, field := range ext {
+		if field.value == "" {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(field.key)
+		b.WriteByte('=')
+		b.WriteString(escapeCEFValue(field.value))
+	}
+	return b.String()
+}
+
+// escapeCEFHeader escapes backslashes and pipes in a header field. Header
+// fields cannot span lines, so line breaks become spaces.
+func escapeCEFHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// escapeCEFValue escapes backslashes, equals signs and line breaks in an
+// extension value
+func escapeCEFValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// cefSeverity converts a finding severity, treating unknown ones as info
+func cefSeverity(severity string) int {
+	if value, ok := cefSeverities[strings.ToLower(severity)]; ok {
+		return value
+	}
+	return cefSeverities["info"]
+}
+
+// cefApp names the application protocol on a port, falling back to the
+// IANA assignment when nothing was detected
+func cefApp(port *models.Port) string {
+	if port.Service != "" {
+		return port.Service
+	}
+	return port.GuessedService
+}
+
+// cefTime converts an RFC 3339 scan time to the milliseconds since the
+// epoch CEF expects in rt, or "" when it cannot be parsed
+func cefTime(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", t.UnixMilli())
+}
@@ -0,0 +1,130 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/analysis"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// cmdbHeader lists the columns of the CMDB import CSV
+var cmdbHeader = []string{"ip", "hostname", "os", "os_confidence", "mac", "vendor", "open_ports", "first_seen", "last_seen", "risk_score"}
+
+// CMDBFormatter formats one CSV row per host for CMDB asset import. Hosts
+// without a recorded sighting are dated by the scan start time.
+type CMDBFormatter struct {
+	load func() ([]*models.HostSighting, error)
+
+	once      sync.Once
+	sightings map[string]*models.HostSighting
+	loadErr   error
+}
+
+// NewCMDBFormatter creates a CMDB formatter dating hosts from the port
+// inventory sightings
+func NewCMDBFormatter(sightings []*models.HostSighting) *CMDBFormatter {
+	return NewLazyCMDBFormatter(func() ([]*models.HostSighting, error) {
+		return sightings, nil
+	})
+}
+
+// NewLazyCMDBFormatter creates a CMDB formatter that calls load for the
+// sightings the first time it formats a result, so they are only read
+// when CMDB output is actually produced
+func NewLazyCMDBFormatter(load func() ([]*models.HostSighting, error)) *CMDBFormatter {
+	return &CMDBFormatter{load: load}
+}
+
+// loadSightings indexes the sightings by address on first use
+func (f *CMDBFormatter) loadSightings() (map[string]*models.HostSighting, error) {
+	f.once.Do(func() {
+		if f.load == nil {
+			return
+		}
+		sightings, err := f.load()
+		if err != nil {
+			f.loadErr = fmt.Errorf("failed to load host sightings: %w", err)
+			return
+		}
+		f.sightings = make(map[string]*models.HostSighting, len(sightings))
+		for _, sighting := range sightings {
+			f.sightings[sighting.IPAddress] = sighting
+		}
+	})
+	return f.sightings, f.loadErr
+}
+
+func (f *CMDBFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	sightings, err := f.loadSightings()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	records := [][]string{cmdbHeader}
+	for _, host := range result.Hosts {
+		firstSeen, lastSeen := result.StartTime, result.StartTime
+		if sighting, ok := sightings[host.IPAddress]; ok {
+			firstSeen = sighting.FirstSeen.UTC().Format(time.RFC3339)
+			lastSeen = sighting.LastSeen.UTC().Format(time.RFC3339)
+		}
+
+		records = append(records, []string{
+			host.IPAddress,
+			host.Hostname,
+			host.OS,
+			fmt.Sprintf("%d", host.OSConfidence),
+			host.MACAddress,
+			host.Vendor,
+			openPortList(host.Ports),
+			firstSeen,
+			lastSeen,
+			fmt.Sprintf("%d", analysis.HostRiskScore(host, result.Findings)),
+		})
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// openPortList renders open ports as "22/tcp;443/tcp", sorted by number
+func openPortList(ports []*models.Port) string {
+	var open []*models.Port
+	for _, port := range ports {
+		if port.State == "open" {
+			open = append(open, port)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].Number != open[j].Number {
+			return open[i].Number < open[j].Number
+		}
+		return open[i].Protocol < open[j].Protocol
+	})
+
+	parts := make([]string, len(open))
+	for i, port := range open {
+		parts[i] = fmt.Sprintf("%d/%s", port.Number, port.Protocol)
+	}
+	return strings.Join(parts, ";")
+}
+
+func (f *CMDBFormatter) GetMimeType() string {
+	return "text/csv"
+}
+
+func (f *CMDBFormatter) GetFileExtension() string {
+	return "csv"
+}
@@ -0,0 +1,83 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func TestCMDBFormatter(t *testing.T) {
+	firstSeen := time.Date(2026, 1, 5, 8, 0, 0, 0, time.FixedZone("CET", 3600))
+	lastSeen := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	loads := 0
+	formatter := NewLazyCMDBFormatter(func() ([]*models.HostSighting, error) {
+		loads++
+		return []*models.HostSighting{{IPAddress: "192.0.2.10", FirstSeen: firstSeen, LastSeen: lastSeen}}, nil
+	})
+
+	result := &scanner.ScanResult{
+		Target:    "192.0.2.0/24",
+		StartTime: "2026-03-09T10:00:00Z",
+		Hosts: []*models.Host{
+			{
+				IPAddress: "192.0.2.10", Hostname: "web01, prod", OS: "Linux 5.X", OSConfidence: 95,
+				MACAddress: "00:16:3E:12:34:56", Vendor: "Xensource", ReputationScore: 10,
+				Ports: []*models.Port{
+					{Number: 8080, Protocol: "tcp", State: "closed"},
+					{Number: 443, Protocol: "tcp", State: "open"},
+					{Number: 53, Protocol: "udp", State: "open"},
+					{Number: 22, Protocol: "tcp", State: "open"},
+				},
+			},
+			{IPAddress: "192.0.2.11"},
+		},
+		Findings: []*models.Finding{
+			{Type: "vulnerability", Severity: "high", IPAddress: "192.0.2.10", Port: 443, Protocol: "tcp"},
+			{Type: "weak_cipher", Severity: "medium", IPAddress: "192.0.2.10", Port: 443, Protocol: "tcp"},
+			{Type: "vulnerability", Severity: "critical", IPAddress: "192.0.2.99"},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		data, err := formatter.Format(result)
+		if err != nil {
+			t.Fatalf("Format() error = %v", err)
+		}
+
+		header, _, _ := strings.Cut(string(data), "\n")
+		if want := "ip,hostname,os,os_confidence,mac,vendor,open_ports,first_seen,last_seen,risk_score"; header != want {
+			t.Errorf("header = %s, want %s", header, want)
+		}
+
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			t.Fatalf("output is not valid CSV: %v", err)
+		}
+		want := [][]string{
+			{"192.0.2.10", "web01, prod", "Linux 5.X", "95", "00:16:3E:12:34:56", "Xensource", "22/tcp;53/udp;443/tcp",
+				"2026-01-05T07:00:00Z", "2026-03-02T09:30:00Z", "55"},
+			// Hosts missing from the inventory are dated by the scan
+			{"192.0.2.11", "", "", "0", "", "", "", "2026-03-09T10:00:00Z", "2026-03-09T10:00:00Z", "0"},
+		}
+		if !reflect.DeepEqual(records[1:], want) {
+			t.Errorf("rows =\n%q\nwant\n%q", records[1:], want)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("sightings loaded %d times, want once", loads)
+	}
+
+	failing := NewLazyCMDBFormatter(func() ([]*models.HostSighting, error) {
+		return nil, errors.New("connection refused")
+	})
+	if _, err := failing.Format(result); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Format() with unavailable sightings error = %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package output
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/analysis"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Dashboard limits
+const (
+	dashboardTopServices = 10
+	dashboardRiskyHosts  = 20
+
+	// DashboardRiskThreshold is the lowest risk score listed as high risk
+	DashboardRiskThreshold = 30
+)
+
+//go:embed templates/dashboard.html.tmpl
+var dashboardTemplateText string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"statusClass": scanStatusClass,
+	"coverage":    formatCoverage,
+}).Parse(dashboardTemplateText))
+
+// DashboardScan is one scan summarized on a dashboard, with the file name of
+// its own report when one was written
+type DashboardScan struct {
+	Result *scanner.ScanResult
+	ScanID string
+	Report string
+}
+
+// Dashboard summarizes many scans, such as every target of one run or the
+// history of one target, on a single HTML page
+type Dashboard struct {
+	Title         string
+	Generated     string
+	RiskThreshold int
+	Scans         []DashboardRow   // Newest first
+	Trend         []TrendPoint     // Oldest first
+	TopServices   []ServiceCount   // Most exposed first
+	RiskyHosts    []RiskyHost      // Riskiest first
+	Totals        DashboardSummary // Across every scan
+}
+
+// DashboardRow is a scan listed in the dashboard table
+type DashboardRow struct {
+	ScanID    string
+	Target    string
+	Scanner   string
+	Status    string
+	Complete  bool
+	Coverage  float64
+	StartTime string
+	Duration  string
+	Hosts     int
+	OpenPorts int
+	Findings  int
+	Report    string
+}
+
+// TrendPoint is the exposure measured by one scan, for the open port trend
+type TrendPoint struct {
+	StartTime string
+	Target    string
+	OpenPorts int
+	Hosts     int
+	Percent   int // Bar width relative to the largest open port count
+}
+
+// ServiceCount is how many distinct host ports expose a service
+type ServiceCount struct {
+	Service string
+	Ports   int
+}
+
+// RiskyHost is a host whose risk score reached DashboardRiskThreshold, as
+// rated in the latest scan it appeared in
+type RiskyHost struct {
+	IPAddress string
+	Hostname  string
+	Score     int
+	OpenPorts int
+	Findings  int
+	LastSeen  string
+	Report    string
+}
+
+// DashboardSummary holds dashboard totals
+type DashboardSummary struct {
+	Scans     int
+	Hosts     int // Distinct addresses
+	OpenPorts int // Distinct address, port and protocol combinations
+	Findings  int
+}
+
+// BuildDashboard aggregates scans into a dashboard
+func BuildDashboard(title string, scans []DashboardScan, now time.Time) *Dashboard {
+	// Work oldest first so later scans of a host override earlier ones
+	ordered := append([]DashboardScan(nil), scans...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Result.StartTime < ordered[j].Result.StartTime
+	})
+
+	d := &Dashboard{Title: title, Generated: now.Format("2006-01-02 15:04:05"), RiskThreshold: DashboardRiskThreshold}
+	hosts := make(map[string]bool)
+	exposed := make(map[string]string) // ip port/proto -> service
+	risky := make(map[string]*RiskyHost)
+	maxOpen := 0
+
+	for _, scan := range ordered {
+		result := scan.Result
+		openPorts := 0
+		for _, host := range result.Hosts {
+			hosts[host.IPAddress] = true
+
+			hostOpen := 0
+			for _, port := range host.Ports {
+				if port.State != "open" {
+					continue
+				}
+				hostOpen++
+				service := port.Service
+				if service == "" {
+					service = port.GuessedService
+				}
+				if service == "" {
+					service = "unknown"
+				}
+				exposed[fmt.Sprintf("%s %d/%s", host.IPAddress, port.Number, port.Protocol)] = service
+			}
+			openPorts += hostOpen
+
+			hostFindings := 0
+			for _, finding := range result.Findings {
+				if finding.IPAddress == host.IPAddress {
+					hostFindings++
+				}
+			}
+			score := analysis.HostRiskScore(host, result.Findings)
+			if score < DashboardRiskThreshold {
+				delete(risky, host.IPAddress)
+				continue
+			}
+			risky[host.IPAddress] = &RiskyHost{
+				IPAddress: host.IPAddress,
+				Hostname:  host.Hostname,
+				Score:     score,
+				OpenPorts: hostOpen,
+				Findings:  hostFindings,
+				LastSeen:  result.StartTime,
+				Report:    scan.Report,
+			}
+		}
+
+		d.Scans = append(d.Scans, DashboardRow{
+			ScanID:    scan.ScanID,
+			Target:    result.Target,
+			Scanner:   result.Scanner,
+			Status:    result.Status,
+			Complete:  result.Complete,
+			Coverage:  result.Coverage,
+			StartTime: result.StartTime,
+			Duration:  result.HumanDuration(),
+			Hosts:     len(result.Hosts),
+			OpenPorts: openPorts,
+			Findings:  len(result.Findings),
+			Report:    scan.Report,
+		})
+		d.Trend = append(d.Trend, TrendPoint{
+			StartTime: result.StartTime,
+			Target:    result.Target,
+			OpenPorts: openPorts,
+			Hosts:     len(result.Hosts),
+		})
+		if openPorts > maxOpen {
+			maxOpen = openPorts
+		}
+		d.Totals.Findings += len(result.Findings)
+	}
+
+	for i := range d.Trend {
+		if maxOpen > 0 {
+			d.Trend[i].Percent = d.Trend[i].OpenPorts * 100 / maxOpen
+		}
+	}
+	for i, j := 0, len(d.Scans)-1; i < j; i, j = i+1, j-1 {
+		d.Scans[i], d.Scans[j] = d.Scans[j], d.Scans[i]
+	}
+
+	services := make(map[string]int)
+	for _, service := range exposed {
+		services[service]++
+	}
+	for service, ports := range services {
+		d.TopServices = append(d.TopServices, ServiceCount{Service: service, Ports: ports})
+	}
+	sort.Slice(d.TopServices, func(i, j int) bool {
+		if d.TopServices[i].Ports != d.TopServices[j].Ports {
+			return d.TopServices[i].Ports > d.TopServices[j].Ports
+		}
+		return d.TopServices[i].Service < d.TopServices[j].Service
+	})
+	if len(d.TopServices) > dashboardTopServices {
+		d.TopServices = d.TopServices[:dashboardTopServices]
+	}
+
+	for _, host := range risky {
+		d.RiskyHosts = append(d.RiskyHosts, *host)
+	}
+	sort.Slice(d.RiskyHosts, func(i, j int) bool {
+		if d.RiskyHosts[i].Score != d.RiskyHosts[j].Score {
+			return d.RiskyHosts[i].Score > d.RiskyHosts[j].Score
+		}
+		return d.RiskyHosts[i].IPAddress < d.RiskyHosts[j].IPAddress
+	})
+	if len(d.RiskyHosts) > dashboardRiskyHosts {
+		d.RiskyHosts = d.RiskyHosts[:dashboardRiskyHosts]
+	}
+
+	d.Totals.Scans = len(scans)
+	d.Totals.Hosts = len(hosts)
+	d.Totals.OpenPorts = len(exposed)
+	return d
+}
+
+// RenderDashboard renders a dashboard as a standalone HTML page
+func RenderDashboard(d *Dashboard) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, d); err != nil {
+		return nil, fmt.Errorf("failed to execute dashboard template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
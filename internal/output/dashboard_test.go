@@ -0,0 +1,118 @@
+package output
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+var dashboardNow = time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func openPort(number int, service string) *models.Port {
+	return &models.Port{Number: number, Protocol: "tcp", State: "open", Service: service}
+}
+
+// dashboardScans returns two scans of one subnet and one of another host,
+// out of order, where the second subnet scan clears a risky host
+func dashboardScans() []DashboardScan {
+	return []DashboardScan{
+		{
+			ScanID: "33333333-3333-3333-3333-333333333333",
+			Report: "scan-3.html",
+			Result: &scanner.ScanResult{
+				Target: "10.0.0.0/24", Scanner: "nmap", Status: scanner.StatusCompleted,
+				StartTime: "2026-03-03T09:00:00Z", DurationMs: 95000, Complete: true, Coverage: 1,
+				Hosts: []*models.Host{
+					{IPAddress: "10.0.0.1", Hostname: "gw.example.internal", Status: "up", Ports: []*models.Port{openPort(22, "ssh"), openPort(443, "https")}},
+					{IPAddress: "10.0.0.2", Status: "up", Ports: []*models.Port{openPort(3389, "ms-wbt-server")}},
+				},
+			},
+		},
+		{
+			ScanID: "11111111-1111-1111-1111-111111111111",
+			Report: "scan-1.html",
+			Result: &scanner.ScanResult{
+				Target: "10.0.0.0/24", Scanner: "nmap", Status: scanner.StatusCompleted,
+				StartTime: "2026-03-01T09:00:00Z", DurationMs: 80000, Complete: true, Coverage: 1,
+				Hosts: []*models.Host{
+					{IPAddress: "10.0.0.1", Hostname: "gw.example.internal", Status: "up", Ports: []*models.Port{openPort(22, "ssh")}},
+					{IPAddress: "10.0.0.2", Status: "up", ReputationScore: 60, Ports: []*models.Port{
+						openPort(3389, "ms-wbt-server"),
+						{Number: 25, Protocol: "tcp", State: "filtered"},
+					}},
+				},
+				Findings: []*models.Finding{
+					{Type: "exposed-rdp", Severity: "high", IPAddress: "10.0.0.2", Port: 3389, Protocol: "tcp", Message: "RDP exposed"},
+				},
+			},
+		},
+		{
+			ScanID: "22222222-2222-2222-2222-222222222222",
+			Result: &scanner.ScanResult{
+				Target: "192.0.2.10", Scanner: "masscan", Status: scanner.StatusTimeout,
+				StartTime: "2026-03-02T09:00:00Z", DurationMs: 600000, Coverage: 0.4,
+				Hosts: []*models.Host{
+					{IPAddress: "192.0.2.10", Status: "up", ReputationScore: 45, Ports: []*models.Port{
+						{Number: 8080, Protocol: "tcp", State: "open", GuessedService: "http-alt"},
+						{Number: 9999, Protocol: "tcp", State: "open"},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestRenderDashboardGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		scans  []DashboardScan
+	}{
+		{"scans", "dashboard.golden", dashboardScans()},
+		{"no scans", "dashboard_empty.golden", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := RenderDashboard(BuildDashboard("Dashboard for 10.0.0.0/24", tt.scans, dashboardNow))
+			if err != nil {
+				t.Fatalf("RenderDashboard() error = %v", err)
+			}
+			assertGolden(t, tt.golden, page)
+		})
+	}
+}
+
+func TestBuildDashboard(t *testing.T) {
+	d := BuildDashboard("test", dashboardScans(), dashboardNow)
+
+	want := DashboardSummary{Scans: 3, Hosts: 3, OpenPorts: 5, Findings: 1}
+	if d.Totals != want {
+		t.Errorf("Totals = %+v, want %+v", d.Totals, want)
+	}
+
+	var order []string
+	for _, row := range d.Scans {
+		order = append(order, row.ScanID[:1])
+	}
+	if got := order[0] + order[1] + order[2]; got != "321" {
+		t.Errorf("scans listed in order %s, want newest first (321)", got)
+	}
+
+	// 10.0.0.2 was risky in the first scan only, so the latest scan clears it
+	if len(d.RiskyHosts) != 1 || d.RiskyHosts[0].IPAddress != "192.0.2.10" {
+		t.Errorf("RiskyHosts = %+v, want only 192.0.2.10", d.RiskyHosts)
+	}
+
+	// Ports seen in several scans count once, ties sorted by name
+	var services string
+	for _, service := range d.TopServices {
+		services += fmt.Sprintf("%s=%d ", service.Service, service.Ports)
+	}
+	if want := "http-alt=1 https=1 ms-wbt-server=1 ssh=1 unknown=1 "; services != want {
+		t.Errorf("TopServices = %q, want %q", services, want)
+	}
+}
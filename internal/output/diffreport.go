@@ -0,0 +1,84 @@
+package output
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/diff"
+)
+
+// DiffFormatter is implemented by formatters that can render a diff between
+// two scans, listing only what was added, removed or changed
+type DiffFormatter interface {
+	FormatDiff(d *diff.ScanDiff) ([]byte, error)
+}
+
+//go:embed templates/diff.html.tmpl
+var diffHTMLTemplateText string
+
+var diffHTMLTemplate = template.Must(template.New("diff").Parse(diffHTMLTemplateText))
+
+// DiffReportData is passed to the HTML diff template
+type DiffReportData struct {
+	*diff.ScanDiff
+	Timestamp string
+}
+
+func (f *JSONFormatter) FormatDiff(d *diff.ScanDiff) ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// FormatDiff renders the built-in diff template; custom report templates
+// only apply to scan results
+func (f *HTMLFormatter) FormatDiff(d *diff.ScanDiff) ([]byte, error) {
+	data := DiffReportData{
+		ScanDiff:  d,
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	var buf bytes.Buffer
+	if err := diffHTMLTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute HTML diff template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatDiff renders scan diffs with the named formatter, applying
+// redaction first when a redactor is set. Field selection does not apply
+// to diffs.
+func (fm *FormatterManager) FormatDiff(d *diff.ScanDiff, format string) ([]byte, error) {
+	formatter, exists := fm.GetFormatter(format)
+	if !exists {
+		return nil, fmt.Errorf("formatter '%s' not available. Available formatters: %v", format, fm.ListFormatters())
+	}
+	diffFormatter, ok := formatter.(DiffFormatter)
+	if !ok {
+		return nil, fmt.Errorf("formatter '%s' does not support diffs (use json, html or markdown)", format)
+	}
+	if fm.projection != nil {
+		return nil, fmt.Errorf("field selection is not supported for diffs")
+	}
+
+	if fm.redactor != nil {
+		d = fm.redactor.RedactDiff(d)
+	}
+
+	data, err := diffFormatter.FormatDiff(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format diff: %w", err)
+	}
+	return data, nil
+}
+
+// FormatDiffAndSave formats a scan diff and saves it to file
+func (fm *FormatterManager) FormatDiffAndSave(d *diff.ScanDiff, format string, filename string) error {
+	data, err := fm.FormatDiff(d, format)
+	if err != nil {
+		return err
+	}
+	return fm.save(data, filename)
+}
@@ -0,0 +1,348 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Projection restricts JSON and CSV output to selected fields, named by
+// their JSON keys with dots for nested fields, e.g. hosts.ports.number.
+// Lists are crossed transparently, so hosts.ip_address selects the address
+// of every host.
+type Projection struct {
+	fields []string
+	paths  [][]string
+	lists  [][]string // Per field, the prefixes of paths naming lists it is read through
+	chain  []string   // Nested lists CSV rows are expanded along, outermost first
+	deep   string     // Field reaching furthest down chain
+	tree   *fieldNode
+}
+
+// fieldNode is one level of the selected field tree
+type fieldNode struct {
+	children map[string]*fieldNode
+	all      bool // Selected as a whole, children are ignored
+}
+
+// ProjectingFormatter is implemented by formatters that can restrict their
+// output to a Projection
+type ProjectingFormatter interface {
+	FormatFields(result *scanner.ScanResult, projection *Projection) ([]byte, error)
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// NewProjection validates field paths against the scan result layout.
+// Unknown names are an error, as are CSV-incompatible combinations such as
+// fields under two unrelated lists, which are only detected when formatting
+// CSV.
+func NewProjection(fields []string) (*Projection, error) {
+	p := &Projection{tree: &fieldNode{}}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		path := strings.Split(field, ".")
+		lists, err := resolveField(reflect.TypeOf(scanner.ScanResult{}), path)
+		if err != nil {
+			return nil, fmt.Errorf("unknown field %q: %w", field, err)
+		}
+
+		p.fields = append(p.fields, field)
+		p.paths = append(p.paths, path)
+		p.lists = append(p.lists, lists)
+		p.tree.add(path)
+		if len(lists) > len(p.chain) {
+			p.chain = lists
+			p.deep = field
+		}
+	}
+	if len(p.fields) == 0 {
+		return nil, fmt.Errorf("no fields given")
+	}
+	return p, nil
+}
+
+// Fields returns the selected field paths in the order given
+func (p *Projection) Fields() []string {
+	return p.fields
+}
+
+// resolveField checks that path names a field of t, returning the path
+// prefixes at which it descends into a list of objects
+func resolveField(t reflect.Type, path []string) ([]string, error) {
+	var lists []string
+	for i, name := range path {
+		t = derefType(t)
+		if t.Kind() == reflect.Map {
+			// Keys of maps such as host script elements are free-form
+			return lists, nil
+		}
+		if t.Kind() != reflect.Struct || t == timeType || t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+			return nil, fmt.Errorf("%s has no fields", strings.Join(path[:i], "."))
+		}
+
+		field, ok := jsonField(t, name)
+		if !ok {
+			return nil, fmt.Errorf("no field %s (available: %s)", name, strings.Join(jsonFieldNames(t), ", "))
+		}
+		t = derefType(field.Type)
+		if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && i < len(path)-1 {
+			lists = append(lists, strings.Join(path[:i+1], "."))
+			t = t.Elem()
+		}
+	}
+	return lists, nil
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonField finds the struct field encoded under name
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if jsonName(field) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonFieldNames lists the names a struct's fields are encoded under
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonName returns the key a field is encoded under, "" when it is skipped
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func (n *fieldNode) add(path []string) {
+	for _, name := range path {
+		if n.all {
+			return
+		}
+		if n.children == nil {
+			n.children = make(map[string]*fieldNode)
+		}
+		child, ok := n.children[name]
+		if !ok {
+			child = &fieldNode{}
+			n.children[name] = child
+		}
+		n = child
+	}
+	// A shorter path selects everything a longer one would
+	n.all = true
+	n.children = nil
+}
+
+// Project returns the result as a generic JSON document holding only the
+// selected fields
+func (p *Projection) Project(result *scanner.ScanResult) (interface{}, error) {
+	doc, err := toDocument(result)
+	if err != nil {
+		return nil, err
+	}
+	return p.tree.prune(doc), nil
+}
+
+// toDocument converts a value to decoded JSON, keeping numbers exact
+func toDocument(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	return doc, nil
+}
+
+func (n *fieldNode) prune(value interface{}) interface{} {
+	if n.all {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		pruned := make(map[string]interface{}, len(n.children))
+		for name, child := range n.children {
+			if fieldValue, ok := v[name]; ok {
+				pruned[name] = child.prune(fieldValue)
+			}
+		}
+		return pruned
+	case []interface{}:
+		pruned := make([]interface{}, len(v))
+		for i, item := range v {
+			pruned[i] = n.prune(item)
+		}
+		return pruned
+	default:
+		return value
+	}
+}
+
+// Rows flattens the selected fields into a table, one row per item of the
+// innermost list a field is read through (one row in total when none is),
+// with the fields of enclosing objects repeated on each row. Items whose
+// nested list is empty still get a row with the inner fields left blank.
+func (p *Projection) Rows(result *scanner.ScanResult) ([][]string, error) {
+	for i, lists := range p.lists {
+		for j, list := range lists {
+			if p.chain[j] != list {
+				return nil, fmt.Errorf("fields %s and %s are in unrelated lists and cannot share CSV rows", p.fields[i], p.deep)
+			}
+		}
+	}
+
+	doc, err := toDocument(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	row := make([]string, len(p.fields))
+	p.expand(doc, 0, "", row, &rows)
+	return rows, nil
+}
+
+// expand fills in the fields read at list depth depth from obj, found at
+// prefix, then recurses into the next list of the chain
+func (p *Projection) expand(obj interface{}, depth int, prefix string, row []string, rows *[][]string) {
+	row = append([]string(nil), row...)
+	for i, path := range p.paths {
+		if len(p.lists[i]) != depth {
+			continue
+		}
+		row[i] = cellValue(lookup(obj, relativePath(path, prefix)))
+	}
+
+	if depth == len(p.chain) {
+		*rows = append(*rows, row)
+		return
+	}
+
+	list := p.chain[depth]
+	items, _ := lookup(obj, relativePath(strings.Split(list, "."), prefix)).([]interface{})
+	if len(items) == 0 {
+		*rows = append(*rows, row)
+		return
+	}
+	for _, item := range items {
+		p.expand(item, depth+1, list, row, rows)
+	}
+}
+
+// relativePath strips the list prefix an object was found at from a path
+func relativePath(path []string, prefix string) []string {
+	if prefix == "" {
+		return path
+	}
+	return path[len(strings.Split(prefix, ".")):]
+}
+
+// lookup follows path through nested objects
+func lookup(value interface{}, path []string) interface{} {
+	for _, name := range path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[name]
+	}
+	return value
+}
+
+// cellValue renders a JSON value as a CSV cell, joining lists of plain
+// values with semicolons like the full CSV report and encoding objects as JSON
+func cellValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+			parts = append(parts, cellValue(item))
+		}
+		return strings.Join(parts, ";")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// FormatFields writes the selected fields, keeping the JSON nesting
+func (f *JSONFormatter) FormatFields(result *scanner.ScanResult, projection *Projection) ([]byte, error) {
+	doc, err := projection.Project(result)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// FormatFields writes one table with a column per selected field, see
+// Projection.Rows
+func (f *CSVFormatter) FormatFields(result *scanner.ScanResult, projection *Projection) ([]byte, error) {
+	rows, err := projection.Rows(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(projection.Fields()); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
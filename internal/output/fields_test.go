@@ -0,0 +1,148 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func fieldsScanResult() *scanner.ScanResult {
+	return &scanner.ScanResult{
+		Target: "10.0.0.0/30", Scanner: "nmap", Status: scanner.StatusCompleted, DurationMs: 1200,
+		Hosts: []*models.Host{
+			{IPAddress: "10.0.0.1", Hostname: "gw", Status: "up", ReputationSources: []string{"spamhaus", "abuseipdb"}, Ports: []*models.Port{
+				{Number: 22, Protocol: "tcp", State: "open", Service: "ssh"},
+				{Number: 443, Protocol: "tcp", State: "open", Service: "https"},
+			}},
+			{IPAddress: "10.0.0.2", Status: "up"},
+		},
+		Findings: []*models.Finding{{Type: "weak-ssh", Severity: "medium", IPAddress: "10.0.0.1"}},
+	}
+}
+
+func TestNewProjection(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		wantErr string
+	}{
+		{"top level", []string{"target", "status"}, ""},
+		{"nested through lists", []string{"hosts.ip_address", "hosts.ports.number"}, ""},
+		{"whole object", []string{"hosts"}, ""},
+		{"map keys are free-form", []string{"metadata.ticket"}, ""},
+		{"blank entries skipped", []string{" target ", ""}, ""},
+		{"unknown field", []string{"hosts.mac"}, `unknown field "hosts.mac"`},
+		{"field of a plain value", []string{"target.length"}, "target has no fields"},
+		{"field of a time", []string{"hosts.created_at.year"}, "hosts.created_at has no fields"},
+		{"none", []string{" "}, "no fields given"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProjection(tt.fields)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("NewProjection() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("NewProjection() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONFormatFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{"top level", []string{"target", "duration_ms"}, `{"duration_ms":1200,"target":"10.0.0.0/30"}`},
+		{
+			"nested", []string{"hosts.ip_address", "hosts.ports.number"},
+			`{"hosts":[{"ip_address":"10.0.0.1","ports":[{"number":22},{"number":443}]},{"ip_address":"10.0.0.2"}]}`,
+		},
+		{"shorter path wins", []string{"findings.type", "findings"}, `{"findings":[{"ip_address":"10.0.0.1","message":"","severity":"medium","type":"weak-ssh"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projection, err := NewProjection(tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := (&JSONFormatter{}).FormatFields(fieldsScanResult(), projection)
+			if err != nil {
+				t.Fatalf("FormatFields() error = %v", err)
+			}
+
+			var doc interface{}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("output is not JSON: %v", err)
+			}
+			if compact, _ := json.Marshal(doc); string(compact) != tt.want {
+				t.Errorf("FormatFields() = %s, want %s", compact, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVFormatFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		want    string
+		wantErr string
+	}{
+		{"one row without lists", []string{"target", "status"}, "target,status\n10.0.0.0/30,completed\n", ""},
+		{
+			"row per port, host without ports kept",
+			[]string{"target", "hosts.ip_address", "hosts.ports.number", "hosts.ports.service"},
+			"target,hosts.ip_address,hosts.ports.number,hosts.ports.service\n" +
+				"10.0.0.0/30,10.0.0.1,22,ssh\n10.0.0.0/30,10.0.0.1,443,https\n10.0.0.0/30,10.0.0.2,,\n",
+			"",
+		},
+		{"plain lists joined", []string{"hosts.ip_address", "hosts.reputation_sources"}, "hosts.ip_address,hosts.reputation_sources\n10.0.0.1,spamhaus;abuseipdb\n10.0.0.2,\n", ""},
+		{"unrelated lists", []string{"hosts.ip_address", "findings.type"}, "", "unrelated lists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projection, err := NewProjection(tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := (&CSVFormatter{}).FormatFields(fieldsScanResult(), projection)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("FormatFields() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FormatFields() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("FormatFields() =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatterManagerProjection(t *testing.T) {
+	projection, err := NewProjection([]string{"target"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm := NewFormatterManager()
+	fm.SetProjection(projection)
+
+	if _, err := fm.Format(fieldsScanResult(), "csv"); err != nil {
+		t.Errorf("Format(csv) error = %v", err)
+	}
+	if _, err := fm.Format(fieldsScanResult(), "html"); err == nil || !strings.Contains(err.Error(), "does not support field selection") {
+		t.Errorf("Format(html) error = %v, want field selection refused", err)
+	}
+}
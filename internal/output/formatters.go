@@ -1,15 +1,20 @@
 package output
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	_ "embed"
 	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"html/template"
-	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/netrecon/toolkit/internal/analysis"
 	"github.com/netrecon/toolkit/internal/models"
 	"github.com/netrecon/toolkit/internal/scanner"
 )
@@ -61,18 +66,19 @@ func (f *XMLFormatter) GetFileExtension() string {
 type CSVFormatter struct{}
 
 func (f *CSVFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
-	var output []byte
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
 
-	// Create CSV writer to a buffer would be better, but for simplicity:
 	records := [][]string{
-		{"Target", "Scanner", "Status", "Start Time", "End Time", "Duration", "Host Count"},
-		{result.Target, result.Scanner, result.Status, result.StartTime, result.EndTime, result.Duration, fmt.Sprintf("%d", len(result.Hosts))},
+		{"Target", "Scanner", "Status", "Start Time", "End Time", "Duration", "Duration (ms)", "Host Count", "Complete", "Coverage"},
+		{result.Target, result.Scanner, result.Status, result.StartTime, result.EndTime, result.HumanDuration(), fmt.Sprintf("%d", result.DurationMs), fmt.Sprintf("%d", len(result.Hosts)),
+			fmt.Sprintf("%t", result.Complete), formatCoverage(result.Coverage)},
 	}
 
 	// Add host information
 	if len(result.Hosts) > 0 {
 		records = append(records, []string{}) // Empty line
-		records = append(records, []string{"IP Address", "Hostname", "Status", "OS", "OS Confidence"})
+		records = append(records, []string{"IP Address", "Hostname", "Status", "OS", "OS Confidence", "OS Family", "NetBIOS Name", "Domain", "Workgroup", "Reputation", "Reputation Sources", "Load Balanced", "Uptime", "Last Boot", "Filtered Ports", "Scan Error"})
 
 		for _, host := range result.Hosts {
 			records = append(records, []string{
@@ -81,23 +87,70 @@ func (f *CSVFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
 				host.Status,
 				host.OS,
 				fmt.Sprintf("%d", host.OSConfidence),
+				analysis.HostOSFamily(host),
+				host.NetBIOSName,
+				host.Domain,
+				host.Workgroup,
+				fmt.Sprintf("%d", host.ReputationScore),
+				strings.Join(host.ReputationSources, ";"),
+				fmt.Sprintf("%t", host.LoadBalanced),
+				formatUptime(host.UptimeSeconds),
+				formatLastBoot(host.LastBoot),
+				fmt.Sprintf("%d", host.FilteredPortCount),
+				host.ScanError,
 			})
 		}
 	}
 
-	// Convert to CSV format (simplified)
-	csvContent := ""
-	for _, record := range records {
-		for i, field := range record {
-			if i > 0 {
-				csvContent += ","
-			}
-			csvContent += fmt.Sprintf("\"%s\"", field)
+	// Add port information
+	var portRecords [][]string
+	for _, host := range result.Hosts {
+		for _, port := range host.Ports {
+			portRecords = append(portRecords, []string{host.IPAddress, fmt.Sprintf("%d", port.Number), port.Protocol,
+				port.State, port.Service, strings.TrimSpace(port.Product + " " + port.Version)})
+		}
+	}
+	if len(portRecords) > 0 {
+		records = append(records, []string{})
+		records = append(records, []string{"IP Address", "Port", "Protocol", "State", "Service", "Version"})
+		records = append(records, portRecords...)
+	}
+
+	// Add host script results
+	var scriptRecords [][]string
+	for _, host := range result.Hosts {
+		for _, script := range host.HostScripts {
+			scriptRecords = append(scriptRecords, []string{host.IPAddress, script.ID, script.Output})
+		}
+	}
+	if len(scriptRecords) > 0 {
+		records = append(records, []string{})
+		records = append(records, []string{"IP Address", "Host Script", "Output"})
+		records = append(records, scriptRecords...)
+	}
+
+	// Add findings
+	if len(result.Findings) > 0 {
+		records = append(records, []string{})
+		records = append(records, []string{"Finding", "Severity", "IP Address", "Port", "Protocol", "Message"})
+
+		for _, finding := range result.Findings {
+			records = append(records, []string{
+				finding.Type,
+				finding.Severity,
+				finding.IPAddress,
+				fmt.Sprintf("%d", finding.Port),
+				finding.Protocol,
+				finding.Message,
+			})
 		}
-		csvContent += "\n"
 	}
 
-	return []byte(csvContent), nil
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return buf.Bytes(), nil
 }
 
 func (f *CSVFormatter) GetMimeType() string {
@@ -108,86 +161,81 @@ func (f *CSVFormatter) GetFileExtension() string {
 	return "csv"
 }
 
-// HTMLFormatter formats output as HTML report
-type HTMLFormatter struct{}
-
-const htmlTemplate = `
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Network Reconnaissance Report</title>
-    <style>
-        body { font-family: Arial, sans-serif; margin: 20px; }
-        .header { background-color: #f0f0f0; padding: 20px; border-radius: 5px; margin-bottom: 20px; }
-        .section { margin-bottom: 30px; }
-        .host { border: 1px solid #ddd; margin: 10px 0; padding: 15px; border-radius: 5px; }
-        .port { background-color: #f9f9f9; margin: 5px 0; padding: 10px; border-left: 4px solid #007cba; }
-        .status-up { color: green; font-weight: bold; }
-        .status-down { color: red; font-weight: bold; }
-        .status-filtered { color: orange; font-weight: bold; }
-        table { border-collapse: collapse; width: 100%; }
-        th, td { border: 1px solid #ddd; padding: 8px; text-align: left; }
-        th { background-color: #f2f2f2; }
-        .error { color: red; background-color: #ffe6e6; padding: 10px; border-radius: 5px; }
-    </style>
-</head>
-<body>
-    <div class="header">
-        <h1>Network Reconnaissance Report</h1>
-        <p><strong>Target:</strong> {{.Target}}</p>
-        <p><strong>Scanner:</strong> {{.Scanner}}</p>
-        <p><strong>Status:</strong> <span class="status-{{.Status}}">{{.Status}}</span></p>
-        <p><strong>Start Time:</strong> {{.StartTime}}</p>
-        <p><strong>End Time:</strong> {{.EndTime}}</p>
-        <p><strong>Duration:</strong> {{.Duration}}</p>
-        <p><strong>Hosts Found:</strong> {{len .Hosts}}</p>
-    </div>
-
-    {{if .Error}}
-    <div class="error">
-        <h3>Errors</h3>
-        <pre>{{.Error}}</pre>
-    </div>
-    {{end}}
-
-    {{if .Hosts}}
-    <div class="section">
-        <h2>Discovered Hosts</h2>
-        {{range .Hosts}}
-        <div class="host">
-            <h3>Host: {{.IPAddress}} {{if .Hostname}}({{.Hostname}}){{end}}</h3>
-            <p><strong>Status:</strong> <span class="status-{{.Status}}">{{.Status}}</span></p>
-            {{if .OS}}<p><strong>OS:</strong> {{.OS}} ({{.OSConfidence}}% confidence)</p>{{end}}
-        </div>
-        {{end}}
-    </div>
-    {{end}}
-
-    <div class="section">
-        <h2>Raw Output</h2>
-        <pre style="background-color: #f5f5f5; padding: 15px; border-radius: 5px; overflow-x: auto;">{{.RawOutput}}</pre>
-    </div>
-
-    <div class="section">
-        <p><em>Report generated on {{.Timestamp}}</em></p>
-    </div>
-</body>
-</html>
-`
+// HTMLFormatter formats output as HTML report. The zero value renders the
+// built-in template.
+type HTMLFormatter struct {
+	tmpl *template.Template
+}
 
-func (f *HTMLFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
-	tmpl, err := template.New("report").Parse(htmlTemplate)
+// DefaultHTMLTemplate is the built-in report template, a starting point for
+// custom templates
+//
+//go:embed templates/report.html.tmpl
+var DefaultHTMLTemplate string
+
+var defaultHTMLTemplate = template.Must(parseHTMLTemplate("report", DefaultHTMLTemplate))
+
+// ReportData is passed to HTML report templates
+type ReportData struct {
+	*scanner.ScanResult
+	Timestamp  string
+	Summary    ReportSummary
+	OSFamilies []analysis.OSFamilyGroup
+}
+
+// ReportSummary holds totals for report templates
+type ReportSummary struct {
+	Hosts    int
+	HostsUp  int
+	Findings int
+}
+
+// NewHTMLFormatter creates an HTML formatter rendering the template file at
+// path, or the built-in template when path is empty. The template is parsed
+// immediately so mistakes surface before any scan runs.
+func NewHTMLFormatter(path string) (*HTMLFormatter, error) {
+	if path == "" {
+		return &HTMLFormatter{tmpl: defaultHTMLTemplate}, nil
+	}
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML template: %w", err)
+	}
+
+	tmpl, err := parseHTMLTemplate(filepath.Base(path), string(text))
+	if err != nil {
+		return nil, err
+	}
+	return &HTMLFormatter{tmpl: tmpl}, nil
+}
+
+// parseHTMLTemplate parses a report template with the report helper functions
+func parseHTMLTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"statusClass": scanStatusClass,
+		"uptime":      formatUptime,
+		"lastBoot":    formatLastBoot,
+		"coverage":    formatCoverage,
+		"discovery":   formatDiscovery,
+	}).Parse(text)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML template: %w", err)
 	}
+	return tmpl, nil
+}
 
-	// Add timestamp to result
-	data := struct {
-		*scanner.ScanResult
-		Timestamp string
-	}{
+func (f *HTMLFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	tmpl := f.tmpl
+	if tmpl == nil {
+		tmpl = defaultHTMLTemplate
+	}
+
+	data := ReportData{
 		ScanResult: result,
 		Timestamp:  time.Now().Format("2006-01-02 15:04:05"),
+		Summary:    summarize(result),
+		OSFamilies: analysis.GroupByOSFamily(result.Hosts),
 	}
 
 	var output []byte
@@ -200,6 +248,61 @@ func (f *HTMLFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
 	return output, nil
 }
 
+// summarize counts the hosts and findings of a scan for report templates
+func summarize(result *scanner.ScanResult) ReportSummary {
+	summary := ReportSummary{
+		Hosts:    len(result.Hosts),
+		Findings: len(result.Findings),
+	}
+	for _, host := range result.Hosts {
+		if host.Status == "up" {
+			summary.HostsUp++
+		}
+	}
+	return summary
+}
+
+// scanStatusClass maps a scan status to its report CSS class, falling back
+// to status-unknown so unexpected values never produce an unstyled class
+func scanStatusClass(status string) string {
+	if !scanner.IsValidStatus(status) {
+		return "status-unknown"
+	}
+	return "status-" + status
+}
+
+// formatUptime renders uptime in days once it exceeds one, since long
+// uptimes are read as missed patch reboots
+func formatUptime(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	if days := seconds / 86400; days >= 1 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return scanner.HumanDuration(seconds * 1000)
+}
+
+// formatLastBoot renders a boot time, empty when unknown
+func formatLastBoot(lastBoot *time.Time) string {
+	if lastBoot == nil {
+		return ""
+	}
+	return lastBoot.Format(time.RFC3339)
+}
+
+// formatDiscovery renders the hosts and open ports never seen before, out
+// of those found
+func formatDiscovery(d *models.DiscoveryCounts) string {
+	return fmt.Sprintf("%d of %d hosts never seen before, %d of %d open ports",
+		d.NewHosts, d.NewHosts+d.KnownHosts, d.NewPorts, d.NewPorts+d.KnownPorts)
+}
+
+// formatCoverage renders a 0-1 coverage fraction as a percentage
+func formatCoverage(coverage float64) string {
+	return fmt.Sprintf("%.0f%%", coverage*100)
+}
+
 func (f *HTMLFormatter) GetMimeType() string {
 	return "text/html"
 }
@@ -221,23 +324,80 @@ func (b *bytesBuffer) Write(p []byte) (int, error) {
 // FormatterManager manages output formatters
 type FormatterManager struct {
 	formatters map[string]Formatter
+	signingKey ed25519.PrivateKey
+	redactor   *Redactor
+	projection *Projection
 }
 
-// NewFormatterManager creates a new formatter manager
+// NewFormatterManager creates a formatter manager with the default
+// formatters and those added with Register
 func NewFormatterManager() *FormatterManager {
 	fm := &FormatterManager{
-		formatters: make(map[string]Formatter),
+		formatters: builtinFormatters(),
 	}
 
-	// Register default formatters
-	fm.RegisterFormatter("json", &JSONFormatter{})
-	fm.RegisterFormatter("xml", &XMLFormatter{})
-	fm.RegisterFormatter("csv", &CSVFormatter{})
-	fm.RegisterFormatter("html", &HTMLFormatter{})
+	// Add formatters registered with Register
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for name, formatter := range registry {
+		fm.RegisterFormatter(name, formatter)
+	}
 
 	return fm
 }
 
+// builtinFormatters creates the default formatters every manager starts with
+func builtinFormatters() map[string]Formatter {
+	return map[string]Formatter{
+		"json":      &JSONFormatter{},
+		"xml":       &XMLFormatter{},
+		"csv":       &CSVFormatter{},
+		"html":      &HTMLFormatter{},
+		"cmdb":      &CMDBFormatter{},
+		"hosts":     &HostsFormatter{},
+		"iplist":    &IPListFormatter{},
+		"cef":       &CEFFormatter{},
+		"capfilter": &CapFilterFormatter{},
+		"markdown":  &MarkdownFormatter{},
+		"text":      &TextFormatter{},
+	}
+}
+
+// SetSigningKey enables writing a detached Ed25519 signature next to every saved report
+func (fm *FormatterManager) SetSigningKey(key ed25519.PrivateKey) {
+	fm.signingKey = key
+}
+
+// SetRedactor masks sensitive fields in every report produced by the manager
+func (fm *FormatterManager) SetRedactor(redactor *Redactor) {
+	fm.redactor = redactor
+}
+
+// SetProjection restricts every report produced by the manager to the
+// projection's fields. Only formatters implementing ProjectingFormatter
+// accept one.
+func (fm *FormatterManager) SetProjection(projection *Projection) {
+	fm.projection = projection
+}
+
+// RedactTarget masks a target name for use outside formatted reports, such
+// as export indexes. It returns target unchanged when no redactor is set.
+func (fm *FormatterManager) RedactTarget(target string) string {
+	if fm.redactor == nil {
+		return target
+	}
+	return fm.redactor.maskTarget(target)
+}
+
+// Redact returns result with the redaction rules applied, or result itself
+// when no redactor is set, for output built outside the formatters
+func (fm *FormatterManager) Redact(result *scanner.ScanResult) *scanner.ScanResult {
+	if fm.redactor == nil {
+		return result
+	}
+	return fm.redactor.Redact(result)
+}
+
 // RegisterFormatter registers a new formatter
 func (fm *FormatterManager) RegisterFormatter(name string, formatter Formatter) {
 	fm.formatters[name] = formatter
@@ -258,19 +418,46 @@ func (fm *FormatterManager) ListFormatters() []string {
 	return names
 }
 
-// FormatAndSave formats scan results and saves to file
-func (fm *FormatterManager) FormatAndSave(result *scanner.ScanResult, format string, filename string) error {
+// Format renders scan results with the named formatter, applying redaction
+// first when a redactor is set
+func (fm *FormatterManager) Format(result *scanner.ScanResult, format string) ([]byte, error) {
 	formatter, exists := fm.GetFormatter(format)
 	if !exists {
-		return fmt.Errorf("formatter '%s' not available. Available formatters: %v", format, fm.ListFormatters())
+		return nil, fmt.Errorf("formatter '%s' not available. Available formatters: %v", format, fm.ListFormatters())
+	}
+
+	if fm.redactor != nil {
+		result = fm.redactor.Redact(result)
 	}
 
-	data, err := formatter.Format(result)
+	var data []byte
+	var err error
+	if fm.projection != nil {
+		projecting, ok := formatter.(ProjectingFormatter)
+		if !ok {
+			return nil, fmt.Errorf("formatter '%s' does not support field selection (use json or csv)", format)
+		}
+		data, err = projecting.FormatFields(result, fm.projection)
+	} else {
+		data, err = formatter.Format(result)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+		return nil, fmt.Errorf("failed to format output: %w", err)
 	}
+	return data, nil
+}
+
+// FormatAndSave formats scan results and saves to file
+func (fm *FormatterManager) FormatAndSave(result *scanner.ScanResult, format string, filename string) error {
+	data, err := fm.Format(result, format)
+	if err != nil {
+		return err
+	}
+	return fm.save(data, filename)
+}
 
-	// Write to file
+// save writes a formatted report to file, signing it when a key is set
+func (fm *FormatterManager) save(data []byte, filename string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
@@ -282,5 +469,12 @@ func (fm *FormatterManager) FormatAndSave(result *scanner.ScanResult, format str
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	// Sign the exact bytes written
+	if fm.signingKey != nil {
+		if err := os.WriteFile(filename+SignatureExtension, SignReport(data, fm.signingKey), 0644); err != nil {
+			return fmt.Errorf("failed to write signature: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,171 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func TestCSVFormatterDuration(t *testing.T) {
+	tests := []struct {
+		name       string
+		durationMs int64
+		want       string
+	}{
+		{"zero", 0, "0ms"},
+		{"milliseconds", 450, "450ms"},
+		{"seconds", 12345, "12.3s"},
+		{"minutes", 65000, "1m 5s"},
+		{"hours", 7384000, "2h 3m 4s"},
+		{"negative", -5, "0ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := (&CSVFormatter{}).Format(&scanner.ScanResult{Target: "192.0.2.1", DurationMs: tt.durationMs})
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+			if err != nil {
+				t.Fatalf("output is not valid CSV: %v", err)
+			}
+			if got := records[1][5]; got != tt.want {
+				t.Errorf("Duration = %q, want %q", got, tt.want)
+			}
+			if got, want := records[1][6], strconv.FormatInt(tt.durationMs, 10); got != want {
+				t.Errorf("Duration (ms) = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestHTMLFormatterDuration(t *testing.T) {
+	data, err := (&HTMLFormatter{}).Format(&scanner.ScanResult{Target: "192.0.2.1", DurationMs: 65000})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "<strong>Duration:</strong> 1m 5s"; !strings.Contains(string(data), want) {
+		t.Errorf("report does not contain %q", want)
+	}
+}
+
+func TestSortByDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []int64
+		want      []string
+	}{
+		{"empty", nil, nil},
+		{"slowest first", []int64{10, 3000, 450}, []string{"b", "c", "a"}},
+		{"ties keep their order", []int64{5, 9, 5}, []string{"b", "a", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []*scanner.ScanResult
+			for i, ms := range tt.durations {
+				results = append(results, &scanner.ScanResult{Target: string(rune('a' + i)), DurationMs: ms})
+			}
+
+			scanner.SortByDuration(results)
+
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Target)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanStatusClass(t *testing.T) {
+	templates := map[string]string{"report": DefaultHTMLTemplate, "dashboard": dashboardTemplateText}
+	for _, status := range scanner.Statuses {
+		class := scanStatusClass(status)
+		if class != "status-"+status {
+			t.Errorf("scanStatusClass(%q) = %q", status, class)
+		}
+		for name, text := range templates {
+			if !strings.Contains(text, "."+class+" ") && !strings.Contains(text, "."+class+",") {
+				t.Errorf("%s template has no style for %s", name, class)
+			}
+		}
+	}
+
+	for _, status := range []string{"", "complete", "Failed", "pending", "x onclick=alert(1)"} {
+		if got := scanStatusClass(status); got != "status-unknown" {
+			t.Errorf("scanStatusClass(%q) = %q, want status-unknown", status, got)
+		}
+	}
+}
+
+func TestCustomHTMLTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "brand.tmpl")
+	text := `<h1>{{.Target}} for ACME</h1><p>{{.Summary.HostsUp}}/{{.Summary.Hosts}} up</p>` +
+		`<ul class="{{statusClass .Status}}">{{range .Hosts}}<li>{{.IPAddress}} {{.Hostname}}</li>{{end}}</ul>`
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	formatter, err := NewHTMLFormatter(path)
+	if err != nil {
+		t.Fatalf("NewHTMLFormatter() error = %v", err)
+	}
+	result := &scanner.ScanResult{
+		Target: "192.0.2.0/30",
+		Status: scanner.StatusCompleted,
+		Hosts: []*models.Host{
+			{IPAddress: "192.0.2.1", Hostname: "<b>web</b>", Status: "up"},
+			{IPAddress: "192.0.2.2", Status: "down"},
+		},
+	}
+
+	// Values are escaped as in the built-in template
+	want := `<h1>192.0.2.0/30 for ACME</h1><p>1/2 up</p>` +
+		`<ul class="status-completed"><li>192.0.2.1 &lt;b&gt;web&lt;/b&gt;</li><li>192.0.2.2 </li></ul>`
+	data, err := formatter.Format(result)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if string(data) != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", data, want)
+	}
+
+	// The manager renders with the custom template once it replaces html
+	fm := NewFormatterManager()
+	fm.RegisterFormatter("html", formatter)
+	if data, err := fm.Format(result, "html"); err != nil || string(data) != want {
+		t.Errorf("FormatterManager.Format() = %s, %v; want the custom report", data, err)
+	}
+
+	// Broken and missing templates are rejected when loaded
+	broken := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(broken, []byte(`<h1>{{.Target</h1>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHTMLFormatter(broken); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Errorf("NewHTMLFormatter() of a broken template error = %v, want a parse error", err)
+	}
+	if _, err := NewHTMLFormatter(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("NewHTMLFormatter() accepted a missing template file")
+	}
+
+	builtin, err := NewHTMLFormatter("")
+	if err != nil {
+		t.Fatalf("NewHTMLFormatter() of the built-in template error = %v", err)
+	}
+	if data, err := builtin.Format(result); err != nil || !strings.Contains(string(data), "<h2>Discovered Hosts</h2>") {
+		t.Errorf("built-in template output = %.80s..., %v; want the default report", data, err)
+	}
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// HostsFormatter formats hosts as /etc/hosts lines ("<ip> <hostname>").
+// Hosts without a resolved hostname are left out.
+type HostsFormatter struct{}
+
+func (f *HostsFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, host := range result.Hosts {
+		if host.Hostname == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s %s\n", host.IPAddress, host.Hostname)
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *HostsFormatter) GetMimeType() string {
+	return "text/plain"
+}
+
+func (f *HostsFormatter) GetFileExtension() string {
+	return "hosts"
+}
+
+// IPListFormatter formats the addresses of up hosts one per line, for
+// feeding into other scanners
+type IPListFormatter struct{}
+
+func (f *IPListFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, host := range result.Hosts {
+		if host.Status != "up" {
+			continue
+		}
+		buf.WriteString(host.IPAddress)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *IPListFormatter) GetMimeType() string {
+	return "text/plain"
+}
+
+func (f *IPListFormatter) GetFileExtension() string {
+	return "txt"
+}
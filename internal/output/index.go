@@ -0,0 +1,84 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// ExportEntry describes one exported report listed in an export index
+type ExportEntry struct {
+	File      string
+	ScanID    string
+	Scanner   string
+	Status    string
+	Complete  bool
+	StartTime string
+	Duration  string
+	HostCount int
+}
+
+const indexTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Scan Reports - {{.Target}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; }
+        table { border-collapse: collapse; width: 100%; }
+        th, td { border: 1px solid #ddd; padding: 8px; text-align: left; }
+        th { background-color: #f2f2f2; }
+    </style>
+</head>
+<body>
+    <h1>Scan Reports for {{.Target}}</h1>
+    <table>
+        <tr><th>Start Time</th><th>Scanner</th><th>Status</th><th>Duration</th><th>Hosts</th><th>Report</th></tr>
+        {{range .Entries}}
+        <tr>
+            <td>{{.StartTime}}</td>
+            <td>{{.Scanner}}</td>
+            <td>{{.Status}}{{if not .Complete}} (incomplete){{end}}</td>
+            <td>{{.Duration}}</td>
+            <td>{{.HostCount}}</td>
+            <td><a href="{{.File}}">{{.File}}</a></td>
+        </tr>
+        {{end}}
+    </table>
+    <p><em>Index generated on {{.Timestamp}}</em></p>
+</body>
+</html>
+`
+
+// RenderExportIndex renders an HTML page linking every exported report for a target
+func RenderExportIndex(target string, entries []ExportEntry) ([]byte, error) {
+	tmpl, err := template.New("index").Parse(indexTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index template: %w", err)
+	}
+
+	data := struct {
+		Target    string
+		Entries   []ExportEntry
+		Timestamp string
+	}{
+		Target:    target,
+		Entries:   entries,
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute index template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportFilename builds a report filename from the scan start time and ID
+func ExportFilename(startTime time.Time, scanID string, extension string) string {
+	shortID := scanID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	return fmt.Sprintf("%s_%s.%s", startTime.UTC().Format("20060102-150405"), shortID, extension)
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// MarkdownFormatter formats output as Markdown tables, for pasting into
+// tickets, wikis and pull requests
+type MarkdownFormatter struct{}
+
+func (f *MarkdownFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Scan of %s\n\n", mdCell(result.Target))
+	fmt.Fprintf(&buf, "- **Scanner:** %s\n", mdCell(result.Scanner))
+	fmt.Fprintf(&buf, "- **Status:** %s", result.Status)
+	if !result.Complete {
+		fmt.Fprintf(&buf, " (incomplete: %s covered)", formatCoverage(result.Coverage))
+	}
+	buf.WriteString("\n")
+	fmt.Fprintf(&buf, "- **Started:** %s\n", result.StartTime)
+	fmt.Fprintf(&buf, "- **Duration:** %s\n", result.HumanDuration())
+	summary := summarize(result)
+	fmt.Fprintf(&buf, "- **Hosts:** %d (%d up)\n", summary.Hosts, summary.HostsUp)
+	if result.Discovery != nil {
+		fmt.Fprintf(&buf, "- **New:** %s\n", formatDiscovery(result.Discovery))
+	}
+	if result.Error != "" {
+		fmt.Fprintf(&buf, "- **Error:** %s\n", mdCell(result.Error))
+	}
+
+	var errored []string
+	for _, host := range result.Hosts {
+		if host.ScanError != "" {
+			errored = append(errored, fmt.Sprintf("| %s | %s |\n", mdCell(host.IPAddress), mdCell(host.ScanError)))
+		}
+	}
+	if len(errored) > 0 {
+		buf.WriteString("\n## Host Errors\n\n| Host | Error |\n|---|---|\n")
+		buf.WriteString(strings.Join(errored, ""))
+	}
+
+	buf.WriteString("\n## Open Ports\n\n")
+	buf.WriteString("| Host | Port | Service | Version |\n|---|---|---|---|\n")
+	for _, host := range result.Hosts {
+		for _, port := range host.Ports {
+			if port.State != "open" {
+				continue
+			}
+			fmt.Fprintf(&buf, "| %s | %d/%s | %s | %s |\n", mdCell(host.IPAddress), port.Number, port.Protocol,
+				mdCell(port.Service), mdCell(strings.TrimSpace(port.Product+" "+port.Version)))
+		}
+	}
+
+	if len(result.Findings) > 0 {
+		buf.WriteString("\n## Findings\n\n")
+		buf.WriteString("| Severity | Host | Port | Message |\n|---|---|---|---|\n")
+		for _, finding := range result.Findings {
+			fmt.Fprintf(&buf, "| %s | %s | %s | %s |\n", finding.Severity, mdCell(finding.IPAddress),
+				mdPort(finding.Port, finding.Protocol), mdCell(finding.Message))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *MarkdownFormatter) FormatDiff(d *diff.ScanDiff) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Changes from %s to %s\n\n", d.BaseScanID, d.CompareScanID)
+	for _, warning := range d.Warnings {
+		fmt.Fprintf(&buf, "> Warning: %s\n\n", mdCell(warning))
+	}
+	if !d.HasChanges() {
+		buf.WriteString("No hosts, ports, services or vulnerabilities changed.\n")
+		return buf.Bytes(), nil
+	}
+
+	if len(d.NewHosts) > 0 || len(d.RemovedHosts) > 0 {
+		buf.WriteString("## Hosts\n\n| Change | Host |\n|---|---|\n")
+		for _, host := range d.NewHosts {
+			fmt.Fprintf(&buf, "| + added | %s |\n", mdCell(host))
+		}
+		for _, host := range d.RemovedHosts {
+			fmt.Fprintf(&buf, "| - removed | %s |\n", mdCell(host))
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(d.NewPorts) > 0 || len(d.RemovedPorts) > 0 {
+		buf.WriteString("## Open Ports\n\n| Change | Host | Port | Service |\n|---|---|---|---|\n")
+		for _, port := range d.NewPorts {
+			fmt.Fprintf(&buf, "| + added | %s | %d/%s | %s |\n", mdCell(port.IPAddress), port.Port, port.Protocol, mdCell(port.Service))
+		}
+		for _, port := range d.RemovedPorts {
+			fmt.Fprintf(&buf, "| - removed | %s | %d/%s | %s |\n", mdCell(port.IPAddress), port.Port, port.Protocol, mdCell(port.Service))
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(d.ChangedServices) > 0 {
+		buf.WriteString("## Changed Services\n\n| Host | Port | Change | Before | After |\n|---|---|---|---|---|\n")
+		for _, change := range d.ChangedServices {
+			direction := change.Direction
+			if change.Notable {
+				direction = "**" + direction + "**"
+			}
+			fmt.Fprintf(&buf, "| %s | %d/%s | %s | %s | %s |\n", mdCell(change.IPAddress), change.Port, change.Protocol, direction,
+				mdCell(strings.Join(strings.Fields(change.BaseService+" "+change.BaseProduct+" "+change.BaseVersion), " ")),
+				mdCell(strings.Join(strings.Fields(change.Service+" "+change.Product+" "+change.Version), " ")))
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(d.NewVulns) > 0 {
+		buf.WriteString("## New Vulnerabilities\n\n| Host | Port | CVE | Severity |\n|---|---|---|---|\n")
+		for _, vuln := range d.NewVulns {
+			fmt.Fprintf(&buf, "| %s | %d/%s | %s | %s |\n", mdCell(vuln.IPAddress), vuln.Port, vuln.Protocol, mdCell(vuln.CVE), mdCell(vuln.Severity))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *MarkdownFormatter) GetMimeType() string {
+	return "text/markdown"
+}
+
+func (f *MarkdownFormatter) GetFileExtension() string {
+	return "md"
+}
+
+var mdCellReplacer = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+// mdCell escapes text for a Markdown table cell, where pipes end the cell
+// and newlines the row
+func mdCell(text string) string {
+	return mdCellReplacer.Replace(text)
+}
+
+// mdPort renders a finding's port, empty for host-level findings
+func mdPort(port int, protocol string) string {
+	if port == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%s", port, protocol)
+}
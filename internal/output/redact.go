@@ -0,0 +1,160 @@
+package output
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// RedactedValue replaces names matched by a redaction pattern
+const RedactedValue = "[redacted]"
+
+// RedactRules configures which data is masked in exported reports
+type RedactRules struct {
+	MaskIPs          bool     // Replace the last IPv4 octet (or the IPv6 interface ID) with "x"
+	HostnamePatterns []string // Regexes; matching hostnames, NetBIOS names and domains are redacted
+}
+
+// Redactor masks sensitive fields of a scan result before it is formatted
+type Redactor struct {
+	maskIPs  bool
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor compiles the redaction rules
+func NewRedactor(rules RedactRules) (*Redactor, error) {
+	r := &Redactor{maskIPs: rules.MaskIPs}
+	for _, pattern := range rules.HostnamePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hostname pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns a copy of result with the configured fields masked. Raw
+// scanner output and host script text cannot be redacted reliably and are
+// dropped.
+func (r *Redactor) Redact(result *scanner.ScanResult) *scanner.ScanResult {
+	redacted := *result
+	redacted.Target = r.maskTarget(result.Target)
+	redacted.Command = strings.ReplaceAll(result.Command, result.Target, redacted.Target)
+	redacted.RawOutput = ""
+
+	redacted.Hosts = make([]*models.Host, 0, len(result.Hosts))
+	for _, host := range result.Hosts {
+		h := *host
+		h.IPAddress = r.maskIP(host.IPAddress)
+		h.Hostname = r.redactName(host.Hostname)
+		h.NetBIOSName = r.redactName(host.NetBIOSName)
+		h.Domain = r.redactName(host.Domain)
+		h.HostScripts = nil
+		redacted.Hosts = append(redacted.Hosts, &h)
+	}
+
+	redacted.Findings = make([]*models.Finding, 0, len(result.Findings))
+	for _, finding := range result.Findings {
+		f := *finding
+		f.IPAddress = r.maskIP(finding.IPAddress)
+		redacted.Findings = append(redacted.Findings, &f)
+	}
+
+	return &redacted
+}
+
+// maskTarget masks an address or the address part of a CIDR or range
+// target, and applies hostname patterns to domain targets
+func (r *Redactor) maskTarget(target string) string {
+	for _, sep := range []string{"/", "-"} {
+		if start, rest, found := strings.Cut(target, sep); found && net.ParseIP(start) != nil {
+			// A dash range may end in a bare final octet
+			if sep == "-" && r.maskIPs && net.ParseIP(rest) == nil {
+				return r.maskIP(start) + sep + "x"
+			}
+			return r.maskIP(start) + sep + r.maskIP(rest)
+		}
+	}
+	if net.ParseIP(target) != nil {
+		return r.maskIP(target)
+	}
+	return r.redactName(target)
+}
+
+// maskIP replaces the host part of an address, leaving anything that is
+// not an IP address unchanged
+func (r *Redactor) maskIP(addr string) string {
+	if !r.maskIPs {
+		return addr
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return addr
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.x", ip4[0], ip4[1], ip4[2])
+	}
+
+	prefix := ip.Mask(net.CIDRMask(64, 128)).String()
+	return strings.TrimSuffix(prefix, "::") + "::x"
+}
+
+// redactName replaces a name matching any hostname pattern
+func (r *Redactor) redactName(name string) string {
+	if name == "" {
+		return name
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(name) {
+			return RedactedValue
+		}
+	}
+	return name
+}
+
+// RedactDiff returns a copy of a scan diff with the addresses masked
+func (r *Redactor) RedactDiff(d *diff.ScanDiff) *diff.ScanDiff {
+	redacted := *d
+
+	redacted.NewHosts = make([]string, 0, len(d.NewHosts))
+	for _, host := range d.NewHosts {
+		redacted.NewHosts = append(redacted.NewHosts, r.maskIP(host))
+	}
+	redacted.RemovedHosts = make([]string, 0, len(d.RemovedHosts))
+	for _, host := range d.RemovedHosts {
+		redacted.RemovedHosts = append(redacted.RemovedHosts, r.maskIP(host))
+	}
+
+	redacted.NewPorts = r.redactPortChanges(d.NewPorts)
+	redacted.RemovedPorts = r.redactPortChanges(d.RemovedPorts)
+
+	redacted.ChangedServices = make([]diff.ServiceChange, 0, len(d.ChangedServices))
+	for _, change := range d.ChangedServices {
+		change.IPAddress = r.maskIP(change.IPAddress)
+		redacted.ChangedServices = append(redacted.ChangedServices, change)
+	}
+	redacted.NewVulns = make([]diff.VulnChange, 0, len(d.NewVulns))
+	for _, vuln := range d.NewVulns {
+		vuln.IPAddress = r.maskIP(vuln.IPAddress)
+		redacted.NewVulns = append(redacted.NewVulns, vuln)
+	}
+
+	return &redacted
+}
+
+func (r *Redactor) redactPortChanges(changes []diff.PortChange) []diff.PortChange {
+	redacted := make([]diff.PortChange, 0, len(changes))
+	for _, change := range changes {
+		change.IPAddress = r.maskIP(change.IPAddress)
+		redacted = append(redacted, change)
+	}
+	return redacted
+}
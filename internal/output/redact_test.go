@@ -0,0 +1,159 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/diff"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func TestRedactorMaskIP(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"192.0.2.10", "192.0.2.x"},
+		{"10.1.2.255", "10.1.2.x"},
+		{"2001:db8:1:2:a:b:c:d", "2001:db8:1:2::x"},
+		{"2001:db8::1", "2001:db8::x"},
+		{"::ffff:192.0.2.7", "192.0.2.x"},
+		{"web01.corp.example", "web01.corp.example"},
+		{"", ""},
+	}
+
+	r, err := NewRedactor(RedactRules{MaskIPs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := r.maskIP(tt.addr); got != tt.want {
+				t.Errorf("maskIP(%q) = %q, want %q", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	unmasked, _ := NewRedactor(RedactRules{})
+	if got := unmasked.maskIP("192.0.2.10"); got != "192.0.2.10" {
+		t.Errorf("maskIP() without MaskIPs = %q, want the address unchanged", got)
+	}
+}
+
+func TestRedactorMaskTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"192.0.2.10", "192.0.2.x"},
+		{"192.0.2.0/24", "192.0.2.x/24"},
+		{"192.0.2.10-20", "192.0.2.x-x"},
+		{"192.0.2.10-192.0.2.20", "192.0.2.x-192.0.2.x"},
+		{"2001:db8::/64", "2001:db8::x/64"},
+		{"web01.corp.example", RedactedValue},
+		{"www.example.com", "www.example.com"},
+	}
+
+	r, err := NewRedactor(RedactRules{MaskIPs: true, HostnamePatterns: []string{`\.corp\.example$`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := r.maskTarget(tt.target); got != tt.want {
+				t.Errorf("maskTarget(%q) = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// redactedResult is a scan of internal and public hosts with the names,
+// scripts and raw output redaction must not let through
+func redactedResult() *scanner.ScanResult {
+	return &scanner.ScanResult{
+		Target:    "192.0.2.0/24",
+		Scanner:   "nmap",
+		Command:   "nmap -oX - 192.0.2.0/24",
+		RawOutput: "<nmaprun><host><address addr=\"192.0.2.10\"/><hostnames><hostname name=\"dc01.corp.example\"/></hostnames></host></nmaprun>",
+		Hosts: []*models.Host{
+			{
+				IPAddress: "192.0.2.10", Hostname: "dc01.corp.example", NetBIOSName: "DC01", Domain: "corp.example",
+				HostScripts: models.HostScripts{{ID: "smb-os-discovery", Output: "Computer name: dc01\nDomain name: corp.example"}},
+			},
+			{IPAddress: "192.0.2.20", Hostname: "www.example.com"},
+		},
+		Findings: []*models.Finding{{Type: "smb-signing", Severity: "medium", IPAddress: "192.0.2.10", Port: 445, Protocol: "tcp", Message: "SMB signing disabled"}},
+	}
+}
+
+func TestRedactHostnamePatterns(t *testing.T) {
+	r, err := NewRedactor(RedactRules{HostnamePatterns: []string{`\.corp\.example$`, `^corp\.example$`, `^DC\d+$`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := redactedResult()
+	redacted := r.Redact(result)
+
+	internal, public := redacted.Hosts[0], redacted.Hosts[1]
+	if internal.Hostname != RedactedValue || internal.NetBIOSName != RedactedValue || internal.Domain != RedactedValue {
+		t.Errorf("internal host names = %q, %q, %q; want all redacted", internal.Hostname, internal.NetBIOSName, internal.Domain)
+	}
+	if public.Hostname != "www.example.com" {
+		t.Errorf("public hostname = %q, want it kept", public.Hostname)
+	}
+	if internal.HostScripts != nil || redacted.RawOutput != "" {
+		t.Error("host scripts and raw output, which may name redacted hosts, were kept")
+	}
+	// Without MaskIPs the addresses are kept
+	if internal.IPAddress != "192.0.2.10" || redacted.Target != "192.0.2.0/24" {
+		t.Errorf("addresses %s and %s changed without MaskIPs", internal.IPAddress, redacted.Target)
+	}
+
+	// The result being formatted is left untouched
+	if original := result.Hosts[0]; original.Hostname != "dc01.corp.example" || original.HostScripts == nil || result.RawOutput == "" {
+		t.Errorf("Redact() modified its input: %+v", original)
+	}
+
+	if _, err := NewRedactor(RedactRules{HostnamePatterns: []string{"("}}); err == nil {
+		t.Error("NewRedactor() accepted an invalid pattern")
+	}
+}
+
+func TestFormatterManagerRedacts(t *testing.T) {
+	r, err := NewRedactor(RedactRules{MaskIPs: true, HostnamePatterns: []string{`\.corp\.example$`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm := NewFormatterManager()
+	fm.SetRedactor(r)
+
+	// Every formatter works on the redacted result
+	for _, format := range []string{"json", "csv", "html", "markdown", "text", "hosts", "iplist"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := fm.Format(redactedResult(), format)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			for _, leak := range []string{"192.0.2.10", "192.0.2.20", "dc01.corp.example"} {
+				if strings.Contains(string(data), leak) {
+					t.Errorf("%s output contains %q", format, leak)
+				}
+			}
+		})
+	}
+
+	d := &diff.ScanDiff{
+		NewHosts:     []string{"192.0.2.30"},
+		NewPorts:     []diff.PortChange{{IPAddress: "192.0.2.30", Port: 22, Protocol: "tcp"}},
+		RemovedPorts: []diff.PortChange{{IPAddress: "192.0.2.10", Port: 80, Protocol: "tcp"}},
+		NewVulns:     []diff.VulnChange{{IPAddress: "2001:db8::10", Port: 443, CVE: "CVE-2024-0001"}},
+	}
+	data, err := fm.FormatDiff(d, "markdown")
+	if err != nil {
+		t.Fatalf("FormatDiff() error = %v", err)
+	}
+	if strings.Contains(string(data), "192.0.2.30") || strings.Contains(string(data), "2001:db8::10") || !strings.Contains(string(data), "192.0.2.x") {
+		t.Errorf("diff addresses not masked:\n%s", data)
+	}
+}
@@ -0,0 +1,35 @@
+package output
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Formatter)
+)
+
+// Register makes a custom formatter available under name to every
+// FormatterManager created afterwards, so the --format flags and the API
+// accept it like the default ones. It is meant to be called from an init
+// function and panics when name is empty or already taken, or formatter is
+// nil, as registering twice is a programming error.
+func Register(name string, formatter Formatter) {
+	if name == "" {
+		panic("output: Register called with an empty name")
+	}
+	if formatter == nil {
+		panic(fmt.Sprintf("output: Register formatter %q is nil", name))
+	}
+	if _, builtin := builtinFormatters()[name]; builtin {
+		panic(fmt.Sprintf("output: Register cannot replace the default formatter %q", name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("output: Register called twice for formatter %q", name))
+	}
+	registry[name] = formatter
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SignatureExtension is appended to a report filename to form its signature sidecar
+const SignatureExtension = ".sig"
+
+// LoadSigningKey reads a PEM encoded PKCS#8 Ed25519 private key, as produced by
+// `openssl genpkey -algorithm ed25519`
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s is not an Ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// LoadVerifyKey reads a PEM encoded PKIX Ed25519 public key, as produced by
+// `openssl pkey -pubout`
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key in %s is not an Ed25519 key", path)
+	}
+	return edKey, nil
+}
+
+// SignReport signs the exact report bytes and returns the base64 signature
+func SignReport(data []byte, key ed25519.PrivateKey) []byte {
+	sig := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+}
+
+// VerifyReport checks a base64 signature produced by SignReport against the report bytes
+func VerifyReport(data, signature []byte, key ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	if !ed25519.Verify(key, data, sig) {
+		return fmt.Errorf("signature does not match report contents")
+	}
+	return nil
+}
+
+// VerifyReportFile verifies a report file against its signature file
+func VerifyReportFile(reportPath, signaturePath string, key ed25519.PublicKey) error {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	return VerifyReport(data, signature, key)
+}
+
+// readPEM reads the first PEM block from a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return public, private
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	public, private := newTestKey(t)
+
+	for _, report := range [][]byte{nil, []byte("{}"), []byte("target,scanner\n192.0.2.1,nmap\n")} {
+		if err := VerifyReport(report, SignReport(report, private), public); err != nil {
+			t.Errorf("VerifyReport(%q) error = %v", report, err)
+		}
+	}
+}
+
+func TestVerifyReportDetectsTampering(t *testing.T) {
+	public, private := newTestKey(t)
+	otherPublic, _ := newTestKey(t)
+
+	report := []byte(`{"target":"192.0.2.1","hosts":[]}`)
+	signature := SignReport(report, private)
+
+	flipped := append([]byte(nil), signature...)
+	if flipped[0] == 'A' {
+		flipped[0] = 'B'
+	} else {
+		flipped[0] = 'A'
+	}
+
+	tests := []struct {
+		name      string
+		report    []byte
+		signature []byte
+		key       ed25519.PublicKey
+	}{
+		{"changed byte", []byte(`{"target":"192.0.2.2","hosts":[]}`), signature, public},
+		{"appended data", append(append([]byte(nil), report...), '\n'), signature, public},
+		{"truncated report", report[:len(report)-1], signature, public},
+		{"altered signature", report, flipped, public},
+		{"signature not base64", report, []byte("not a signature!"), public},
+		{"empty signature", report, nil, public},
+		{"other key", report, signature, otherPublic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyReport(tt.report, tt.signature, tt.key); err == nil {
+				t.Error("VerifyReport() accepted a tampered report")
+			}
+		})
+	}
+}
+
+func TestLoadKeysRoundTrip(t *testing.T) {
+	public, private := newTestKey(t)
+	dir := t.TempDir()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePath := filepath.Join(dir, "report.key")
+	publicPath := filepath.Join(dir, "report.pub")
+	writePEM(t, privatePath, "PRIVATE KEY", privateDER)
+	writePEM(t, publicPath, "PUBLIC KEY", publicDER)
+
+	signingKey, err := LoadSigningKey(privatePath)
+	if err != nil {
+		t.Fatalf("LoadSigningKey() error = %v", err)
+	}
+	verifyKey, err := LoadVerifyKey(publicPath)
+	if err != nil {
+		t.Fatalf("LoadVerifyKey() error = %v", err)
+	}
+
+	report := []byte("report")
+	if err := VerifyReport(report, SignReport(report, signingKey), verifyKey); err != nil {
+		t.Errorf("VerifyReport() with loaded keys error = %v", err)
+	}
+
+	// Each loader rejects the other kind of key
+	if _, err := LoadSigningKey(publicPath); err == nil {
+		t.Error("LoadSigningKey() accepted a public key")
+	}
+	if _, err := LoadVerifyKey(privatePath); err == nil {
+		t.Error("LoadVerifyKey() accepted a private key")
+	}
+}
+
+func TestFormatAndSaveSignsReport(t *testing.T) {
+	public, private := newTestKey(t)
+	path := filepath.Join(t.TempDir(), "scan.json")
+
+	fm := NewFormatterManager()
+	fm.SetSigningKey(private)
+	if err := fm.FormatAndSave(&scanner.ScanResult{Target: "192.0.2.1", Scanner: "nmap"}, "json", path); err != nil {
+		t.Fatalf("FormatAndSave() error = %v", err)
+	}
+
+	if err := VerifyReportFile(path, path+SignatureExtension, public); err != nil {
+		t.Fatalf("VerifyReportFile() error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"target":"192.0.2.1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyReportFile(path, path+SignatureExtension, public); err == nil {
+		t.Error("VerifyReportFile() accepted a report changed after signing")
+	}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
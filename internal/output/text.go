@@ -0,0 +1,228 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Terminal widths assumed by the text formatter
+const (
+	DefaultTextWidth = 80
+	minTextWidth     = 40
+)
+
+// ANSI colors used by the text formatter
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// TextFormatter renders a scan result for the terminal: a summary, then
+// each host with its ports in aligned columns and the findings. The zero
+// value renders without color for an 80 column terminal.
+type TextFormatter struct {
+	color bool
+	width int
+}
+
+// NewTextFormatter creates a text formatter fitting lines to width columns,
+// or DefaultTextWidth when width is below 40, with ANSI colors if color is
+// set
+func NewTextFormatter(color bool, width int) *TextFormatter {
+	return &TextFormatter{color: color, width: width}
+}
+
+func (f *TextFormatter) Format(result *scanner.ScanResult) ([]byte, error) {
+	width := f.width
+	if width < minTextWidth {
+		width = DefaultTextWidth
+	}
+
+	var buf bytes.Buffer
+	summary := summarize(result)
+	openPorts := 0
+	for _, host := range result.Hosts {
+		for _, port := range host.Ports {
+			if port.State == "open" {
+				openPorts++
+			}
+		}
+	}
+
+	status := f.paint(result.Status, scanStatusColor(result.Status))
+	if !result.Complete {
+		status += f.paint(fmt.Sprintf(" (incomplete, %s covered)", formatCoverage(result.Coverage)), ansiYellow)
+	}
+	fields := [][2]string{
+		{"Target", result.Target},
+		{"Scanner", result.Scanner},
+		{"Status", status},
+		{"Duration", result.HumanDuration()},
+		{"Hosts", fmt.Sprintf("%d (%d up), %d open ports", summary.Hosts, summary.HostsUp, openPorts)},
+	}
+	if d := result.Discovery; d != nil {
+		fields = append(fields, [2]string{"New", formatDiscovery(d)})
+	}
+	if result.CorrelationID != "" {
+		fields = append(fields, [2]string{"Correlation ID", result.CorrelationID})
+	}
+	if result.TraceID != "" {
+		fields = append(fields, [2]string{"Trace", result.TraceID})
+	}
+	if result.Error != "" {
+		fields = append(fields, [2]string{"Error", f.paint(result.Error, ansiRed)})
+	}
+	for _, field := range fields {
+		fmt.Fprintf(&buf, "%-15s %s\n", field[0]+":", field[1])
+	}
+
+	if len(result.Hosts) == 0 {
+		buf.WriteString("\nNo hosts found.\n")
+		return buf.Bytes(), nil
+	}
+
+	// Port columns are aligned across hosts; the version column takes
+	// whatever width is left
+	portWidth, stateWidth, serviceWidth := len("PORT"), len("STATE"), len("SERVICE")
+	for _, host := range result.Hosts {
+		for _, port := range host.Ports {
+			portWidth = max(portWidth, len(fmt.Sprintf("%d/%s", port.Number, port.Protocol)))
+			stateWidth = max(stateWidth, len(port.State))
+			serviceWidth = max(serviceWidth, utf8.RuneCountInString(port.Service))
+		}
+	}
+	// Narrow terminals shrink the service column before the version one
+	serviceWidth = min(serviceWidth, 20)
+	versionWidth := width - 2 - portWidth - stateWidth - serviceWidth - 3
+	if versionWidth < 10 {
+		serviceWidth = max(serviceWidth-(10-versionWidth), len("SERVICE"))
+		versionWidth = max(width-2-portWidth-stateWidth-serviceWidth-3, len("VERSION"))
+	}
+
+	for _, host := range result.Hosts {
+		buf.WriteString("\n")
+		line := f.paint(host.IPAddress, ansiBold)
+		used := len(host.IPAddress)
+		if host.Hostname != "" {
+			line += " (" + host.Hostname + ")"
+			used += utf8.RuneCountInString(host.Hostname) + 3
+		}
+		line += "  " + f.paint(host.Status, hostStatusColor(host.Status))
+		used += 2 + len(host.Status)
+		// The OS gets the rest of the line
+		if room := width - used - 2; host.OS != "" && room > 0 {
+			line += "  " + truncateText(host.OS, room)
+		}
+		buf.WriteString(line + "\n")
+		if host.ScanError != "" {
+			buf.WriteString("  " + f.paint(truncateText("error: "+host.ScanError, width-2), ansiRed) + "\n")
+		}
+
+		if len(host.Ports) == 0 {
+			buf.WriteString("  no ports reported\n")
+			continue
+		}
+		fmt.Fprintf(&buf, "  %-*s %-*s %-*s %s\n", portWidth, "PORT", stateWidth, "STATE", serviceWidth, "SERVICE", "VERSION")
+		for _, port := range host.Ports {
+			version := strings.TrimSpace(port.Product + " " + port.Version)
+			if port.HitRatio > 0 {
+				// Repeated scans: how often the port answered
+				version = strings.TrimSpace(fmt.Sprintf("%s [open in %.0f%% of runs]", version, port.HitRatio*100))
+			}
+			version = truncateText(version, versionWidth)
+			row := fmt.Sprintf("  %-*s %s %-*s %s", portWidth, fmt.Sprintf("%d/%s", port.Number, port.Protocol),
+				f.paint(fmt.Sprintf("%-*s", stateWidth, port.State), portStateColor(port.State)),
+				serviceWidth, truncateText(port.Service, serviceWidth), version)
+			buf.WriteString(strings.TrimRight(row, " ") + "\n")
+		}
+	}
+
+	if len(result.Findings) > 0 {
+		fmt.Fprintf(&buf, "\nFindings (%d):\n", len(result.Findings))
+		for _, finding := range result.Findings {
+			location := finding.IPAddress
+			if finding.Port != 0 {
+				location += fmt.Sprintf(":%d/%s", finding.Port, finding.Protocol)
+			}
+			line := fmt.Sprintf("%-6s %s %s", finding.Severity, location, finding.Message)
+			buf.WriteString("  " + f.paint(truncateText(line, width-2), severityColor(finding.Severity)) + "\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (f *TextFormatter) GetMimeType() string {
+	return "text/plain"
+}
+
+func (f *TextFormatter) GetFileExtension() string {
+	return "txt"
+}
+
+// paint wraps text in an ANSI color when color is enabled
+func (f *TextFormatter) paint(text, color string) string {
+	if !f.color || color == "" || text == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// truncateText shortens text to at most width runes, marking the cut
+func truncateText(text string, width int) string {
+	if width < 1 || utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width-1]) + "…"
+}
+
+func scanStatusColor(status string) string {
+	switch status {
+	case scanner.StatusCompleted:
+		return ansiGreen
+	case scanner.StatusCompletedWithErrors, scanner.StatusTimeout:
+		return ansiYellow
+	case scanner.StatusFailed, scanner.StatusCancelled:
+		return ansiRed
+	}
+	return ansiCyan
+}
+
+func hostStatusColor(status string) string {
+	switch status {
+	case "up":
+		return ansiGreen
+	case "down":
+		return ansiRed
+	}
+	return ansiYellow
+}
+
+func portStateColor(state string) string {
+	switch state {
+	case "open":
+		return ansiGreen
+	case "closed":
+		return ansiRed
+	}
+	return ansiYellow
+}
+
+func severityColor(severity string) string {
+	switch severity {
+	case "high", "critical":
+		return ansiRed
+	case "medium":
+		return ansiYellow
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func textScanResult() *scanner.ScanResult {
+	return &scanner.ScanResult{
+		Target: "10.0.0.0/29", Scanner: "nmap", Status: scanner.StatusCompleted,
+		DurationMs: 42300, Complete: true, Coverage: 1,
+		CorrelationID: "run-42",
+		Hosts: []*models.Host{
+			{
+				IPAddress: "10.0.0.1", Hostname: "gw.example.internal", Status: "up",
+				OS: "Linux 5.0 - 5.14 (embedded, OpenWrt 21.02 and later releases)",
+				Ports: []*models.Port{
+					{Number: 22, Protocol: "tcp", State: "open", Service: "ssh", Product: "OpenSSH", Version: "8.9p1 Ubuntu 3ubuntu0.6"},
+					{Number: 53, Protocol: "udp", State: "open|filtered", Service: "domain"},
+					{Number: 8443, Protocol: "tcp", State: "open", Service: "https-alt-management-console", Product: "Jetty", Version: "9.4.51.v20230217 with an unusually long banner string"},
+				},
+			},
+			{IPAddress: "10.0.0.2", Status: "up", ScanError: "host timeout after 900s"},
+			{
+				IPAddress: "10.0.0.3", Status: "up",
+				Ports: []*models.Port{
+					{Number: 80, Protocol: "tcp", State: "open", Service: "http", Product: "nginx", HitRatio: 0.5},
+					{Number: 25, Protocol: "tcp", State: "closed", Service: "smtp"},
+				},
+			},
+		},
+		Findings: []*models.Finding{
+			{Type: "weak-ssh", Severity: "medium", IPAddress: "10.0.0.1", Port: 22, Protocol: "tcp", Message: "SSH allows password authentication"},
+			{Type: "host-timeout", Severity: "low", IPAddress: "10.0.0.2", Message: "host was not fully scanned"},
+		},
+	}
+}
+
+func TestTextFormatterGolden(t *testing.T) {
+	partial := textScanResult()
+	partial.Status = scanner.StatusTimeout
+	partial.Complete = false
+	partial.Coverage = 0.6
+	partial.Error = "scan timed out"
+
+	tests := []struct {
+		name      string
+		golden    string
+		formatter *TextFormatter
+		result    *scanner.ScanResult
+	}{
+		{"default width", "text.golden", &TextFormatter{}, textScanResult()},
+		{"narrow", "text_narrow.golden", NewTextFormatter(false, 50), textScanResult()},
+		{"color", "text_color.golden", NewTextFormatter(true, 100), textScanResult()},
+		{"incomplete", "text_incomplete.golden", &TextFormatter{}, partial},
+		{"no hosts", "text_empty.golden", &TextFormatter{}, &scanner.ScanResult{Target: "192.0.2.1", Scanner: "nmap", Status: scanner.StatusCompleted, Complete: true, Coverage: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.formatter.Format(tt.result)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			assertGolden(t, tt.golden, data)
+		})
+	}
+}
+
+func TestTextFormatterFitsWidth(t *testing.T) {
+	for _, width := range []int{50, 80, 120} {
+		data, err := NewTextFormatter(false, width).Format(textScanResult())
+		if err != nil {
+			t.Fatalf("Format() error = %v", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if n := utf8.RuneCountInString(line); n > width {
+				t.Errorf("width %d: line of %d columns: %q", width, n, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// DefaultSSHPort is used when neither the config nor --via name a port
+const DefaultSSHPort = 22
+
+// SSHConfig holds jump host connection settings
+type SSHConfig struct {
+	Host           string
+	Port           int
+	User           string
+	KeyFile        string // Private key used for authentication
+	KnownHostsFile string // known_hosts file used to verify the host key
+	Timeout        time.Duration
+}
+
+// ApplyVia overrides the host, port and user from an ssh://user@host[:port] URL
+func (c *SSHConfig) ApplyVia(via string) error {
+	u, err := url.Parse(via)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return fmt.Errorf("invalid --via %q: expected ssh://user@host[:port]", via)
+	}
+
+	c.Host = u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		c.User = u.User.Username()
+	}
+	if port := u.Port(); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid --via port %q", port)
+		}
+		c.Port = p
+	}
+
+	return nil
+}
+
+// SSHRunner runs scanner binaries on a jump host over SSH. It implements
+// scanner.CommandRunner so scanners parse remote output exactly as they
+// parse local runs.
+type SSHRunner struct {
+	client *ssh.Client
+	host   string
+
+	privilegedOnce sync.Once
+	privileged     bool
+	privilegedErr  error
+}
+
+// DialSSH connects to the jump host, verifying its key against known_hosts
+func DialSSH(config SSHConfig) (*SSHRunner, error) {
+	if config.Host == "" || config.User == "" {
+		return nil, fmt.Errorf("SSH host and user are required")
+	}
+	if config.Port == 0 {
+		config.Port = DefaultSSHPort
+	}
+
+	keyData, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key %s: %w", config.KeyFile, err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(config.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %w", err)
+	}
+
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         config.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	return &SSHRunner{client: client, host: config.Host}, nil
+}
+
+// LookPath resolves a binary on the jump host
+func (r *SSHRunner) LookPath(name string) (string, error) {
+	output, err := r.Output(context.Background(), "command", "-v", name)
+	path := strings.TrimSpace(string(output))
+	if err != nil || path == "" {
+		return "", fmt.Errorf("%s not found on %s", name, r.host)
+	}
+	return path, nil
+}
+
+// Output runs the command on the jump host and returns its standard output,
+// streaming it to the tail carried by ctx, if any. Cancelling ctx kills the
+// remote command.
+func (r *SSHRunner) Output(ctx context.Context, path string, args ...string) ([]byte, error) {
+	session, err := r.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if stdoutTail, stderrTail := scanner.TailWriter(ctx), scanner.TailWriter(ctx); stdoutTail != nil {
+		defer stdoutTail.Flush()
+		defer stderrTail.Flush()
+		session.Stdout = io.MultiWriter(&stdout, stdoutTail)
+		session.Stderr = io.MultiWriter(&stderr, stderrTail)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(shellJoin(path, args))
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		session.Close()
+		// Run returns once output already received has been copied
+		<-done
+		return stdout.Bytes(), ctx.Err()
+	}
+
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%w: %s", err, msg)
+		}
+		return stdout.Bytes(), err
+	}
+	return stdout.Bytes(), nil
+}
+
+// Privileged reports whether commands run on the jump host as root. The
+// answer is looked up once per connection.
+func (r *SSHRunner) Privileged(ctx context.Context) (bool, error) {
+	r.privilegedOnce.Do(func() {
+		output, err := r.Output(ctx, "id", "-u")
+		if err != nil {
+			r.privilegedErr = fmt.Errorf("failed to check privileges on %s: %w", r.host, err)
+			return
+		}
+		r.privileged = strings.TrimSpace(string(output)) == "0"
+	})
+	return r.privileged, r.privilegedErr
+}
+
+// Close closes the SSH connection
+func (r *SSHRunner) Close() error {
+	return r.client.Close()
+}
+
+// shellJoin quotes a command line for the remote shell
+func shellJoin(path string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, shellQuote(path))
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote single-quotes s unless it only contains safe characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:,=+@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,294 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// commandReply is what the fake jump host answers to a command
+type commandReply struct {
+	stdout, stderr string
+	status         uint32
+	hang           bool // Never finish, until the client closes the session
+}
+
+// fakeJumpHost is an SSH server that answers exec requests from a table of
+// canned replies and records the command lines it was sent
+type fakeJumpHost struct {
+	config  SSHConfig
+	replies map[string]commandReply
+
+	mu       sync.Mutex
+	commands []string
+}
+
+// newFakeJumpHost starts an SSH server trusting a fresh client key, and
+// returns a config with that key and a known_hosts file for the server
+func newFakeJumpHost(t *testing.T, replies map[string]commandReply) *fakeJumpHost {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, hostKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPublic, clientKey, _ := ed25519.GenerateKey(rand.Reader)
+	clientSSHKey, err := ssh.NewPublicKey(clientPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientSSHKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	addr := listener.Addr().(*net.TCPAddr)
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr.String())}, hostSigner.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &fakeJumpHost{
+		config: SSHConfig{
+			Host:           "127.0.0.1",
+			Port:           addr.Port,
+			User:           "scan",
+			KeyFile:        keyFile,
+			KnownHostsFile: knownHostsFile,
+			Timeout:        5 * time.Second,
+		},
+		replies: replies,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go h.serve(conn, serverConfig)
+		}
+	}()
+	return h
+}
+
+func (h *fakeJumpHost) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "sessions only")
+			continue
+		}
+		channel, sessionRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go h.session(channel, sessionRequests)
+	}
+}
+
+func (h *fakeJumpHost) session(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		var exec struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &exec); err != nil {
+			req.Reply(false, nil)
+			return
+		}
+		req.Reply(true, nil)
+
+		h.mu.Lock()
+		h.commands = append(h.commands, exec.Command)
+		h.mu.Unlock()
+
+		reply, ok := h.replies[exec.Command]
+		if !ok {
+			reply = commandReply{stderr: "sh: command not found", status: 127}
+		}
+		if reply.hang {
+			continue
+		}
+		channel.Write([]byte(reply.stdout))
+		channel.Stderr().Write([]byte(reply.stderr))
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{reply.status}))
+		return
+	}
+}
+
+// Commands returns the command lines run so far
+func (h *fakeJumpHost) Commands() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.commands...)
+}
+
+func TestSSHRunner(t *testing.T) {
+	nmapCommand := `/usr/bin/nmap -oX - --script 'ssl-*' 'it'\''s' 192.0.2.0/24`
+	host := newFakeJumpHost(t, map[string]commandReply{
+		"command -v nmap":    {stdout: "/usr/bin/nmap\n"},
+		"command -v masscan": {status: 1},
+		"id -u":              {stdout: "0\n"},
+		nmapCommand:          {stdout: "<nmaprun/>", stderr: "Starting Nmap"},
+		"/usr/bin/false":     {stdout: "partial", stderr: "permission denied\n", status: 1},
+		"/usr/bin/sleep 600": {hang: true},
+	})
+
+	runner, err := DialSSH(host.config)
+	if err != nil {
+		t.Fatalf("DialSSH() error = %v", err)
+	}
+	defer runner.Close()
+
+	if path, err := runner.LookPath("nmap"); err != nil || path != "/usr/bin/nmap" {
+		t.Errorf("LookPath(nmap) = %q, %v", path, err)
+	}
+	if _, err := runner.LookPath("masscan"); err == nil || !strings.Contains(err.Error(), "127.0.0.1") {
+		t.Errorf("LookPath(masscan) error = %v, want it not found on the jump host", err)
+	}
+
+	// Arguments reach the remote shell quoted
+	output, err := runner.Output(context.Background(), "/usr/bin/nmap", "-oX", "-", "--script", "ssl-*", "it's", "192.0.2.0/24")
+	if err != nil || string(output) != "<nmaprun/>" {
+		t.Errorf("Output() = %q, %v; want the command's stdout alone", output, err)
+	}
+
+	output, err = runner.Output(context.Background(), "/usr/bin/false")
+	if err == nil || !strings.Contains(err.Error(), "permission denied") || string(output) != "partial" {
+		t.Errorf("Output() of a failing command = %q, %v; want its stdout and stderr in the error", output, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if privileged, err := runner.Privileged(context.Background()); err != nil || !privileged {
+			t.Errorf("Privileged() = %t, %v; want true", privileged, err)
+		}
+	}
+
+	// Cancelling the context stops a command that never finishes
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := runner.Output(ctx, "/usr/bin/sleep", "600"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Output() of a cancelled command error = %v, want DeadlineExceeded", err)
+	}
+
+	idRuns := 0
+	for _, command := range host.Commands() {
+		if command == "id -u" {
+			idRuns++
+		}
+	}
+	if idRuns != 1 {
+		t.Errorf("privileges checked %d times, want once per connection (commands %q)", idRuns, host.Commands())
+	}
+}
+
+func TestDialSSHRejectsUnknownHostKey(t *testing.T) {
+	host := newFakeJumpHost(t, nil)
+	config := host.config
+	config.KnownHostsFile = filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(config.KnownHostsFile, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if runner, err := DialSSH(config); err == nil {
+		runner.Close()
+		t.Fatal("DialSSH() connected to a host missing from known_hosts")
+	}
+}
+
+func TestApplyVia(t *testing.T) {
+	tests := []struct {
+		via     string
+		want    SSHConfig
+		wantErr bool
+	}{
+		{"ssh://scan@jump.example.internal", SSHConfig{Host: "jump.example.internal", Port: 22, User: "scan"}, false},
+		{"ssh://jump.example.internal:2222", SSHConfig{Host: "jump.example.internal", Port: 2222, User: "ops"}, false},
+		{"ssh://scan@[2001:db8::1]:2200", SSHConfig{Host: "2001:db8::1", Port: 2200, User: "scan"}, false},
+		{"jump.example.internal", SSHConfig{}, true},
+		{"http://jump.example.internal", SSHConfig{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.via, func(t *testing.T) {
+			config := SSHConfig{Port: DefaultSSHPort, User: "ops"}
+			err := config.ApplyVia(tt.via)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyVia() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && config != tt.want {
+				t.Errorf("ApplyVia() = %+v, want %+v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"-sS", "-sS"},
+		{"192.0.2.0/24,198.51.100.1", "192.0.2.0/24,198.51.100.1"},
+		{"", "''"},
+		{"ssl-*", "'ssl-*'"},
+		{"a b", "'a b'"},
+		{"it's", `'it'\''s'`},
+		{"$(reboot)", "'$(reboot)'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Quote(tt.arg), func(t *testing.T) {
+			if got := shellQuote(tt.arg); got != tt.want {
+				t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
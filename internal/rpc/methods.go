@@ -0,0 +1,319 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/netrecon/toolkit/internal/analysis"
+	"github.com/netrecon/toolkit/internal/database"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Service implements the netrecon methods served over JSON-RPC
+type Service struct {
+	repo     *database.Repository
+	scanners *scanner.ScannerManager
+	logger   *logrus.Logger
+	lookupIP func(string) ([]net.IP, error)
+	inFlight *scanner.InFlight
+
+	maxDuration time.Duration
+
+	actor          string
+	defaultScanner string
+	fallback       []string
+	baseConfig     scanner.ScanConfig
+}
+
+// NewService creates the service. Scans use the nmap scanner with no
+// fallback until SetDefaults is called.
+func NewService(repo *database.Repository, scanners *scanner.ScannerManager, logger *logrus.Logger) *Service {
+	return &Service{
+		repo:           repo,
+		scanners:       scanners,
+		logger:         logger,
+		lookupIP:       net.LookupIP,
+		defaultScanner: "nmap",
+	}
+}
+
+// SetActor sets the identity recorded in the audit log for scans
+func (s *Service) SetActor(actor string) {
+	s.actor = actor
+}
+
+// SetLookupIP replaces the resolver used to refuse targets naming this
+// machine, such as a caching enrich.Resolver
+func (s *Service) SetLookupIP(lookupIP func(string) ([]net.IP, error)) {
+	s.lookupIP = lookupIP
+}
+
+// SetInFlight limits how many scans may run against one target at a time
+// across concurrent requests
+func (s *Service) SetInFlight(inFlight *scanner.InFlight) {
+	s.inFlight = inFlight
+}
+
+// SetMaxDuration bounds each scan request once it starts, cancelling the
+// scan and returning what it gathered as a partial result; 0 sets no limit
+func (s *Service) SetMaxDuration(d time.Duration) {
+	s.maxDuration = d
+}
+
+// SetDefaults sets the scanner used when a request names none, the
+// fallback order tried when it is unavailable, and the configuration
+// request settings are applied over
+func (s *Service) SetDefaults(scannerName string, fallback []string, config scanner.ScanConfig) {
+	s.defaultScanner = scannerName
+	s.fallback = fallback
+	s.baseConfig = config
+}
+
+// Register adds the service's methods to a server
+func (s *Service) Register(server *Server) {
+	server.Register("scan", s.scan)
+	server.Register("getResult", s.getResult)
+	server.Register("listTargets", s.listTargets)
+}
+
+// scanParams are the parameters of the scan method
+type scanParams struct {
+	Target         string          `json:"target"`
+	Scanner        string          `json:"scanner"`
+	Config         json.RawMessage `json:"config"` // Fields of scanner.ScanConfig overriding the defaults
+	AllowLocalhost bool            `json:"allow_localhost"`
+	CorrelationID  string          `json:"correlation_id"`
+	Metadata       models.Metadata `json:"metadata"`
+}
+
+// scan runs a scan and returns its result. The scan is audited like any
+// other; cancelling the request stops the scanner.
+func (s *Service) scan(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var params scanParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Target == "" {
+		return nil, Errorf(CodeInvalidParams, "target is required")
+	}
+	if _, err := scanner.DetectTargetType(params.Target); err != nil {
+		return nil, Errorf(CodeInvalidParams, "invalid target: %v", err)
+	}
+	if params.CorrelationID != "" {
+		if err := scanner.ValidateCorrelationID(params.CorrelationID); err != nil {
+			return nil, Errorf(CodeInvalidParams, "%v", err)
+		}
+	} else {
+		params.CorrelationID = scanner.NewCorrelationID()
+	}
+	if err := database.ValidateMetadata(params.Metadata); err != nil {
+		return nil, Errorf(CodeInvalidParams, "%v", err)
+	}
+
+	config := s.baseConfig
+	config.Options = make(map[string]string)
+	for key, value := range s.baseConfig.Options {
+		config.Options[key] = value
+	}
+	if len(params.Config) > 0 {
+		if err := json.Unmarshal(params.Config, &config); err != nil {
+			return nil, Errorf(CodeInvalidParams, "invalid config: %v", err)
+		}
+	}
+
+	if !params.AllowLocalhost {
+		local, err := scanner.LocalAddresses()
+		if err != nil {
+			s.logger.Debugf("Local target check skipped interfaces: %v", err)
+		}
+		if ip, ok := scanner.LocalTarget(params.Target, local, s.lookupIP); ok {
+			return nil, Errorf(CodeInvalidParams, "target %s is this machine (%s); set allow_localhost to scan it anyway", params.Target, ip)
+		}
+	}
+
+	exclude, err := s.exclusions(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	if err := scanner.CheckExclusions(params.Target, exclude); err != nil {
+		return nil, Errorf(CodeInvalidParams, "%v", err)
+	}
+	config.Exclude = exclude
+
+	name, fallback := params.Scanner, []string(nil)
+	if name == "" {
+		name, fallback = s.defaultScanner, s.fallback
+	}
+	selected, err := s.scanners.SelectScanner(name, fallback)
+	if err != nil {
+		return nil, Errorf(CodeInvalidParams, "%v", err)
+	}
+	if err := selected.ValidateConfig(&config); err != nil {
+		return nil, Errorf(CodeInvalidParams, "invalid scan configuration: %v", err)
+	}
+
+	// Queued requests are only audited once their scan can start
+	release, err := s.inFlight.Acquire(ctx, params.Target)
+	if err != nil {
+		if errors.Is(err, scanner.ErrTargetBusy) {
+			return nil, Errorf(CodeTargetBusy, "%v", err)
+		}
+		return nil, err
+	}
+	defer release()
+
+	if err := s.audit(params, selected.GetName(), &config); err != nil {
+		return nil, err
+	}
+
+	scanCtx, cancel := scanner.WithMaxDuration(ctx, s.maxDuration)
+	defer cancel()
+
+	result, err := scanner.Run(scanCtx, selected, params.Target, &config)
+	if result != nil && result.MarkOverrun(scanCtx) {
+		// Cut short by the watchdog: return what was gathered
+		err = nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, scanner.ErrUnprivileged) {
+			return nil, Errorf(CodeInvalidParams, "%v", err)
+		}
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	for _, notice := range result.Notices {
+		s.logger.Warnf("Scan of %s: %s", params.Target, notice)
+	}
+	result.CorrelationID = params.CorrelationID
+	result.Metadata = params.Metadata
+	return result, nil
+}
+
+// audit records the scan before it starts; without a database nothing can
+// be recorded, otherwise a scan that cannot be audited is not run
+func (s *Service) audit(params scanParams, scannerName string, config *scanner.ScanConfig) error {
+	if s.repo == nil {
+		s.logger.Debug("Scan not audited: no database connection")
+		return nil
+	}
+
+	scanConfig, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode scan configuration: %w", err)
+	}
+
+	entry := &models.AuditEntry{
+		Action:        "scan",
+		Actor:         s.actor,
+		Source:        models.AuditSourceRPC,
+		Target:        params.Target,
+		Scanner:       scannerName,
+		ScanConfig:    scanConfig,
+		CorrelationID: params.CorrelationID,
+	}
+	if err := s.repo.RecordAudit(entry); err != nil {
+		return fmt.Errorf("refusing to scan without an audit entry: %w", err)
+	}
+	return nil
+}
+
+// exclusions merges the configured and stored do-not-scan lists with the
+// entries of a request, which may add to the list but never shorten it
+func (s *Service) exclusions(requested []string) ([]string, error) {
+	lists := [][]string{s.baseConfig.Exclude, requested}
+	if s.repo != nil {
+		stored, err := s.repo.ExcludedNetworks()
+		if err != nil {
+			return nil, fmt.Errorf("cannot load the do-not-scan list: %w", err)
+		}
+		lists = append(lists, stored)
+	}
+	exclude, err := scanner.MergeExclusions(lists...)
+	if err != nil {
+		return nil, Errorf(CodeInvalidParams, "invalid config: %v", err)
+	}
+	return exclude, nil
+}
+
+// getResult returns a stored scan with its findings
+func (s *Service) getResult(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var params struct {
+		ID string `json:"id"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(params.ID)
+	if err != nil {
+		return nil, Errorf(CodeInvalidParams, "invalid scan ID: %s", params.ID)
+	}
+	repo, err := s.database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := repo.GetScanGraph(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Errorf(CodeNotFound, "scan %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scan %s: %w", id, err)
+	}
+
+	target := graph.TargetID.String()
+	if scanTarget, err := repo.GetScanTarget(graph.TargetID); err == nil {
+		target = scanTarget.Target
+	}
+
+	result := scanner.FromStored(graph, target)
+	result.Findings = analysis.AnalyzeGraph(graph)
+	return result, nil
+}
+
+// listTargets returns every stored scan target
+func (s *Service) listTargets(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	repo, err := s.database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := repo.ListScanTargets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list targets: %w", err)
+	}
+	if targets == nil {
+		targets = []*models.ScanTarget{}
+	}
+	return targets, nil
+}
+
+// database returns the repository bound to ctx, or an error when running
+// without a database
+func (s *Service) database(ctx context.Context) (*database.Repository, error) {
+	if s.repo == nil {
+		return nil, Errorf(CodeInternalError, "database connection required")
+	}
+	return s.repo.WithContext(ctx), nil
+}
+
+// decodeParams decodes by-name params; absent params decode as empty
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return Errorf(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes, plus the request-cancelled code used by LSP
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeNotFound       = -32001
+	CodeTargetBusy     = -32002 // The target's scan limit is reached and busy targets are rejected
+	CodeCancelled      = -32800
+)
+
+// CancelMethod is the notification cancelling an in-flight request by ID
+const CancelMethod = "$/cancelRequest"
+
+// Error is a JSON-RPC error object. Handlers return it to choose the code;
+// any other error is reported as an internal error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf builds an Error with the given code
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Handler serves one method. ctx is cancelled when the client cancels the
+// request or the connection ends.
+type Handler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Server speaks JSON-RPC 2.0 over a stream, one JSON message per line.
+// Requests run concurrently and responses are written as they complete.
+type Server struct {
+	handlers map[string]Handler
+}
+
+// NewServer creates a server with no methods
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]Handler)}
+}
+
+// Register adds a method
+func (s *Server) Register(method string, handler Handler) {
+	s.handlers[method] = handler
+}
+
+// Serve reads requests from r and writes responses to w until r is
+// exhausted or ctx is cancelled, then waits for running requests
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+
+	conn := &connection{
+		server:   s,
+		enc:      json.NewEncoder(w),
+		inFlight: make(map[string]context.CancelFunc),
+	}
+	defer conn.wg.Wait()
+
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lines.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := bytes.TrimSpace(lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		conn.dispatch(ctx, append([]byte(nil), line...))
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// connection is the state of one Serve call
+type connection struct {
+	server *Server
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu       sync.Mutex
+	inFlight map[string]context.CancelFunc // Request ID -> cancel
+
+	wg sync.WaitGroup
+}
+
+// dispatch handles one message: a request, a notification or a batch
+func (c *connection) dispatch(ctx context.Context, message []byte) {
+	if message[0] != '[' {
+		var req request
+		if err := json.Unmarshal(message, &req); err != nil {
+			c.write(response{ID: json.RawMessage("null"), Error: Errorf(CodeParseError, "parse error: %v", err)})
+			return
+		}
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			if resp, ok := c.handle(ctx, req); ok {
+				c.write(resp)
+			}
+		}()
+		return
+	}
+
+	var batch []request
+	if err := json.Unmarshal(message, &batch); err != nil {
+		c.write(response{ID: json.RawMessage("null"), Error: Errorf(CodeParseError, "parse error: %v", err)})
+		return
+	}
+	if len(batch) == 0 {
+		c.write(response{ID: json.RawMessage("null"), Error: Errorf(CodeInvalidRequest, "empty batch")})
+		return
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		responses := make([]*response, len(batch))
+		var wg sync.WaitGroup
+		for i, req := range batch {
+			wg.Add(1)
+			go func(i int, req request) {
+				defer wg.Done()
+				if resp, ok := c.handle(ctx, req); ok {
+					responses[i] = &resp
+				}
+			}(i, req)
+		}
+		wg.Wait()
+
+		var replies []response
+		for _, resp := range responses {
+			if resp != nil {
+				replies = append(replies, *resp)
+			}
+		}
+		if len(replies) > 0 {
+			c.write(replies)
+		}
+	}()
+}
+
+// handle runs a request and returns its response; notifications, which
+// have no ID, get none
+func (c *connection) handle(ctx context.Context, req request) (response, bool) {
+	isNotification := len(req.ID) == 0
+	resp := response{ID: req.ID}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = Errorf(CodeInvalidRequest, "invalid request")
+		if isNotification {
+			resp.ID = json.RawMessage("null")
+		}
+		return resp, true
+	}
+
+	if req.Method == CancelMethod {
+		c.cancel(req.Params)
+		return resp, false
+	}
+
+	handler, ok := c.server.handlers[req.Method]
+	if !ok {
+		resp.Error = Errorf(CodeMethodNotFound, "method not found: %s", req.Method)
+		return resp, !isNotification
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if !isNotification {
+		key := string(req.ID)
+		c.mu.Lock()
+		c.inFlight[key] = cancel
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.inFlight, key)
+			c.mu.Unlock()
+		}()
+	}
+
+	result, err := handler(ctx, req.Params)
+	var rpcErr *Error
+	switch {
+	case err == nil:
+		resp.Result = result
+		if result == nil {
+			resp.Result = json.RawMessage("null")
+		}
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		resp.Error = Errorf(CodeCancelled, "request cancelled")
+	case errors.As(err, &rpcErr):
+		resp.Error = rpcErr
+	default:
+		resp.Error = Errorf(CodeInternalError, "%v", err)
+	}
+	return resp, !isNotification
+}
+
+// cancel cancels the in-flight request named by $/cancelRequest params
+func (c *connection) cancel(params json.RawMessage) {
+	var p struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || len(p.ID) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	cancel, ok := c.inFlight[string(p.ID)]
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// write sends one response or batch of responses
+func (c *connection) write(v interface{}) {
+	if resp, ok := v.(response); ok {
+		resp.JSONRPC = "2.0"
+		v = resp
+	}
+	if replies, ok := v.([]response); ok {
+		for i := range replies {
+			replies[i].JSONRPC = "2.0"
+		}
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.enc.Encode(v)
+}
@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// Repeat runs scan n times in sequence, so the scanner's rate applies to
+// each run rather than adding up, and merges the results with Aggregate.
+// It stops early when ctx is done or a run returns no result; the runs
+// finished by then are still merged, marked incomplete. The error of the
+// last run is returned with the merged result.
+func Repeat(ctx context.Context, n int, scan func(ctx context.Context) (*ScanResult, error)) (*ScanResult, error) {
+	var (
+		results []*ScanResult
+		err     error
+	)
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		var result *ScanResult
+		result, err = scan(ctx)
+		if result == nil {
+			break
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		if err == nil {
+			err = ctx.Err()
+		}
+		return nil, err
+	}
+
+	merged := Aggregate(results)
+	if len(results) < n {
+		merged.Complete = false
+		if merged.Status == StatusCompleted {
+			merged.Status = StatusCompletedWithErrors
+		}
+		merged.Error = joinErrors(merged.Error, fmt.Sprintf("stopped after %d of %d runs", len(results), n))
+	}
+	return merged, err
+}
+
+// Aggregate merges the results of repeated scans of one target. Hosts are
+// matched by address and ports by number and protocol. A port is open when
+// any run saw it open, and its HitRatio records the fraction of runs that
+// did, since UDP and filtered ports often answer only some probes. Other
+// ports keep their state from the last run reporting them. Raw output is
+// dropped as the runs' outputs cannot be combined into one.
+func Aggregate(results []*ScanResult) *ScanResult {
+	first, last := results[0], results[len(results)-1]
+	merged := &ScanResult{
+		Target:        first.Target,
+		Scanner:       first.Scanner,
+		StartTime:     first.StartTime,
+		EndTime:       last.EndTime,
+		Command:       first.Command,
+		Complete:      true,
+		TraceID:       last.TraceID,
+		CorrelationID: first.CorrelationID,
+		Metadata:      first.Metadata,
+	}
+
+	type portTally struct {
+		port *models.Port
+		open int
+	}
+	var (
+		hosts   []*models.Host
+		byIP    = make(map[string]*models.Host)
+		tallies = make(map[string]*portTally)
+		notices = make(map[string]bool)
+		status  = first.Status
+	)
+
+	for i, result := range results {
+		merged.DurationMs += result.DurationMs
+		merged.Complete = merged.Complete && result.Complete
+		merged.Coverage = max(merged.Coverage, result.Coverage)
+		if result.Status != status {
+			status = ""
+		}
+		if result.Error != "" {
+			merged.Error = joinErrors(merged.Error, fmt.Sprintf("run %d: %s", i+1, result.Error))
+		}
+		for _, notice := range result.Notices {
+			if !notices[notice] {
+				notices[notice] = true
+				merged.Notices = append(merged.Notices, notice)
+			}
+		}
+
+		for _, host := range result.Hosts {
+			existing, ok := byIP[host.IPAddress]
+			if !ok {
+				copied := *host
+				copied.Ports = nil
+				existing = &copied
+				byIP[host.IPAddress] = existing
+				hosts = append(hosts, existing)
+			} else {
+				mergeHost(existing, host)
+			}
+
+			for _, port := range host.Ports {
+				key := fmt.Sprintf("%s %d/%s", host.IPAddress, port.Number, port.Protocol)
+				tally, ok := tallies[key]
+				if !ok {
+					copied := *port
+					tally = &portTally{port: &copied}
+					tallies[key] = tally
+					existing.Ports = append(existing.Ports, tally.port)
+				} else {
+					mergePort(tally.port, port)
+				}
+				if port.State == "open" {
+					tally.open++
+				}
+			}
+		}
+	}
+
+	for _, tally := range tallies {
+		tally.port.HitRatio = float64(tally.open) / float64(len(results))
+	}
+	merged.Hosts = hosts
+
+	switch {
+	case status != "":
+		merged.Status = status
+	case merged.Coverage > 0:
+		merged.Status = StatusCompletedWithErrors
+	default:
+		merged.Status = last.Status
+	}
+	if merged.Complete {
+		merged.Coverage = 1
+	}
+	return merged
+}
+
+// mergeHost fills details missing from a host with those another run found
+// and clears its error once a run has scanned it without one
+func mergeHost(host, other *models.Host) {
+	if other.Status == "up" {
+		host.Status = "up"
+	}
+	if host.Hostname == "" {
+		host.Hostname = other.Hostname
+	}
+	if other.OSConfidence > host.OSConfidence {
+		host.OS, host.OSConfidence, host.OSFamily = other.OS, other.OSConfidence, other.OSFamily
+	}
+	if host.MACAddress == "" {
+		host.MACAddress, host.Vendor = other.MACAddress, other.Vendor
+	}
+	if host.IPv6Address == "" {
+		host.IPv6Address = other.IPv6Address
+	}
+	// A host error stands only while no run has scanned the host cleanly
+	if host.ScanError != "" && other.ScanError == "" {
+		host.ScanError = ""
+	}
+}
+
+// mergePort folds a later sighting of a port into the merged one: an open
+// sighting wins over any other state, and service details missing so far
+// are taken from open sightings
+func mergePort(port, other *models.Port) {
+	switch {
+	case other.State == "open":
+		if port.State != "open" {
+			port.State, port.Reason, port.ReasonTTL = other.State, other.Reason, other.ReasonTTL
+		}
+		if port.Service == "" && port.Product == "" && port.Version == "" {
+			port.Service, port.Product, port.Version, port.ExtraInfo = other.Service, other.Product, other.Version, other.ExtraInfo
+		}
+		for _, vuln := range other.Vulnerabilities {
+			if !hasVulnerability(port, vuln.CVE) {
+				port.Vulnerabilities = append(port.Vulnerabilities, vuln)
+			}
+		}
+	case port.State != "open":
+		port.State, port.Reason, port.ReasonTTL = other.State, other.Reason, other.ReasonTTL
+	}
+}
+
+// hasVulnerability reports whether a port already lists a CVE
+func hasVulnerability(port *models.Port, cve string) bool {
+	for _, vuln := range port.Vulnerabilities {
+		if vuln.CVE == cve {
+			return true
+		}
+	}
+	return false
+}
+
+// joinErrors appends an error message to those collected so far
+func joinErrors(collected, message string) string {
+	if collected == "" {
+		return message
+	}
+	return strings.Join([]string{collected, message}, "; ")
+}
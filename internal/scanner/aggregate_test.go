@@ -0,0 +1,218 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+func newPort(number int, protocol, state string) *models.Port {
+	return &models.Port{Number: number, Protocol: protocol, State: state}
+}
+
+// repeatedRuns returns three runs of one target in which UDP ports and a
+// late host answer only some of the probes
+func repeatedRuns() []*ScanResult {
+	return []*ScanResult{
+		{
+			Target: "10.0.0.0/30", Scanner: "nmap", Status: StatusCompleted, DurationMs: 1000, Complete: true, Coverage: 1,
+			Hosts: []*models.Host{
+				{IPAddress: "10.0.0.1", Status: "up", OS: "Linux", OSConfidence: 80, ScanError: "host timeout", Ports: []*models.Port{
+					newPort(22, "tcp", "open"), newPort(161, "udp", "open"), newPort(53, "udp", "filtered"),
+				}},
+			},
+		},
+		{
+			Target: "10.0.0.0/30", Scanner: "nmap", Status: StatusCompleted, DurationMs: 2000, Complete: true, Coverage: 1,
+			Hosts: []*models.Host{
+				{IPAddress: "10.0.0.1", Status: "up", OS: "Linux 5.X", OSConfidence: 95, Hostname: "gw", Ports: []*models.Port{
+					newPort(22, "tcp", "open"), newPort(161, "udp", "open|filtered"), newPort(53, "udp", "open|filtered"),
+				}},
+				{IPAddress: "10.0.0.2", Status: "up", Ports: []*models.Port{newPort(80, "tcp", "open")}},
+			},
+		},
+		{
+			Target: "10.0.0.0/30", Scanner: "nmap", Status: StatusCompleted, DurationMs: 1500, Complete: true, Coverage: 1,
+			Hosts: []*models.Host{
+				{IPAddress: "10.0.0.1", Status: "up", OS: "Linux", OSConfidence: 70, Ports: []*models.Port{
+					newPort(22, "tcp", "open"), newPort(161, "udp", "open"),
+				}},
+			},
+		},
+	}
+}
+
+func TestAggregatePorts(t *testing.T) {
+	runs := repeatedRuns()
+	merged := Aggregate(runs)
+
+	if len(merged.Hosts) != 2 {
+		t.Fatalf("merged %d hosts, want 2", len(merged.Hosts))
+	}
+	want := map[string]struct {
+		state string
+		ratio float64
+	}{
+		"10.0.0.1 22/tcp":  {"open", 1},
+		"10.0.0.1 161/udp": {"open", 2.0 / 3},
+		"10.0.0.1 53/udp":  {"open|filtered", 0},
+		"10.0.0.2 80/tcp":  {"open", 1.0 / 3},
+	}
+	got := 0
+	for _, host := range merged.Hosts {
+		for _, p := range host.Ports {
+			key := host.IPAddress + " " + portKey(p)
+			w, ok := want[key]
+			if !ok {
+				t.Errorf("unexpected port %s", key)
+				continue
+			}
+			got++
+			if p.State != w.state || p.HitRatio != w.ratio {
+				t.Errorf("port %s = %s with hit ratio %.2f, want %s with %.2f", key, p.State, p.HitRatio, w.state, w.ratio)
+			}
+		}
+	}
+	if got != len(want) {
+		t.Errorf("merged %d ports, want %d", got, len(want))
+	}
+
+	// The runs themselves are left as they were
+	if runs[0].Hosts[0].Ports[1].HitRatio != 0 || runs[0].Hosts[0].Ports[2].State != "filtered" {
+		t.Error("Aggregate modified the ports of a run")
+	}
+}
+
+func TestAggregateHost(t *testing.T) {
+	host := Aggregate(repeatedRuns()).Hosts[0]
+
+	if host.OS != "Linux 5.X" || host.OSConfidence != 95 {
+		t.Errorf("OS = %q (%d), want the most confident match", host.OS, host.OSConfidence)
+	}
+	if host.Hostname != "gw" {
+		t.Errorf("Hostname = %q, want the one a later run found", host.Hostname)
+	}
+	if host.ScanError != "" {
+		t.Errorf("ScanError = %q, want it cleared by a clean run", host.ScanError)
+	}
+}
+
+func TestAggregateSummary(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []string
+		complete     []bool
+		wantStatus   string
+		wantComplete bool
+	}{
+		{"all completed", []string{StatusCompleted, StatusCompleted}, []bool{true, true}, StatusCompleted, true},
+		{"one timed out", []string{StatusCompleted, StatusTimeout}, []bool{true, false}, StatusCompletedWithErrors, false},
+		{"all timed out", []string{StatusTimeout, StatusTimeout}, []bool{false, false}, StatusTimeout, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs []*ScanResult
+			for i, status := range tt.statuses {
+				run := &ScanResult{Target: "192.0.2.1", Status: status, Complete: tt.complete[i], Coverage: 0.5, DurationMs: 100}
+				if status != StatusCompleted {
+					run.Error = "timed out"
+				}
+				runs = append(runs, run)
+			}
+
+			merged := Aggregate(runs)
+			if merged.Status != tt.wantStatus || merged.Complete != tt.wantComplete {
+				t.Errorf("status %s, complete %t; want %s, %t", merged.Status, merged.Complete, tt.wantStatus, tt.wantComplete)
+			}
+			if merged.DurationMs != int64(100*len(runs)) {
+				t.Errorf("DurationMs = %d, want the sum of the runs", merged.DurationMs)
+			}
+			if tt.wantComplete && merged.Coverage != 1 {
+				t.Errorf("Coverage = %.2f, want 1 for complete runs", merged.Coverage)
+			}
+			if !tt.wantComplete && !strings.Contains(merged.Error, "run 2: timed out") {
+				t.Errorf("Error = %q, want the failed run named", merged.Error)
+			}
+		})
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	errScan := errors.New("scanner crashed")
+
+	tests := []struct {
+		name      string
+		runs      int
+		failAt    int // Run returning no result, 0 for none
+		wantRuns  int
+		wantErr   error
+		wantShort bool
+	}{
+		{"all runs", 3, 0, 3, nil, false},
+		{"run without result", 3, 2, 1, errScan, true},
+		{"first run fails", 3, 1, 0, errScan, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			merged, err := Repeat(context.Background(), tt.runs, func(context.Context) (*ScanResult, error) {
+				calls++
+				if calls == tt.failAt {
+					return nil, errScan
+				}
+				return &ScanResult{Target: "192.0.2.1", Status: StatusCompleted, Complete: true, DurationMs: 10}, nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Repeat() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantRuns == 0 {
+				if merged != nil {
+					t.Errorf("Repeat() = %+v, want no result", merged)
+				}
+				return
+			}
+			if merged.DurationMs != int64(10*tt.wantRuns) {
+				t.Errorf("merged %d ms of runs, want %d runs", merged.DurationMs, tt.wantRuns)
+			}
+			if short := !merged.Complete; short != tt.wantShort {
+				t.Errorf("incomplete = %t, want %t", short, tt.wantShort)
+			}
+			if tt.wantShort && !strings.Contains(merged.Error, "stopped after 1 of 3 runs") {
+				t.Errorf("Error = %q, want the runs counted", merged.Error)
+			}
+		})
+	}
+}
+
+func TestRepeatStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	merged, err := Repeat(ctx, 5, func(context.Context) (*ScanResult, error) {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return &ScanResult{Target: "192.0.2.1", Status: StatusCompleted, Complete: true}, nil
+	})
+
+	if err != nil {
+		t.Errorf("Repeat() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("ran %d times after cancellation, want 2", calls)
+	}
+	if merged.Complete || merged.Status != StatusCompletedWithErrors {
+		t.Errorf("status %s, complete %t; want a partial result", merged.Status, merged.Complete)
+	}
+}
+
+func portKey(p *models.Port) string {
+	return fmt.Sprintf("%d/%s", p.Number, p.Protocol)
+}
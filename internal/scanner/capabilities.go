@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"context"
+	"regexp"
+	"sort"
+)
+
+// Capabilities describes which features a scanner supports through this toolkit
+type Capabilities struct {
+	TCP            bool `json:"tcp"`
+	UDP            bool `json:"udp"`
+	IPv6           bool `json:"ipv6"`
+	OSDetection    bool `json:"os_detection"`
+	ServiceVersion bool `json:"service_version"` // Banner grabbing and version detection
+	Scripts        bool `json:"scripts"`         // Host and port scripts such as NSE
+}
+
+// DefaultCapabilities are assumed for scanners that do not report their own:
+// a plain TCP port scan
+var DefaultCapabilities = Capabilities{TCP: true}
+
+// CapabilityReporter is implemented by scanners that describe their features
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// BinaryReporter is implemented by scanners backed by an external binary
+type BinaryReporter interface {
+	// BinaryPath returns the path of the binary that is executed
+	BinaryPath() string
+
+	// Version runs the binary to detect its version
+	Version(ctx context.Context) (string, error)
+}
+
+// ScannerInfo describes a registered scanner
+type ScannerInfo struct {
+	Name         string       `json:"name"`
+	Path         string       `json:"path,omitempty"`
+	Version      string       `json:"version,omitempty"`
+	VersionError string       `json:"version_error,omitempty"`
+	Capabilities Capabilities `json:"capabilities"`
+}
+
+// CapabilitiesOf returns the scanner's capabilities, or DefaultCapabilities
+// when it does not report any
+func CapabilitiesOf(s Scanner) Capabilities {
+	if cr, ok := s.(CapabilityReporter); ok {
+		return cr.Capabilities()
+	}
+	return DefaultCapabilities
+}
+
+// Describe gathers the name, binary, version and capabilities of a scanner.
+// A version that cannot be detected is reported in VersionError.
+func Describe(ctx context.Context, s Scanner) ScannerInfo {
+	info := ScannerInfo{
+		Name:         s.GetName(),
+		Capabilities: CapabilitiesOf(s),
+	}
+
+	if br, ok := s.(BinaryReporter); ok {
+		info.Path = br.BinaryPath()
+		version, err := br.Version(ctx)
+		if err != nil {
+			info.VersionError = err.Error()
+		}
+		info.Version = version
+	}
+
+	return info
+}
+
+// DescribeScanners describes every registered scanner, sorted by name
+func (sm *ScannerManager) DescribeScanners(ctx context.Context) []ScannerInfo {
+	names := sm.ListScanners()
+	sort.Strings(names)
+
+	infos := make([]ScannerInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, Describe(ctx, sm.scanners[name]))
+	}
+	return infos
+}
+
+var versionRegex = regexp.MustCompile(`(?i)\bversion\s+(\S+)`)
+
+// ParseVersionOutput extracts the version number from "--version" output
+// such as "Nmap version 7.94 ( https://nmap.org )"
+func ParseVersionOutput(output []byte) string {
+	if m := versionRegex.FindSubmatch(output); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// StatusForError returns the status of a scan whose process exited with an
+// error: timeout or cancelled when ctx ended it, failed otherwise
+func StatusForError(ctx context.Context) string {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return StatusTimeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		return StatusCancelled
+	default:
+		return StatusFailed
+	}
+}
+
+// MarkCompleteness flags the result complete when the scan finished cleanly
+// and sets Coverage to done/total, clamped to 0-1. Complete scans always
+// have full coverage; a zero total means the amount of work is unknown and
+// gives incomplete scans no coverage.
+func (r *ScanResult) MarkCompleteness(done, total float64) {
+	r.Complete = r.Status == StatusCompleted
+	switch {
+	case r.Complete:
+		r.Coverage = 1
+	case total <= 0 || done <= 0:
+		r.Coverage = 0
+	case done >= total:
+		r.Coverage = 1
+	default:
+		r.Coverage = done / total
+	}
+}
+
+// CompletenessFromStatus derives the completeness of a stored scan from its
+// status alone, for records written before completeness was tracked
+func CompletenessFromStatus(status string) (bool, float64) {
+	if status == StatusCompleted {
+		return true, 1
+	}
+	return false, 0
+}
+
+// TargetProgress estimates how far a scan cut short got through its target.
+// Nmap works through an IPv4 range in address order, so the highest
+// reported address gives the position reached; for other targets any
+// reported host counts as the whole target. The progress of a batch adds
+// up that of its targets.
+func TargetProgress(target string, hosts []*models.Host) (done, total float64) {
+	if targets := SplitTargets(target); len(targets) > 1 {
+		matches := MatchRequestedTargets(hosts, targets)
+		for _, t := range targets {
+			d, n := TargetProgress(t, matches[t])
+			done, total = done+d, total+n
+		}
+		return done, total
+	}
+
+	first, count, ok := ipv4Range(target)
+	if !ok {
+		if len(hosts) > 0 {
+			return 1, 1
+		}
+		return 0, 1
+	}
+
+	var furthest uint32
+	seen := false
+	for _, host := range hosts {
+		ip := net.ParseIP(host.IPAddress).To4()
+		if ip == nil {
+			continue
+		}
+		offset := binary.BigEndian.Uint32(ip) - first
+		if uint64(offset) >= count {
+			continue
+		}
+		if !seen || offset > furthest {
+			furthest, seen = offset, true
+		}
+	}
+	if !seen {
+		return 0, float64(count)
+	}
+	return float64(furthest) + 1, float64(count)
+}
+
+// ipv4Range returns the first address and size of an IPv4 CIDR or dash range
+func ipv4Range(target string) (uint32, uint64, bool) {
+	if strings.Contains(target, "/") {
+		_, ipNet, err := net.ParseCIDR(target)
+		if err != nil || ipNet.IP.To4() == nil {
+			return 0, 0, false
+		}
+		ones, bits := ipNet.Mask.Size()
+		return binary.BigEndian.Uint32(ipNet.IP.To4()), uint64(1) << uint(bits-ones), true
+	}
+
+	start, _, found := strings.Cut(target, "-")
+	startIP := net.ParseIP(start).To4()
+	if !found || startIP == nil {
+		return 0, 0, false
+	}
+	count, err := CountTargetHosts(target)
+	if err != nil {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint32(startIP), count, true
+}
@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+func TestStatusForError(t *testing.T) {
+	timedOut, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-timedOut.Done()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"deadline passed", timedOut, StatusTimeout},
+		{"cancelled", cancelled, StatusCancelled},
+		{"process failed", context.Background(), StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusForError(tt.ctx); got != tt.want {
+				t.Errorf("StatusForError() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkCompleteness(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		done, total  float64
+		wantComplete bool
+		wantCoverage float64
+	}{
+		{"completed", StatusCompleted, 1, 1, true, 1},
+		{"completed with unknown work", StatusCompleted, 0, 0, true, 1},
+		{"timeout a quarter through", StatusTimeout, 64, 256, false, 0.25},
+		{"timeout before any host", StatusTimeout, 0, 256, false, 0},
+		{"cancelled with unknown work", StatusCancelled, 3, 0, false, 0},
+		{"truncated", StatusCompletedWithErrors, 100, 400, false, 0.25},
+		{"failed", StatusFailed, 0, 1, false, 0},
+		{"progress past the total", StatusTimeout, 300, 256, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ScanResult{Status: tt.status}
+			result.MarkCompleteness(tt.done, tt.total)
+			if result.Complete != tt.wantComplete || result.Coverage != tt.wantCoverage {
+				t.Errorf("MarkCompleteness(%v, %v) = complete %t, coverage %v; want %t, %v",
+					tt.done, tt.total, result.Complete, result.Coverage, tt.wantComplete, tt.wantCoverage)
+			}
+		})
+	}
+
+	for _, status := range Statuses {
+		complete, coverage := CompletenessFromStatus(status)
+		if want := status == StatusCompleted; complete != want || (coverage == 1) != want {
+			t.Errorf("CompletenessFromStatus(%s) = %t, %v", status, complete, coverage)
+		}
+	}
+}
+
+func TestTargetProgress(t *testing.T) {
+	hosts := func(addresses ...string) []*models.Host {
+		var list []*models.Host
+		for _, address := range addresses {
+			list = append(list, &models.Host{IPAddress: address})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name      string
+		target    string
+		hosts     []*models.Host
+		wantDone  float64
+		wantTotal float64
+	}{
+		{"reached the middle of a /24", "192.0.2.0/24", hosts("192.0.2.5", "192.0.2.127"), 128, 256},
+		{"nothing reported", "192.0.2.0/24", nil, 0, 256},
+		{"hosts outside the range ignored", "192.0.2.0/30", hosts("192.0.2.1", "198.51.100.9"), 2, 4},
+		{"dash range", "192.0.2.10-19", hosts("192.0.2.14"), 5, 10},
+		{"single host reported", "scanme.example.com", hosts("192.0.2.7"), 1, 1},
+		{"single host missing", "192.0.2.7", nil, 0, 1},
+		{"batch adds up its targets", "192.0.2.0/30 192.0.2.9", hosts("192.0.2.1", "192.0.2.9"), 3, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, total := TargetProgress(tt.target, tt.hosts)
+			if done != tt.wantDone || total != tt.wantTotal {
+				t.Errorf("TargetProgress() = %v/%v, want %v/%v", done, total, tt.wantDone, tt.wantTotal)
+			}
+		})
+	}
+}
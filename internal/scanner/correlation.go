@@ -0,0 +1,32 @@
+package scanner
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxCorrelationIDLength is the longest correlation ID that can be stored
+const MaxCorrelationIDLength = 128
+
+// NewCorrelationID generates a correlation ID for callers that did not supply one
+func NewCorrelationID() string {
+	return uuid.New().String()
+}
+
+// ValidateCorrelationID checks that a caller-supplied correlation ID fits
+// the database column and contains only printable ASCII
+func ValidateCorrelationID(id string) error {
+	if id == "" {
+		return fmt.Errorf("correlation ID must not be empty")
+	}
+	if len(id) > MaxCorrelationIDLength {
+		return fmt.Errorf("correlation ID is %d characters long (max %d)", len(id), MaxCorrelationIDLength)
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return fmt.Errorf("correlation ID %q may only contain printable ASCII without spaces", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// DifferentialPlan is the port selection of a differential scan: ports seen
+// open before are scanned every run, the full range only every few runs
+type DifferentialPlan struct {
+	Ports         string // Port specification to scan
+	Full          bool   // The full requested range is scanned
+	KnownOpen     int    // Previously open ports within the requested range
+	RunsUntilFull int    // Reduced runs left before the next full scan
+}
+
+// RunsSinceFullScan counts the differential scans among results, newest
+// first, since the last complete full scan. Full scans that did not
+// complete are skipped, so a failed run never resets the cadence.
+func RunsSinceFullScan(results []*models.ScanResult) int {
+	runs := 0
+	for _, result := range results {
+		var config ScanConfig
+		if len(result.ScanConfig) > 0 {
+			if err := json.Unmarshal(result.ScanConfig, &config); err != nil {
+				continue
+			}
+		}
+		if config.Differential {
+			runs++
+			continue
+		}
+		if result.Complete {
+			break
+		}
+	}
+	return runs
+}
+
+// PlanDifferentialScan reduces a port specification to the ports of the
+// given protocol previously seen open, unless a full scan is due: every
+// fullEvery runs, or whenever no open port is known within the range. For
+// ProtocolBoth ports seen open over either protocol are kept.
+func PlanDifferentialScan(ports, protocol string, known []*models.PortCadence, runsSinceFull, fullEvery int) (DifferentialPlan, error) {
+	if fullEvery < 1 {
+		return DifferentialPlan{}, fmt.Errorf("differential scans need a full scan every 1 or more runs, got %d", fullEvery)
+	}
+
+	ranges, _, err := ParsePortRanges(ports)
+	if err != nil {
+		return DifferentialPlan{}, err
+	}
+
+	seen := make(map[int]bool)
+	var open []int
+	for _, c := range known {
+		if (protocol != ProtocolBoth && c.Protocol != protocol) || seen[c.Port] || !PortInRanges(c.Port, ranges) {
+			continue
+		}
+		seen[c.Port] = true
+		open = append(open, c.Port)
+	}
+	sort.Ints(open)
+
+	plan := DifferentialPlan{KnownOpen: len(open)}
+	if len(open) == 0 || runsSinceFull+1 >= fullEvery {
+		plan.Ports = ports
+		plan.Full = true
+		plan.RunsUntilFull = fullEvery - 1
+		return plan, nil
+	}
+
+	specs := make([]string, len(open))
+	for i, port := range open {
+		specs[i] = strconv.Itoa(port)
+	}
+	plan.Ports = strings.Join(specs, ",")
+	plan.RunsUntilFull = fullEvery - 1 - (runsSinceFull + 1)
+	return plan, nil
+}
+
+// PortInRanges reports whether port falls in one of the ranges
+func PortInRanges(port int, ranges []PortRange) bool {
+	for _, r := range ranges {
+		if port >= r.Low && port <= r.High {
+			return true
+		}
+	}
+	return false
+}
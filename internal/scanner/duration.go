@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HumanDuration renders a millisecond duration in a human-friendly form
+// such as "450ms", "12.3s" or "2h 3m 4s"
+func HumanDuration(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+
+	d := time.Duration(ms) * time.Millisecond
+	if d < time.Second {
+		return fmt.Sprintf("%dms", ms)
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+
+	hours := int64(d / time.Hour)
+	minutes := int64((d % time.Hour) / time.Minute)
+	seconds := int64((d % time.Minute) / time.Second)
+
+	var parts []string
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if hours > 0 || minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	parts = append(parts, fmt.Sprintf("%ds", seconds))
+
+	return strings.Join(parts, " ")
+}
+
+// HumanDuration returns the scan duration formatted for display
+func (r *ScanResult) HumanDuration() string {
+	return HumanDuration(r.DurationMs)
+}
+
+// SortByDuration sorts scan results from slowest to fastest
+func SortByDuration(results []*ScanResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].DurationMs > results[j].DurationMs
+	})
+}
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estimate is a rough prediction of a scan's size and duration
+type Estimate struct {
+	Hosts            uint64        `json:"hosts"`
+	Ports            int           `json:"ports"`
+	Packets          uint64        `json:"packets"`
+	PacketsPerSecond float64       `json:"packets_per_second"`
+	Duration         time.Duration `json:"duration"`
+	Command          string        `json:"command"`
+}
+
+// Estimator is implemented by scanners that can predict a scan's cost
+// without running it
+type Estimator interface {
+	Estimate(target string, config *ScanConfig) (*Estimate, error)
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	Low, High int
+}
+
+// String renders the range as a port specification
+func (r PortRange) String() string {
+	if r.Low == r.High {
+		return strconv.Itoa(r.Low)
+	}
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+// ParsePortRanges parses a port specification such as "22,80,8000-9000"
+// into sorted, merged ranges and returns the number of distinct ports
+func ParsePortRanges(ports string) ([]PortRange, int, error) {
+	var ranges []PortRange
+	for _, part := range strings.Split(ports, ",") {
+		lowStr, highStr, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		low, err := strconv.Atoi(lowStr)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid port: %s", part)
+		}
+		high := low
+		if isRange {
+			if high, err = strconv.Atoi(highStr); err != nil {
+				return nil, 0, fmt.Errorf("invalid port: %s", part)
+			}
+		}
+		if low < 0 || high > 65535 || low > high {
+			return nil, 0, fmt.Errorf("invalid port range: %s", part)
+		}
+		ranges = append(ranges, PortRange{low, high})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Low < ranges[j].Low })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Low <= last.High+1 {
+			if r.High > last.High {
+				last.High = r.High
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	total := 0
+	for _, r := range merged {
+		total += r.High - r.Low + 1
+	}
+	return merged, total, nil
+}
+
+// CountTargetHosts returns the number of addresses a target covers, adding
+// up the targets of a batch. Single IPs and domains count as one host;
+// prefixes too large for a uint64 saturate at math.MaxUint64.
+func CountTargetHosts(target string) (uint64, error) {
+	if targets := SplitTargets(target); len(targets) > 1 {
+		var total uint64
+		for _, t := range targets {
+			count, err := CountTargetHosts(t)
+			if err != nil {
+				return 0, err
+			}
+			if total += count; total < count {
+				return math.MaxUint64, nil
+			}
+		}
+		return total, nil
+	}
+
+	targetType, err := DetectTargetType(target)
+	if err != nil {
+		return 0, err
+	}
+	if targetType != TargetTypeRange {
+		return 1, nil
+	}
+
+	if strings.Contains(target, "/") {
+		_, ipNet, _ := net.ParseCIDR(target)
+		ones, bits := ipNet.Mask.Size()
+		if bits-ones >= 64 {
+			return math.MaxUint64, nil
+		}
+		return uint64(1) << uint(bits-ones), nil
+	}
+
+	start, end, _ := strings.Cut(target, "-")
+	startIP := net.ParseIP(start).To4()
+	endIP := net.ParseIP(end).To4()
+	if endIP == nil {
+		octet, _ := strconv.Atoi(end)
+		endIP = net.IPv4(startIP[0], startIP[1], startIP[2], byte(octet)).To4()
+	}
+	return uint64(binary.BigEndian.Uint32(endIP)-binary.BigEndian.Uint32(startIP)) + 1, nil
+}
+
+// EstimateDuration converts a packet count and send rate to a duration,
+// saturating rather than overflowing for enormous scans
+func EstimateDuration(packets uint64, packetsPerSecond float64) time.Duration {
+	seconds := float64(packets) / packetsPerSecond
+	if seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// saturatingMul multiplies without overflowing past math.MaxUint64
+func saturatingMul(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
+
+// EstimatePackets multiplies hosts, ports and probes per port, saturating
+// at math.MaxUint64
+func EstimatePackets(hosts uint64, ports int, probesPerPort int) uint64 {
+	return saturatingMul(saturatingMul(hosts, uint64(ports)), uint64(probesPerPort))
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCountTargetHosts(t *testing.T) {
+	tests := []struct {
+		target string
+		want   uint64
+	}{
+		{"192.0.2.0/24", 256},
+		{"192.0.2.7", 1},
+		{"scanme.example.com", 1},
+		{"192.0.2.1-10", 10},
+		{"192.0.2.250-192.0.3.5", 12},
+		{"192.0.2.0/24 198.51.100.0/30", 260},
+		{"2001:db8::/120", 256},
+		{"2001:db8::/32", math.MaxUint64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := CountTargetHosts(tt.target)
+			if err != nil {
+				t.Fatalf("CountTargetHosts() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CountTargetHosts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePortRanges(t *testing.T) {
+	tests := []struct {
+		ports      string
+		wantRanges []PortRange
+		wantTotal  int
+		wantErr    bool
+	}{
+		{"1-1000", []PortRange{{1, 1000}}, 1000, false},
+		{"443,22,80", []PortRange{{22, 22}, {80, 80}, {443, 443}}, 3, false},
+		{"1-100,50-150,151", []PortRange{{1, 151}}, 151, false},
+		{"1-65535", []PortRange{{1, 65535}}, 65535, false},
+		{"80-22", nil, 0, true},
+		{"65536", nil, 0, true},
+		{"http", nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			ranges, total, err := ParsePortRanges(tt.ports)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePortRanges() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ranges, tt.wantRanges) || total != tt.wantTotal {
+				t.Errorf("ParsePortRanges() = %v, %d; want %v, %d", ranges, total, tt.wantRanges, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestEstimatePacketsAndDuration(t *testing.T) {
+	// A /24 with 1000 ports probed once each
+	packets := EstimatePackets(256, 1000, 1)
+	if packets != 256000 {
+		t.Errorf("EstimatePackets() = %d, want 256000", packets)
+	}
+	if got := EstimateDuration(packets, 1000); got != 256*time.Second {
+		t.Errorf("EstimateDuration() at 1000 pps = %v, want 4m16s", got)
+	}
+
+	if got := EstimatePackets(math.MaxUint64, 65535, 2); got != math.MaxUint64 {
+		t.Errorf("EstimatePackets() of an enormous scan = %d, want it saturated", got)
+	}
+	if got := EstimateDuration(math.MaxUint64, 1.0/300); got != time.Duration(math.MaxInt64) {
+		t.Errorf("EstimateDuration() of an enormous scan = %v, want it saturated", got)
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// NormalizeExclusion checks that a do-not-scan entry is an IP address or
+// CIDR block and returns it as a CIDR block, so "192.0.2.1" and
+// "192.0.2.1/32" are the same entry. Host bits of a block are cleared.
+func NormalizeExclusion(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if ip := net.ParseIP(entry); ip != nil {
+		if v4 := ip.To4(); v4 != nil && !strings.Contains(entry, ":") {
+			return (&net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}).String(), nil
+		}
+		return (&net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}).String(), nil
+	}
+	_, ipNet, err := net.ParseCIDR(entry)
+	if err != nil {
+		return "", fmt.Errorf("invalid exclusion %q: must be an IP address or CIDR block", entry)
+	}
+	return ipNet.String(), nil
+}
+
+// ValidateExclusions returns an error for entries of ScanConfig.Exclude that
+// are not IP addresses or CIDR blocks
+func ValidateExclusions(exclude []string) error {
+	for _, entry := range exclude {
+		if _, err := NormalizeExclusion(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeExclusions normalizes and deduplicates exclusion lists, keeping the
+// order entries were first given in
+func MergeExclusions(lists ...[]string) ([]string, error) {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, entry := range list {
+			network, err := NormalizeExclusion(entry)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[network] {
+				seen[network] = true
+				merged = append(merged, network)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// FamilyExclusions returns the IPv6 exclusions when ipv6 is set and the
+// IPv4 ones otherwise, for scanners that handle one family per run
+func FamilyExclusions(exclude []string, ipv6 bool) []string {
+	var family []string
+	for _, entry := range exclude {
+		if IsIPv6Target(entry) == ipv6 {
+			family = append(family, entry)
+		}
+	}
+	return family
+}
+
+// FullyExcluded reports whether every address of target lies inside the
+// exclusions. IPv4 targets may be covered by several adjacent exclusions;
+// IPv6 targets must fit inside one. Hostnames are never fully excluded, as
+// the addresses they resolve to are not known here.
+func FullyExcluded(target string, exclude []string) bool {
+	if len(exclude) == 0 {
+		return false
+	}
+	if _, ok := ipv4Span(target); ok {
+		return len(subtractTargets([]string{target}, exclude)) == 0
+	}
+
+	var ipNet *net.IPNet
+	if ip := net.ParseIP(target); ip != nil {
+		ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	} else if _, parsed, err := net.ParseCIDR(target); err == nil {
+		ipNet = parsed
+	} else {
+		return false
+	}
+	ones, _ := ipNet.Mask.Size()
+	for _, entry := range exclude {
+		_, excluded, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		excludedOnes, bits := excluded.Mask.Size()
+		if bits == 128 && excludedOnes <= ones && excluded.Contains(ipNet.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckExclusions refuses a target argument when any of its targets lies
+// entirely inside the exclusions, as scanning it could only ever be blocked
+func CheckExclusions(target string, exclude []string) error {
+	for _, t := range SplitTargets(target) {
+		if FullyExcluded(t, exclude) {
+			return fmt.Errorf("target %s is on the do-not-scan list (see netrecon exclusion list)", t)
+		}
+	}
+	return nil
+}
+
+// SubtractExclusions removes the exclusions from targets, for scanners that
+// cannot skip addresses themselves. IPv4 space is subtracted exactly and
+// returned as CIDR blocks; IPv6 targets inside an exclusion are dropped and
+// hostnames are kept.
+func SubtractExclusions(targets, exclude []string) []string {
+	if len(exclude) == 0 {
+		return targets
+	}
+	var kept []string
+	for _, target := range subtractTargets(targets, exclude) {
+		if !FullyExcluded(target, exclude) {
+			kept = append(kept, target)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeExclusion(t *testing.T) {
+	tests := []struct {
+		entry   string
+		want    string
+		wantErr bool
+	}{
+		{"192.0.2.1", "192.0.2.1/32", false},
+		{" 192.0.2.1/32 ", "192.0.2.1/32", false},
+		{"192.0.2.77/24", "192.0.2.0/24", false},
+		{"2001:db8::1", "2001:db8::1/128", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"192.0.2.1-10", "", true},
+		{"gw.example.internal", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			got, err := NormalizeExclusion(tt.entry)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("NormalizeExclusion(%q) = %q, %v; want %q, error %t", tt.entry, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateExclusions([]string{"192.0.2.1", "10.0.0.0/8", "scanme.example"}); err == nil || !strings.Contains(err.Error(), "scanme.example") {
+		t.Errorf("ValidateExclusions() error = %v, want the hostname refused", err)
+	}
+}
+
+func TestMergeExclusions(t *testing.T) {
+	merged, err := MergeExclusions([]string{"192.0.2.1", "10.0.0.0/8"}, []string{"192.0.2.1/32", "2001:db8::1"})
+	if err != nil {
+		t.Fatalf("MergeExclusions() error = %v", err)
+	}
+	want := []string{"192.0.2.1/32", "10.0.0.0/8", "2001:db8::1/128"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeExclusions() = %v, want %v", merged, want)
+	}
+
+	if _, err := MergeExclusions([]string{"192.0.2.1"}, []string{"not-an-address"}); err == nil {
+		t.Error("MergeExclusions() accepted an invalid entry")
+	}
+}
+
+func TestFamilyExclusions(t *testing.T) {
+	exclude := []string{"192.0.2.1/32", "2001:db8::/32", "10.0.0.0/8"}
+
+	if got, want := FamilyExclusions(exclude, false), []string{"192.0.2.1/32", "10.0.0.0/8"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FamilyExclusions(IPv4) = %v, want %v", got, want)
+	}
+	if got, want := FamilyExclusions(exclude, true), []string{"2001:db8::/32"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FamilyExclusions(IPv6) = %v, want %v", got, want)
+	}
+}
+
+func TestFullyExcluded(t *testing.T) {
+	exclude := []string{"10.0.0.0/25", "10.0.0.128/25", "192.0.2.1/32", "2001:db8::/48"}
+
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"192.0.2.1", true},
+		{"192.0.2.2", false},
+		{"10.0.0.0/24", true}, // Covered by two adjacent blocks
+		{"10.0.0.0/23", false},
+		{"10.0.0.5-10", true},
+		{"2001:db8::1", true},
+		{"2001:db8::/64", true},
+		{"2001:db8::/32", false}, // Wider than the exclusion
+		{"gw.example.internal", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if got := FullyExcluded(tt.target, exclude); got != tt.want {
+				t.Errorf("FullyExcluded(%q) = %t, want %t", tt.target, got, tt.want)
+			}
+		})
+	}
+
+	if FullyExcluded("192.0.2.1", nil) {
+		t.Error("FullyExcluded() with no exclusions = true")
+	}
+}
+
+func TestCheckExclusions(t *testing.T) {
+	exclude := []string{"192.0.2.0/28"}
+
+	if err := CheckExclusions("192.0.2.0/24 198.51.100.1", exclude); err != nil {
+		t.Errorf("CheckExclusions() refused partly excluded targets: %v", err)
+	}
+	err := CheckExclusions("198.51.100.1 192.0.2.5", exclude)
+	if err == nil || !strings.Contains(err.Error(), "192.0.2.5") {
+		t.Errorf("CheckExclusions() error = %v, want the excluded target named", err)
+	}
+}
+
+func TestSubtractExclusions(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		exclude []string
+		want    []string
+	}{
+		{"no exclusions", []string{"192.0.2.0/24"}, nil, []string{"192.0.2.0/24"}},
+		{"hole in a block", []string{"192.0.2.0/30"}, []string{"192.0.2.1/32"}, []string{"192.0.2.0", "192.0.2.2/31"}},
+		{"whole block", []string{"192.0.2.0/30", "198.51.100.1"}, []string{"192.0.2.0/24"}, []string{"198.51.100.1"}},
+		{"excluded IPv6 dropped", []string{"2001:db8::1", "2001:db9::1"}, []string{"2001:db8::/32"}, []string{"2001:db9::1"}},
+		{"hostnames kept", []string{"gw.example.internal", "192.0.2.1"}, []string{"192.0.2.1/32"}, []string{"gw.example.internal"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SubtractExclusions(tt.targets, tt.exclude); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SubtractExclusions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// TargetExpression is the normalized form of a target expression: what to
+// scan and, when the expression names ports, which ports
+type TargetExpression struct {
+	Targets []string // IPv4 space as minimal CIDR blocks, then other targets as written
+	Ports   string   // Merged port specification, empty when no ports were given
+}
+
+// ParseTargetExpression parses a target expression such as
+//
+//	10.0.0.0/24 exclude 10.0.0.1-10 and :22,80,443
+//
+// The grammar, with items separated by spaces or commas:
+//
+//	expression = clause { "and" clause }
+//	clause     = targets [ "exclude" targets ] | ":" ports
+//	targets    = target { target }
+//	target     = IP | CIDR | dash range | hostname
+//	ports      = port or port range { port or port range }
+//
+// Exclusions apply to the whole expression, not only their clause. IPv4
+// addresses are subtracted exactly and the remainder merged into CIDR
+// blocks; IPv6 and hostname exclusions only remove identical targets.
+// Port clauses are merged into one specification.
+func ParseTargetExpression(expr string) (*TargetExpression, error) {
+	const (
+		modeInclude = iota
+		modeExclude
+		modePorts
+	)
+
+	var included, excluded, ports []string
+	mode := modeInclude
+	pending := "" // keyword still waiting for its items
+
+	for _, token := range strings.Fields(strings.ReplaceAll(expr, ",", " ")) {
+		switch strings.ToLower(token) {
+		case "and":
+			if pending != "" {
+				return nil, fmt.Errorf("%q needs targets after it", pending)
+			}
+			mode, pending = modeInclude, "and"
+			continue
+		case "exclude":
+			if pending != "" || mode != modeInclude || len(included) == 0 {
+				return nil, fmt.Errorf("\"exclude\" must follow the targets it excludes from")
+			}
+			mode, pending = modeExclude, "exclude"
+			continue
+		}
+
+		// A leading colon starts ports, unless the token is an IPv6 address
+		if rest, isPorts := strings.CutPrefix(token, ":"); isPorts && (rest == "" || portSpecRegex.MatchString(rest)) {
+			if mode == modeExclude {
+				return nil, fmt.Errorf("ports must be joined with \"and\", not excluded")
+			}
+			mode, pending = modePorts, ":"
+			if rest == "" {
+				continue
+			}
+			token = rest
+		}
+		pending = ""
+
+		switch mode {
+		case modePorts:
+			ports = append(ports, token)
+		case modeExclude:
+			excluded = append(excluded, token)
+		default:
+			included = append(included, token)
+		}
+	}
+	if pending != "" {
+		return nil, fmt.Errorf("expression ends after %q", pending)
+	}
+	if len(included) == 0 {
+		return nil, fmt.Errorf("expression has no targets")
+	}
+
+	for _, target := range append(append([]string{}, included...), excluded...) {
+		if _, err := DetectTargetType(target); err != nil {
+			return nil, fmt.Errorf("invalid target in expression: %w", err)
+		}
+	}
+
+	result := &TargetExpression{Targets: subtractTargets(included, excluded)}
+	if len(result.Targets) == 0 {
+		return nil, fmt.Errorf("expression excludes every target")
+	}
+
+	if len(ports) > 0 {
+		ranges, _, err := ParsePortRanges(strings.Join(ports, ","))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ports in expression: %w", err)
+		}
+		specs := make([]string, len(ranges))
+		for i, r := range ranges {
+			specs[i] = r.String()
+		}
+		result.Ports = strings.Join(specs, ",")
+	}
+
+	return result, nil
+}
+
+// addressSpan is an inclusive range of IPv4 addresses
+type addressSpan struct {
+	first, last uint32
+}
+
+// subtractTargets removes excluded from included, returning the remaining
+// IPv4 space as CIDR blocks followed by the other targets in order
+func subtractTargets(included, excluded []string) []string {
+	var spans, holes []addressSpan
+	var others []string
+	seen := make(map[string]bool)
+	removed := make(map[string]bool)
+
+	for _, target := range excluded {
+		if span, ok := ipv4Span(target); ok {
+			holes = append(holes, span)
+		} else {
+			removed[strings.ToLower(target)] = true
+		}
+	}
+	for _, target := range included {
+		if span, ok := ipv4Span(target); ok {
+			spans = append(spans, span)
+			continue
+		}
+		key := strings.ToLower(target)
+		if !seen[key] && !removed[key] {
+			seen[key] = true
+			others = append(others, target)
+		}
+	}
+
+	var blocks []string
+	for _, span := range subtractSpans(mergeSpans(spans), mergeSpans(holes)) {
+		blocks = append(blocks, rangeToCIDRs(span.first, span.last)...)
+	}
+	return append(blocks, others...)
+}
+
+// ipv4Span returns the addresses covered by an IPv4 address, CIDR or range
+func ipv4Span(target string) (addressSpan, bool) {
+	if ip := net.ParseIP(target).To4(); ip != nil && !strings.Contains(target, ":") {
+		v := binary.BigEndian.Uint32(ip)
+		return addressSpan{v, v}, true
+	}
+	first, count, ok := ipv4Range(target)
+	if !ok || count == 0 {
+		return addressSpan{}, false
+	}
+	return addressSpan{first, uint32(uint64(first) + count - 1)}, true
+}
+
+// mergeSpans sorts spans and joins overlapping or adjacent ones
+func mergeSpans(spans []addressSpan) []addressSpan {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].first < spans[j].first })
+
+	var merged []addressSpan
+	for _, span := range spans {
+		if n := len(merged); n > 0 && uint64(span.first) <= uint64(merged[n-1].last)+1 {
+			if span.last > merged[n-1].last {
+				merged[n-1].last = span.last
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// subtractSpans removes the merged holes from the merged spans
+func subtractSpans(spans, holes []addressSpan) []addressSpan {
+	var result []addressSpan
+	for _, span := range spans {
+		first := uint64(span.first)
+		for _, hole := range holes {
+			if uint64(hole.last) < first || hole.first > span.last {
+				continue
+			}
+			if uint64(hole.first) > first {
+				result = append(result, addressSpan{uint32(first), hole.first - 1})
+			}
+			first = uint64(hole.last) + 1
+		}
+		if first <= uint64(span.last) {
+			result = append(result, addressSpan{uint32(first), span.last})
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// GroupTargetsIntoCIDRs merges contiguous individual IPv4 addresses into the
+// smallest exact set of CIDR blocks so they can be scanned in one pass.
+// Addresses that cannot be merged stay as plain IPs; hostnames, CIDRs, ranges
+// and IPv6 addresses are passed through unchanged after the grouped blocks.
+func GroupTargetsIntoCIDRs(targets []string) []string {
+	seen := make(map[uint32]bool)
+	var addrs []uint32
+	var passthrough []string
+
+	for _, target := range targets {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		ip := net.ParseIP(target).To4()
+		if ip == nil {
+			passthrough = append(passthrough, target)
+			continue
+		}
+		v := binary.BigEndian.Uint32(ip)
+		if !seen[v] {
+			seen[v] = true
+			addrs = append(addrs, v)
+		}
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	var grouped []string
+	for i := 0; i < len(addrs); {
+		j := i
+		for j+1 < len(addrs) && addrs[j+1] == addrs[j]+1 {
+			j++
+		}
+		grouped = append(grouped, rangeToCIDRs(addrs[i], addrs[j])...)
+		i = j + 1
+	}
+
+	return append(grouped, passthrough...)
+}
+
+// MatchRequestedTargets maps discovered hosts back to the targets originally
+// requested, matching exact addresses, CIDR or range membership or hostnames. Hosts
+// that match no requested target are omitted.
+func MatchRequestedTargets(hosts []*models.Host, requested []string) map[string][]*models.Host {
+	matches := make(map[string][]*models.Host)
+
+	for _, host := range hosts {
+		ip := net.ParseIP(host.IPAddress)
+		for _, target := range requested {
+			target = strings.TrimSpace(target)
+			if targetContains(target, ip, host) {
+				matches[target] = append(matches[target], host)
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// targetContains reports whether a requested target covers a discovered host
+func targetContains(target string, ip net.IP, host *models.Host) bool {
+	if span, ok := ipv4Span(target); ok {
+		v4 := ip.To4()
+		if v4 == nil {
+			return false
+		}
+		v := binary.BigEndian.Uint32(v4)
+		return v >= span.first && v <= span.last
+	}
+	if strings.Contains(target, "/") {
+		_, ipNet, err := net.ParseCIDR(target)
+		return err == nil && ip != nil && ipNet.Contains(ip)
+	}
+	if targetIP := net.ParseIP(target); targetIP != nil {
+		return ip != nil && targetIP.Equal(ip)
+	}
+	return strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(host.Hostname, "."))
+}
+
+// SplitTargetBlocks divides an IPv4 address, CIDR or dash range into CIDR
+// blocks of at most maxHosts addresses (rounded down to a power of two), so
+// a huge target can be scanned and checkpointed a piece at a time. A zero
+// maxHosts only aligns the target into blocks. ok is false for hostnames
+// and IPv6 targets, which cannot be divided.
+func SplitTargetBlocks(target string, maxHosts uint64) (blocks []string, ok bool) {
+	span, ok := ipv4Span(target)
+	if !ok {
+		return nil, false
+	}
+
+	var size uint64
+	if maxHosts > 0 {
+		size = uint64(1) << uint(bits.Len64(maxHosts)-1)
+	}
+
+	for _, block := range rangeToCIDRs(span.first, span.last) {
+		blockSpan, _ := ipv4Span(block)
+		if size == 0 || uint64(blockSpan.last-blockSpan.first)+1 <= size {
+			blocks = append(blocks, block)
+			continue
+		}
+		// Blocks are aligned to their own size, so every piece is one CIDR
+		for first := uint64(blockSpan.first); first <= uint64(blockSpan.last); first += size {
+			blocks = append(blocks, rangeToCIDRs(uint32(first), uint32(first+size-1))...)
+		}
+	}
+	return blocks, true
+}
+
+// rangeToCIDRs converts an inclusive IPv4 range into the minimal list of aligned blocks
+func rangeToCIDRs(start, end uint32) []string {
+	var blocks []string
+
+	for start <= end {
+		// Largest block aligned at start
+		size := 32 - bits.TrailingZeros32(start)
+		if start == 0 {
+			size = 0
+		}
+		// Shrink until the block fits inside the range
+		for size < 32 && uint64(start)+(uint64(1)<<uint(32-size))-1 > uint64(end) {
+			size++
+		}
+
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, start)
+		if size == 32 {
+			blocks = append(blocks, ip.String())
+		} else {
+			blocks = append(blocks, fmt.Sprintf("%s/%d", ip, size))
+		}
+
+		next := uint64(start) + (uint64(1) << uint(32-size))
+		if next > uint64(end) {
+			break
+		}
+		start = uint32(next)
+	}
+
+	return blocks
+}
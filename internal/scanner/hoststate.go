@@ -0,0 +1,53 @@
+package scanner
+
+import "fmt"
+
+// Host states reported by nmap, all of which the hosts.status column accepts
+const (
+	HostStateUp      = "up"
+	HostStateDown    = "down"
+	HostStateUnknown = "unknown"
+	HostStateSkipped = "skipped"
+)
+
+// HostStates lists every host state a scan can report
+var HostStates = []string{
+	HostStateUp,
+	HostStateDown,
+	HostStateUnknown,
+	HostStateSkipped,
+}
+
+// DefaultReportedStates are the host states kept when ScanConfig.ReportedStates is empty
+var DefaultReportedStates = []string{HostStateUp}
+
+// ValidateReportedStates returns an error for unknown host states
+func ValidateReportedStates(states []string) error {
+	for _, state := range states {
+		known := false
+		for _, s := range HostStates {
+			if s == state {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown host state %q (must be one of up, down, unknown, skipped)", state)
+		}
+	}
+	return nil
+}
+
+// ReportsHostState reports whether hosts in state are kept in the results
+func (c *ScanConfig) ReportsHostState(state string) bool {
+	states := c.ReportedStates
+	if len(states) == 0 {
+		states = DefaultReportedStates
+	}
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package scanner
+
+import "testing"
+
+func TestReportsHostState(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []string
+		want   map[string]bool
+	}{
+		{"default", nil, map[string]bool{HostStateUp: true, HostStateDown: false, HostStateUnknown: false, HostStateSkipped: false}},
+		{"up and down", []string{HostStateUp, HostStateDown}, map[string]bool{HostStateUp: true, HostStateDown: true, HostStateUnknown: false, HostStateSkipped: false}},
+		{"down only", []string{HostStateDown}, map[string]bool{HostStateUp: false, HostStateDown: true, HostStateUnknown: false, HostStateSkipped: false}},
+		{"every state", HostStates, map[string]bool{HostStateUp: true, HostStateDown: true, HostStateUnknown: true, HostStateSkipped: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ScanConfig{ReportedStates: tt.states}
+			for state, want := range tt.want {
+				if got := config.ReportsHostState(state); got != want {
+					t.Errorf("ReportsHostState(%s) = %t, want %t", state, got, want)
+				}
+			}
+		})
+	}
+
+	if err := ValidateReportedStates([]string{HostStateUp, "asleep"}); err == nil {
+		t.Error("ValidateReportedStates() accepted an unknown state")
+	}
+	if err := ValidateReportedStates(HostStates); err != nil {
+		t.Errorf("ValidateReportedStates() error = %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// ErrTargetBusy is returned by InFlight.Acquire in reject mode when a target
+// already has as many scans running as allowed
+var ErrTargetBusy = errors.New("target is already being scanned")
+
+// Behaviours when a target has no free scan slot
+const (
+	BusyQueue  = "queue"  // Wait for a running scan of the target to finish
+	BusyReject = "reject" // Fail with ErrTargetBusy
+)
+
+// InFlight tracks the scans running in this process by normalized target,
+// limiting how many may run against one target at a time so overlapping
+// jobs do not hammer it. A nil InFlight places no limit.
+type InFlight struct {
+	limit  int
+	reject bool
+
+	mu      sync.Mutex
+	running map[string]*targetSlots
+}
+
+// targetSlots counts the scans of one target; waiters block on free until
+// one finishes
+type targetSlots struct {
+	count int
+	free  chan struct{}
+}
+
+// NewInFlight creates a registry allowing limit concurrent scans per target,
+// queuing or rejecting further ones according to busy. A limit below 1
+// returns nil, which allows any number.
+func NewInFlight(limit int, busy string) (*InFlight, error) {
+	if busy != BusyQueue && busy != BusyReject {
+		return nil, fmt.Errorf("busy target behaviour must be %s or %s, got %q", BusyQueue, BusyReject, busy)
+	}
+	if limit < 1 {
+		return nil, nil
+	}
+	return &InFlight{
+		limit:   limit,
+		reject:  busy == BusyReject,
+		running: make(map[string]*targetSlots),
+	}, nil
+}
+
+// Acquire claims a scan slot for target and returns the function releasing
+// it. When the target is busy it waits for a slot, or fails with
+// ErrTargetBusy in reject mode; a cancelled ctx stops the wait.
+func (f *InFlight) Acquire(ctx context.Context, target string) (func(), error) {
+	if f == nil {
+		return func() {}, nil
+	}
+	key := NormalizeTarget(target)
+
+	for {
+		f.mu.Lock()
+		slots, ok := f.running[key]
+		if !ok {
+			slots = &targetSlots{free: make(chan struct{})}
+			f.running[key] = slots
+		}
+		if slots.count < f.limit {
+			slots.count++
+			f.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { f.release(key, slots) }) }, nil
+		}
+		free := slots.free
+		f.mu.Unlock()
+
+		if f.reject {
+			return nil, fmt.Errorf("%w: %s (%d running)", ErrTargetBusy, target, f.limit)
+		}
+		select {
+		case <-free:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release frees a slot and wakes every waiter to compete for it
+func (f *InFlight) release(key string, slots *targetSlots) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	slots.count--
+	close(slots.free)
+	slots.free = make(chan struct{})
+	if slots.count == 0 {
+		delete(f.running, key)
+	}
+}
+
+// NormalizeTarget returns the canonical form of a target so different
+// spellings of the same one share a key: addresses in their shortest form,
+// CIDRs with host bits cleared, ranges with both ends expanded and
+// hostnames lowercased without a trailing dot. Overlapping but different
+// targets, such as a host and a CIDR holding it, stay distinct.
+func NormalizeTarget(target string) string {
+	target = strings.TrimSpace(target)
+
+	if prefix, err := netip.ParsePrefix(target); err == nil {
+		return prefix.Masked().String()
+	}
+	if addr, err := netip.ParseAddr(target); err == nil {
+		return addr.Unmap().String()
+	}
+	if start, end, found := strings.Cut(target, "-"); found {
+		if startAddr, err := netip.ParseAddr(start); err == nil {
+			// A short range like 10.0.0.1-20 ends at 10.0.0.20
+			if !strings.ContainsAny(end, ".:") && startAddr.Is4() {
+				octets := strings.Split(startAddr.String(), ".")
+				end = strings.Join(append(octets[:3], end), ".")
+			}
+			if endAddr, err := netip.ParseAddr(end); err == nil {
+				return startAddr.Unmap().String() + "-" + endAddr.Unmap().String()
+			}
+		}
+	}
+	return strings.TrimSuffix(strings.ToLower(target), ".")
+}
@@ -2,6 +2,11 @@ package scanner
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/clock"
 	"github.com/netrecon/toolkit/internal/models"
 )
 
@@ -19,42 +24,113 @@ type Scanner interface {
 
 // ScanConfig holds configuration for a scan
 type ScanConfig struct {
-	Ports     string            `json:"ports"`     // Port range (e.g., "1-1000", "80,443,8080")
-	Timing    string            `json:"timing"`    // Timing template (0-5 for nmap)
-	Arguments string            `json:"arguments"` // Additional scanner arguments
-	Output    string            `json:"output"`    // Output format
-	Timeout   int               `json:"timeout"`   // Timeout in seconds
-	Threads   int               `json:"threads"`   // Number of threads
-	Options   map[string]string `json:"options"`   // Scanner-specific options
+	Ports            string            `json:"ports"`                       // Port range (e.g., "1-1000", "80,443,8080")
+	Timing           string            `json:"timing"`                      // Timing template (0-5 for nmap)
+	Arguments        string            `json:"arguments"`                   // Additional scanner arguments
+	Output           string            `json:"output"`                      // Output format
+	Timeout          int               `json:"timeout"`                     // Timeout in seconds
+	Threads          int               `json:"threads"`                     // Number of threads
+	MaxHosts         int               `json:"max_hosts"`                   // Stop parsing after this many hosts (0 = unlimited)
+	OpenOnly         bool              `json:"open_only"`                   // Only report open ports (nmap --open)
+	ReportedStates   []string          `json:"reported_states,omitempty"`   // Host states kept in the results (empty = up only)
+	Wait             int               `json:"wait"`                        // Seconds to wait for late responses (masscan --wait, 0 = default)
+	Retries          int               `json:"retries"`                     // Probe retransmissions (masscan --retries, 0 = none)
+	Split            int               `json:"split"`                       // Parallel processes sharing the port range and rate (masscan, 0 = one)
+	AdaptiveRate     bool              `json:"adaptive_rate,omitempty"`     // Scan in bursts, ramping the rate up to Threads and backing off on loss (masscan)
+	DNSServers       []string          `json:"dns_servers,omitempty"`       // Resolvers used for target and reverse lookups (nmap --dns-servers)
+	NoDNS            bool              `json:"no_dns,omitempty"`            // Skip DNS resolution entirely (nmap -n)
+	UDP              bool              `json:"udp,omitempty"`               // Scan UDP instead of TCP (nmap -sU); kept for older configs, see Protocol
+	Protocol         string            `json:"protocol,omitempty"`          // tcp, udp or both (empty = tcp, or udp when UDP is set), see ScanProtocol
+	VersionIntensity int               `json:"version_intensity,omitempty"` // Service probe intensity 1-9 (nmap --version-intensity, 0 = default)
+	Differential     bool              `json:"differential,omitempty"`      // Ports were reduced to those previously seen open, see PlanDifferentialScan
+	CountFiltered    bool              `json:"count_filtered,omitempty"`    // Record how many ports each host filters (nmap), see models.Host.FilteredPortCount
+	Scripts          []string          `json:"scripts,omitempty"`           // NSE scripts, categories or patterns to run (nmap --script)
+	Exclude          []string          `json:"exclude,omitempty"`           // Addresses and CIDR blocks never scanned (nmap --exclude, masscan --excludefile)
+	Options          map[string]string `json:"options"`                     // Scanner-specific options
 }
 
+// DefaultOpenOnly is the OpenOnly setting of scans that do not choose one,
+// whether started from the command line or over RPC
+const DefaultOpenOnly = true
+
+// ErrMaxHostsExceeded is returned by parsers when a scan discovers more hosts
+// than ScanConfig.MaxHosts allows. The cap limits the hosts converted and
+// stored; CommandRunner.Output still buffers the scanner's entire output.
+var ErrMaxHostsExceeded = errors.New("maximum host count exceeded")
+
+// ErrTruncatedOutput is returned by parsers when scanner output ends in the
+// middle of an element, as when the scanner was killed while writing it.
+// The hosts completed before the cut are still returned.
+var ErrTruncatedOutput = errors.New("output is truncated")
+
 // ScanResult holds the results of a network scan
 type ScanResult struct {
-	Target    string         `json:"target"`
-	Scanner   string         `json:"scanner"`
-	Status    string         `json:"status"`
-	StartTime string         `json:"start_time"`
-	EndTime   string         `json:"end_time"`
-	Duration  string         `json:"duration"`
-	Hosts     []*models.Host `json:"hosts"`
-	RawOutput string         `json:"raw_output"`
-	Error     string         `json:"error,omitempty"`
+	Target        string                  `json:"target"`
+	Scanner       string                  `json:"scanner"`
+	Status        string                  `json:"status"`
+	StartTime     string                  `json:"start_time"`
+	EndTime       string                  `json:"end_time"`
+	DurationMs    int64                   `json:"duration_ms"`
+	Hosts         []*models.Host          `json:"hosts"`
+	Findings      []*models.Finding       `json:"findings,omitempty"`
+	Command       string                  `json:"command,omitempty"`
+	RawOutput     string                  `json:"raw_output"`
+	Error         string                  `json:"error,omitempty"`
+	Complete      bool                    `json:"complete"` // False when the result is partial, see MarkCompleteness
+	Coverage      float64                 `json:"coverage"` // Estimated fraction of the requested work in the result, 0-1
+	TraceID       string                  `json:"trace_id,omitempty"`
+	CorrelationID string                  `json:"correlation_id,omitempty"` // Caller-supplied key tying the scan to an external workflow
+	Notices       []string                `json:"notices,omitempty"`        // Adjustments the scanner made to the requested scan, for callers to log
+	Metadata      models.Metadata         `json:"metadata,omitempty"`       // Caller-supplied context such as environment, requester or ticket
+	Discovery     *models.DiscoveryCounts `json:"discovery,omitempty"`      // New and known hosts and ports, when the inventory was available
+}
+
+// RawParser is implemented by scanners that can rebuild hosts and ports from
+// previously stored raw output without re-running the scan. Parsing is
+// best-effort: hosts recovered before an error are returned with it.
+type RawParser interface {
+	ParseRaw(data []byte) ([]*models.HostGraph, error)
+}
+
+// ClockSetter is implemented by scanners that accept an injected clock
+type ClockSetter interface {
+	SetClock(c clock.Clock)
 }
 
 // ScannerManager manages multiple scanners
 type ScannerManager struct {
 	scanners map[string]Scanner
+	clock    clock.Clock
 }
 
 // NewScannerManager creates a new scanner manager
 func NewScannerManager() *ScannerManager {
 	return &ScannerManager{
 		scanners: make(map[string]Scanner),
+		clock:    clock.Real{},
+	}
+}
+
+// SetClock replaces the clock handed to registered scanners
+func (sm *ScannerManager) SetClock(c clock.Clock) {
+	sm.clock = c
+	for _, scanner := range sm.scanners {
+		if cs, ok := scanner.(ClockSetter); ok {
+			cs.SetClock(c)
+		}
 	}
 }
 
+// Clock returns the clock used by the manager
+func (sm *ScannerManager) Clock() clock.Clock {
+	return sm.clock
+}
+
 // RegisterScanner registers a scanner with the manager
 func (sm *ScannerManager) RegisterScanner(scanner Scanner) {
+	if cs, ok := scanner.(ClockSetter); ok {
+		cs.SetClock(sm.clock)
+	}
 	sm.scanners[scanner.GetName()] = scanner
 }
 
@@ -64,6 +140,23 @@ func (sm *ScannerManager) GetScanner(name string) (Scanner, bool) {
 	return scanner, exists
 }
 
+// SelectScanner returns the preferred scanner if it is registered, otherwise
+// the first registered scanner from the fallback order. The caller can
+// compare the returned scanner's name with preferred to detect a substitution.
+func (sm *ScannerManager) SelectScanner(preferred string, fallback []string) (Scanner, error) {
+	if scanner, exists := sm.scanners[preferred]; exists {
+		return scanner, nil
+	}
+
+	for _, name := range fallback {
+		if scanner, exists := sm.scanners[name]; exists {
+			return scanner, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no scanner available (tried %s)", strings.Join(append([]string{preferred}, fallback...), ", "))
+}
+
 // ListScanners returns all available scanner names
 func (sm *ScannerManager) ListScanners() []string {
 	var names []string
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// namedScanner is a registered scanner that only has a name
+type namedScanner string
+
+func (s namedScanner) Scan(context.Context, string, *ScanConfig) (*ScanResult, error) {
+	return &ScanResult{Scanner: string(s)}, nil
+}
+
+func (s namedScanner) GetName() string { return string(s) }
+
+func (s namedScanner) ValidateConfig(*ScanConfig) error { return nil }
+
+func TestSelectScanner(t *testing.T) {
+	tests := []struct {
+		name       string
+		registered []string
+		preferred  string
+		fallback   []string
+		want       string
+	}{
+		{"preferred available", []string{"nmap", "masscan"}, "nmap", []string{"masscan"}, "nmap"},
+		{"preferred unavailable", []string{"masscan"}, "nmap", []string{"masscan"}, "masscan"},
+		{"first available fallback", []string{"rustscan", "masscan"}, "nmap", []string{"naabu", "masscan", "rustscan"}, "masscan"},
+		{"preferred over earlier fallback", []string{"nmap", "masscan"}, "masscan", []string{"nmap"}, "masscan"},
+		{"nothing available", []string{"rustscan"}, "nmap", []string{"masscan"}, ""},
+		{"no fallback", []string{"masscan"}, "nmap", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := NewScannerManager()
+			for _, name := range tt.registered {
+				mgr.RegisterScanner(namedScanner(name))
+			}
+
+			selected, err := mgr.SelectScanner(tt.preferred, tt.fallback)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("SelectScanner() = %s, want an error", selected.GetName())
+				}
+				for _, name := range append([]string{tt.preferred}, tt.fallback...) {
+					if !strings.Contains(err.Error(), name) {
+						t.Errorf("error %q does not name %s", err, name)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectScanner() error = %v", err)
+			}
+			if got := selected.GetName(); got != tt.want {
+				t.Errorf("SelectScanner() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
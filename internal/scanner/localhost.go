@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// LocalAddresses returns the addresses assigned to this machine's interfaces
+func LocalAddresses() ([]net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interface addresses: %w", err)
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips, nil
+}
+
+// LocalTarget reports whether a target points at the machine running the
+// scanner and returns the matching address. Single addresses and hostnames
+// match when they are loopback, unspecified or one of local; hostnames are
+// resolved with resolve, and lookup failures are left for the scanner to
+// report. Ranges only match when they are entirely loopback, since a LAN
+// range that includes this machine is usually scanned on purpose.
+func LocalTarget(target string, local []net.IP, resolve func(string) ([]net.IP, error)) (net.IP, bool) {
+	target = strings.TrimSpace(target)
+
+	if strings.Contains(target, "/") {
+		_, ipNet, err := net.ParseCIDR(target)
+		if err != nil {
+			return nil, false
+		}
+		ones, _ := ipNet.Mask.Size()
+		if ipNet.IP.IsLoopback() && (ipNet.IP.To4() == nil || ones >= 8) {
+			return ipNet.IP, true
+		}
+		return nil, false
+	}
+
+	if start, end, found := strings.Cut(target, "-"); found {
+		startIP, endIP := net.ParseIP(start), net.ParseIP(end)
+		if startIP != nil && startIP.IsLoopback() && (endIP == nil || endIP.IsLoopback()) {
+			return startIP, true
+		}
+		return nil, false
+	}
+
+	if ip := net.ParseIP(target); ip != nil {
+		return ip, isLocalIP(ip, local)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(target, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return net.IPv4(127, 0, 0, 1), true
+	}
+	if resolve == nil {
+		return nil, false
+	}
+	ips, err := resolve(host)
+	if err != nil {
+		return nil, false
+	}
+	for _, ip := range ips {
+		if isLocalIP(ip, local) {
+			return ip, true
+		}
+	}
+	return nil, false
+}
+
+// isLocalIP reports whether ip is loopback, unspecified or one of local
+func isLocalIP(ip net.IP, local []net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() {
+		return true
+	}
+	for _, addr := range local {
+		if addr.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scan modes selected with scan --mode
+const (
+	ModeDefault = "default"
+	ModeVuln    = "vuln" // Runs vulnerability-detection NSE scripts, nmap only
+)
+
+// ScanModes lists the accepted scan modes
+var ScanModes = []string{ModeDefault, ModeVuln}
+
+// ModeScripts resolves a scan mode to the NSE scripts it runs: none for
+// the default mode and vulnScripts, whose findings the nmap parser stores as
+// vulnerabilities, for the vuln mode
+func ModeScripts(mode string, vulnScripts []string) ([]string, error) {
+	switch mode {
+	case "", ModeDefault:
+		return nil, nil
+	case ModeVuln:
+		if len(vulnScripts) == 0 {
+			return nil, fmt.Errorf("scan mode %s has no scripts configured (scanner.nmap.vuln_scripts)", mode)
+		}
+		return append([]string(nil), vulnScripts...), nil
+	default:
+		return nil, fmt.Errorf("unknown scan mode %q (available: %s)", mode, strings.Join(ScanModes, ", "))
+	}
+}
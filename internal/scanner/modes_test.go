@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestModeScripts(t *testing.T) {
+	configured := []string{"vulners", "http-enum", "ssl-*"}
+
+	tests := []struct {
+		mode        string
+		vulnScripts []string
+		want        []string
+		wantErr     bool
+	}{
+		{"", configured, nil, false},
+		{ModeDefault, configured, nil, false},
+		{ModeVuln, configured, configured, false},
+		{ModeVuln, nil, nil, true},
+		{"aggressive", configured, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got, err := ModeScripts(tt.mode, tt.vulnScripts)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ModeScripts(%q) = %v, %v; want %v, error %t", tt.mode, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	// The configured list is copied, not shared with the scan config
+	scripts, _ := ModeScripts(ModeVuln, configured)
+	scripts[0] = "broadcast"
+	if configured[0] != "vulners" {
+		t.Error("ModeScripts() returned the configured slice")
+	}
+}
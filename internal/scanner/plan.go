@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// TargetPorts is a target together with the ports to scan on it
+type TargetPorts struct {
+	Target string
+	Ports  string
+}
+
+// ScanBatch is one scanner invocation covering every target that shares a
+// port specification
+type ScanBatch struct {
+	Ports   string
+	Targets []string
+}
+
+// Target returns the batch's targets as the single target argument scanners
+// take, see JoinTargets
+func (b ScanBatch) Target() string {
+	return JoinTargets(b.Targets)
+}
+
+// PlanScans buckets targets by their port specification so each bucket is
+// scanned by one process instead of one per target. Specifications naming
+// the same ports share a bucket however they are written ("80,22" and
+// "22,80"); IPv6 targets get buckets of their own, as nmap cannot mix them
+// with IPv4 ones. Contiguous addresses in a bucket are merged into CIDR
+// blocks. Buckets keep the order their first target was given in.
+func PlanScans(pairs []TargetPorts) ([]ScanBatch, error) {
+	type bucketKey struct {
+		ports string
+		ipv6  bool
+	}
+	var (
+		keys    []bucketKey
+		batches = make(map[bucketKey]*ScanBatch)
+	)
+	for _, pair := range pairs {
+		key := bucketKey{ipv6: IsIPv6Target(pair.Target)}
+		// No ports leaves the choice to the scanner
+		if pair.Ports != "" {
+			ranges, _, err := ParsePortRanges(pair.Ports)
+			if err != nil {
+				return nil, err
+			}
+			specs := make([]string, len(ranges))
+			for i, r := range ranges {
+				specs[i] = r.String()
+			}
+			key.ports = strings.Join(specs, ",")
+		}
+
+		batch, ok := batches[key]
+		if !ok {
+			batch = &ScanBatch{Ports: pair.Ports}
+			batches[key] = batch
+			keys = append(keys, key)
+		}
+		batch.Targets = append(batch.Targets, pair.Target)
+	}
+
+	plan := make([]ScanBatch, 0, len(keys))
+	for _, key := range keys {
+		batch := batches[key]
+		batch.Targets = GroupTargetsIntoCIDRs(batch.Targets)
+		plan = append(plan, *batch)
+	}
+	return plan, nil
+}
+
+// JoinTargets combines several targets into one target argument, separated
+// by spaces. Scanners pass each of them to the scanner process.
+func JoinTargets(targets []string) string {
+	return strings.Join(targets, " ")
+}
+
+// SplitTargets returns the targets a target argument combines, a single one
+// unless it was built with JoinTargets
+func SplitTargets(target string) []string {
+	return strings.Fields(target)
+}
+
+// SplitResult divides the result of a batch scan into one result per
+// target, each holding the hosts MatchRequestedTargets assigns to it, so
+// they can be stored and published like separate scans. Hosts matching no
+// target, such as a hostname nmap reported under another name, go with the
+// first so none are lost. The batch's raw output cannot be divided and is
+// kept on every part. A result for a single target is returned as is.
+func SplitResult(result *ScanResult) []*ScanResult {
+	targets := SplitTargets(result.Target)
+	if len(targets) < 2 {
+		return []*ScanResult{result}
+	}
+
+	matches := MatchRequestedTargets(result.Hosts, targets)
+	matched := make(map[*models.Host]bool, len(result.Hosts))
+	for _, hosts := range matches {
+		for _, host := range hosts {
+			matched[host] = true
+		}
+	}
+	for _, host := range result.Hosts {
+		if !matched[host] {
+			matches[targets[0]] = append(matches[targets[0]], host)
+		}
+	}
+
+	parts := make([]*ScanResult, 0, len(targets))
+	for _, target := range targets {
+		part := *result
+		part.Target = target
+		part.Hosts = matches[target]
+		part.Findings = findingsFor(result.Findings, part.Hosts)
+		parts = append(parts, &part)
+	}
+	return parts
+}
+
+// findingsFor selects the findings about the given hosts
+func findingsFor(findings []*models.Finding, hosts []*models.Host) []*models.Finding {
+	addresses := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		addresses[host.IPAddress] = true
+	}
+	var selected []*models.Finding
+	for _, finding := range findings {
+		if addresses[finding.IPAddress] {
+			selected = append(selected, finding)
+		}
+	}
+	return selected
+}
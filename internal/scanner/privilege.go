@@ -0,0 +1,40 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrUnprivileged is returned when a scan needs raw sockets the user
+// running the scanner does not have
+var ErrUnprivileged = errors.New("raw socket access requires root privileges")
+
+// PrivilegeChecker is implemented by runners that can tell whether the
+// scanners they start may open raw sockets. Runners without it are assumed
+// privileged.
+type PrivilegeChecker interface {
+	Privileged(ctx context.Context) (bool, error)
+}
+
+// Privileged reports whether scanners started by runner may open raw
+// sockets, assuming they may when the runner cannot tell
+func Privileged(ctx context.Context, runner CommandRunner) bool {
+	checker, ok := runner.(PrivilegeChecker)
+	if !ok {
+		return true
+	}
+	privileged, err := checker.Privileged(ctx)
+	return err != nil || privileged
+}
+
+// Privileged reports whether this process runs as root. NMAP_PRIVILEGED
+// marks a user granted raw socket capabilities another way, as nmap
+// itself honours it. Systems without user IDs are assumed privileged.
+func (LocalRunner) Privileged(ctx context.Context) (bool, error) {
+	if os.Getenv("NMAP_PRIVILEGED") != "" {
+		return true, nil
+	}
+	uid := os.Geteuid()
+	return uid == 0 || uid == -1, nil
+}
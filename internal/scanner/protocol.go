@@ -0,0 +1,47 @@
+package scanner
+
+import "fmt"
+
+// Transport protocols a scan covers, see ScanConfig.Protocol
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+	ProtocolBoth = "both"
+)
+
+// ValidateProtocol returns an error for an unknown ScanConfig.Protocol, or
+// one contradicting the older UDP flag
+func (c *ScanConfig) ValidateProtocol() error {
+	switch c.Protocol {
+	case "", ProtocolTCP, ProtocolUDP, ProtocolBoth:
+	default:
+		return fmt.Errorf("unknown protocol %q (must be tcp, udp or both)", c.Protocol)
+	}
+	if c.UDP && c.Protocol == ProtocolTCP {
+		return fmt.Errorf("UDP scanning cannot be combined with protocol tcp")
+	}
+	return nil
+}
+
+// ScanProtocol returns the protocol scanned: Protocol when set, otherwise
+// udp for configs that only set the older UDP flag and tcp by default
+func (c *ScanConfig) ScanProtocol() string {
+	switch {
+	case c.Protocol != "":
+		return c.Protocol
+	case c.UDP:
+		return ProtocolUDP
+	default:
+		return ProtocolTCP
+	}
+}
+
+// ScansTCP reports whether TCP ports are scanned
+func (c *ScanConfig) ScansTCP() bool {
+	return c.ScanProtocol() != ProtocolUDP
+}
+
+// ScansUDP reports whether UDP ports are scanned
+func (c *ScanConfig) ScansUDP() bool {
+	return c.ScanProtocol() != ProtocolTCP
+}
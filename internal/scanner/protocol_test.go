@@ -0,0 +1,42 @@
+package scanner
+
+import "testing"
+
+func TestScanProtocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ScanConfig
+		want    string
+		wantTCP bool
+		wantUDP bool
+		wantErr bool
+	}{
+		{"default", ScanConfig{}, ProtocolTCP, true, false, false},
+		{"tcp", ScanConfig{Protocol: ProtocolTCP}, ProtocolTCP, true, false, false},
+		{"udp", ScanConfig{Protocol: ProtocolUDP}, ProtocolUDP, false, true, false},
+		{"both", ScanConfig{Protocol: ProtocolBoth}, ProtocolBoth, true, true, false},
+		{"older UDP flag", ScanConfig{UDP: true}, ProtocolUDP, false, true, false},
+		{"UDP flag with udp", ScanConfig{UDP: true, Protocol: ProtocolUDP}, ProtocolUDP, false, true, false},
+		{"UDP flag with both", ScanConfig{UDP: true, Protocol: ProtocolBoth}, ProtocolBoth, true, true, false},
+		{"UDP flag with tcp", ScanConfig{UDP: true, Protocol: ProtocolTCP}, ProtocolTCP, true, false, true},
+		{"unknown protocol", ScanConfig{Protocol: "sctp"}, "sctp", true, true, true},
+		{"protocol names are lower case", ScanConfig{Protocol: "UDP"}, "UDP", true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.ValidateProtocol(); (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateProtocol() error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := tt.config.ScanProtocol(); got != tt.want {
+				t.Errorf("ScanProtocol() = %s, want %s", got, tt.want)
+			}
+			if tt.config.ScansTCP() != tt.wantTCP || tt.config.ScansUDP() != tt.wantUDP {
+				t.Errorf("ScansTCP() = %t, ScansUDP() = %t; want %t, %t", tt.config.ScansTCP(), tt.config.ScansUDP(), tt.wantTCP, tt.wantUDP)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// CommandRunner runs scanner binaries, either locally or on a remote host
+type CommandRunner interface {
+	// LookPath resolves a binary name to the path that will be executed
+	LookPath(name string) (string, error)
+
+	// Output runs the command and returns its standard output. When ctx
+	// carries a tail (WithTail), stdout and stderr are also streamed to it
+	// while the command runs.
+	Output(ctx context.Context, path string, args ...string) ([]byte, error)
+}
+
+// LocalRunner runs commands on the local machine
+type LocalRunner struct{}
+
+// LookPath searches the local PATH
+func (LocalRunner) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// Output runs the command locally
+func (LocalRunner) Output(ctx context.Context, path string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+
+	stdoutTail, stderrTail := TailWriter(ctx), TailWriter(ctx)
+	if stdoutTail == nil {
+		return cmd.Output()
+	}
+	defer stdoutTail.Flush()
+	defer stderrTail.Flush()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&stdout, stdoutTail)
+	cmd.Stderr = io.MultiWriter(&stderr, stderrTail)
+
+	err := cmd.Run()
+	// Keep the stderr Output would have attached for error messages
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
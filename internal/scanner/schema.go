@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"fmt"
+
+	"github.com/netrecon/toolkit/internal/analysis"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// UpgradeScan brings a scan stored under an older models.SchemaVersion up
+// to the current one, filling in the fields older versions did not record.
+// Unversioned results may lack completeness, which is then derived from the
+// status as for records older than it. Scans from a newer version are left
+// as they are, see CheckSchemaVersion.
+func UpgradeScan(scan *models.ScanResult) {
+	if scan.SchemaVersion >= models.SchemaVersion {
+		return
+	}
+	if scan.SchemaVersion < 1 && !scan.Complete && scan.Coverage == 0 {
+		scan.Complete, scan.Coverage = CompletenessFromStatus(scan.Status)
+	}
+	scan.SchemaVersion = models.SchemaVersion
+}
+
+// UpgradeStored upgrades a scan graph and its hosts with UpgradeScan. Hosts
+// of unversioned results may lack an OS family, which is classified from
+// their OS.
+func UpgradeStored(graph *models.FullScanResult) error {
+	if err := CheckSchemaVersion(graph.ScanResult); err != nil {
+		return err
+	}
+	if graph.SchemaVersion < 1 {
+		for _, hg := range graph.Hosts {
+			if hg.Host != nil && hg.Host.OSFamily == "" {
+				hg.Host.OSFamily = analysis.ClassifyOS(hg.Host.OS)
+			}
+		}
+	}
+	UpgradeScan(graph.ScanResult)
+	return nil
+}
+
+// CheckSchemaVersion rejects scans written by a newer release, whose fields
+// this one would silently drop when storing them again
+func CheckSchemaVersion(scan *models.ScanResult) error {
+	if scan.SchemaVersion > models.SchemaVersion {
+		return fmt.Errorf("scan %s uses schema version %d, newer than the supported %d; upgrade netrecon to load it",
+			scan.ID, scan.SchemaVersion, models.SchemaVersion)
+	}
+	return nil
+}
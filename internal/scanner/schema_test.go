@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// unversionedScan is a scan graph as written before schema versions,
+// completeness and OS families were recorded
+const unversionedScan = `{
+  "id": "0b9f6c1e-4a8e-4d2a-9c55-3f1f2b6f7a10",
+  "target_id": "5d2f0c3a-9b7e-4f61-8a0e-2c4d6e8f1a2b",
+  "scan_type": "nmap",
+  "status": "completed",
+  "start_time": "2023-05-02T10:00:00Z",
+  "duration_ms": 42000,
+  "raw_output": "<nmaprun/>",
+  "hosts": [
+    {"ip_address": "192.0.2.10", "status": "up", "os": "Linux 4.15 - 5.6", "ports": [
+      {"number": 22, "protocol": "tcp", "state": "open", "service": "ssh", "vulnerabilities": null}
+    ]},
+    {"ip_address": "192.0.2.11", "status": "up", "os": "", "ports": []}
+  ]
+}`
+
+func TestUpgradeStoredUnversioned(t *testing.T) {
+	var graph models.FullScanResult
+	if err := json.Unmarshal([]byte(unversionedScan), &graph); err != nil {
+		t.Fatalf("unversioned scan does not decode: %v", err)
+	}
+	if err := UpgradeStored(&graph); err != nil {
+		t.Fatalf("UpgradeStored() error = %v", err)
+	}
+
+	if graph.SchemaVersion != models.SchemaVersion {
+		t.Errorf("schema version = %d, want %d", graph.SchemaVersion, models.SchemaVersion)
+	}
+	if !graph.Complete || graph.Coverage != 1 {
+		t.Errorf("completed scan upgraded to complete=%t coverage=%v, want complete", graph.Complete, graph.Coverage)
+	}
+	if len(graph.Hosts) != 2 || len(graph.Hosts[0].Ports) != 1 || graph.Hosts[0].Ports[0].Service != "ssh" {
+		t.Fatalf("hosts not loaded: %+v", graph.Hosts)
+	}
+	if got := graph.Hosts[0].OSFamily; got != models.OSFamilyLinux {
+		t.Errorf("OS family = %q, want %q", got, models.OSFamilyLinux)
+	}
+	if got := graph.Hosts[1].OSFamily; got != models.OSFamilyUnknown {
+		t.Errorf("OS family without an OS = %q, want %q", got, models.OSFamilyUnknown)
+	}
+}
+
+func TestUpgradeScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		scan         models.ScanResult
+		wantComplete bool
+		wantCoverage float64
+	}{
+		{"unversioned completed", models.ScanResult{Status: StatusCompleted}, true, 1},
+		{"unversioned timeout", models.ScanResult{Status: StatusTimeout}, false, 0},
+		{"unversioned with coverage", models.ScanResult{Status: StatusTimeout, Coverage: 0.4}, false, 0.4},
+		{"current version", models.ScanResult{Status: StatusCompleted, SchemaVersion: models.SchemaVersion}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := tt.scan
+			UpgradeScan(&scan)
+			if scan.Complete != tt.wantComplete || scan.Coverage != tt.wantCoverage || scan.SchemaVersion != models.SchemaVersion {
+				t.Errorf("UpgradeScan() = complete=%t coverage=%v version %d, want complete=%t coverage=%v version %d",
+					scan.Complete, scan.Coverage, scan.SchemaVersion, tt.wantComplete, tt.wantCoverage, models.SchemaVersion)
+			}
+		})
+	}
+
+	newer := &models.FullScanResult{ScanResult: &models.ScanResult{Status: StatusCompleted, SchemaVersion: models.SchemaVersion + 1}}
+	if err := UpgradeStored(newer); err == nil {
+		t.Error("UpgradeStored() accepted a scan from a newer schema version")
+	}
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ServiceGroups maps service categories to the ports they are commonly served on
+var ServiceGroups = map[string][]int{
+	"web":           {80, 443, 591, 3000, 5000, 8000, 8008, 8080, 8081, 8443, 8888, 9443},
+	"db":            {1433, 1521, 3306, 5432, 5984, 6379, 7474, 9042, 9200, 11211, 27017},
+	"mail":          {25, 110, 143, 465, 587, 993, 995},
+	"remote-access": {22, 23, 512, 513, 514, 3389, 5800, 5900, 5985, 5986},
+	"file-sharing":  {20, 21, 69, 111, 137, 138, 139, 445, 873, 2049},
+}
+
+// ServiceGroupNames returns the service group names in alphabetical order
+func ServiceGroupNames() []string {
+	names := make([]string, 0, len(ServiceGroups))
+	for name := range ServiceGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServiceGroupPorts returns the sorted, de-duplicated ports of the named groups
+func ServiceGroupPorts(groups []string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, group := range groups {
+		groupPorts, ok := ServiceGroups[strings.TrimSpace(group)]
+		if !ok {
+			return nil, fmt.Errorf("unknown service group %q (available: %s)", group, strings.Join(ServiceGroupNames(), ", "))
+		}
+		for _, port := range groupPorts {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// CombineServiceGroups merges the ports of the named groups into a port
+// specification, which may be empty, and returns the merged specification
+func CombineServiceGroups(ports string, groups []string) (string, error) {
+	groupPorts, err := ServiceGroupPorts(groups)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(groupPorts)+1)
+	if ports != "" {
+		parts = append(parts, ports)
+	}
+	for _, port := range groupPorts {
+		parts = append(parts, strconv.Itoa(port))
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	ranges, _, err := ParsePortRanges(strings.Join(parts, ","))
+	if err != nil {
+		return "", err
+	}
+
+	specs := make([]string, len(ranges))
+	for i, r := range ranges {
+		specs[i] = r.String()
+	}
+	return strings.Join(specs, ","), nil
+}
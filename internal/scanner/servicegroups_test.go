@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServiceGroupPorts(t *testing.T) {
+	web, err := ServiceGroupPorts([]string{"web"})
+	if err != nil {
+		t.Fatalf("ServiceGroupPorts(web) error = %v", err)
+	}
+	for _, port := range []int{80, 443, 8080, 8443} {
+		if !slices.Contains(web, port) {
+			t.Errorf("web group %v lacks port %d", web, port)
+		}
+	}
+	if !slices.IsSorted(web) {
+		t.Errorf("web group %v is not sorted", web)
+	}
+
+	tests := []struct {
+		name    string
+		groups  []string
+		want    []int
+		wantErr bool
+	}{
+		{"mail", []string{"mail"}, []int{25, 110, 143, 465, 587, 993, 995}, false},
+		{"spaces trimmed", []string{" mail "}, []int{25, 110, 143, 465, 587, 993, 995}, false},
+		{"overlapping groups merged", []string{"file-sharing", "remote-access"},
+			[]int{20, 21, 22, 23, 69, 111, 137, 138, 139, 445, 512, 513, 514, 873, 2049, 3389, 5800, 5900, 5985, 5986}, false},
+		{"group repeated", []string{"mail", "mail"}, []int{25, 110, 143, 465, 587, 993, 995}, false},
+		{"unknown group", []string{"web", "games"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServiceGroupPorts(tt.groups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServiceGroupPorts() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ServiceGroupPorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Every group is listed, and no group is empty
+	if names := ServiceGroupNames(); !reflect.DeepEqual(names, []string{"db", "file-sharing", "mail", "remote-access", "web"}) {
+		t.Errorf("ServiceGroupNames() = %v", names)
+	}
+	for name, ports := range ServiceGroups {
+		if len(ports) == 0 {
+			t.Errorf("service group %s has no ports", name)
+		}
+	}
+}
+
+func TestCombineServiceGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   string
+		groups  []string
+		want    string
+		wantErr string
+	}{
+		{"groups only", "", []string{"mail"}, "25,110,143,465,587,993,995", ""},
+		{"explicit ports added", "22,3306", []string{"web"}, "22,80,443,591,3000,3306,5000,8000,8008,8080-8081,8443,8888,9443", ""},
+		{"range absorbs group ports", "1-1024", []string{"web"}, "1-1024,3000,5000,8000,8008,8080-8081,8443,8888,9443", ""},
+		{"no ports or groups", "", nil, "", ""},
+		{"unknown group", "22", []string{"games"}, "", "available: db, file-sharing, mail, remote-access, web"},
+		{"invalid ports", "http", []string{"web"}, "", "invalid port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CombineServiceGroups(tt.ports, tt.groups)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CombineServiceGroups() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CombineServiceGroups() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
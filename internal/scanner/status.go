@@ -0,0 +1,46 @@
+package scanner
+
+import "fmt"
+
+// Scan statuses, matching the scan_results.status column
+const (
+	StatusRunning             = "running"
+	StatusCompleted           = "completed"
+	StatusCompletedWithErrors = "completed_with_errors"
+	StatusFailed              = "failed"
+	StatusTimeout             = "timeout"
+	StatusCancelled           = "cancelled"
+)
+
+// Statuses lists every valid scan status
+var Statuses = []string{
+	StatusRunning,
+	StatusCompleted,
+	StatusCompletedWithErrors,
+	StatusFailed,
+	StatusTimeout,
+	StatusCancelled,
+}
+
+// IsValidStatus reports whether status is a known scan status
+func IsValidStatus(status string) bool {
+	for _, s := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateStatus returns an error for unknown scan statuses
+func ValidateStatus(status string) error {
+	if !IsValidStatus(status) {
+		return fmt.Errorf("unknown scan status %q", status)
+	}
+	return nil
+}
+
+// IsFinished reports whether a scan with the given status has stopped running
+func IsFinished(status string) bool {
+	return IsValidStatus(status) && status != StatusRunning
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var statusCheckRegex = regexp.MustCompile(`scan_results_status_check\s+CHECK \(status IN \(([^)]*)\)\)`)
+
+func TestStatusesMatchSchema(t *testing.T) {
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(migrations)
+
+	// The latest migration adding the constraint defines the column's values
+	var allowed []string
+	for _, path := range migrations {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := statusCheckRegex.FindSubmatch(data); m != nil {
+			allowed = nil
+			for _, value := range strings.Split(string(m[1]), ",") {
+				allowed = append(allowed, strings.Trim(strings.TrimSpace(value), "'"))
+			}
+		}
+	}
+	if allowed == nil {
+		t.Fatal("no migration defines scan_results_status_check")
+	}
+
+	got := append([]string(nil), Statuses...)
+	sort.Strings(got)
+	sort.Strings(allowed)
+	if !reflect.DeepEqual(got, allowed) {
+		t.Errorf("Statuses = %q, want the schema's %q", got, allowed)
+	}
+}
+
+// TestNoStrayStatusStrings fails when code outside this file spells a scan
+// status as a string literal instead of using the Status constants
+func TestNoStrayStatusStrings(t *testing.T) {
+	statuses := make(map[string]bool)
+	for _, s := range Statuses {
+		statuses[s] = true
+	}
+
+	root := filepath.Join("..", "..")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); name == "testdata" || name == "vendor" || (strings.HasPrefix(name, ".") && path != root) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || path == filepath.Join(root, "internal", "scanner", "status.go") {
+			return nil
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			// Other vocabularies that share a word, such as campaign shard
+			// states, are named constants of their own
+			if decl, ok := n.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+				return false
+			}
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			if value, err := strconv.Unquote(lit.Value); err == nil && statuses[value] {
+				t.Errorf("%s: scan status %s spelled as a literal", fset.Position(lit.Pos()), lit.Value)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusHelpers(t *testing.T) {
+	tests := []struct {
+		status   string
+		valid    bool
+		finished bool
+	}{
+		{StatusRunning, true, false},
+		{StatusCompleted, true, true},
+		{StatusCompletedWithErrors, true, true},
+		{StatusFailed, true, true},
+		{StatusTimeout, true, true},
+		{StatusCancelled, true, true},
+		{"", false, false},
+		{"complete", false, false},
+		{"Completed", false, false},
+		{"pending", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Quote(tt.status), func(t *testing.T) {
+			if got := IsValidStatus(tt.status); got != tt.valid {
+				t.Errorf("IsValidStatus() = %t, want %t", got, tt.valid)
+			}
+			if err := ValidateStatus(tt.status); (err == nil) != tt.valid {
+				t.Errorf("ValidateStatus() error = %v, want error %t", err, !tt.valid)
+			}
+			if got := IsFinished(tt.status); got != tt.finished {
+				t.Errorf("IsFinished() = %t, want %t", got, tt.finished)
+			}
+		})
+	}
+}
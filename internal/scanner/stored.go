@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// FromStored rebuilds a ScanResult from a scan graph loaded from the database
+// so stored scans can be rendered with the same formatters as live ones
+func FromStored(graph *models.FullScanResult, target string) *ScanResult {
+	result := &ScanResult{
+		Target:        target,
+		Scanner:       graph.ScanType,
+		Status:        graph.Status,
+		StartTime:     graph.StartTime.Format(time.RFC3339),
+		DurationMs:    graph.DurationMs,
+		RawOutput:     graph.RawOutput,
+		Complete:      graph.Complete,
+		Coverage:      graph.Coverage,
+		TraceID:       graph.TraceID,
+		CorrelationID: graph.CorrelationID,
+		Metadata:      graph.Metadata,
+	}
+	if graph.EndTime != nil {
+		result.EndTime = graph.EndTime.Format(time.RFC3339)
+	}
+
+	for _, hg := range graph.Hosts {
+		hg.Host.Ports = nil
+		for _, port := range hg.Ports {
+			port.Port.Vulnerabilities = port.Vulnerabilities
+			hg.Host.Ports = append(hg.Host.Ports, port.Port)
+		}
+		result.Hosts = append(result.Hosts, hg.Host)
+	}
+
+	return result
+}
+
+// ToStored converts a live ScanResult into the scan graph stored for the
+// target, the reverse of FromStored. The start and end times fall back to
+// now when the scanner did not report them.
+func ToStored(result *ScanResult, targetID uuid.UUID, now time.Time) *models.FullScanResult {
+	start, err := time.Parse(time.RFC3339, result.StartTime)
+	if err != nil {
+		start = now
+	}
+	end, err := time.Parse(time.RFC3339, result.EndTime)
+	if err != nil {
+		end = now
+	}
+
+	graph := &models.FullScanResult{
+		ScanResult: &models.ScanResult{
+			TargetID:      targetID,
+			ScanType:      result.Scanner,
+			Status:        result.Status,
+			StartTime:     start,
+			EndTime:       &end,
+			DurationMs:    result.DurationMs,
+			RawOutput:     result.RawOutput,
+			Complete:      result.Complete,
+			Coverage:      result.Coverage,
+			TraceID:       result.TraceID,
+			CorrelationID: result.CorrelationID,
+			Metadata:      result.Metadata,
+			SchemaVersion: models.SchemaVersion,
+		},
+	}
+
+	for _, host := range result.Hosts {
+		hg := &models.HostGraph{Host: host}
+		for _, port := range host.Ports {
+			hg.Ports = append(hg.Ports, &models.PortGraph{Port: port, Vulnerabilities: port.Vulnerabilities})
+		}
+		graph.Hosts = append(graph.Hosts, hg)
+	}
+
+	return graph
+}
+
+// rawContentTypes are the media types of each scanner's native output:
+// nmap writes an XML document, masscan and naabu one JSON object per line
+var rawContentTypes = map[string]string{
+	"nmap":    "application/xml",
+	"masscan": "application/x-ndjson",
+	"naabu":   "application/x-ndjson",
+}
+
+// RawContentType returns the media type of the raw output stored for scans
+// of the given type, plain text for scanners with no known format
+func RawContentType(scanType string) string {
+	if contentType, ok := rawContentTypes[scanType]; ok {
+		return contentType
+	}
+	return "text/plain; charset=utf-8"
+}
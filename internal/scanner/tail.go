@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+type tailKey struct{}
+
+// tail is the destination set with WithTail, shared by every process of a scan
+type tail struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithTail returns a context under which runners copy the scanner's stdout
+// and stderr to w as it is produced, while still returning the full output
+func WithTail(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, tailKey{}, &tail{w: w})
+}
+
+// TailWriter returns a writer copying one output stream of a process to the
+// tail set with WithTail, or nil when the scan is not tailed. Output is
+// written a line at a time so processes running side by side do not
+// interleave mid-line; call Flush when the process exits.
+func TailWriter(ctx context.Context) *LineWriter {
+	t, _ := ctx.Value(tailKey{}).(*tail)
+	if t == nil {
+		return nil
+	}
+	return &LineWriter{tail: t}
+}
+
+// LineWriter buffers a stream and forwards it to a shared tail in whole
+// lines. Carriage returns end a line too, since scanners redraw progress
+// lines with them.
+type LineWriter struct {
+	tail *tail
+	buf  []byte
+}
+
+// Write forwards every complete line in p and keeps the remainder
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	if end := bytes.LastIndexAny(l.buf, "\r\n"); end >= 0 {
+		l.forward(l.buf[:end+1])
+		l.buf = append(l.buf[:0], l.buf[end+1:]...)
+	}
+	return len(p), nil
+}
+
+// Flush forwards a final line without a line ending
+func (l *LineWriter) Flush() {
+	if len(l.buf) > 0 {
+		l.forward(append(l.buf, '\n'))
+		l.buf = l.buf[:0]
+	}
+}
+
+// forward writes to the tail; failures to display output never fail a scan
+func (l *LineWriter) forward(p []byte) {
+	l.tail.mu.Lock()
+	defer l.tail.mu.Unlock()
+	_, _ = l.tail.w.Write(p)
+}
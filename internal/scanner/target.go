@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Target types, matching the scan_targets.type column
+const (
+	TargetTypeIP     = "ip"
+	TargetTypeRange  = "range"
+	TargetTypeDomain = "domain"
+)
+
+var (
+	hostnameRegex = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*\.?$`)
+	portSpecRegex = regexp.MustCompile(`^(\d+(-\d+)?)(,\d+(-\d+)?)*$`)
+)
+
+// TargetLine is a target read from a targets file
+type TargetLine struct {
+	Line   int    // 1-based line number in the source
+	Target string // Target as written
+	Ports  string // Ports given after the target, empty for the default ones
+	Type   string // Detected target type, empty when invalid
+	Err    error  // Validation error, if any
+}
+
+// ReadTargets reads newline-separated targets, skipping blank lines and
+// '#' comments, and detects the type of each one. A target may be followed
+// by the ports to scan on it, e.g. "10.0.0.0/24 22,80,443".
+func ReadTargets(r io.Reader) ([]TargetLine, error) {
+	var targets []TargetLine
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		target, ports := fields[0], ""
+		if len(fields) > 1 {
+			ports = fields[1]
+		}
+		targetType, err := DetectTargetType(target)
+		if err == nil && len(fields) > 2 {
+			err = fmt.Errorf("unexpected %q after the ports", strings.Join(fields[2:], " "))
+		} else if err == nil && ports != "" {
+			err = ValidatePortSpec(ports)
+		}
+		targets = append(targets, TargetLine{
+			Line:   lineNum,
+			Target: target,
+			Ports:  ports,
+			Type:   targetType,
+			Err:    err,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return targets, fmt.Errorf("failed to read targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// ReadTargetList reads targets like ReadTargets and returns them with their
+// ports, failing with every invalid line reported when any are invalid
+func ReadTargetList(r io.Reader) ([]TargetPorts, error) {
+	lines, err := ReadTargets(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []TargetPorts
+	var invalid []string
+	for _, line := range lines {
+		if line.Err != nil {
+			invalid = append(invalid, fmt.Sprintf("line %d: %v", line.Line, line.Err))
+			continue
+		}
+		targets = append(targets, TargetPorts{Target: line.Target, Ports: line.Ports})
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid targets: %s", strings.Join(invalid, "; "))
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no targets given")
+	}
+
+	return targets, nil
+}
+
+// DetectTargetType classifies a target as an IP, a range (CIDR or
+// dash-separated) or a domain name
+func DetectTargetType(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", fmt.Errorf("empty target")
+	}
+
+	if net.ParseIP(target) != nil {
+		return TargetTypeIP, nil
+	}
+
+	if strings.Contains(target, "/") {
+		if _, _, err := net.ParseCIDR(target); err != nil {
+			return "", fmt.Errorf("invalid CIDR %s", target)
+		}
+		return TargetTypeRange, nil
+	}
+
+	if start, end, found := strings.Cut(target, "-"); found && net.ParseIP(start) != nil {
+		if err := validateRangeEnd(start, end); err != nil {
+			return "", err
+		}
+		return TargetTypeRange, nil
+	}
+
+	if len(target) <= 253 && hostnameRegex.MatchString(target) && strings.ContainsAny(target, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		return TargetTypeDomain, nil
+	}
+
+	return "", fmt.Errorf("unrecognized target %q", target)
+}
+
+// ValidatePortSpec checks a port specification such as "1-1000" or "80,443,8000-8100"
+func ValidatePortSpec(ports string) error {
+	if !portSpecRegex.MatchString(ports) {
+		return fmt.Errorf("invalid port format: %s", ports)
+	}
+
+	for _, part := range strings.Split(ports, ",") {
+		var low, high int
+		if n, _ := fmt.Sscanf(part, "%d-%d", &low, &high); n < 2 {
+			high = low
+		}
+		if low < 0 || high > 65535 || low > high {
+			return fmt.Errorf("invalid port range: %s", part)
+		}
+	}
+
+	return nil
+}
+
+// validateRangeEnd checks the end of a dash range, which is either a full
+// address or the final octet of an IPv4 address
+func validateRangeEnd(start, end string) error {
+	startIP := net.ParseIP(start).To4()
+	if startIP == nil {
+		return fmt.Errorf("dash ranges are only supported for IPv4: %s-%s", start, end)
+	}
+
+	if endIP := net.ParseIP(end).To4(); endIP != nil {
+		for i := range startIP {
+			if endIP[i] != startIP[i] {
+				if endIP[i] < startIP[i] {
+					return fmt.Errorf("range end %s is before start %s", end, start)
+				}
+				break
+			}
+		}
+		return nil
+	}
+
+	var octet int
+	if _, err := fmt.Sscanf(end, "%d", &octet); err != nil || fmt.Sprint(octet) != end || octet > 255 || octet < int(startIP[3]) {
+		return fmt.Errorf("invalid range end %q for %s", end, start)
+	}
+	return nil
+}
+
+// IsIPv6Target reports whether the target is an IPv6 address or prefix.
+// IPv6 prefixes are never enumerated, as even a /64 holds 2^64 addresses:
+// they are passed to the scanner as they are and hosts come from
+// scanner-side discovery.
+func IsIPv6Target(target string) bool {
+	host := strings.TrimSpace(target)
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"math"
+	"testing"
+)
+
+// IPv6 prefixes are counted without being enumerated and kept as they are
+func TestIPv6Prefixes(t *testing.T) {
+	tests := []struct {
+		target string
+		count  uint64
+	}{
+		{"2001:db8::1", 1},
+		{"2001:db8::/120", 256},
+		{"2001:db8::/64", math.MaxUint64}, // Too many to count, let alone enumerate
+		{"2001:db8::/32", math.MaxUint64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			if !IsIPv6Target(tt.target) {
+				t.Errorf("IsIPv6Target(%q) = false", tt.target)
+			}
+			if _, err := DetectTargetType(tt.target); err != nil {
+				t.Errorf("DetectTargetType(%q) error = %v", tt.target, err)
+			}
+			count, err := CountTargetHosts(tt.target)
+			if err != nil || count != tt.count {
+				t.Errorf("CountTargetHosts(%q) = %d, %v; want %d", tt.target, count, err, tt.count)
+			}
+			if got := SubtractExclusions([]string{tt.target}, []string{"192.0.2.0/24"}); len(got) != 1 || got[0] != tt.target {
+				t.Errorf("SubtractExclusions() = %v, want the prefix unchanged", got)
+			}
+		})
+	}
+
+	for _, target := range []string{"192.0.2.0/24", "::ffff:192.0.2.1", "scanme.example"} {
+		if IsIPv6Target(target) {
+			t.Errorf("IsIPv6Target(%q) = true", target)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package scanner
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/netrecon/toolkit/internal/tracing"
+)
+
+// Run scans target inside a "scan" span and records the trace ID on the
+// result, so stored scans and log lines can be matched to their trace
+func Run(ctx context.Context, s Scanner, target string, config *ScanConfig) (result *ScanResult, err error) {
+	ctx, span := tracing.Start(ctx, "scan")
+	span.SetAttributes(
+		attribute.String("netrecon.scanner", s.GetName()),
+		attribute.String("netrecon.target", target),
+		attribute.String("netrecon.ports", config.Ports),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	result, err = s.Scan(ctx, target, config)
+	if result != nil {
+		result.TraceID = tracing.TraceID(ctx)
+		span.SetAttributes(
+			attribute.String("netrecon.status", result.Status),
+			attribute.Int("netrecon.hosts", len(result.Hosts)),
+			attribute.Float64("netrecon.coverage", result.Coverage),
+		)
+	}
+	return result, err
+}
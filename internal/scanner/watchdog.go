@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrMaxDurationExceeded is the cause of a pipeline context cancelled by
+// WithMaxDuration
+var ErrMaxDurationExceeded = errors.New("scan exceeded its maximum total duration")
+
+// WithMaxDuration bounds a whole scan pipeline, the scan itself along with
+// enrichment, to d. Unlike the scanner's own timeout it covers every step
+// sharing the returned context; saving the result must not share it, or a
+// partial result could never be stored. A d of 0 sets no limit.
+func WithMaxDuration(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, d, fmt.Errorf("%w (%s)", ErrMaxDurationExceeded, d))
+}
+
+// MarkOverrun flags the result partial when ctx was cancelled by
+// WithMaxDuration and reports whether it was. Hosts gathered before the
+// cut are kept; a scan that had already finished is downgraded to
+// completed_with_errors since later steps were skipped.
+func (r *ScanResult) MarkOverrun(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	if !errors.Is(cause, ErrMaxDurationExceeded) {
+		return false
+	}
+
+	r.Complete = false
+	if r.Status == StatusCompleted {
+		r.Status = StatusCompletedWithErrors
+	}
+	if r.Error == "" {
+		r.Error = cause.Error()
+	} else {
+		r.Error = fmt.Sprintf("%v: %s", cause, r.Error)
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// wellKnown maps "port/protocol" to the IANA-assigned service name, using the
+// names nmap reports (nmap-services is derived from the IANA registry)
+var wellKnown = map[string]string{
+	"7/tcp":     "echo",
+	"13/tcp":    "daytime",
+	"20/tcp":    "ftp-data",
+	"21/tcp":    "ftp",
+	"22/tcp":    "ssh",
+	"23/tcp":    "telnet",
+	"25/tcp":    "smtp",
+	"37/tcp":    "time",
+	"43/tcp":    "whois",
+	"49/tcp":    "tacacs",
+	"53/tcp":    "domain",
+	"53/udp":    "domain",
+	"67/udp":    "dhcps",
+	"68/udp":    "dhcpc",
+	"69/udp":    "tftp",
+	"70/tcp":    "gopher",
+	"79/tcp":    "finger",
+	"80/tcp":    "http",
+	"88/tcp":    "kerberos-sec",
+	"88/udp":    "kerberos-sec",
+	"110/tcp":   "pop3",
+	"111/tcp":   "rpcbind",
+	"111/udp":   "rpcbind",
+	"113/tcp":   "ident",
+	"119/tcp":   "nntp",
+	"123/udp":   "ntp",
+	"135/tcp":   "msrpc",
+	"137/udp":   "netbios-ns",
+	"138/udp":   "netbios-dgm",
+	"139/tcp":   "netbios-ssn",
+	"143/tcp":   "imap",
+	"161/tcp":   "snmp",
+	"161/udp":   "snmp",
+	"162/udp":   "snmptrap",
+	"179/tcp":   "bgp",
+	"389/tcp":   "ldap",
+	"389/udp":   "ldap",
+	"427/tcp":   "svrloc",
+	"443/tcp":   "https",
+	"445/tcp":   "microsoft-ds",
+	"464/tcp":   "kpasswd5",
+	"465/tcp":   "smtps",
+	"500/udp":   "isakmp",
+	"512/tcp":   "exec",
+	"513/tcp":   "login",
+	"514/tcp":   "shell",
+	"514/udp":   "syslog",
+	"515/tcp":   "printer",
+	"543/tcp":   "klogin",
+	"544/tcp":   "kshell",
+	"548/tcp":   "afp",
+	"554/tcp":   "rtsp",
+	"587/tcp":   "submission",
+	"631/tcp":   "ipp",
+	"636/tcp":   "ldapssl",
+	"873/tcp":   "rsync",
+	"990/tcp":   "ftps",
+	"993/tcp":   "imaps",
+	"995/tcp":   "pop3s",
+	"1080/tcp":  "socks",
+	"1194/udp":  "openvpn",
+	"1433/tcp":  "ms-sql-s",
+	"1434/udp":  "ms-sql-m",
+	"1521/tcp":  "oracle",
+	"1723/tcp":  "pptp",
+	"1812/udp":  "radius",
+	"1813/udp":  "radacct",
+	"1883/tcp":  "mqtt",
+	"1900/udp":  "upnp",
+	"2049/tcp":  "nfs",
+	"2375/tcp":  "docker",
+	"3128/tcp":  "squid-http",
+	"3268/tcp":  "globalcatLDAP",
+	"3269/tcp":  "globalcatLDAPssl",
+	"3306/tcp":  "mysql",
+	"3389/tcp":  "ms-wbt-server",
+	"4500/udp":  "nat-t-ike",
+	"5060/udp":  "sip",
+	"5222/tcp":  "xmpp-client",
+	"5269/tcp":  "xmpp-server",
+	"5353/udp":  "mdns",
+	"5432/tcp":  "postgresql",
+	"5631/tcp":  "pcanywheredata",
+	"5672/tcp":  "amqp",
+	"5900/tcp":  "vnc",
+	"5985/tcp":  "wsman",
+	"5986/tcp":  "wsmans",
+	"6000/tcp":  "X11",
+	"6379/tcp":  "redis",
+	"6667/tcp":  "irc",
+	"8000/tcp":  "http-alt",
+	"8008/tcp":  "http",
+	"8080/tcp":  "http-proxy",
+	"8443/tcp":  "https-alt",
+	"9100/tcp":  "jetdirect",
+	"9200/tcp":  "elasticsearch",
+	"9418/tcp":  "git",
+	"11211/tcp": "memcache",
+	"11211/udp": "memcache",
+	"27017/tcp": "mongodb",
+}
+
+// aliases maps service name variants onto a canonical name so that, for
+// example, "ssl/http" on 443 is not reported as differing from "https"
+var aliases = map[string]string{
+	"https":      "http",
+	"https-alt":  "http",
+	"http-proxy": "http",
+	"http-alt":   "http",
+	"smtps":      "smtp",
+	"submission": "smtp",
+	"imaps":      "imap",
+	"pop3s":      "pop3",
+	"ldapssl":    "ldap",
+	"ldaps":      "ldap",
+	"ms-sql-m":   "ms-sql-s",
+	"domain-s":   "domain",
+	"ftps":       "ftp",
+	"squid-http": "http",
+	"wsmans":     "wsman",
+
+	"globalcatldap":    "ldap",
+	"globalcatldapssl": "ldap",
+}
+
+// standardPorts maps canonical service names to their assigned ports
+var standardPorts = buildStandardPorts()
+
+// Lookup returns the IANA-assigned service name for a port and protocol
+func Lookup(port int, protocol string) (string, bool) {
+	name, ok := wellKnown[key(port, protocol)]
+	return name, ok
+}
+
+// ResolveService returns a best-guess service name for a port from its IANA
+// assignment, or "" when the port has none. It is meant for results without
+// service detection, such as masscan's, and says nothing about what actually
+// listens on the port.
+func ResolveService(port int, protocol string) string {
+	name, _ := Lookup(port, protocol)
+	return name
+}
+
+// Normalize canonicalizes a service name, stripping TLS prefixes and folding aliases
+func Normalize(service string) string {
+	name := strings.ToLower(strings.TrimSpace(service))
+	name = strings.TrimPrefix(name, "ssl/")
+	name = strings.TrimPrefix(name, "tls/")
+	if canonical, ok := aliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// StandardPorts returns the ports a service is assigned to, if known
+func StandardPorts(service string) []int {
+	return standardPorts[Normalize(service)]
+}
+
+func key(port int, protocol string) string {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return fmt.Sprintf("%d/%s", port, strings.ToLower(protocol))
+}
+
+func buildStandardPorts() map[string][]int {
+	ports := make(map[string][]int)
+	for portProto, name := range wellKnown {
+		var port int
+		fmt.Sscanf(portProto, "%d/", &port)
+		canonical := Normalize(name)
+		if !containsPort(ports[canonical], port) {
+			ports[canonical] = append(ports[canonical], port)
+		}
+	}
+	for name := range ports {
+		sort.Ints(ports[name])
+	}
+	return ports
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestResolveService(t *testing.T) {
+	tests := []struct {
+		port     int
+		protocol string
+		want     string
+	}{
+		{21, "tcp", "ftp"},
+		{22, "tcp", "ssh"},
+		{25, "tcp", "smtp"},
+		{53, "udp", "domain"},
+		{80, "tcp", "http"},
+		{123, "udp", "ntp"},
+		{161, "udp", "snmp"},
+		{443, "tcp", "https"},
+		{445, "tcp", "microsoft-ds"},
+		{3306, "tcp", "mysql"},
+		{3389, "tcp", "ms-wbt-server"},
+		{5432, "tcp", "postgresql"},
+		{8080, "tcp", "http-proxy"},
+		{22, "", "ssh"}, // The protocol defaults to TCP
+		{443, "TCP", "https"},
+		{22, "udp", ""}, // Assigned on TCP only
+		{123, "tcp", ""},
+		{0, "tcp", ""},
+		{49151, "tcp", ""},
+		{80, "sctp", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", tt.port, tt.protocol), func(t *testing.T) {
+			if got := ResolveService(tt.port, tt.protocol); got != tt.want {
+				t.Errorf("ResolveService(%d, %q) = %q, want %q", tt.port, tt.protocol, got, tt.want)
+			}
+			if _, ok := Lookup(tt.port, tt.protocol); ok != (tt.want != "") {
+				t.Errorf("Lookup(%d, %q) found = %t, want %t", tt.port, tt.protocol, ok, tt.want != "")
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		service string
+		want    string
+	}{
+		{"http", "http"},
+		{"ssl/http", "http"},
+		{"https", "http"},
+		{" HTTP-Proxy ", "http"},
+		{"tls/imap", "imap"},
+		{"pop3s", "pop3"},
+		{"globalcatLDAPssl", "ldap"},
+		{"ssh", "ssh"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			if got := Normalize(tt.service); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.service, got, tt.want)
+			}
+		})
+	}
+
+	if got, want := StandardPorts("ssl/http"), []int{80, 443, 3128, 8000, 8008, 8080, 8443}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StandardPorts(ssl/http) = %v, want %v", got, want)
+	}
+	if got := StandardPorts("gopherd"); got != nil {
+		t.Errorf("StandardPorts() of an unknown service = %v, want none", got)
+	}
+}
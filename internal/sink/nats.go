@@ -0,0 +1,99 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// NATSConfig holds NATS sink settings
+type NATSConfig struct {
+	URL             string
+	Subject         string
+	PerHost         bool          // Publish one message per host instead of one per scan
+	MaxReconnects   int           // Reconnect attempts before giving up (-1 = forever)
+	ReconnectWait   time.Duration // Delay between reconnect attempts
+	ReconnectBufMiB int           // Messages buffered while disconnected
+}
+
+// NATSSink publishes scan results as JSON to a NATS subject. While the
+// broker is unreachable messages are held in a bounded reconnect buffer;
+// publishing fails once that buffer is full.
+type NATSSink struct {
+	conn   *nats.Conn
+	config NATSConfig
+}
+
+// NewNATSSink connects to the configured NATS server. The initial connect
+// is retried in the background so that a broker outage at startup does not
+// fail the scan.
+func NewNATSSink(config NATSConfig) (*NATSSink, error) {
+	if config.Subject == "" {
+		return nil, fmt.Errorf("NATS subject is required")
+	}
+
+	conn, err := nats.Connect(config.URL,
+		nats.Name("netrecon"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(config.MaxReconnects),
+		nats.ReconnectWait(config.ReconnectWait),
+		nats.ReconnectBufSize(config.ReconnectBufMiB*1024*1024),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", config.URL, err)
+	}
+
+	return &NATSSink{conn: conn, config: config}, nil
+}
+
+// GetName returns the sink name
+func (s *NATSSink) GetName() string {
+	return "nats"
+}
+
+// Publish sends the result, or one event per host in per-host mode, to the
+// configured subject
+func (s *NATSSink) Publish(ctx context.Context, result *scanner.ScanResult) error {
+	if !s.config.PerHost {
+		return s.publish(ctx, result)
+	}
+
+	for _, event := range hostEvents(result) {
+		if err := s.publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *NATSSink) publish(ctx context.Context, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if err := s.conn.Publish(s.config.Subject, data); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", s.config.Subject, err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the connection
+func (s *NATSSink) Close() error {
+	if s.conn.IsConnected() {
+		if err := s.conn.Flush(); err != nil {
+			s.conn.Close()
+			return fmt.Errorf("failed to flush NATS messages: %w", err)
+		}
+	}
+	s.conn.Close()
+	return nil
+}
@@ -0,0 +1,163 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// natsMessage is a message received by fakeBroker
+type natsMessage struct {
+	subject string
+	data    []byte
+}
+
+// fakeBroker speaks enough of the NATS client protocol to accept a
+// connection and record what is published on it
+type fakeBroker struct {
+	listener net.Listener
+	messages chan natsMessage
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{listener: listener, messages: make(chan natsMessage, 16)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) URL() string {
+	return "nats://" + b.listener.Addr().String()
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		case "PUB":
+			// PUB <subject> [reply-to] <size>
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			b.messages <- natsMessage{subject: fields[1], data: payload[:size]}
+		}
+	}
+}
+
+// next returns the next published message
+func (b *fakeBroker) next(t *testing.T) natsMessage {
+	t.Helper()
+	select {
+	case msg := <-b.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message published")
+		return natsMessage{}
+	}
+}
+
+func TestNATSSinkPublish(t *testing.T) {
+	result := &scanner.ScanResult{
+		Target:        "192.0.2.0/30",
+		Scanner:       "nmap",
+		Status:        scanner.StatusCompleted,
+		CorrelationID: "run-1",
+		Hosts: []*models.Host{
+			{IPAddress: "192.0.2.1", Status: "up"},
+			{IPAddress: "192.0.2.2", Status: "up"},
+		},
+	}
+
+	t.Run("whole result", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		s, err := NewNATSSink(NATSConfig{URL: broker.URL(), Subject: "netrecon.results"})
+		if err != nil {
+			t.Fatalf("NewNATSSink() error = %v", err)
+		}
+		if err := s.Publish(context.Background(), result); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		msg := broker.next(t)
+		var got scanner.ScanResult
+		if err := json.Unmarshal(msg.data, &got); err != nil {
+			t.Fatalf("message is not a scan result: %v", err)
+		}
+		if msg.subject != "netrecon.results" || got.Target != result.Target || len(got.Hosts) != 2 {
+			t.Errorf("published %s to %s with %d hosts, want %s to netrecon.results with 2", got.Target, msg.subject, len(got.Hosts), result.Target)
+		}
+	})
+
+	t.Run("per host", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		s, err := NewNATSSink(NATSConfig{URL: broker.URL(), Subject: "netrecon.hosts", PerHost: true})
+		if err != nil {
+			t.Fatalf("NewNATSSink() error = %v", err)
+		}
+		if err := s.Publish(context.Background(), result); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		for _, want := range []string{"192.0.2.1", "192.0.2.2"} {
+			var event HostEvent
+			if err := json.Unmarshal(broker.next(t).data, &event); err != nil {
+				t.Fatalf("message is not a host event: %v", err)
+			}
+			if event.Host == nil || event.Host.IPAddress != want || event.CorrelationID != "run-1" {
+				t.Errorf("event = %+v, want host %s of run-1", event, want)
+			}
+		}
+	})
+
+	if _, err := NewNATSSink(NATSConfig{URL: "nats://127.0.0.1:1"}); err == nil {
+		t.Error("NewNATSSink() accepted an empty subject")
+	}
+}
@@ -0,0 +1,109 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Sink receives completed scan results, for example to feed an
+// event-driven pipeline
+type Sink interface {
+	// Publish sends a completed scan result
+	Publish(ctx context.Context, result *scanner.ScanResult) error
+
+	// Close flushes buffered messages and releases the connection
+	Close() error
+
+	// GetName returns the sink name
+	GetName() string
+}
+
+// HostEvent is the message published per host when a sink runs in
+// per-host mode
+type HostEvent struct {
+	Target        string       `json:"target"`
+	Scanner       string       `json:"scanner"`
+	StartTime     string       `json:"start_time"`
+	EndTime       string       `json:"end_time"`
+	CorrelationID string       `json:"correlation_id,omitempty"`
+	Host          *models.Host `json:"host"`
+}
+
+// hostEvents splits a scan result into one event per host
+func hostEvents(result *scanner.ScanResult) []HostEvent {
+	events := make([]HostEvent, 0, len(result.Hosts))
+	for _, host := range result.Hosts {
+		events = append(events, HostEvent{
+			Target:        result.Target,
+			Scanner:       result.Scanner,
+			StartTime:     result.StartTime,
+			EndTime:       result.EndTime,
+			CorrelationID: result.CorrelationID,
+			Host:          host,
+		})
+	}
+	return events
+}
+
+// Factory creates a sink from configuration
+type Factory func() (Sink, error)
+
+// Registry maps sink names to their factories
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty sink registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Register adds a sink factory under name
+func (r *Registry) Register(name string, factory Factory) {
+	r.factories[name] = factory
+}
+
+// Open creates the named sinks, closing any already opened if one fails
+func (r *Registry) Open(names []string) ([]Sink, error) {
+	var sinks []Sink
+	for _, name := range names {
+		factory, exists := r.factories[name]
+		if !exists {
+			CloseAll(sinks)
+			return nil, fmt.Errorf("sink '%s' not available. Available sinks: %v", name, r.List())
+		}
+
+		s, err := factory()
+		if err != nil {
+			CloseAll(sinks)
+			return nil, fmt.Errorf("failed to open sink %s: %w", name, err)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// List returns the registered sink names
+func (r *Registry) List() []string {
+	var names []string
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CloseAll closes every sink, returning the first error
+func CloseAll(sinks []Sink) error {
+	var firstErr error
+	for _, s := range sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close sink %s: %w", s.GetName(), err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,139 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// SyslogFacilities maps facility names to their RFC 5424 codes
+var SyslogFacilities = map[string]int{
+	"user": 1, "daemon": 3, "auth": 4, "authpriv": 10,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogInformational is the RFC 5424 severity of every message sent
+const syslogInformational = 6
+
+// SyslogConfig holds syslog sink settings
+type SyslogConfig struct {
+	Network  string // udp or tcp
+	Address  string // host:port of the collector
+	AppName  string
+	Facility string // Key of SyslogFacilities
+}
+
+// SyslogSink sends scan results to a syslog collector as RFC 5424
+// messages, one per line produced by its formatter, so a SIEM can ingest
+// events such as CEF directly. Over TCP messages are octet-counted
+// (RFC 6587); over UDP each message is one datagram.
+type SyslogSink struct {
+	config   SyslogConfig
+	format   func(*scanner.ScanResult) []string
+	priority int
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink connects to the configured collector. format turns a scan
+// result into the messages sent, for example output.CEFFormatter.Lines.
+func NewSyslogSink(config SyslogConfig, format func(*scanner.ScanResult) []string) (*SyslogSink, error) {
+	if config.Network != "udp" && config.Network != "tcp" {
+		return nil, fmt.Errorf("syslog network must be udp or tcp, got %q", config.Network)
+	}
+	facility, ok := SyslogFacilities[config.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", config.Facility)
+	}
+	if config.AppName == "" {
+		config.AppName = "netrecon"
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &SyslogSink{
+		config:   config,
+		format:   format,
+		priority: facility*8 + syslogInformational,
+		hostname: hostname,
+	}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetName returns the sink name
+func (s *SyslogSink) GetName() string {
+	return "syslog"
+}
+
+// Publish sends one message per formatted line. A broken TCP connection
+// is redialled once before the send fails.
+func (s *SyslogSink) Publish(ctx context.Context, result *scanner.ScanResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, line := range s.format(result) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg := s.message(line)
+		if err := s.write(msg); err != nil {
+			if s.config.Network != "tcp" {
+				return fmt.Errorf("failed to send to syslog at %s: %w", s.config.Address, err)
+			}
+			s.conn.Close()
+			if err := s.dial(); err != nil {
+				return err
+			}
+			if err := s.write(msg); err != nil {
+				return fmt.Errorf("failed to send to syslog at %s: %w", s.config.Address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the collector
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
+
+func (s *SyslogSink) dial() error {
+	conn, err := net.DialTimeout(s.config.Network, s.config.Address, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog at %s: %w", s.config.Address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// message formats an RFC 5424 message with no structured data
+func (s *SyslogSink) message(line string) string {
+	return fmt.Sprintf("<%d>1 %s %s %s %d - - %s",
+		s.priority, time.Now().UTC().Format(time.RFC3339Nano), s.hostname, s.config.AppName, os.Getpid(), line)
+}
+
+func (s *SyslogSink) write(msg string) error {
+	if s.config.Network == "tcp" {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
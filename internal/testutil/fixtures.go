@@ -0,0 +1,179 @@
+// Package testutil generates large synthetic scan data for benchmarks of the
+// parsing and database paths. The output is deterministic so runs compare.
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// FixtureTime is the timestamp given to every generated record
+var FixtureTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Reason and reply TTL given to every generated open port
+const (
+	fixtureReason    = "syn-ack"
+	fixtureReasonTTL = 64
+)
+
+// fixtureServices are cycled through for generated ports
+var fixtureServices = []struct {
+	port    int
+	name    string
+	product string
+	version string
+}{
+	{22, "ssh", "OpenSSH", "8.9p1"},
+	{80, "http", "nginx", "1.24.0"},
+	{443, "https", "nginx", "1.24.0"},
+	{3306, "mysql", "MySQL", "8.0.36"},
+	{5432, "postgresql", "PostgreSQL", "15.4"},
+	{6379, "redis", "Redis", "7.2.4"},
+	{8080, "http-proxy", "Apache Tomcat", "9.0.85"},
+	{3389, "ms-wbt-server", "Microsoft Terminal Services", ""},
+}
+
+// HostIP returns the address of the i-th generated host, counting up from 10.0.0.1
+func HostIP(i int) string {
+	n := i + 1
+	return fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff)
+}
+
+// fixturePort returns the number, service, product and version of the j-th
+// port of a host: the well-known services first, then sequential high ports
+func fixturePort(j int) (int, string, string, string) {
+	if j < len(fixtureServices) {
+		s := fixtureServices[j]
+		return s.port, s.name, s.product, s.version
+	}
+	return 10000 + j, "unknown", "", ""
+}
+
+// GenerateHosts builds hosts with portsPerHost open TCP ports each
+func GenerateHosts(hosts, portsPerHost int) []*models.HostGraph {
+	graphs := make([]*models.HostGraph, 0, hosts)
+	for i := 0; i < hosts; i++ {
+		host := &models.Host{
+			ID:           uuid.New(),
+			IPAddress:    HostIP(i),
+			Hostname:     fmt.Sprintf("host-%d.example.internal", i+1),
+			Status:       scanner.HostStateUp,
+			OS:           "Linux 5.X",
+			OSConfidence: 95,
+			OSFamily:     models.OSFamilyLinux,
+			CreatedAt:    FixtureTime,
+		}
+
+		graph := &models.HostGraph{Host: host}
+		for j := 0; j < portsPerHost; j++ {
+			number, service, product, version := fixturePort(j)
+			port := &models.Port{
+				ID:        uuid.New(),
+				HostID:    host.ID,
+				Number:    number,
+				Protocol:  "tcp",
+				State:     "open",
+				Service:   service,
+				Product:   product,
+				Version:   version,
+				Reason:    fixtureReason,
+				ReasonTTL: fixtureReasonTTL,
+				CreatedAt: FixtureTime,
+			}
+			host.Ports = append(host.Ports, port)
+			graph.Ports = append(graph.Ports, &models.PortGraph{Port: port})
+		}
+		graphs = append(graphs, graph)
+	}
+	return graphs
+}
+
+// GenerateScanGraph builds a completed scan of the given size, as stored by
+// the repository
+func GenerateScanGraph(hosts, portsPerHost int) *models.FullScanResult {
+	endTime := FixtureTime.Add(time.Minute)
+	result := &models.ScanResult{
+		ID:         uuid.New(),
+		TargetID:   uuid.New(),
+		ScanType:   "nmap",
+		Status:     scanner.StatusCompleted,
+		StartTime:  FixtureTime,
+		EndTime:    &endTime,
+		DurationMs: time.Minute.Milliseconds(),
+		CreatedAt:  FixtureTime,
+	}
+
+	graph := &models.FullScanResult{ScanResult: result, Hosts: GenerateHosts(hosts, portsPerHost)}
+	for _, host := range graph.Hosts {
+		host.ScanID = result.ID
+	}
+	return graph
+}
+
+// GenerateScanResult builds a completed scanner result of the given size
+// whose raw output is the matching nmap XML
+func GenerateScanResult(hosts, portsPerHost int) *scanner.ScanResult {
+	result := &scanner.ScanResult{
+		Target:     fmt.Sprintf("10.0.0.0/%d", prefixFor(hosts)),
+		Scanner:    "nmap",
+		Status:     scanner.StatusCompleted,
+		StartTime:  FixtureTime.Format(time.RFC3339),
+		EndTime:    FixtureTime.Add(time.Minute).Format(time.RFC3339),
+		DurationMs: time.Minute.Milliseconds(),
+		RawOutput:  string(GenerateNmapXML(hosts, portsPerHost)),
+	}
+	for _, graph := range GenerateHosts(hosts, portsPerHost) {
+		result.Hosts = append(result.Hosts, graph.Host)
+	}
+	return result
+}
+
+// GenerateNmapXML renders nmap -oX output with the same hosts and ports as
+// GenerateHosts
+func GenerateNmapXML(hosts, portsPerHost int) []byte {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<nmaprun scanner="nmap" args="nmap -oX - -sV -O" version="7.94" xmloutputversion="1.05">` + "\n")
+
+	for i := 0; i < hosts; i++ {
+		fmt.Fprintf(&b, `<host><status state="up" reason="syn-ack"/><address addr="%s" addrtype="ipv4"/>`, HostIP(i))
+		fmt.Fprintf(&b, `<hostnames><hostname name="host-%d.example.internal" type="PTR"/></hostnames><ports>`, i+1)
+		for j := 0; j < portsPerHost; j++ {
+			number, service, product, version := fixturePort(j)
+			fmt.Fprintf(&b, `<port protocol="tcp" portid="%d"><state state="open" reason="%s" reason_ttl="%d"/>`, number, fixtureReason, fixtureReasonTTL)
+			fmt.Fprintf(&b, `<service name="%s" product="%s" version="%s"/></port>`, service, product, version)
+		}
+		b.WriteString(`</ports><os><osmatch name="Linux 5.X" accuracy="95"/></os></host>` + "\n")
+	}
+
+	b.WriteString(`</nmaprun>` + "\n")
+	return []byte(b.String())
+}
+
+// GenerateMasscanJSON renders masscan JSON output with the same hosts and
+// ports as GenerateHosts, one object per port and line
+func GenerateMasscanJSON(hosts, portsPerHost int) []byte {
+	var b strings.Builder
+	for i := 0; i < hosts; i++ {
+		for j := 0; j < portsPerHost; j++ {
+			number, _, _, _ := fixturePort(j)
+			fmt.Fprintf(&b, `{"ip": "%s", "timestamp": "%d", "ports": [{"port": %d, "proto": "tcp", "status": "open", "reason": "%s", "ttl": %d}]}`+"\n",
+				HostIP(i), FixtureTime.Unix(), number, fixtureReason, fixtureReasonTTL)
+		}
+	}
+	return []byte(b.String())
+}
+
+// prefixFor returns the smallest IPv4 prefix length covering n hosts from 10.0.0.1
+func prefixFor(n int) int {
+	prefix := 32
+	for prefix > 8 && 1<<(32-prefix) < n+2 {
+		prefix--
+	}
+	return prefix
+}
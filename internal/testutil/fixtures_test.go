@@ -0,0 +1,128 @@
+package testutil_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/testutil"
+	"github.com/netrecon/toolkit/pkg/masscan"
+	"github.com/netrecon/toolkit/pkg/nmap"
+)
+
+func TestHostIP(t *testing.T) {
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{0, "10.0.0.1"},
+		{254, "10.0.0.255"},
+		{255, "10.0.1.0"},
+		{65535, "10.1.0.0"},
+	}
+
+	for _, tt := range tests {
+		if got := testutil.HostIP(tt.i); got != tt.want {
+			t.Errorf("HostIP(%d) = %q, want %q", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateHosts(t *testing.T) {
+	hosts := testutil.GenerateHosts(300, 12)
+	if len(hosts) != 300 {
+		t.Fatalf("generated %d hosts, want 300", len(hosts))
+	}
+
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if seen[host.IPAddress] {
+			t.Errorf("address %s generated twice", host.IPAddress)
+		}
+		seen[host.IPAddress] = true
+
+		if len(host.Ports) != 12 || len(host.Host.Ports) != 12 {
+			t.Fatalf("host %s has %d graph and %d host ports, want 12", host.IPAddress, len(host.Ports), len(host.Host.Ports))
+		}
+		numbers := make(map[int]bool)
+		for _, port := range host.Ports {
+			if port.HostID != host.ID {
+				t.Errorf("port %d of %s belongs to host %s", port.Number, host.IPAddress, port.HostID)
+			}
+			if numbers[port.Number] {
+				t.Errorf("port %d generated twice on %s", port.Number, host.IPAddress)
+			}
+			numbers[port.Number] = true
+		}
+	}
+}
+
+func TestGenerateScanGraph(t *testing.T) {
+	graph := testutil.GenerateScanGraph(5, 3)
+	for _, host := range graph.Hosts {
+		if host.ScanID != graph.ScanResult.ID {
+			t.Errorf("host %s belongs to scan %s, want %s", host.IPAddress, host.ScanID, graph.ScanResult.ID)
+		}
+	}
+}
+
+func TestGenerateScanResultTarget(t *testing.T) {
+	tests := []struct {
+		hosts int
+		want  string
+	}{
+		{1, "10.0.0.0/30"},
+		{254, "10.0.0.0/24"},
+		{255, "10.0.0.0/23"},
+	}
+
+	for _, tt := range tests {
+		if got := testutil.GenerateScanResult(tt.hosts, 1).Target; got != tt.want {
+			t.Errorf("GenerateScanResult(%d) target = %q, want %q", tt.hosts, got, tt.want)
+		}
+	}
+}
+
+// The raw outputs decode to the hosts and ports GenerateHosts builds
+func TestGeneratedOutputParses(t *testing.T) {
+	const hosts, ports = 40, 10
+	want := summarize(testutil.GenerateHosts(hosts, ports))
+
+	tests := []struct {
+		name  string
+		data  []byte
+		parse func([]byte) ([]*models.HostGraph, error)
+	}{
+		{"nmap", testutil.GenerateNmapXML(hosts, ports), nmap.NewParser().ParseRaw},
+		{"masscan", testutil.GenerateMasscanJSON(hosts, ports), masscan.NewParser().ParseRaw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.parse(tt.data)
+			if err != nil {
+				t.Fatalf("ParseRaw() error = %v", err)
+			}
+			got := summarize(parsed)
+			if len(got) != len(want) {
+				t.Fatalf("parsed %d hosts, want %d", len(got), len(want))
+			}
+			for ip, ports := range want {
+				if got[ip] != ports {
+					t.Errorf("host %s parsed with ports %q, want %q", ip, got[ip], ports)
+				}
+			}
+		})
+	}
+}
+
+// summarize maps each host address to its open ports
+func summarize(hosts []*models.HostGraph) map[string]string {
+	summary := make(map[string]string, len(hosts))
+	for _, host := range hosts {
+		for _, port := range host.Ports {
+			summary[host.IPAddress] += fmt.Sprintf("%d/%s ", port.Number, port.State)
+		}
+	}
+	return summary
+}
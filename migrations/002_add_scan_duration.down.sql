@@ -0,0 +1,6 @@
+-- Migration: 002_add_scan_duration.down.sql
+-- Remove scan duration column
+
+DROP INDEX IF EXISTS idx_scan_results_duration_ms;
+
+ALTER TABLE scan_results DROP COLUMN IF EXISTS duration_ms;
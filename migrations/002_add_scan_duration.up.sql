@@ -0,0 +1,6 @@
+-- Migration: 002_add_scan_duration.up.sql
+-- Store scan duration as integer milliseconds for sorting and filtering
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS duration_ms BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_scan_results_duration_ms ON scan_results(duration_ms);
@@ -0,0 +1,6 @@
+-- Migration: 003_create_http_probes.down.sql
+-- Drop HTTP probe results
+
+DROP INDEX IF EXISTS idx_http_probes_port_id;
+
+DROP TABLE IF EXISTS http_probes;
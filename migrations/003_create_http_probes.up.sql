@@ -0,0 +1,15 @@
+-- Migration: 003_create_http_probes.up.sql
+-- Store HTTP enrichment probe results per port and path
+
+CREATE TABLE IF NOT EXISTS http_probes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    port_id UUID NOT NULL REFERENCES ports(id) ON DELETE CASCADE,
+    path VARCHAR(2048) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    title TEXT,
+    server VARCHAR(255),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_http_probes_port_id ON http_probes(port_id);
@@ -0,0 +1,7 @@
+-- Migration: 004_add_host_reputation.down.sql
+-- Remove threat-intel reputation annotations
+
+DROP INDEX IF EXISTS idx_hosts_reputation_score;
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS reputation_sources;
+ALTER TABLE hosts DROP COLUMN IF EXISTS reputation_score;
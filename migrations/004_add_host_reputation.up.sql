@@ -0,0 +1,8 @@
+-- Migration: 004_add_host_reputation.up.sql
+-- Threat-intel reputation annotations on hosts
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS reputation_score INTEGER NOT NULL DEFAULT 0
+    CHECK (reputation_score >= 0 AND reputation_score <= 100);
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS reputation_sources TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_hosts_reputation_score ON hosts(reputation_score) WHERE reputation_score > 0;
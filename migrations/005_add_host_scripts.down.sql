@@ -0,0 +1,4 @@
+-- Migration: 005_add_host_scripts.down.sql
+-- Remove host-level NSE script results
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS host_scripts;
@@ -0,0 +1,4 @@
+-- Migration: 005_add_host_scripts.up.sql
+-- Host-level NSE script results (smb-os-discovery, nbstat, ...)
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS host_scripts JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,8 @@
+-- Migration: 006_add_host_netbios.down.sql
+-- Remove Windows naming information
+
+DROP INDEX IF EXISTS idx_hosts_domain;
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS workgroup;
+ALTER TABLE hosts DROP COLUMN IF EXISTS domain;
+ALTER TABLE hosts DROP COLUMN IF EXISTS netbios_name;
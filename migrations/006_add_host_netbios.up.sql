@@ -0,0 +1,8 @@
+-- Migration: 006_add_host_netbios.up.sql
+-- Windows naming information extracted from smb-os-discovery / nbstat
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS netbios_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS domain VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS workgroup VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_hosts_domain ON hosts(domain) WHERE domain <> '';
@@ -0,0 +1,4 @@
+-- Migration: 007_add_scan_config.down.sql
+-- Remove stored scan configuration
+
+ALTER TABLE scan_results DROP COLUMN IF EXISTS scan_config;
@@ -0,0 +1,4 @@
+-- Migration: 007_add_scan_config.up.sql
+-- Effective scan configuration, used to re-run a scan with identical settings
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS scan_config JSONB;
@@ -0,0 +1,6 @@
+-- Migration: 008_add_scan_pinned.down.sql
+-- Remove scan pinning
+
+DROP INDEX IF EXISTS idx_scan_results_pinned;
+
+ALTER TABLE scan_results DROP COLUMN IF EXISTS pinned;
@@ -0,0 +1,6 @@
+-- Migration: 008_add_scan_pinned.up.sql
+-- Pinned scans are listed for quick access and kept by retention pruning
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_scan_results_pinned ON scan_results(pinned) WHERE pinned;
@@ -0,0 +1,10 @@
+-- Migration: 009_widen_scan_status.down.sql
+-- Restore the original scan status set
+
+ALTER TABLE scan_results DROP CONSTRAINT IF EXISTS scan_results_status_check;
+
+UPDATE scan_results SET status = 'completed' WHERE status = 'completed_with_errors';
+UPDATE scan_results SET status = 'failed' WHERE status IN ('timeout', 'cancelled');
+
+ALTER TABLE scan_results ADD CONSTRAINT scan_results_status_check
+    CHECK (status IN ('running', 'completed', 'failed'));
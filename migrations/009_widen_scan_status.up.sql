@@ -0,0 +1,7 @@
+-- Migration: 009_widen_scan_status.up.sql
+-- Allow every scan status the scanners report
+
+ALTER TABLE scan_results DROP CONSTRAINT IF EXISTS scan_results_status_check;
+
+ALTER TABLE scan_results ADD CONSTRAINT scan_results_status_check
+    CHECK (status IN ('running', 'completed', 'completed_with_errors', 'failed', 'timeout', 'cancelled'));
@@ -0,0 +1,6 @@
+-- Migration: 010_create_port_assets.down.sql
+-- Remove port longevity tracking
+
+DROP INDEX IF EXISTS idx_port_assets_last_seen;
+
+DROP TABLE IF EXISTS port_assets;
@@ -0,0 +1,15 @@
+-- Migration: 010_create_port_assets.up.sql
+-- Track when each host:port/protocol was first and last seen open
+
+CREATE TABLE IF NOT EXISTS port_assets (
+    ip_address INET NOT NULL,
+    port INTEGER NOT NULL CHECK (port > 0 AND port <= 65535),
+    protocol VARCHAR(10) NOT NULL,
+    service VARCHAR(100) NOT NULL DEFAULT '',
+    first_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_scan_id UUID REFERENCES scan_results(id) ON DELETE SET NULL,
+    PRIMARY KEY (ip_address, port, protocol)
+);
+
+CREATE INDEX IF NOT EXISTS idx_port_assets_last_seen ON port_assets(last_seen);
@@ -0,0 +1,4 @@
+-- Migration: 011_add_host_load_balanced.down.sql
+-- Remove the load balancer hint
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS load_balanced;
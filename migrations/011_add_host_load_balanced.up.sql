@@ -0,0 +1,4 @@
+-- Migration: 011_add_host_load_balanced.up.sql
+-- Hint that several machines answer for one address, from IP ID analysis
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS load_balanced BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- Migration: 012_add_host_uptime.down.sql
+-- Remove uptime information
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS last_boot;
+ALTER TABLE hosts DROP COLUMN IF EXISTS uptime_seconds;
@@ -0,0 +1,5 @@
+-- Migration: 012_add_host_uptime.up.sql
+-- Uptime and last boot time estimated by nmap OS detection
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS uptime_seconds BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS last_boot TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,9 @@
+-- Migration: 013_widen_port_state.down.sql
+-- Restore the original port state set
+
+ALTER TABLE ports DROP CONSTRAINT IF EXISTS ports_state_check;
+
+UPDATE ports SET state = 'filtered' WHERE state IN ('unfiltered', 'open|filtered', 'closed|filtered');
+
+ALTER TABLE ports ADD CONSTRAINT ports_state_check
+    CHECK (state IN ('open', 'closed', 'filtered'));
@@ -0,0 +1,7 @@
+-- Migration: 013_widen_port_state.up.sql
+-- Keep nmap's ambiguous states (common for UDP) instead of rejecting them
+
+ALTER TABLE ports DROP CONSTRAINT IF EXISTS ports_state_check;
+
+ALTER TABLE ports ADD CONSTRAINT ports_state_check
+    CHECK (state IN ('open', 'closed', 'filtered', 'unfiltered', 'open|filtered', 'closed|filtered'));
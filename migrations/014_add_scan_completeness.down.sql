@@ -0,0 +1,5 @@
+-- Migration: 014_add_scan_completeness.down.sql
+-- Remove the scan completeness flags
+
+ALTER TABLE scan_results DROP COLUMN IF EXISTS coverage;
+ALTER TABLE scan_results DROP COLUMN IF EXISTS complete;
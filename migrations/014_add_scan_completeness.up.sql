@@ -0,0 +1,9 @@
+-- Migration: 014_add_scan_completeness.up.sql
+-- Flag partial results of timed out, cancelled or truncated scans
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS complete BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS coverage DOUBLE PRECISION NOT NULL DEFAULT 1
+    CHECK (coverage >= 0 AND coverage <= 1);
+
+-- Only scans that finished cleanly are known to be complete
+UPDATE scan_results SET complete = FALSE, coverage = 0 WHERE status <> 'completed';
@@ -0,0 +1,4 @@
+-- Migration: 015_add_port_guessed_service.down.sql
+-- Remove the guessed service name
+
+ALTER TABLE ports DROP COLUMN IF EXISTS guessed_service;
@@ -0,0 +1,4 @@
+-- Migration: 015_add_port_guessed_service.up.sql
+-- IANA service name for the port, kept apart from the detected service
+
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS guessed_service VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Migration: 016_add_scan_trace_id.down.sql
+-- Remove the scan trace ID
+
+ALTER TABLE scan_results DROP COLUMN IF EXISTS trace_id;
@@ -0,0 +1,4 @@
+-- Migration: 016_add_scan_trace_id.up.sql
+-- Link scan results to the trace of the run that produced them
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS trace_id VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration: 017_add_scan_correlation_id.down.sql
+-- Remove the scan correlation ID
+
+DROP INDEX IF EXISTS idx_scan_results_correlation_id;
+ALTER TABLE scan_results DROP COLUMN IF EXISTS correlation_id;
@@ -0,0 +1,6 @@
+-- Migration: 017_add_scan_correlation_id.up.sql
+-- Let external systems tie scans back to their own workflow
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(128) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_scan_results_correlation_id ON scan_results(correlation_id);
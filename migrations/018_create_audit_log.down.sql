@@ -0,0 +1,6 @@
+-- Migration: 018_create_audit_log.down.sql
+-- Drop the scan audit log
+
+DROP TRIGGER IF EXISTS audit_log_immutable ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,32 @@
+-- Migration: 018_create_audit_log.up.sql
+-- Record every scan launched, independently of the results it produced
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY,
+    action VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL CHECK (source IN ('cli', 'api')),
+    source_ip INET,
+    target VARCHAR(255) NOT NULL,
+    scanner VARCHAR(50) NOT NULL DEFAULT '',
+    scan_config JSONB,
+    correlation_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+
+-- Entries are append-only: no scan_results foreign key, so pruning results
+-- never touches them, and updates or deletes are rejected outright
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log entries cannot be modified or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_immutable ON audit_log;
+CREATE TRIGGER audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
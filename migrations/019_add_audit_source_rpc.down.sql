@@ -0,0 +1,8 @@
+-- Migration: 019_add_audit_source_rpc.down.sql
+-- Restore the original audit sources. Entries cannot be modified, so
+-- existing rpc entries are kept and only new ones are rejected.
+
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_source_check;
+
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_source_check
+    CHECK (source IN ('cli', 'api')) NOT VALID;
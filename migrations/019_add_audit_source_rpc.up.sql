@@ -0,0 +1,7 @@
+-- Migration: 019_add_audit_source_rpc.up.sql
+-- Allow scans launched over JSON-RPC in the audit log
+
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_source_check;
+
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_source_check
+    CHECK (source IN ('cli', 'api', 'rpc'));
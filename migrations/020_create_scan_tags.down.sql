@@ -0,0 +1,7 @@
+-- Migration: 020_create_scan_tags.down.sql
+-- Remove scan tags
+
+DROP INDEX IF EXISTS idx_scan_result_tags_tag_id;
+
+DROP TABLE IF EXISTS scan_result_tags;
+DROP TABLE IF EXISTS tags;
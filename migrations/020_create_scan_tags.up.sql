@@ -0,0 +1,18 @@
+-- Migration: 020_create_scan_tags.up.sql
+-- Tag scans with normalized, indexed labels shared across targets
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS scan_result_tags (
+    scan_id UUID NOT NULL REFERENCES scan_results(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (scan_id, tag_id)
+);
+
+-- The primary key serves lookups by scan; this one serves lookups by tag
+CREATE INDEX IF NOT EXISTS idx_scan_result_tags_tag_id ON scan_result_tags(tag_id, scan_id);
@@ -0,0 +1,8 @@
+-- Migration: 021_create_scan_campaigns.down.sql
+-- Remove scan campaign checkpoints
+
+DROP TABLE IF EXISTS scan_campaign_shards;
+
+DROP INDEX IF EXISTS idx_scan_campaigns_open_key;
+
+DROP TABLE IF EXISTS scan_campaigns;
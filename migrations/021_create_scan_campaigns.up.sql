@@ -0,0 +1,29 @@
+-- Migration: 021_create_scan_campaigns.up.sql
+-- Checkpoint sharded internet-scale scans so they can resume after interruption
+
+CREATE TABLE IF NOT EXISTS scan_campaigns (
+    id UUID PRIMARY KEY,
+    key VARCHAR(64) NOT NULL,
+    scanner VARCHAR(50) NOT NULL,
+    ports TEXT NOT NULL,
+    shards INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one unfinished campaign per key, the one a rerun resumes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scan_campaigns_open_key ON scan_campaigns(key) WHERE completed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS scan_campaign_shards (
+    campaign_id UUID NOT NULL REFERENCES scan_campaigns(id) ON DELETE CASCADE,
+    shard_index INTEGER NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    ports TEXT NOT NULL,
+    packets BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'completed', 'failed')),
+    scan_id UUID REFERENCES scan_results(id) ON DELETE SET NULL,
+    hosts INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    finished_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (campaign_id, shard_index)
+);
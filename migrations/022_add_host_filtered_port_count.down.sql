@@ -0,0 +1,4 @@
+-- Migration: 022_add_host_filtered_port_count.down.sql
+-- Remove the filtered port count
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS filtered_port_count;
@@ -0,0 +1,4 @@
+-- Migration: 022_add_host_filtered_port_count.up.sql
+-- Count of ports reported filtered per host, a sign of a firewall
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS filtered_port_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Migration: 023_add_port_reason.down.sql
+-- Remove the port state reason
+
+ALTER TABLE ports DROP COLUMN IF EXISTS reason_ttl;
+ALTER TABLE ports DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,5 @@
+-- Migration: 023_add_port_reason.up.sql
+-- Why the scanner gave each port its state, and the TTL of the reply
+
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS reason VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS reason_ttl INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Migration: 024_add_host_os_family.down.sql
+-- Remove the host OS family
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS os_family;
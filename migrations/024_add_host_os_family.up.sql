@@ -0,0 +1,5 @@
+-- Migration: 024_add_host_os_family.up.sql
+-- Detected OS normalized to a family (windows, linux, network, ...) for grouping.
+-- Hosts stored before are left empty and classified from their OS when read.
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS os_family VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Migration: 025_add_scan_metadata.down.sql
+-- Remove scan metadata
+
+DROP INDEX IF EXISTS idx_scan_results_metadata;
+ALTER TABLE scan_results DROP COLUMN IF EXISTS metadata;
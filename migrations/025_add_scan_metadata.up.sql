@@ -0,0 +1,7 @@
+-- Migration: 025_add_scan_metadata.up.sql
+-- Free-form key-value context on scans (environment, requester, ticket),
+-- indexed for containment queries such as metadata @> '{"env": "prod"}'
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_scan_results_metadata ON scan_results USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,4 @@
+-- Migration: 026_add_port_hit_ratio.down.sql
+-- Remove the repeated-run hit ratio of ports
+
+ALTER TABLE ports DROP COLUMN IF EXISTS hit_ratio;
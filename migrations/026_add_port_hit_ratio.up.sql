@@ -0,0 +1,4 @@
+-- Migration: 026_add_port_hit_ratio.up.sql
+-- Fraction of repeated runs that saw each port open (scan --repeat --aggregate)
+
+ALTER TABLE ports ADD COLUMN IF NOT EXISTS hit_ratio DOUBLE PRECISION NOT NULL DEFAULT 0;
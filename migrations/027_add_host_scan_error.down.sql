@@ -0,0 +1,4 @@
+-- Migration: 027_add_host_scan_error.down.sql
+-- Remove per-host scan errors
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS scan_error;
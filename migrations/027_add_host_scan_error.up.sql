@@ -0,0 +1,5 @@
+-- Migration: 027_add_host_scan_error.up.sql
+-- Per-host scan errors, such as a host timing out or being unreachable,
+-- kept apart from the scan-wide error
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS scan_error TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Migration: 028_add_scan_schema_version.down.sql
+-- Remove the scan schema version
+
+ALTER TABLE scan_results DROP COLUMN IF EXISTS schema_version;
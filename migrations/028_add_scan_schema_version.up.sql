@@ -0,0 +1,5 @@
+-- Migration: 028_add_scan_schema_version.up.sql
+-- Version of the scan models each result was stored under, so results from
+-- older releases can be upgraded when read. Existing scans are left at 0.
+
+ALTER TABLE scan_results ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 0;
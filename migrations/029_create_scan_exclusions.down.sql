@@ -0,0 +1,4 @@
+-- Migration: 029_create_scan_exclusions.down.sql
+-- Remove the do-not-scan list
+
+DROP TABLE IF EXISTS scan_exclusions;
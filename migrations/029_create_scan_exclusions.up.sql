@@ -0,0 +1,9 @@
+-- Migration: 029_create_scan_exclusions.up.sql
+-- Keep a do-not-scan list of addresses and CIDR blocks every scan skips
+
+CREATE TABLE IF NOT EXISTS scan_exclusions (
+    id UUID PRIMARY KEY,
+    network CIDR NOT NULL UNIQUE,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
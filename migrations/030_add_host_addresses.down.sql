@@ -0,0 +1,6 @@
+-- Migration: 030_add_host_addresses.down.sql
+-- Remove host MAC, vendor and IPv6 addresses
+
+ALTER TABLE hosts DROP COLUMN IF EXISTS ipv6_address;
+ALTER TABLE hosts DROP COLUMN IF EXISTS vendor;
+ALTER TABLE hosts DROP COLUMN IF EXISTS mac_address;
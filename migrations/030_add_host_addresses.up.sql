@@ -0,0 +1,7 @@
+-- Migration: 030_add_host_addresses.up.sql
+-- Hardware address and NIC vendor of hosts on the scanner's segment, and the
+-- IPv6 address of dual-stack hosts
+
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS mac_address VARCHAR(17) NOT NULL DEFAULT '';
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS vendor VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS ipv6_address VARCHAR(45) NOT NULL DEFAULT '';
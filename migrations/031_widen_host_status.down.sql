@@ -0,0 +1,9 @@
+-- Migration: 031_widen_host_status.down.sql
+-- Restore the original host status set
+
+ALTER TABLE hosts DROP CONSTRAINT IF EXISTS hosts_status_check;
+
+UPDATE hosts SET status = 'down' WHERE status IN ('unknown', 'skipped');
+
+ALTER TABLE hosts ADD CONSTRAINT hosts_status_check
+    CHECK (status IN ('up', 'down', 'filtered'));
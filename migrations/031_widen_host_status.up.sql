@@ -0,0 +1,7 @@
+-- Migration: 031_widen_host_status.up.sql
+-- Allow every host state nmap reports
+
+ALTER TABLE hosts DROP CONSTRAINT IF EXISTS hosts_status_check;
+
+ALTER TABLE hosts ADD CONSTRAINT hosts_status_check
+    CHECK (status IN ('up', 'down', 'filtered', 'unknown', 'skipped'));
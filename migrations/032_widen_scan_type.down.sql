@@ -0,0 +1,8 @@
+-- Migration: 032_widen_scan_type.down.sql
+-- Restore the original scanner set; results of other scanners are kept,
+-- so the constraint only applies to new rows
+
+ALTER TABLE scan_results DROP CONSTRAINT IF EXISTS scan_results_scan_type_check;
+
+ALTER TABLE scan_results ADD CONSTRAINT scan_results_scan_type_check
+    CHECK (scan_type IN ('nmap', 'masscan')) NOT VALID;
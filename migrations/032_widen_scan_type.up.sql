@@ -0,0 +1,7 @@
+-- Migration: 032_widen_scan_type.up.sql
+-- Allow results of every scanner backend
+
+ALTER TABLE scan_results DROP CONSTRAINT IF EXISTS scan_results_scan_type_check;
+
+ALTER TABLE scan_results ADD CONSTRAINT scan_results_scan_type_check
+    CHECK (scan_type IN ('nmap', 'masscan', 'rustscan', 'naabu'));
@@ -0,0 +1,187 @@
+package masscan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Adaptive rate defaults: the first burst runs at AdaptiveStartRate, the
+// port range is scanned in AdaptiveBursts runs, and a burst losing more
+// than AdaptiveLossThreshold of its canaries halves the rate
+const (
+	AdaptiveStartRate     = 100
+	AdaptiveBursts        = 8
+	AdaptiveLossThreshold = 0.1
+	adaptiveCanaryPorts   = 4
+)
+
+// RateController adjusts the masscan rate between bursts from the share of
+// known-open ports that went unanswered. The rate doubles until the first
+// loss, then grows by a quarter per clean burst; any loss above the
+// threshold halves it. The rate always stays between Min and Max.
+type RateController struct {
+	Rate      int
+	Min       int
+	Max       int
+	Threshold float64
+	backedOff bool
+}
+
+// NewRateController creates a controller ramping from AdaptiveStartRate up
+// to max packets per second
+func NewRateController(max int) *RateController {
+	if max < 1 {
+		max = 1
+	}
+	start := AdaptiveStartRate
+	if start > max {
+		start = max
+	}
+	return &RateController{Rate: start, Min: 1, Max: max, Threshold: AdaptiveLossThreshold}
+}
+
+// Observe records the loss measured during a burst at the current rate,
+// between 0 and 1, and returns the rate for the next burst
+func (c *RateController) Observe(loss float64) int {
+	switch {
+	case loss > c.Threshold:
+		c.backedOff = true
+		c.Rate /= 2
+	case c.backedOff:
+		c.Rate += (c.Rate + 3) / 4
+	default:
+		c.Rate *= 2
+	}
+
+	if c.Rate < c.Min {
+		c.Rate = c.Min
+	}
+	if c.Rate > c.Max {
+		c.Rate = c.Max
+	}
+	return c.Rate
+}
+
+// runAdaptive scans the port chunks one burst at a time, letting a
+// RateController pick each burst's rate. Ports found open in earlier bursts
+// are probed again as canaries; the share of them that stops answering is
+// the loss signal. Canary responses are left out of the combined output so
+// every port is reported once.
+func (s *Scanner) runAdaptive(ctx context.Context, chunks []string, max int, build func(ports string, rate int) []string) ([]byte, string, error) {
+	controller := NewRateController(max)
+
+	var combined []byte
+	var commands []string
+	canaries := make(map[canaryKey]bool)
+
+	for i, chunk := range chunks {
+		ports, probed := withCanaryPorts(chunk, canaries)
+		args := build(ports, controller.Rate)
+		commands = append(commands, strings.Join(append([]string{s.path}, args...), " "))
+
+		output, err := s.runner.Output(ctx, s.path, args...)
+		kept, answered := splitCanaryLines(output, probed)
+		combined = append(combined, kept...)
+		if err != nil {
+			return combined, strings.Join(commands, " ; "), fmt.Errorf("masscan burst %d of %d on ports %s failed: %w", i+1, len(chunks), chunk, err)
+		}
+
+		expected, lost := 0, 0
+		for key := range canaries {
+			if !probed[key.port] {
+				continue
+			}
+			expected++
+			if !answered[key] {
+				lost++
+			}
+		}
+		if expected > 0 {
+			controller.Observe(float64(lost) / float64(expected))
+		} else {
+			// Nothing known to answer yet, so no loss can be seen
+			controller.Observe(0)
+		}
+
+		for key := range answered {
+			canaries[key] = true
+		}
+	}
+
+	return combined, strings.Join(commands, " ; "), nil
+}
+
+// canaryKey identifies an open port on a host
+type canaryKey struct {
+	ip   string
+	port int
+}
+
+// withCanaryPorts appends up to adaptiveCanaryPorts of the lowest known-open
+// port numbers outside chunk and returns them as a set
+func withCanaryPorts(chunk string, canaries map[canaryKey]bool) (string, map[int]bool) {
+	ranges, _, err := scanner.ParsePortRanges(chunk)
+	if err != nil {
+		return chunk, nil
+	}
+
+	var numbers []int
+	seen := make(map[int]bool)
+	for key := range canaries {
+		if !seen[key.port] && !scanner.PortInRanges(key.port, ranges) {
+			seen[key.port] = true
+			numbers = append(numbers, key.port)
+		}
+	}
+	sort.Ints(numbers)
+	if len(numbers) > adaptiveCanaryPorts {
+		numbers = numbers[:adaptiveCanaryPorts]
+	}
+
+	probed := make(map[int]bool, len(numbers))
+	specs := []string{chunk}
+	for _, port := range numbers {
+		probed[port] = true
+		specs = append(specs, strconv.Itoa(port))
+	}
+	return strings.Join(specs, ","), probed
+}
+
+// splitCanaryLines drops the canary responses from burst output and returns
+// the remaining lines with every open port answered, canaries included
+func splitCanaryLines(output []byte, probed map[int]bool) ([]byte, map[canaryKey]bool) {
+	answered := make(map[canaryKey]bool)
+	var kept []byte
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		result, ok := decodeResultLine(line)
+		if !ok || len(result.Ports) == 0 {
+			kept = append(kept, line+"\n"...)
+			continue
+		}
+
+		canary := false
+		for _, port := range result.Ports {
+			if port.Status == "open" {
+				answered[canaryKey{ip: result.IP, port: port.Port}] = true
+			}
+			if probed[port.Port] {
+				canary = true
+			}
+		}
+		if !canary {
+			kept = append(kept, line+"\n"...)
+		}
+	}
+
+	return kept, answered
+}
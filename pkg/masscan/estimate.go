@@ -0,0 +1,68 @@
+package masscan
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// Estimate predicts the packet count and duration of a scan from the
+// target size, port count, retries and rate. Split scans share the rate,
+// so splitting does not change the estimate.
+func (s *Scanner) Estimate(target string, config *scanner.ScanConfig) (*scanner.Estimate, error) {
+	hosts, err := scanner.CountTargetHosts(target)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ports, err := scanner.ParsePortRanges(config.Ports)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := config.Threads
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	wait := config.Wait
+	if wait <= 0 {
+		wait = DefaultWait
+	}
+
+	packets := scanner.EstimatePackets(hosts, ports, 1+config.Retries)
+	duration := scanner.EstimateDuration(packets, float64(rate))
+	if duration < time.Duration(math.MaxInt64)-time.Duration(wait)*time.Second {
+		duration += time.Duration(wait) * time.Second
+	}
+
+	path := s.path
+	if path == "" {
+		path = s.GetName()
+	}
+
+	var commands []string
+	chunks := []string{config.Ports}
+	chunkRate := rate
+	if config.Split > 1 {
+		if chunks, err = SplitPortRange(config.Ports, config.Split); err != nil {
+			return nil, err
+		}
+		if chunkRate = rate / len(chunks); chunkRate < 1 {
+			chunkRate = 1
+		}
+	}
+	for _, ports := range chunks {
+		commands = append(commands, strings.Join(append([]string{path}, buildArgs(target, ports, chunkRate, config)...), " "))
+	}
+
+	return &scanner.Estimate{
+		Hosts:            hosts,
+		Ports:            ports,
+		Packets:          packets,
+		PacketsPerSecond: float64(rate),
+		Duration:         duration,
+		Command:          strings.Join(commands, " & "),
+	}, nil
+}
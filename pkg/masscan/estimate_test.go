@@ -0,0 +1,69 @@
+package masscan
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func TestEstimate(t *testing.T) {
+	wait := DefaultWait * time.Second
+	tests := []struct {
+		name         string
+		config       scanner.ScanConfig
+		wantPackets  uint64
+		wantDuration time.Duration
+		wantCommands []string
+	}{
+		{
+			"default rate",
+			scanner.ScanConfig{Ports: "1-1000"},
+			256000, 256*time.Second + wait,
+			[]string{"/usr/bin/masscan 192.0.2.0/24 -p 1-1000 --rate 1000"},
+		},
+		{
+			"configured rate and retries",
+			scanner.ScanConfig{Ports: "1-1000", Threads: 10000, Retries: 1},
+			512000, 51200*time.Millisecond + wait,
+			[]string{"/usr/bin/masscan 192.0.2.0/24 -p 1-1000 --rate 10000"},
+		},
+		{
+			"split shares the rate",
+			scanner.ScanConfig{Ports: "1-1000", Threads: 10000, Split: 2},
+			256000, 25600*time.Millisecond + wait,
+			[]string{"/usr/bin/masscan 192.0.2.0/24 -p 1-500 --rate 5000", "/usr/bin/masscan 192.0.2.0/24 -p 501-1000 --rate 5000"},
+		},
+	}
+
+	s := &Scanner{path: "/usr/bin/masscan"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, err := s.Estimate("192.0.2.0/24", &tt.config)
+			if err != nil {
+				t.Fatalf("Estimate() error = %v", err)
+			}
+			if estimate.Hosts != 256 || estimate.Ports != 1000 {
+				t.Errorf("Estimate() counted %d hosts and %d ports, want 256 and 1000", estimate.Hosts, estimate.Ports)
+			}
+			if estimate.Packets != tt.wantPackets || estimate.Duration != tt.wantDuration {
+				t.Errorf("Estimate() = %d packets in %v, want %d in %v", estimate.Packets, estimate.Duration, tt.wantPackets, tt.wantDuration)
+			}
+
+			commands := strings.Split(estimate.Command, " & ")
+			if len(commands) != len(tt.wantCommands) {
+				t.Fatalf("Estimate() command = %q, want %d processes", estimate.Command, len(tt.wantCommands))
+			}
+			for i, command := range commands {
+				if !strings.HasPrefix(command, tt.wantCommands[i]) {
+					t.Errorf("command %d = %q, want it to start %q", i, command, tt.wantCommands[i])
+				}
+			}
+		})
+	}
+
+	if _, err := s.Estimate("192.0.2.0/24", &scanner.ScanConfig{Ports: "1-x"}); err == nil {
+		t.Error("Estimate() accepted an invalid port specification")
+	}
+}
@@ -4,31 +4,61 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/clock"
 	"github.com/netrecon/toolkit/internal/models"
 	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/services"
 )
 
+// Masscan defaults for --wait and --retries
+const (
+	DefaultWait    = 10
+	DefaultRetries = 0
+)
+
+// DefaultRate is the packet rate used when ScanConfig.Threads is unset
+const DefaultRate = 1000
+
 // Scanner implements the masscan scanner
 type Scanner struct {
-	path string
+	path   string
+	clock  clock.Clock
+	runner scanner.CommandRunner
 }
 
-// NewScanner creates a new masscan scanner
+// NewScanner creates a new masscan scanner running the local binary
 func NewScanner() (*Scanner, error) {
+	return NewScannerWithRunner(scanner.LocalRunner{})
+}
+
+// NewScannerWithRunner creates a masscan scanner that executes through runner,
+// for example on a remote jump host
+func NewScannerWithRunner(runner scanner.CommandRunner) (*Scanner, error) {
 	// Check if masscan is installed
-	path, err := exec.LookPath("masscan")
+	path, err := runner.LookPath("masscan")
 	if err != nil {
 		return nil, fmt.Errorf("masscan not found in PATH: %w", err)
 	}
 
-	return &Scanner{path: path}, nil
+	return &Scanner{path: path, clock: clock.Real{}, runner: runner}, nil
+}
+
+// NewParser creates a scanner that only parses masscan output, for use where
+// the binary is not installed; Scan is not available
+func NewParser() *Scanner {
+	return &Scanner{clock: clock.Real{}}
+}
+
+// SetClock replaces the clock used for scan timestamps
+func (s *Scanner) SetClock(c clock.Clock) {
+	s.clock = c
 }
 
 // GetName returns the scanner name
@@ -36,6 +66,34 @@ func (s *Scanner) GetName() string {
 	return "masscan"
 }
 
+// Capabilities reports the features available through masscan
+func (s *Scanner) Capabilities() scanner.Capabilities {
+	// Ports are passed as plain numbers, which masscan scans over TCP, and
+	// neither --banners nor OS fingerprinting is used
+	return scanner.Capabilities{
+		TCP:  true,
+		IPv6: true,
+	}
+}
+
+// BinaryPath returns the path of the masscan binary
+func (s *Scanner) BinaryPath() string {
+	return s.path
+}
+
+// Version runs "masscan --version" and returns the reported version
+func (s *Scanner) Version(ctx context.Context) (string, error) {
+	output, err := s.runner.Output(ctx, s.path, "--version")
+	if err != nil {
+		return "", fmt.Errorf("failed to run masscan --version: %w", err)
+	}
+	version := scanner.ParseVersionOutput(output)
+	if version == "" {
+		return "", fmt.Errorf("unrecognized masscan version output")
+	}
+	return version, nil
+}
+
 // ValidateConfig validates the masscan configuration
 func (s *Scanner) ValidateConfig(config *scanner.ScanConfig) error {
 	if config.Ports == "" {
@@ -52,6 +110,43 @@ func (s *Scanner) ValidateConfig(config *scanner.ScanConfig) error {
 		return fmt.Errorf("thread count too high: %d (max 100000)", config.Threads)
 	}
 
+	if config.Wait < 0 {
+		return fmt.Errorf("invalid wait: %d (must not be negative)", config.Wait)
+	}
+
+	if config.Retries < 0 {
+		return fmt.Errorf("invalid retries: %d (must not be negative)", config.Retries)
+	}
+
+	if config.Split < 0 || config.Split > MaxSplit {
+		return fmt.Errorf("invalid split: %d (must be between 0 and %d)", config.Split, MaxSplit)
+	}
+
+	if config.AdaptiveRate && config.Split > 1 {
+		return fmt.Errorf("adaptive rate and split cannot be combined")
+	}
+
+	if len(config.Scripts) > 0 {
+		return fmt.Errorf("masscan cannot run scripts; use nmap")
+	}
+
+	if err := config.ValidateProtocol(); err != nil {
+		return err
+	}
+	if config.ScansUDP() {
+		return fmt.Errorf("masscan scans TCP only; use nmap for UDP")
+	}
+
+	// Masscan only reports hosts that answered, so every host is up and the
+	// reported states never filter anything
+	if err := scanner.ValidateReportedStates(config.ReportedStates); err != nil {
+		return err
+	}
+
+	if err := scanner.ValidateExclusions(config.Exclude); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -61,77 +156,142 @@ func (s *Scanner) Scan(ctx context.Context, target string, config *scanner.ScanC
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	startTime := time.Now()
+	startTime := s.clock.Now()
 
-	// Build masscan command
-	args := []string{}
+	rate := config.Threads
+	if rate <= 0 {
+		rate = DefaultRate
+	}
 
-	// Add target
-	args = append(args, target)
+	exclude, cleanup, err := s.excludeArgs(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 
-	// Add ports
-	args = append(args, "-p", config.Ports)
+	var output []byte
+	var command string
+	build := func(ports string, rate int) []string {
+		return append(buildArgs(target, ports, rate, config), exclude...)
+	}
+	switch {
+	case config.AdaptiveRate:
+		var chunks []string
+		if chunks, err = SplitPortRange(config.Ports, AdaptiveBursts); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		output, command, err = s.runAdaptive(ctx, chunks, rate, build)
+	case config.Split > 1:
+		var chunks []string
+		if chunks, err = SplitPortRange(config.Ports, config.Split); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		output, command, err = s.runSplit(ctx, chunks, rate, build)
+	default:
+		args := build(config.Ports, rate)
+		command = strings.Join(append([]string{s.path}, args...), " ")
+		output, err = s.runner.Output(ctx, s.path, args...)
+	}
+	endTime := s.clock.Now()
+
+	result := &scanner.ScanResult{
+		Target:     target,
+		Scanner:    s.GetName(),
+		Status:     scanner.StatusCompleted,
+		StartTime:  startTime.Format(time.RFC3339),
+		EndTime:    endTime.Format(time.RFC3339),
+		DurationMs: endTime.Sub(startTime).Milliseconds(),
+		Command:    command,
+		RawOutput:  string(output),
+	}
 
-	// Add rate (threads)
-	if config.Threads > 0 {
-		args = append(args, "--rate", strconv.Itoa(config.Threads))
+	if err != nil {
+		result.Status = scanner.StatusForError(ctx)
+		result.Error = err.Error()
+		if result.Status != scanner.StatusFailed {
+			// Keep the hosts masscan wrote before it was stopped
+			result.Hosts, _, _ = s.parseMasscanJSON(output, config.MaxHosts)
+		}
+		result.MarkCompleteness(s.progress(target, config, endTime.Sub(startTime)))
+		return result, err
+	}
+
+	// Parse JSON output
+	hosts, found, limitErr := s.parseMasscanJSON(output, config.MaxHosts)
+	result.Hosts = hosts
+	if limitErr != nil {
+		result.Status = scanner.StatusCompletedWithErrors
+		result.Error = fmt.Sprintf("results truncated to %d of %d hosts: %v", len(hosts), found, limitErr)
+		result.MarkCompleteness(float64(len(hosts)), float64(found))
 	} else {
-		args = append(args, "--rate", "1000") // Default rate
+		result.MarkCompleteness(1, 1)
 	}
 
-	// Output in JSON format
-	args = append(args, "--output-format", "json")
+	return result, nil
+}
 
-	// Additional arguments
-	if config.Arguments != "" {
-		additionalArgs := strings.Fields(config.Arguments)
-		args = append(args, additionalArgs...)
+// progress estimates how much of a scan stopped after elapsed has run.
+// Masscan sends at a fixed rate in random order, so the elapsed share of
+// the estimated duration is the share of probes sent.
+func (s *Scanner) progress(target string, config *scanner.ScanConfig, elapsed time.Duration) (done, total float64) {
+	estimate, err := s.Estimate(target, config)
+	if err != nil || estimate.Duration <= 0 {
+		return 0, 0
+	}
+	return elapsed.Seconds(), estimate.Duration.Seconds()
+}
+
+// excludeArgs returns the flags making masscan skip the do-not-scan list.
+// The list is written to a file for --excludefile, removed by cleanup once
+// the scan is over; a jump host cannot read local files, so a remote runner
+// gets the list on the command line with --exclude instead.
+func (s *Scanner) excludeArgs(exclude []string) (args []string, cleanup func(), err error) {
+	cleanup = func() {}
+	if len(exclude) == 0 {
+		return nil, cleanup, nil
+	}
+	if _, local := s.runner.(scanner.LocalRunner); !local {
+		return []string{"--exclude", strings.Join(exclude, ",")}, cleanup, nil
 	}
 
-	// Execute masscan command
-	cmd := exec.CommandContext(ctx, s.path, args...)
-	output, err := cmd.Output()
+	file, err := os.CreateTemp("", "netrecon-exclude-*.txt")
 	if err != nil {
-		endTime := time.Now()
-		return &scanner.ScanResult{
-			Target:    target,
-			Scanner:   s.GetName(),
-			Status:    "failed",
-			StartTime: startTime.Format(time.RFC3339),
-			EndTime:   endTime.Format(time.RFC3339),
-			Duration:  endTime.Sub(startTime).String(),
-			RawOutput: string(output),
-			Error:     err.Error(),
-		}, err
+		return nil, cleanup, fmt.Errorf("failed to create exclude file: %w", err)
 	}
+	cleanup = func() { os.Remove(file.Name()) }
+	_, err = file.WriteString(strings.Join(exclude, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, func() {}, fmt.Errorf("failed to write exclude file: %w", err)
+	}
+	return []string{"--excludefile", file.Name()}, cleanup, nil
+}
 
-	endTime := time.Now()
+// buildArgs builds the masscan command line for one process
+func buildArgs(target, ports string, rate int, config *scanner.ScanConfig) []string {
+	// Targets first; masscan accepts IPv6 addresses and ranges natively
+	args := append(scanner.SplitTargets(target), "-p", ports, "--rate", strconv.Itoa(rate))
 
-	// Parse JSON output
-	hosts, parseErr := s.parseMasscanJSON(output)
-	if parseErr != nil {
-		return &scanner.ScanResult{
-			Target:    target,
-			Scanner:   s.GetName(),
-			Status:    "completed_with_errors",
-			StartTime: startTime.Format(time.RFC3339),
-			EndTime:   endTime.Format(time.RFC3339),
-			Duration:  endTime.Sub(startTime).String(),
-			RawOutput: string(output),
-			Error:     parseErr.Error(),
-		}, nil
-	}
-
-	return &scanner.ScanResult{
-		Target:    target,
-		Scanner:   s.GetName(),
-		Status:    "completed",
-		StartTime: startTime.Format(time.RFC3339),
-		EndTime:   endTime.Format(time.RFC3339),
-		Duration:  endTime.Sub(startTime).String(),
-		Hosts:     hosts,
-		RawOutput: string(output),
-	}, nil
+	// Reliability tuning for lossy or high-latency links
+	if config.Wait > 0 {
+		args = append(args, "--wait", strconv.Itoa(config.Wait))
+	}
+	if config.Retries > 0 {
+		args = append(args, "--retries", strconv.Itoa(config.Retries))
+	}
+
+	// Output in JSON format
+	args = append(args, "--output-format", "json")
+
+	// Additional arguments
+	if config.Arguments != "" {
+		args = append(args, strings.Fields(config.Arguments)...)
+	}
+
+	return args
 }
 
 // MasscanResult represents a masscan JSON result
@@ -147,56 +307,118 @@ type MasscanResult struct {
 	} `json:"ports"`
 }
 
-// parseMasscanJSON parses masscan JSON output
-func (s *Scanner) parseMasscanJSON(jsonData []byte) ([]*models.Host, error) {
-	// Masscan outputs one JSON object per line
-	lines := strings.Split(strings.TrimSpace(string(jsonData)), "\n")
-
-	hostMap := make(map[string]*models.Host)
+// decodeResultLine decodes one line of masscan JSON output. Masscan writes
+// its results as a JSON array with one element per line, so the brackets
+// and the commas separating elements, at either end of a line depending on
+// the masscan version, are ignored. Lines holding no result report false.
+func decodeResultLine(line string) (MasscanResult, bool) {
+	var result MasscanResult
+	line = strings.Trim(strings.TrimSpace(line), ",")
+	if line == "" || line == "[" || line == "]" {
+		return result, false
+	}
+	if err := json.Unmarshal([]byte(line), &result); err != nil {
+		return result, false
+	}
+	return result, true
+}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+// decodeResults decodes every result in masscan JSON output, skipping the
+// lines that hold none or are malformed
+func decodeResults(data []byte) []MasscanResult {
+	var results []MasscanResult
+	for _, line := range strings.Split(string(data), "\n") {
+		if result, ok := decodeResultLine(line); ok {
+			results = append(results, result)
 		}
+	}
+	return results
+}
 
-		var result MasscanResult
-		if err := json.Unmarshal([]byte(line), &result); err != nil {
-			// Skip malformed lines
-			continue
-		}
+// parseMasscanJSON parses masscan JSON output and returns the hosts along
+// with the number of distinct hosts found. When more than maxHosts are
+// present only the first maxHosts are kept and scanner.ErrMaxHostsExceeded
+// is returned with them.
+func (s *Scanner) parseMasscanJSON(jsonData []byte, maxHosts int) ([]*models.Host, int, error) {
+	hostMap := make(map[string]*models.Host)
+	var order []*models.Host
+	var limitErr error
 
-		// Get or create host
+	for _, result := range decodeResults(jsonData) {
+		// Get or create host; hosts past the cap are only counted
 		host, exists := hostMap[result.IP]
 		if !exists {
+			if maxHosts > 0 && len(order) >= maxHosts {
+				limitErr = fmt.Errorf("%w: more than %d hosts", scanner.ErrMaxHostsExceeded, maxHosts)
+				hostMap[result.IP] = nil
+				continue
+			}
 			host = &models.Host{
 				ID:        uuid.New(),
 				IPAddress: result.IP,
 				Status:    "up",
-				CreatedAt: time.Now(),
+				CreatedAt: s.clock.Now(),
 			}
 			hostMap[result.IP] = host
+			order = append(order, host)
+		}
+
+		if host == nil {
+			continue
 		}
 
 		// Add ports to host
 		for _, portInfo := range result.Ports {
-			_ = &models.Port{
+			host.Ports = append(host.Ports, &models.Port{
 				ID:        uuid.New(),
 				HostID:    host.ID,
 				Number:    portInfo.Port,
 				Protocol:  portInfo.Proto,
 				State:     portInfo.Status,
-				CreatedAt: time.Now(),
-			}
+				Reason:    portInfo.Reason,
+				ReasonTTL: portInfo.TTL,
+				CreatedAt: s.clock.Now(),
 
-			// Note: We can't directly add ports to host model here
-			// This would need to be handled by the calling code
+				GuessedService: services.ResolveService(portInfo.Port, portInfo.Proto),
+			})
 		}
 	}
 
-	// Convert map to slice
-	var hosts []*models.Host
-	for _, host := range hostMap {
-		hosts = append(hosts, host)
+	return order, len(hostMap), limitErr
+}
+
+// ParseRaw rebuilds hosts and their ports from stored masscan JSON output
+func (s *Scanner) ParseRaw(data []byte) ([]*models.HostGraph, error) {
+	hostMap := make(map[string]*models.HostGraph)
+	var hosts []*models.HostGraph
+
+	for _, result := range decodeResults(data) {
+		host, exists := hostMap[result.IP]
+		if !exists {
+			host = &models.HostGraph{Host: &models.Host{
+				ID:        uuid.New(),
+				IPAddress: result.IP,
+				Status:    "up",
+				CreatedAt: s.clock.Now(),
+			}}
+			hostMap[result.IP] = host
+			hosts = append(hosts, host)
+		}
+
+		for _, portInfo := range result.Ports {
+			host.Ports = append(host.Ports, &models.PortGraph{Port: &models.Port{
+				ID:        uuid.New(),
+				HostID:    host.ID,
+				Number:    portInfo.Port,
+				Protocol:  portInfo.Proto,
+				State:     portInfo.Status,
+				Reason:    portInfo.Reason,
+				ReasonTTL: portInfo.TTL,
+				CreatedAt: s.clock.Now(),
+
+				GuessedService: services.ResolveService(portInfo.Port, portInfo.Proto),
+			}})
+		}
 	}
 
 	return hosts, nil
@@ -204,20 +426,9 @@ func (s *Scanner) parseMasscanJSON(jsonData []byte) ([]*models.Host, error) {
 
 // GetPortsFromJSON extracts port information from masscan JSON output
 func (s *Scanner) GetPortsFromJSON(jsonData []byte, hostID uuid.UUID) ([]*models.Port, error) {
-	lines := strings.Split(strings.TrimSpace(string(jsonData)), "\n")
-
 	var ports []*models.Port
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		var result MasscanResult
-		if err := json.Unmarshal([]byte(line), &result); err != nil {
-			continue
-		}
-
+	for _, result := range decodeResults(jsonData) {
 		for _, portInfo := range result.Ports {
 			port := &models.Port{
 				ID:        uuid.New(),
@@ -225,7 +436,11 @@ func (s *Scanner) GetPortsFromJSON(jsonData []byte, hostID uuid.UUID) ([]*models
 				Number:    portInfo.Port,
 				Protocol:  portInfo.Proto,
 				State:     portInfo.Status,
-				CreatedAt: time.Now(),
+				Reason:    portInfo.Reason,
+				ReasonTTL: portInfo.TTL,
+				CreatedAt: s.clock.Now(),
+
+				GuessedService: services.ResolveService(portInfo.Port, portInfo.Proto),
 			}
 			ports = append(ports, port)
 		}
@@ -0,0 +1,273 @@
+package masscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// fakeRunner answers every command with canned output, recording the
+// arguments it was run with
+type fakeRunner struct {
+	output []byte
+
+	mu   sync.Mutex
+	args [][]string
+}
+
+func (r *fakeRunner) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+func (r *fakeRunner) Output(_ context.Context, _ string, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.args = append(r.args, args)
+	return r.output, nil
+}
+
+// arrayOutput is masscan -oJ output: a JSON array with one element per line
+// and the separating commas at the end of the lines, as older masscan
+// versions write them
+const arrayOutput = `[
+{"ip": "192.0.2.1", "timestamp": "1700000000", "ports": [{"port": 22, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]},
+{"ip": "192.0.2.2", "timestamp": "1700000000", "ports": [{"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 56}]},
+{"ip": "192.0.2.1", "timestamp": "1700000001", "ports": [{"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]}
+]
+`
+
+// leadingCommaOutput puts the separating commas at the start of the lines,
+// as newer masscan versions do
+const leadingCommaOutput = `[
+{"ip": "192.0.2.1", "timestamp": "1700000000", "ports": [{"port": 22, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]}
+,{"ip": "192.0.2.2", "timestamp": "1700000000", "ports": [{"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 56}]}
+,{"ip": "192.0.2.1", "timestamp": "1700000001", "ports": [{"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64}]}
+]
+`
+
+func TestDecodeResultLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		ip   string
+		ok   bool
+	}{
+		{"object", `{"ip": "192.0.2.1", "ports": []}`, "192.0.2.1", true},
+		{"trailing comma", `{"ip": "192.0.2.1", "ports": []},`, "192.0.2.1", true},
+		{"leading comma", ` ,{"ip": "192.0.2.1", "ports": []}`, "192.0.2.1", true},
+		{"opening bracket", "[", "", false},
+		{"closing bracket", "]", "", false},
+		{"blank", "   ", "", false},
+		{"malformed", `{"ip": "192.0.2.1"`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := decodeResultLine(tt.line)
+			if ok != tt.ok || result.IP != tt.ip {
+				t.Errorf("decodeResultLine(%q) = %q, %t; want %q, %t", tt.line, result.IP, ok, tt.ip, tt.ok)
+			}
+		})
+	}
+}
+
+// hostPorts maps each host address to its ports in order
+func hostPorts(hosts []*models.Host) map[string]string {
+	summary := make(map[string]string)
+	for _, host := range hosts {
+		var ports []string
+		for _, port := range host.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s %s %s", port.Number, port.Protocol, port.State, port.GuessedService))
+		}
+		summary[host.IPAddress] = strings.Join(ports, ", ")
+	}
+	return summary
+}
+
+func TestParseMasscanJSONMergesLines(t *testing.T) {
+	want := map[string]string{
+		"192.0.2.1": "22/tcp open ssh, 443/tcp open https",
+		"192.0.2.2": "80/tcp open http",
+	}
+
+	for name, output := range map[string]string{"trailing commas": arrayOutput, "leading commas": leadingCommaOutput} {
+		t.Run(name, func(t *testing.T) {
+			hosts, found, err := NewParser().parseMasscanJSON([]byte(output), 0)
+			if err != nil {
+				t.Fatalf("parseMasscanJSON() error = %v", err)
+			}
+			if found != 2 || len(hosts) != 2 {
+				t.Fatalf("parsed %d hosts (%d found), want 2", len(hosts), found)
+			}
+			if hosts[0].IPAddress != "192.0.2.1" {
+				t.Errorf("first host = %s, want hosts in the order masscan found them", hosts[0].IPAddress)
+			}
+			got := hostPorts(hosts)
+			for ip, ports := range want {
+				if got[ip] != ports {
+					t.Errorf("host %s ports = %q, want %q", ip, got[ip], ports)
+				}
+			}
+			for _, host := range hosts {
+				for _, port := range host.Ports {
+					if port.HostID != host.ID {
+						t.Errorf("port %d of %s belongs to host %s", port.Number, host.IPAddress, port.HostID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseMasscanJSONReasons(t *testing.T) {
+	hosts, _, err := NewParser().parseMasscanJSON([]byte(arrayOutput), 0)
+	if err != nil {
+		t.Fatalf("parseMasscanJSON() error = %v", err)
+	}
+
+	want := map[int]string{22: "syn-ack 64", 80: "syn-ack 56", 443: "syn-ack 64"}
+	for _, host := range hosts {
+		for _, port := range host.Ports {
+			if got := fmt.Sprintf("%s %d", port.Reason, port.ReasonTTL); got != want[port.Number] {
+				t.Errorf("port %d reason = %q, want %q", port.Number, got, want[port.Number])
+			}
+		}
+	}
+}
+
+func TestParseMasscanJSONMaxHosts(t *testing.T) {
+	hosts, found, err := NewParser().parseMasscanJSON([]byte(arrayOutput), 1)
+	if !errors.Is(err, scanner.ErrMaxHostsExceeded) {
+		t.Fatalf("parseMasscanJSON() error = %v, want ErrMaxHostsExceeded", err)
+	}
+	if len(hosts) != 1 || found != 2 {
+		t.Fatalf("kept %d of %d hosts, want 1 of 2", len(hosts), found)
+	}
+	// Later lines of a kept host still add their ports
+	if got := len(hosts[0].Ports); got != 2 {
+		t.Errorf("kept host has %d ports, want 2", got)
+	}
+}
+
+func TestParseRawMatchesScan(t *testing.T) {
+	graphs, err := NewParser().ParseRaw([]byte(leadingCommaOutput))
+	if err != nil {
+		t.Fatalf("ParseRaw() error = %v", err)
+	}
+	var hosts []*models.Host
+	for _, graph := range graphs {
+		host := *graph.Host
+		for _, port := range graph.Ports {
+			host.Ports = append(host.Ports, port.Port)
+		}
+		hosts = append(hosts, &host)
+	}
+	parsed, _, _ := NewParser().parseMasscanJSON([]byte(leadingCommaOutput), 0)
+
+	got, want := hostPorts(hosts), hostPorts(parsed)
+	for ip := range want {
+		if got[ip] != want[ip] {
+			t.Errorf("ParseRaw host %s ports = %q, want %q", ip, got[ip], want[ip])
+		}
+	}
+
+	ports, err := NewParser().GetPortsFromJSON([]byte(arrayOutput), graphs[0].ID)
+	if err != nil {
+		t.Fatalf("GetPortsFromJSON() error = %v", err)
+	}
+	if len(ports) != 3 {
+		t.Errorf("GetPortsFromJSON() returned %d ports, want 3", len(ports))
+	}
+}
+
+func TestScanResultStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxHosts     int
+		wantStatus   string
+		wantHosts    int
+		wantComplete bool
+	}{
+		{"all hosts", 0, scanner.StatusCompleted, 2, true},
+		{"truncated", 1, scanner.StatusCompletedWithErrors, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScannerWithRunner(&fakeRunner{output: []byte(arrayOutput)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := s.Scan(context.Background(), "192.0.2.0/30", &scanner.ScanConfig{Ports: "1-1000", MaxHosts: tt.maxHosts})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || len(result.Hosts) != tt.wantHosts || result.Complete != tt.wantComplete {
+				t.Errorf("Scan() = %s with %d hosts, complete %t; want %s with %d, %t",
+					result.Status, len(result.Hosts), result.Complete, tt.wantStatus, tt.wantHosts, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestExcludeArgs(t *testing.T) {
+	exclude := []string{"192.0.2.1/32", "198.51.100.0/24"}
+
+	// A remote runner cannot read local files
+	remote := &fakeRunner{output: []byte(arrayOutput)}
+	s, err := NewScannerWithRunner(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Scan(context.Background(), "192.0.2.0/24", &scanner.ScanConfig{Ports: "80", Exclude: exclude}); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if args := strings.Join(remote.args[0], " "); !strings.Contains(args, "--exclude 192.0.2.1/32,198.51.100.0/24") {
+		t.Errorf("remote masscan run with %q, want the list on the command line", args)
+	}
+
+	// A local run reads the list from a file removed afterwards
+	local := &Scanner{path: "/usr/bin/masscan", runner: scanner.LocalRunner{}}
+	args, cleanup, err := local.excludeArgs(exclude)
+	if err != nil {
+		t.Fatalf("excludeArgs() error = %v", err)
+	}
+	if len(args) != 2 || args[0] != "--excludefile" {
+		t.Fatalf("excludeArgs() = %v, want --excludefile", args)
+	}
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		t.Fatalf("exclude file not written: %v", err)
+	}
+	if string(data) != "192.0.2.1/32\n198.51.100.0/24\n" {
+		t.Errorf("exclude file = %q", data)
+	}
+	cleanup()
+	if _, err := os.Stat(args[1]); !os.IsNotExist(err) {
+		t.Errorf("exclude file left behind: %v", err)
+	}
+
+	if args, _, _ := local.excludeArgs(nil); args != nil {
+		t.Errorf("excludeArgs(nil) = %v, want no flags", args)
+	}
+}
+
+func TestBuildArgsIPv6Prefix(t *testing.T) {
+	// masscan takes IPv6 targets natively, without a flag
+	args := buildArgs("2001:db8::/64 192.0.2.0/24", "80", DefaultRate, &scanner.ScanConfig{})
+	if args[0] != "2001:db8::/64" || args[1] != "192.0.2.0/24" {
+		t.Errorf("targets passed as %v, want them unexpanded", args[:2])
+	}
+	for _, arg := range args {
+		if arg == "-6" {
+			t.Errorf("args %v have an nmap-only -6 flag", args)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package masscan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// MaxSplit is the largest number of concurrent masscan processes per scan
+const MaxSplit = 64
+
+// SplitPortRange divides a port specification such as "1-65535" or
+// "22,80,8000-9000" into at most n specifications covering every port
+// exactly once, with chunk sizes differing by at most one port
+func SplitPortRange(ports string, n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid split count: %d", n)
+	}
+
+	ranges, total, err := scanner.ParsePortRanges(ports)
+	if err != nil {
+		return nil, err
+	}
+	if n > total {
+		n = total
+	}
+
+	var chunks []string
+	var current []string
+	remaining := total
+	chunkLeft := (remaining + n - 1) / n
+	for _, r := range ranges {
+		for low := r.Low; low <= r.High; {
+			high := low + chunkLeft - 1
+			if high > r.High {
+				high = r.High
+			}
+			current = append(current, scanner.PortRange{Low: low, High: high}.String())
+
+			count := high - low + 1
+			chunkLeft -= count
+			remaining -= count
+			low = high + 1
+
+			if chunkLeft == 0 {
+				chunks = append(chunks, strings.Join(current, ","))
+				current = nil
+				n--
+				if n > 0 {
+					chunkLeft = (remaining + n - 1) / n
+				}
+			}
+		}
+	}
+
+	return chunks, nil
+}
+
+// runSplit scans each port chunk with its own masscan process, dividing the
+// configured rate between them so the combined rate stays within bounds.
+// Outputs are concatenated in chunk order; the first failure cancels the rest.
+func (s *Scanner) runSplit(ctx context.Context, chunks []string, rate int, build func(ports string, rate int) []string) ([]byte, string, error) {
+	chunkRate := rate / len(chunks)
+	if chunkRate < 1 {
+		chunkRate = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([][]byte, len(chunks))
+	commands := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	for i, ports := range chunks {
+		args := build(ports, chunkRate)
+		commands[i] = strings.Join(append([]string{s.path}, args...), " ")
+
+		wg.Add(1)
+		go func(i int, args []string) {
+			defer wg.Done()
+			outputs[i], errs[i] = s.runner.Output(ctx, s.path, args...)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, args)
+	}
+	wg.Wait()
+
+	var combined []byte
+	for _, output := range outputs {
+		combined = append(combined, output...)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			combined = append(combined, '\n')
+		}
+	}
+	command := strings.Join(commands, " & ")
+
+	for i, err := range errs {
+		if err != nil {
+			return combined, command, fmt.Errorf("masscan on ports %s failed: %w", chunks[i], err)
+		}
+	}
+	return combined, command, nil
+}
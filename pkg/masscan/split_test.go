@@ -0,0 +1,150 @@
+package masscan
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// chunkCoverage counts how many chunks list each port, failing on chunks
+// that do not parse
+func chunkCoverage(t *testing.T, chunks []string) (map[int]int, []int) {
+	t.Helper()
+	seen := make(map[int]int)
+	sizes := make([]int, len(chunks))
+	for i, chunk := range chunks {
+		ranges, total, err := scanner.ParsePortRanges(chunk)
+		if err != nil {
+			t.Fatalf("chunk %q does not parse: %v", chunk, err)
+		}
+		sizes[i] = total
+		for _, r := range ranges {
+			for port := r.Low; port <= r.High; port++ {
+				seen[port]++
+			}
+		}
+	}
+	return seen, sizes
+}
+
+func TestSplitPortRange(t *testing.T) {
+	tests := []struct {
+		ports      string
+		n          int
+		wantChunks int
+	}{
+		{"1-65535", 1, 1},
+		{"1-65535", 2, 2},
+		{"1-65535", 3, 3},
+		{"1-65535", 7, 7},
+		{"1-65535", 16, 16},
+		{"1-65535", MaxSplit, MaxSplit},
+		{"22,80,443,8000-9000", 4, 4},
+		{"22,80", 4, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ports+"/"+strconv.Itoa(tt.n), func(t *testing.T) {
+			chunks, err := SplitPortRange(tt.ports, tt.n)
+			if err != nil {
+				t.Fatalf("SplitPortRange() error = %v", err)
+			}
+			if len(chunks) != tt.wantChunks {
+				t.Fatalf("SplitPortRange() = %d chunks %q, want %d", len(chunks), chunks, tt.wantChunks)
+			}
+
+			ranges, _, _ := scanner.ParsePortRanges(tt.ports)
+			want := make(map[int]bool)
+			for _, r := range ranges {
+				for port := r.Low; port <= r.High; port++ {
+					want[port] = true
+				}
+			}
+
+			seen, sizes := chunkCoverage(t, chunks)
+			for port, count := range seen {
+				if !want[port] {
+					t.Errorf("port %d is not in %s", port, tt.ports)
+				}
+				if count > 1 {
+					t.Errorf("port %d is in %d chunks", port, count)
+				}
+			}
+			if len(seen) != len(want) {
+				t.Errorf("chunks cover %d ports, want %d", len(seen), len(want))
+			}
+
+			smallest, largest := sizes[0], sizes[0]
+			for _, size := range sizes {
+				if size < smallest {
+					smallest = size
+				}
+				if size > largest {
+					largest = size
+				}
+			}
+			if largest-smallest > 1 {
+				t.Errorf("chunk sizes %v differ by more than one port", sizes)
+			}
+		})
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := SplitPortRange("1-65535", n); err == nil {
+			t.Errorf("SplitPortRange(%d) succeeded", n)
+		}
+	}
+	if _, err := SplitPortRange("1-x", 2); err == nil {
+		t.Error("SplitPortRange() accepted an invalid port specification")
+	}
+}
+
+func TestScanSplit(t *testing.T) {
+	runner := &fakeRunner{output: []byte(arrayOutput)}
+	s, err := NewScannerWithRunner(runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.Scan(context.Background(), "192.0.2.0/30", &scanner.ScanConfig{Ports: "1-65535", Threads: 10000, Split: 4})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(runner.args) != 4 {
+		t.Fatalf("ran masscan %d times, want once per chunk", len(runner.args))
+	}
+
+	// Each process gets a quarter of the rate and its own ports
+	var chunks []string
+	for _, args := range runner.args {
+		if rate := flagArg(args, "--rate"); rate != "2500" {
+			t.Errorf("masscan run with --rate %s, want 2500", rate)
+		}
+		chunks = append(chunks, flagArg(args, "-p"))
+	}
+	seen, _ := chunkCoverage(t, chunks)
+	if len(seen) != 65535 {
+		t.Errorf("processes scanned %d ports, want 65535", len(seen))
+	}
+	for port, count := range seen {
+		if count > 1 {
+			t.Errorf("port %d scanned by %d processes", port, count)
+		}
+	}
+
+	// Every process reports the same hosts, which are merged
+	if len(result.Hosts) != 2 {
+		t.Errorf("Scan() found %d hosts, want the merged 2", len(result.Hosts))
+	}
+}
+
+// flagArg returns the value following flag in args
+func flagArg(args []string, flag string) string {
+	for i, arg := range args[:len(args)-1] {
+		if arg == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,325 @@
+package naabu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/services"
+)
+
+// Scanner implements the naabu scanner
+type Scanner struct {
+	path       string
+	clock      clock.Clock
+	runner     scanner.CommandRunner
+	maxThreads int
+}
+
+// NewScanner creates a new naabu scanner running the local binary
+func NewScanner() (*Scanner, error) {
+	return NewScannerWithRunner(scanner.LocalRunner{})
+}
+
+// NewScannerWithRunner creates a naabu scanner that executes through runner,
+// for example on a remote jump host
+func NewScannerWithRunner(runner scanner.CommandRunner) (*Scanner, error) {
+	path, err := runner.LookPath("naabu")
+	if err != nil {
+		return nil, fmt.Errorf("naabu not found in PATH: %w", err)
+	}
+
+	return &Scanner{path: path, clock: clock.Real{}, runner: runner}, nil
+}
+
+// NewParser creates a scanner that only parses naabu output, for use where
+// the binary is not installed; Scan is not available
+func NewParser() *Scanner {
+	return &Scanner{clock: clock.Real{}}
+}
+
+// SetClock replaces the clock used for scan timestamps
+func (s *Scanner) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetMaxThreads caps the worker threads a scan may request, 0 for no cap
+func (s *Scanner) SetMaxThreads(n int) {
+	s.maxThreads = n
+}
+
+// GetName returns the scanner name
+func (s *Scanner) GetName() string {
+	return "naabu"
+}
+
+// Capabilities reports the features available through naabu
+func (s *Scanner) Capabilities() scanner.Capabilities {
+	// Only open TCP ports are reported, without service or OS detection
+	return scanner.Capabilities{TCP: true}
+}
+
+// BinaryPath returns the path of the naabu binary
+func (s *Scanner) BinaryPath() string {
+	return s.path
+}
+
+var versionRegex = regexp.MustCompile(`(?i)current version:\s*v?(\d\S*)`)
+
+// Version runs "naabu -version" and returns the reported version
+func (s *Scanner) Version(ctx context.Context) (string, error) {
+	output, err := s.runner.Output(ctx, s.path, "-version")
+	if err != nil {
+		return "", fmt.Errorf("failed to run naabu -version: %w", err)
+	}
+	// naabu prints "Current Version: 2.3.1" rather than "version 2.3.1"
+	if m := versionRegex.FindSubmatch(output); m != nil {
+		return string(m[1]), nil
+	}
+	if version := scanner.ParseVersionOutput(output); version != "" {
+		return version, nil
+	}
+	return "", fmt.Errorf("unrecognized naabu version output")
+}
+
+// ValidateConfig validates the naabu configuration
+func (s *Scanner) ValidateConfig(config *scanner.ScanConfig) error {
+	if config.Ports == "" {
+		return fmt.Errorf("ports must be specified for naabu")
+	}
+	if err := scanner.ValidatePortSpec(config.Ports); err != nil {
+		return err
+	}
+
+	if config.Threads < 0 {
+		return fmt.Errorf("invalid thread count: %d (must not be negative)", config.Threads)
+	}
+	if s.maxThreads > 0 && config.Threads > s.maxThreads {
+		return fmt.Errorf("thread count too high: %d (max %d)", config.Threads, s.maxThreads)
+	}
+
+	if err := config.ValidateProtocol(); err != nil {
+		return err
+	}
+	if config.ScansUDP() {
+		return fmt.Errorf("naabu scans TCP only; use nmap for UDP")
+	}
+
+	if len(config.Scripts) > 0 {
+		return fmt.Errorf("naabu cannot run scripts; use nmap")
+	}
+
+	// Only hosts with open ports are reported, so every host is up
+	if err := scanner.ValidateReportedStates(config.ReportedStates); err != nil {
+		return err
+	}
+
+	if err := scanner.ValidateExclusions(config.Exclude); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Scan performs a naabu scan
+func (s *Scanner) Scan(ctx context.Context, target string, config *scanner.ScanConfig) (*scanner.ScanResult, error) {
+	if err := s.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	args := buildArgs(target, config)
+
+	startTime := s.clock.Now()
+	output, err := s.runner.Output(ctx, s.path, args...)
+	endTime := s.clock.Now()
+
+	result := &scanner.ScanResult{
+		Target:     target,
+		Scanner:    s.GetName(),
+		Status:     scanner.StatusCompleted,
+		StartTime:  startTime.Format(time.RFC3339),
+		EndTime:    endTime.Format(time.RFC3339),
+		DurationMs: endTime.Sub(startTime).Milliseconds(),
+		Command:    strings.Join(append([]string{s.path}, args...), " "),
+		RawOutput:  string(output),
+	}
+
+	if err != nil {
+		result.Status = scanner.StatusForError(ctx)
+		result.Error = err.Error()
+		if result.Status != scanner.StatusFailed {
+			// Keep the hosts naabu wrote before it was stopped
+			result.Hosts, _, _ = s.parseNaabuJSON(output, config.MaxHosts)
+		}
+		result.MarkCompleteness(scanner.TargetProgress(target, result.Hosts))
+		return result, err
+	}
+
+	hosts, found, parseErr := s.parseNaabuJSON(output, config.MaxHosts)
+	switch {
+	case errors.Is(parseErr, scanner.ErrMaxHostsExceeded):
+		result.Status = scanner.StatusCompletedWithErrors
+		result.Hosts = hosts
+		result.Error = fmt.Sprintf("results truncated to %d of %d hosts: %v", len(hosts), found, parseErr)
+		result.MarkCompleteness(float64(len(hosts)), float64(found))
+	case parseErr != nil:
+		result.Status = scanner.StatusCompletedWithErrors
+		result.Error = parseErr.Error()
+		result.MarkCompleteness(0, 0)
+	default:
+		result.Hosts = hosts
+		result.MarkCompleteness(1, 1)
+	}
+
+	return result, nil
+}
+
+// buildArgs builds the naabu command line. Targets are passed as one comma
+// separated host list; -silent keeps the banner out of the JSON lines.
+func buildArgs(target string, config *scanner.ScanConfig) []string {
+	args := []string{"-json", "-silent", "-p", config.Ports, "-host", strings.Join(scanner.SplitTargets(target), ",")}
+
+	if config.Threads > 0 {
+		args = append(args, "-c", strconv.Itoa(config.Threads))
+	}
+
+	// Skip the do-not-scan list
+	if len(config.Exclude) > 0 {
+		args = append(args, "-exclude-hosts", strings.Join(config.Exclude, ","))
+	}
+
+	// Additional arguments
+	if config.Arguments != "" {
+		args = append(args, strings.Fields(config.Arguments)...)
+	}
+
+	return args
+}
+
+// NaabuResult represents one line of naabu JSON output, an open port
+type NaabuResult struct {
+	Host     string `json:"host"`
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// parseNaabuJSON parses naabu JSON output and returns the hosts along with
+// the number of distinct hosts found. When more than maxHosts are present
+// only the first maxHosts are kept and scanner.ErrMaxHostsExceeded is
+// returned with them.
+func (s *Scanner) parseNaabuJSON(jsonData []byte, maxHosts int) ([]*models.Host, int, error) {
+	hostMap := make(map[string]*models.Host)
+	var order []*models.Host
+	var limitErr error
+
+	err := eachResult(jsonData, func(result NaabuResult) {
+		host, exists := hostMap[result.IP]
+		if !exists {
+			if maxHosts > 0 && len(order) >= maxHosts {
+				limitErr = fmt.Errorf("%w: more than %d hosts", scanner.ErrMaxHostsExceeded, maxHosts)
+				hostMap[result.IP] = nil
+				return
+			}
+			host = s.newHost(result)
+			hostMap[result.IP] = host
+			order = append(order, host)
+		}
+		if host == nil {
+			return
+		}
+
+		host.Ports = append(host.Ports, s.newPort(host.ID, result))
+	})
+	if err != nil {
+		return order, len(hostMap), err
+	}
+
+	return order, len(hostMap), limitErr
+}
+
+// ParseRaw rebuilds hosts and their ports from stored naabu JSON output
+func (s *Scanner) ParseRaw(data []byte) ([]*models.HostGraph, error) {
+	hostMap := make(map[string]*models.HostGraph)
+	var hosts []*models.HostGraph
+
+	err := eachResult(data, func(result NaabuResult) {
+		host, exists := hostMap[result.IP]
+		if !exists {
+			host = &models.HostGraph{Host: s.newHost(result)}
+			hostMap[result.IP] = host
+			hosts = append(hosts, host)
+		}
+
+		host.Ports = append(host.Ports, &models.PortGraph{Port: s.newPort(host.ID, result)})
+	})
+
+	return hosts, err
+}
+
+// eachResult calls fn with every open port in naabu JSON output, one object
+// per line. Malformed lines and lines without an address are skipped.
+func eachResult(data []byte, fn func(NaabuResult)) error {
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for lines.Scan() {
+		line := bytes.TrimSpace(lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var result NaabuResult
+		if err := json.Unmarshal(line, &result); err != nil || result.IP == "" {
+			continue
+		}
+		fn(result)
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read naabu output: %w", err)
+	}
+	return nil
+}
+
+// newHost creates an up host for the first port reported on an address,
+// named after the hostname it was scanned as, if any
+func (s *Scanner) newHost(result NaabuResult) *models.Host {
+	host := &models.Host{
+		ID:        uuid.New(),
+		IPAddress: result.IP,
+		Status:    "up",
+		CreatedAt: s.clock.Now(),
+	}
+	if result.Host != result.IP {
+		host.Hostname = result.Host
+	}
+	return host
+}
+
+// newPort creates the open port of a naabu result, TCP unless naabu says
+// otherwise
+func (s *Scanner) newPort(hostID uuid.UUID, result NaabuResult) *models.Port {
+	protocol := strings.ToLower(result.Protocol)
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return &models.Port{
+		ID:        uuid.New(),
+		HostID:    hostID,
+		Number:    result.Port,
+		Protocol:  protocol,
+		State:     "open",
+		CreatedAt: s.clock.Now(),
+
+		GuessedService: services.ResolveService(result.Port, protocol),
+	}
+}
@@ -0,0 +1,97 @@
+package naabu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// jsonOutput is naabu -json output: one open port per line, a host scanned
+// by name, and a line naabu does not emit for results
+const jsonOutput = `{"host":"192.0.2.1","ip":"192.0.2.1","port":22,"protocol":"tcp","timestamp":"2024-01-01T00:00:00Z"}
+{"host":"www.example.com","ip":"192.0.2.2","port":443,"protocol":"tcp","timestamp":"2024-01-01T00:00:00Z"}
+not json
+{"host":"192.0.2.1","ip":"192.0.2.1","port":80,"timestamp":"2024-01-01T00:00:01Z"}
+
+{"host":"","ip":"","port":8080}
+{"host":"192.0.2.3","ip":"192.0.2.3","port":53,"protocol":"UDP"}
+`
+
+func TestParseNaabuJSON(t *testing.T) {
+	hosts, found, err := NewParser().parseNaabuJSON([]byte(jsonOutput), 0)
+	if err != nil {
+		t.Fatalf("parseNaabuJSON() error = %v", err)
+	}
+	if found != 3 || len(hosts) != 3 {
+		t.Fatalf("parsed %d hosts (%d found), want 3", len(hosts), found)
+	}
+
+	want := []struct {
+		ip, hostname, ports string
+	}{
+		{"192.0.2.1", "", "22/tcp ssh, 80/tcp http"},
+		{"192.0.2.2", "www.example.com", "443/tcp https"},
+		{"192.0.2.3", "", "53/udp domain"},
+	}
+	for i, w := range want {
+		host := hosts[i]
+		var ports []string
+		for _, port := range host.Ports {
+			if port.HostID != host.ID || port.State != "open" {
+				t.Errorf("port %d of %s: host %s, state %q", port.Number, host.IPAddress, port.HostID, port.State)
+			}
+			ports = append(ports, fmt.Sprintf("%d/%s %s", port.Number, port.Protocol, port.GuessedService))
+		}
+		got := strings.Join(ports, ", ")
+		if host.IPAddress != w.ip || host.Hostname != w.hostname || got != w.ports {
+			t.Errorf("host %d = %s (%q) with %q, want %s (%q) with %q", i, host.IPAddress, host.Hostname, got, w.ip, w.hostname, w.ports)
+		}
+	}
+}
+
+func TestParseNaabuJSONMaxHosts(t *testing.T) {
+	hosts, found, err := NewParser().parseNaabuJSON([]byte(jsonOutput), 1)
+	if !errors.Is(err, scanner.ErrMaxHostsExceeded) {
+		t.Fatalf("parseNaabuJSON() error = %v, want ErrMaxHostsExceeded", err)
+	}
+	if len(hosts) != 1 || found != 3 {
+		t.Fatalf("kept %d of %d hosts, want 1 of 3", len(hosts), found)
+	}
+	if got := len(hosts[0].Ports); got != 2 {
+		t.Errorf("kept host has %d ports, want 2", got)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxThreads int
+		config     scanner.ScanConfig
+		wantErr    string
+	}{
+		{"valid", 0, scanner.ScanConfig{Ports: "1-1000", Threads: 50}, ""},
+		{"no ports", 0, scanner.ScanConfig{}, "ports must be specified"},
+		{"negative threads", 0, scanner.ScanConfig{Ports: "80", Threads: -1}, "must not be negative"},
+		{"threads at the cap", 100, scanner.ScanConfig{Ports: "80", Threads: 100}, ""},
+		{"threads over the cap", 100, scanner.ScanConfig{Ports: "80", Threads: 101}, "thread count too high: 101 (max 100)"},
+		{"no cap", 0, scanner.ScanConfig{Ports: "80", Threads: 100000}, ""},
+		{"scripts", 0, scanner.ScanConfig{Ports: "80", Scripts: []string{"vulners"}}, "cannot run scripts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewParser()
+			s.SetMaxThreads(tt.maxThreads)
+			err := s.ValidateConfig(&tt.config)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ValidateConfig() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("ValidateConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package nmap
+
+import (
+	"context"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// defaultPortCount is the number of ports nmap scans without -p (its top 1000)
+const defaultPortCount = 1000
+
+// discoveryProbes approximates the host discovery packets sent per host
+const discoveryProbes = 4
+
+// timingRates approximates the probes per second sent by each timing
+// template. T0 and T1 serialize probes with 5 minute and 15 second gaps;
+// the rest are ballpark figures for a responsive LAN.
+var timingRates = map[string]float64{
+	"0": 1.0 / 300,
+	"1": 1.0 / 15,
+	"2": 2.5,
+	"3": 300,
+	"4": 1000,
+	"5": 5000,
+}
+
+// Estimate predicts the packet count and duration of a scan from the
+// target size, port count and timing template
+func (s *Scanner) Estimate(target string, config *scanner.ScanConfig) (*scanner.Estimate, error) {
+	hosts, err := scanner.CountTargetHosts(target)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := defaultPortCount
+	if config.Ports != "" {
+		if _, ports, err = scanner.ParsePortRanges(config.Ports); err != nil {
+			return nil, err
+		}
+	}
+
+	timing := config.Timing
+	if timing == "" {
+		timing = "3"
+	}
+	rate, ok := timingRates[timing]
+	if !ok {
+		rate = timingRates["3"]
+	}
+
+	packets := scanner.EstimatePackets(hosts, ports+discoveryProbes, 1)
+
+	path := s.path
+	if path == "" {
+		path = s.GetName()
+	}
+	// A scan that would be refused is shown as requested
+	connect, _ := s.connectScan(context.Background(), config)
+
+	return &scanner.Estimate{
+		Hosts:            hosts,
+		Ports:            ports,
+		Packets:          packets,
+		PacketsPerSecond: rate,
+		Duration:         scanner.EstimateDuration(packets, rate),
+		Command:          strings.Join(append([]string{path}, buildArgs(target, config, connect)...), " "),
+	}, nil
+}
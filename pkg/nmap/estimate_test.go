@@ -0,0 +1,43 @@
+package nmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+func TestEstimate(t *testing.T) {
+	// Each host also gets the host discovery probes
+	const packets = 256 * (1000 + discoveryProbes)
+	tests := []struct {
+		name         string
+		config       scanner.ScanConfig
+		wantDuration time.Duration
+		wantArgs     string
+	}{
+		{"default timing", scanner.ScanConfig{Ports: "1-1000"}, 14*time.Minute + 16746*time.Millisecond, "-p 1-1000"},
+		{"aggressive timing", scanner.ScanConfig{Ports: "1-1000", Timing: "4"}, 4*time.Minute + 17024*time.Millisecond, "-T4"},
+		{"top 1000 ports", scanner.ScanConfig{Timing: "5"}, 51405 * time.Millisecond, "-T5"},
+	}
+
+	s := &Scanner{path: "/usr/bin/nmap"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, err := s.Estimate("192.0.2.0/24", &tt.config)
+			if err != nil {
+				t.Fatalf("Estimate() error = %v", err)
+			}
+			if estimate.Hosts != 256 || estimate.Ports != 1000 {
+				t.Errorf("Estimate() counted %d hosts and %d ports, want 256 and 1000", estimate.Hosts, estimate.Ports)
+			}
+			if diff := estimate.Duration - tt.wantDuration; estimate.Packets != packets || diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("Estimate() = %d packets in %v, want %d in %v", estimate.Packets, estimate.Duration, packets, tt.wantDuration)
+			}
+			if !strings.HasPrefix(estimate.Command, "/usr/bin/nmap ") || !strings.Contains(estimate.Command, tt.wantArgs) {
+				t.Errorf("Estimate() command = %q, want nmap run with %s", estimate.Command, tt.wantArgs)
+			}
+		})
+	}
+}
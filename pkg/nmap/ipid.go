@@ -0,0 +1,71 @@
+package nmap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// scriptIPIDSeq classifies a host's IP ID generation
+const scriptIPIDSeq = "ipidseq"
+
+// ipidMaxStep is the largest gap between consecutive IP IDs still treated
+// as the same incrementing counter
+const ipidMaxStep = 1024
+
+// NmapIPIDSequence is the IP ID sequence nmap records during OS detection
+type NmapIPIDSequence struct {
+	Class  string `xml:"class,attr"`
+	Values string `xml:"values,attr"` // Comma-separated hex IP IDs in probe order
+}
+
+// applyLoadBalancerHint flags hosts whose IP IDs look like several machines
+// answering for one address: the OS detection probes interleave two or more
+// separate incrementing counters, or ipidseq could not fit its samples to
+// any single pattern. Byte-swapped ("broken") counters are a single machine
+// and are not flagged.
+func applyLoadBalancerHint(host *models.Host, seq NmapIPIDSequence) {
+	if seq.Class == "Incremental" && countIPIDCounters(seq.Values) >= 2 {
+		host.LoadBalanced = true
+		return
+	}
+
+	for _, script := range host.HostScripts {
+		if script.ID == scriptIPIDSeq && strings.TrimSpace(script.Output) == "Unknown" {
+			host.LoadBalanced = true
+			return
+		}
+	}
+}
+
+// countIPIDCounters returns how many separate incrementing counters are
+// needed to explain a sequence of hex IP IDs. Each value extends the
+// counter it most closely follows, or starts a new one.
+func countIPIDCounters(values string) int {
+	var counters []uint64
+	for _, field := range strings.Split(values, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(field), 16, 16)
+		if err != nil {
+			continue
+		}
+
+		best := -1
+		for i, last := range counters {
+			step := (id - last) & 0xffff // IP IDs wrap at 16 bits
+			if step == 0 || step > ipidMaxStep {
+				continue
+			}
+			if best < 0 || step < (id-counters[best])&0xffff {
+				best = i
+			}
+		}
+
+		if best < 0 {
+			counters = append(counters, id)
+		} else {
+			counters[best] = id
+		}
+	}
+	return len(counters)
+}
@@ -1,34 +1,63 @@
 package nmap
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"net"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/analysis"
+	"github.com/netrecon/toolkit/internal/clock"
 	"github.com/netrecon/toolkit/internal/models"
 	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/services"
 )
 
+// OptionDataDir is the ScanConfig.Options key for a custom nmap data directory
+const OptionDataDir = "datadir"
+
 // Scanner implements the nmap scanner
 type Scanner struct {
-	path string
+	path   string
+	clock  clock.Clock
+	runner scanner.CommandRunner
 }
 
-// NewScanner creates a new nmap scanner
+// NewScanner creates a new nmap scanner running the local binary
 func NewScanner() (*Scanner, error) {
+	return NewScannerWithRunner(scanner.LocalRunner{})
+}
+
+// NewScannerWithRunner creates a nmap scanner that executes through runner,
+// for example on a remote jump host
+func NewScannerWithRunner(runner scanner.CommandRunner) (*Scanner, error) {
 	// Check if nmap is installed
-	path, err := exec.LookPath("nmap")
+	path, err := runner.LookPath("nmap")
 	if err != nil {
 		return nil, fmt.Errorf("nmap not found in PATH: %w", err)
 	}
 
-	return &Scanner{path: path}, nil
+	return &Scanner{path: path, clock: clock.Real{}, runner: runner}, nil
+}
+
+// NewParser creates a scanner that only parses nmap output, for use where
+// the binary is not installed; Scan is not available
+func NewParser() *Scanner {
+	return &Scanner{clock: clock.Real{}}
+}
+
+// SetClock replaces the clock used for scan timestamps
+func (s *Scanner) SetClock(c clock.Clock) {
+	s.clock = c
 }
 
 // GetName returns the scanner name
@@ -36,6 +65,36 @@ func (s *Scanner) GetName() string {
 	return "nmap"
 }
 
+// Capabilities reports the features available through nmap
+func (s *Scanner) Capabilities() scanner.Capabilities {
+	return scanner.Capabilities{
+		TCP:            true,
+		UDP:            true,
+		IPv6:           true,
+		OSDetection:    true,
+		ServiceVersion: true,
+		Scripts:        true,
+	}
+}
+
+// BinaryPath returns the path of the nmap binary
+func (s *Scanner) BinaryPath() string {
+	return s.path
+}
+
+// Version runs "nmap --version" and returns the reported version
+func (s *Scanner) Version(ctx context.Context) (string, error) {
+	output, err := s.runner.Output(ctx, s.path, "--version")
+	if err != nil {
+		return "", fmt.Errorf("failed to run nmap --version: %w", err)
+	}
+	version := scanner.ParseVersionOutput(output)
+	if version == "" {
+		return "", fmt.Errorf("unrecognized nmap version output")
+	}
+	return version, nil
+}
+
 // ValidateConfig validates the nmap configuration
 func (s *Scanner) ValidateConfig(config *scanner.ScanConfig) error {
 	if config.Ports != "" {
@@ -53,6 +112,49 @@ func (s *Scanner) ValidateConfig(config *scanner.ScanConfig) error {
 		}
 	}
 
+	if err := config.ValidateProtocol(); err != nil {
+		return err
+	}
+
+	if config.VersionIntensity < 0 || config.VersionIntensity > 9 {
+		return fmt.Errorf("invalid version intensity: %d (must be 1-9, or 0 for the default)", config.VersionIntensity)
+	}
+
+	if err := scanner.ValidateReportedStates(config.ReportedStates); err != nil {
+		return err
+	}
+	if err := scanner.ValidateExclusions(config.Exclude); err != nil {
+		return err
+	}
+	if config.OpenOnly && config.ReportsHostState(scanner.HostStateDown) {
+		return fmt.Errorf("reporting down hosts requires disabling open-only mode (nmap --open omits them)")
+	}
+
+	for _, script := range config.Scripts {
+		if !scriptNameRegex.MatchString(script) {
+			return fmt.Errorf("invalid NSE script: %q", script)
+		}
+	}
+
+	if config.NoDNS && len(config.DNSServers) > 0 {
+		return fmt.Errorf("DNS servers cannot be combined with disabling DNS resolution")
+	}
+	for _, server := range config.DNSServers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("invalid DNS server: %s (must be an IP address)", server)
+		}
+	}
+
+	if dataDir := config.Options[OptionDataDir]; dataDir != "" {
+		info, err := os.Stat(dataDir)
+		if err != nil {
+			return fmt.Errorf("invalid nmap data directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid nmap data directory: %s is not a directory", dataDir)
+		}
+	}
+
 	return nil
 }
 
@@ -62,9 +164,94 @@ func (s *Scanner) Scan(ctx context.Context, target string, config *scanner.ScanC
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	startTime := time.Now()
+	connect, err := s.connectScan(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := s.clock.Now()
 
-	// Build nmap command
+	args := buildArgs(target, config, connect)
+
+	// Execute nmap command
+	command := strings.Join(append([]string{s.path}, args...), " ")
+	output, err := s.runner.Output(ctx, s.path, args...)
+	endTime := s.clock.Now()
+
+	result := &scanner.ScanResult{
+		Target:     target,
+		Scanner:    s.GetName(),
+		Status:     scanner.StatusCompleted,
+		StartTime:  startTime.Format(time.RFC3339),
+		EndTime:    endTime.Format(time.RFC3339),
+		DurationMs: endTime.Sub(startTime).Milliseconds(),
+		Command:    command,
+		RawOutput:  string(output),
+	}
+	if connect {
+		result.Notices = append(result.Notices, "nmap is not running as root: using a TCP connect scan (-sT) without OS detection")
+	}
+
+	if err != nil {
+		result.Status = scanner.StatusForError(ctx)
+		result.Error = err.Error()
+		if result.Status != scanner.StatusFailed {
+			// Keep the hosts nmap wrote before it was stopped
+			result.Hosts, _, _ = s.parseNmapXML(output, config)
+		}
+		result.MarkCompleteness(scanner.TargetProgress(target, result.Hosts))
+		return result, err
+	}
+
+	// Parse XML output
+	hosts, reported, parseErr := s.parseNmapXML(output, config)
+	result.Hosts = hosts
+	switch {
+	case errors.Is(parseErr, scanner.ErrMaxHostsExceeded):
+		result.Status = scanner.StatusCompletedWithErrors
+		result.Error = fmt.Sprintf("results truncated to %d of %d hosts: %v", len(hosts), reported, parseErr)
+		result.MarkCompleteness(float64(len(hosts)), float64(reported))
+	case parseErr != nil:
+		// Keep the hosts decoded before the error
+		result.Status = scanner.StatusCompletedWithErrors
+		result.Error = fmt.Sprintf("parsed %d hosts before error: %v", len(hosts), parseErr)
+		result.MarkCompleteness(scanner.TargetProgress(target, hosts))
+	default:
+		result.MarkCompleteness(1, 1)
+	}
+
+	return result, nil
+}
+
+// rawScanArgs are nmap options that only work with raw sockets
+var rawScanArgs = map[string]bool{
+	"-sS": true, "-sA": true, "-sW": true, "-sM": true, "-sN": true, "-sF": true, "-sX": true,
+	"-sU": true, "-sO": true, "-sY": true, "-sZ": true, "-O": true, "--osscan-guess": true,
+}
+
+// connectScan reports whether the scan falls back to a TCP connect scan
+// because nmap cannot open raw sockets, which SYN scans and OS detection
+// need. Raw-socket options the user asked for are refused with
+// scanner.ErrUnprivileged instead of being dropped silently.
+func (s *Scanner) connectScan(ctx context.Context, config *scanner.ScanConfig) (bool, error) {
+	if s.runner == nil || scanner.Privileged(ctx, s.runner) {
+		return false, nil
+	}
+
+	if config.ScansUDP() {
+		return false, fmt.Errorf("%w: UDP scans (-sU) cannot fall back to a connect scan", scanner.ErrUnprivileged)
+	}
+	for _, arg := range strings.Fields(config.Arguments) {
+		if rawScanArgs[arg] || strings.HasPrefix(arg, "-sI") {
+			return false, fmt.Errorf("%w: %s was requested; run as root or remove it to fall back to a connect scan (-sT)", scanner.ErrUnprivileged, arg)
+		}
+	}
+	return true, nil
+}
+
+// buildArgs builds the nmap command line for a scan; connect replaces
+// nmap's default SYN scan with a TCP connect scan and drops OS detection
+func buildArgs(target string, config *scanner.ScanConfig, connect bool) []string {
 	args := []string{"-oX", "-"} // Output XML to stdout
 
 	// Add port specification
@@ -77,11 +264,74 @@ func (s *Scanner) Scan(ctx context.Context, target string, config *scanner.ScanC
 		args = append(args, "-T"+config.Timing)
 	}
 
+	// Use custom fingerprint and service databases
+	if dataDir := config.Options[OptionDataDir]; dataDir != "" {
+		args = append(args, "--datadir", dataDir)
+	}
+
+	// Resolve through specific servers, e.g. the internal view of split-horizon DNS
+	if config.NoDNS {
+		args = append(args, "-n")
+	} else if len(config.DNSServers) > 0 {
+		args = append(args, "--dns-servers", strings.Join(config.DNSServers, ","))
+	}
+
+	// Let nmap drop closed/filtered ports itself to keep the XML small
+	if config.OpenOnly {
+		args = append(args, "--open")
+	}
+
+	// Nmap only writes down hosts to its output in verbose mode
+	if config.ReportsHostState(scanner.HostStateDown) {
+		args = append(args, "-v")
+	}
+
+	// UDP ports rarely answer empty probes and mostly come back open|filtered.
+	// Version detection sends each port's protocol-specific payload, which
+	// confirms open services; intensity 0 limits it to those payloads. With
+	// -sU nmap scans TCP only when a TCP scan type is given too, and TCP
+	// service detection keeps the default intensity.
+	switch config.ScanProtocol() {
+	case scanner.ProtocolUDP:
+		args = append(args, "-sU")
+	case scanner.ProtocolBoth:
+		args = append(args, "-sU", "-sS")
+	default:
+		if connect {
+			args = append(args, "-sT")
+		}
+	}
+
 	// Add service detection
 	args = append(args, "-sV")
+	if config.VersionIntensity > 0 {
+		args = append(args, "--version-intensity", strconv.Itoa(config.VersionIntensity))
+	} else if config.ScanProtocol() == scanner.ProtocolUDP {
+		args = append(args, "--version-intensity", "0")
+	}
+
+	// Add OS detection, which needs raw sockets
+	if !connect {
+		args = append(args, "-O")
+	}
 
-	// Add OS detection
-	args = append(args, "-O")
+	// Run NSE scripts against the ports found open
+	if len(config.Scripts) > 0 {
+		args = append(args, "--script", strings.Join(config.Scripts, ","))
+	}
+
+	// IPv6 targets are passed through unexpanded and need nmap's -6 mode;
+	// batches never mix them with IPv4 targets
+	targets := scanner.SplitTargets(target)
+	ipv6 := len(targets) > 0 && scanner.IsIPv6Target(targets[0])
+	if ipv6 {
+		args = append(args, "-6")
+	}
+
+	// Skip the do-not-scan list; nmap rejects exclusions of the other family
+	if exclude := scanner.FamilyExclusions(config.Exclude, ipv6); len(exclude) > 0 {
+		args = append(args, "--exclude", strings.Join(exclude, ","))
+	}
 
 	// Add additional arguments
 	if config.Arguments != "" {
@@ -89,53 +339,10 @@ func (s *Scanner) Scan(ctx context.Context, target string, config *scanner.ScanC
 		args = append(args, additionalArgs...)
 	}
 
-	// Add target
-	args = append(args, target)
+	// Add targets
+	args = append(args, targets...)
 
-	// Execute nmap command
-	cmd := exec.CommandContext(ctx, s.path, args...)
-	output, err := cmd.Output()
-	if err != nil {
-		endTime := time.Now()
-		return &scanner.ScanResult{
-			Target:    target,
-			Scanner:   s.GetName(),
-			Status:    "failed",
-			StartTime: startTime.Format(time.RFC3339),
-			EndTime:   endTime.Format(time.RFC3339),
-			Duration:  endTime.Sub(startTime).String(),
-			RawOutput: string(output),
-			Error:     err.Error(),
-		}, err
-	}
-
-	endTime := time.Now()
-
-	// Parse XML output
-	hosts, parseErr := s.parseNmapXML(output)
-	if parseErr != nil {
-		return &scanner.ScanResult{
-			Target:    target,
-			Scanner:   s.GetName(),
-			Status:    "completed_with_errors",
-			StartTime: startTime.Format(time.RFC3339),
-			EndTime:   endTime.Format(time.RFC3339),
-			Duration:  endTime.Sub(startTime).String(),
-			RawOutput: string(output),
-			Error:     parseErr.Error(),
-		}, nil
-	}
-
-	return &scanner.ScanResult{
-		Target:    target,
-		Scanner:   s.GetName(),
-		Status:    "completed",
-		StartTime: startTime.Format(time.RFC3339),
-		EndTime:   endTime.Format(time.RFC3339),
-		Duration:  endTime.Sub(startTime).String(),
-		Hosts:     hosts,
-		RawOutput: string(output),
-	}, nil
+	return args
 }
 
 // NmapRun represents the root XML element
@@ -146,23 +353,35 @@ type NmapRun struct {
 
 // NmapHost represents a host in the XML output
 type NmapHost struct {
-	XMLName   xml.Name      `xml:"host"`
-	Status    NmapStatus    `xml:"status"`
-	Address   []NmapAddress `xml:"address"`
-	Hostnames NmapHostnames `xml:"hostnames"`
-	Ports     NmapPorts     `xml:"ports"`
-	OS        NmapOS        `xml:"os"`
+	XMLName     xml.Name         `xml:"host"`
+	TimedOut    bool             `xml:"timedout,attr"` // Set when --host-timeout cut the host short
+	Status      NmapStatus       `xml:"status"`
+	Address     []NmapAddress    `xml:"address"`
+	Hostnames   NmapHostnames    `xml:"hostnames"`
+	Ports       NmapPorts        `xml:"ports"`
+	OS          NmapOS           `xml:"os"`
+	HostScripts NmapHostScript   `xml:"hostscript"`
+	IPIDSeq     NmapIPIDSequence `xml:"ipidsequence"`
+	Uptime      NmapUptime       `xml:"uptime"`
+}
+
+// NmapUptime is nmap's uptime guess from TCP timestamps
+type NmapUptime struct {
+	Seconds  int64  `xml:"seconds,attr"`
+	LastBoot string `xml:"lastboot,attr"` // ctime format in the scanner's local time zone
 }
 
 // NmapStatus represents host status
 type NmapStatus struct {
-	State string `xml:"state,attr"`
+	State  string `xml:"state,attr"`
+	Reason string `xml:"reason,attr"`
 }
 
 // NmapAddress represents an address
 type NmapAddress struct {
 	Addr     string `xml:"addr,attr"`
 	AddrType string `xml:"addrtype,attr"`
+	Vendor   string `xml:"vendor,attr"`
 }
 
 // NmapHostnames contains hostnames
@@ -177,20 +396,31 @@ type NmapHostname struct {
 
 // NmapPorts contains port information
 type NmapPorts struct {
-	Ports []NmapPort `xml:"port"`
+	ExtraPorts []NmapExtraPorts `xml:"extraports"`
+	Ports      []NmapPort       `xml:"port"`
+}
+
+// NmapExtraPorts counts ports nmap collapsed into one state instead of
+// listing them, as in "Not shown: 997 filtered ports"
+type NmapExtraPorts struct {
+	State string `xml:"state,attr"`
+	Count int    `xml:"count,attr"`
 }
 
 // NmapPort represents a port
 type NmapPort struct {
-	Protocol string      `xml:"protocol,attr"`
-	PortID   int         `xml:"portid,attr"`
-	State    NmapState   `xml:"state"`
-	Service  NmapService `xml:"service"`
+	Protocol string       `xml:"protocol,attr"`
+	PortID   int          `xml:"portid,attr"`
+	State    NmapState    `xml:"state"`
+	Service  NmapService  `xml:"service"`
+	Scripts  []NmapScript `xml:"script"`
 }
 
 // NmapState represents port state
 type NmapState struct {
-	State string `xml:"state,attr"`
+	State     string `xml:"state,attr"`
+	Reason    string `xml:"reason,attr"`
+	ReasonTTL int    `xml:"reason_ttl,attr"`
 }
 
 // NmapService represents service information
@@ -201,6 +431,32 @@ type NmapService struct {
 	Info    string `xml:"extrainfo,attr"`
 }
 
+// NmapHostScript contains host-level script results
+type NmapHostScript struct {
+	Scripts []NmapScript `xml:"script"`
+}
+
+// NmapScript represents an NSE script result
+type NmapScript struct {
+	ID       string      `xml:"id,attr"`
+	Output   string      `xml:"output,attr"`
+	Elements []NmapElem  `xml:"elem"`
+	Tables   []NmapTable `xml:"table"`
+}
+
+// NmapTable is a nested, optionally keyed group of structured script output
+type NmapTable struct {
+	Key      string      `xml:"key,attr"`
+	Elements []NmapElem  `xml:"elem"`
+	Tables   []NmapTable `xml:"table"`
+}
+
+// NmapElem represents a keyed value in structured script output
+type NmapElem struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
 // NmapOS represents OS information
 type NmapOS struct {
 	OSMatches []NmapOSMatch `xml:"osmatch"`
@@ -212,44 +468,251 @@ type NmapOSMatch struct {
 	Accuracy int    `xml:"accuracy,attr"`
 }
 
-// parseNmapXML parses nmap XML output
-func (s *Scanner) parseNmapXML(xmlData []byte) ([]*models.Host, error) {
-	var nmapRun NmapRun
-	if err := xml.Unmarshal(xmlData, &nmapRun); err != nil {
-		return nil, fmt.Errorf("failed to parse nmap XML: %w", err)
+// parseNmapXML parses nmap XML output host by host and returns the hosts in
+// states the config reports, along with how many such hosts the output
+// holds. Hosts past config.MaxHosts are only counted, not converted, and
+// scanner.ErrMaxHostsExceeded is returned with the first MaxHosts. Parsing
+// is best-effort: on malformed or truncated XML the hosts decoded before the
+// error are returned along with it.
+func (s *Scanner) parseNmapXML(xmlData []byte, config *scanner.ScanConfig) ([]*models.Host, int, error) {
+	var hosts []*models.Host
+	reported := 0
+
+	err := walkNmapHosts(xmlData, func(nmapHost NmapHost) error {
+		if !config.ReportsHostState(nmapHost.Status.State) {
+			return nil
+		}
+		reported++
+		if config.MaxHosts > 0 && len(hosts) >= config.MaxHosts {
+			return nil
+		}
+		host := s.convertHost(nmapHost)
+		if !config.CountFiltered {
+			host.FilteredPortCount = 0
+		}
+		for _, port := range s.convertPorts(nmapHost, host.ID) {
+			host.Ports = append(host.Ports, port.Port)
+		}
+		hosts = append(hosts, host)
+		return nil
+	})
+	if err == nil && reported > len(hosts) {
+		err = fmt.Errorf("%w: more than %d hosts", scanner.ErrMaxHostsExceeded, config.MaxHosts)
 	}
 
-	var hosts []*models.Host
+	return hosts, reported, err
+}
 
-	for _, nmapHost := range nmapRun.Hosts {
-		host := &models.Host{
-			ID:        uuid.New(),
-			Status:    nmapHost.Status.State,
-			CreatedAt: time.Now(),
+// ParseRaw rebuilds hosts and their ports from stored nmap XML output. On a
+// parse error the hosts decoded before it are returned with the error.
+func (s *Scanner) ParseRaw(data []byte) ([]*models.HostGraph, error) {
+	var hosts []*models.HostGraph
+
+	err := walkNmapHosts(data, func(nmapHost NmapHost) error {
+		host := s.convertHost(nmapHost)
+		hosts = append(hosts, &models.HostGraph{
+			Host:  host,
+			Ports: s.convertPorts(nmapHost, host.ID),
+		})
+		return nil
+	})
+
+	return hosts, err
+}
+
+// walkNmapHosts decodes nmap XML one host element at a time, calling fn for
+// each host. Parsing stops at the first error returned by fn.
+func walkNmapHosts(xmlData []byte, fn func(NmapHost) error) error {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlData))
+	sawRoot := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nmapXMLError(err)
 		}
 
-		// Get IP address
-		for _, addr := range nmapHost.Address {
-			if addr.AddrType == "ipv4" {
-				host.IPAddress = addr.Addr
-				break
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "nmaprun":
+			sawRoot = true
+		case "host":
+			var nmapHost NmapHost
+			if err := decoder.DecodeElement(&nmapHost, &start); err != nil {
+				return nmapXMLError(err)
+			}
+			if err := fn(nmapHost); err != nil {
+				return err
 			}
 		}
+	}
 
-		// Get hostname
-		if len(nmapHost.Hostnames.Hostnames) > 0 {
-			host.Hostname = nmapHost.Hostnames.Hostnames[0].Name
+	if !sawRoot {
+		return fmt.Errorf("failed to parse nmap XML: missing nmaprun element")
+	}
+
+	return nil
+}
+
+// nmapXMLError wraps a decoding error, reporting output that simply stops
+// mid-document as scanner.ErrTruncatedOutput
+func nmapXMLError(err error) error {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Msg == "unexpected EOF" {
+		return fmt.Errorf("failed to parse nmap XML: %w at line %d", scanner.ErrTruncatedOutput, syntaxErr.Line)
+	}
+	return fmt.Errorf("failed to parse nmap XML: %w", err)
+}
+
+// convertHost converts a parsed nmap host element into a models.Host
+func (s *Scanner) convertHost(nmapHost NmapHost) *models.Host {
+	host := &models.Host{
+		ID:        uuid.New(),
+		Status:    nmapHost.Status.State,
+		CreatedAt: s.clock.Now(),
+	}
+
+	// Nmap lists each address type in its own element; IPAddress prefers
+	// IPv4 and falls back to IPv6 for hosts without an IPv4 address
+	for _, addr := range nmapHost.Address {
+		switch addr.AddrType {
+		case "ipv4":
+			if host.IPAddress == "" {
+				host.IPAddress = addr.Addr
+			}
+		case "ipv6":
+			if host.IPv6Address == "" {
+				host.IPv6Address = addr.Addr
+			}
+		case "mac":
+			host.MACAddress = addr.Addr
+			host.Vendor = addr.Vendor
 		}
+	}
+	if host.IPAddress == "" {
+		host.IPAddress = host.IPv6Address
+	}
+
+	// Get hostname
+	if len(nmapHost.Hostnames.Hostnames) > 0 {
+		host.Hostname = nmapHost.Hostnames.Hostnames[0].Name
+	}
+
+	// Get OS information
+	if len(nmapHost.OS.OSMatches) > 0 {
+		osMatch := nmapHost.OS.OSMatches[0]
+		host.OS = osMatch.Name
+		host.OSConfidence = osMatch.Accuracy
+	}
+	host.OSFamily = analysis.ClassifyOS(host.OS)
 
-		// Get OS information
-		if len(nmapHost.OS.OSMatches) > 0 {
-			osMatch := nmapHost.OS.OSMatches[0]
-			host.OS = osMatch.Name
-			host.OSConfidence = osMatch.Accuracy
+	// Get host-level script results
+	for _, script := range nmapHost.HostScripts.Scripts {
+		hostScript := models.HostScript{
+			ID:     script.ID,
+			Output: strings.TrimSpace(script.Output),
+		}
+		for _, elem := range script.Elements {
+			if elem.Key == "" {
+				continue
+			}
+			if hostScript.Elements == nil {
+				hostScript.Elements = make(map[string]string)
+			}
+			// smb scripts print NetBIOS names with a literal NUL terminator
+			hostScript.Elements[elem.Key] = strings.TrimSuffix(strings.TrimSpace(elem.Value), `\x00`)
+		}
+		host.HostScripts = append(host.HostScripts, hostScript)
+	}
+	applySMBInfo(host)
+	applyLoadBalancerHint(host, nmapHost.IPIDSeq)
+
+	// Get uptime, preferring the boot time nmap printed and deriving it otherwise
+	if nmapHost.Uptime.Seconds > 0 {
+		host.UptimeSeconds = nmapHost.Uptime.Seconds
+		lastBoot, err := time.ParseInLocation(time.ANSIC, nmapHost.Uptime.LastBoot, time.Local)
+		if err != nil {
+			lastBoot = host.CreatedAt.Add(-time.Duration(nmapHost.Uptime.Seconds) * time.Second)
 		}
+		host.LastBoot = &lastBoot
+	}
 
-		hosts = append(hosts, host)
+	host.FilteredPortCount = filteredPortCount(nmapHost.Ports)
+	host.ScanError = hostScanError(nmapHost)
+
+	return host
+}
+
+// unreachableReasons maps the ICMP errors nmap gives as the reason a host
+// is down to a description
+var unreachableReasons = map[string]string{
+	"net-unreach":      "network unreachable",
+	"host-unreach":     "host unreachable",
+	"proto-unreach":    "protocol unreachable",
+	"net-prohibited":   "network administratively prohibited",
+	"host-prohibited":  "host administratively prohibited",
+	"admin-prohibited": "communication administratively prohibited",
+}
+
+// hostScanError describes why nmap could not fully scan a host: it timed
+// out, leaving partial results, or a router answered for it with an ICMP
+// error. Hosts that are simply down have no error.
+func hostScanError(nmapHost NmapHost) string {
+	if nmapHost.TimedOut {
+		return "host timed out; results are partial"
 	}
+	if description, ok := unreachableReasons[nmapHost.Status.Reason]; ok {
+		return fmt.Sprintf("%s (%s)", description, nmapHost.Status.Reason)
+	}
+	return ""
+}
+
+// filteredPortCount adds up the filtered ports nmap collapsed into
+// extraports and those it listed individually
+func filteredPortCount(ports NmapPorts) int {
+	count := 0
+	for _, extra := range ports.ExtraPorts {
+		if extra.State == "filtered" {
+			count += extra.Count
+		}
+	}
+	for _, port := range ports.Ports {
+		if port.State.State == "filtered" {
+			count++
+		}
+	}
+	return count
+}
 
-	return hosts, nil
+// convertPorts converts the port elements of a parsed nmap host
+func (s *Scanner) convertPorts(nmapHost NmapHost, hostID uuid.UUID) []*models.PortGraph {
+	var ports []*models.PortGraph
+	for _, nmapPort := range nmapHost.Ports.Ports {
+		vulns := s.portVulnerabilities(nmapPort)
+		ports = append(ports, &models.PortGraph{Port: &models.Port{
+			ID:        uuid.New(),
+			HostID:    hostID,
+			Number:    nmapPort.PortID,
+			Protocol:  nmapPort.Protocol,
+			State:     nmapPort.State.State,
+			Service:   nmapPort.Service.Name,
+			Version:   nmapPort.Service.Version,
+			Product:   nmapPort.Service.Product,
+			ExtraInfo: nmapPort.Service.Info,
+			Reason:    nmapPort.State.Reason,
+			ReasonTTL: nmapPort.State.ReasonTTL,
+			CreatedAt: s.clock.Now(),
+
+			GuessedService:  services.ResolveService(nmapPort.PortID, nmapPort.Protocol),
+			Vulnerabilities: vulns,
+		}, Vulnerabilities: vulns})
+	}
+	return ports
 }
@@ -0,0 +1,713 @@
+package nmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// flagValue returns the argument following flag, or "" when flag is absent
+func flagValue(args []string, flag string) string {
+	if i := slices.Index(args, flag); i >= 0 && i+1 < len(args) {
+		return args[i+1]
+	}
+	return ""
+}
+
+func TestBuildArgsExclusions(t *testing.T) {
+	exclude := []string{"192.0.2.1/32", "2001:db8::/32", "198.51.100.0/24"}
+
+	tests := []struct {
+		name     string
+		target   string
+		exclude  []string
+		want     string
+		wantIPv6 bool
+	}{
+		{"IPv4 target", "192.0.2.0/24", exclude, "192.0.2.1/32,198.51.100.0/24", false},
+		{"IPv6 target", "2001:db8::/64", exclude, "2001:db8::/32", true},
+		{"only the other family", "2001:db8::1", []string{"192.0.2.1/32"}, "", true},
+		{"no exclusions", "192.0.2.0/24", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildArgs(tt.target, &scanner.ScanConfig{Exclude: tt.exclude}, false)
+			if got := flagValue(args, "--exclude"); got != tt.want {
+				t.Errorf("--exclude %q, want %q (args %v)", got, tt.want, args)
+			}
+			if got := slices.Contains(args, "-6"); got != tt.wantIPv6 {
+				t.Errorf("-6 given = %t, want %t", got, tt.wantIPv6)
+			}
+		})
+	}
+}
+
+func TestBuildArgsIPv6Prefix(t *testing.T) {
+	args := buildArgs("2001:db8::/120", &scanner.ScanConfig{Ports: "22"}, false)
+	if !slices.Contains(args, "-6") {
+		t.Errorf("args %v lack -6 for an IPv6 prefix", args)
+	}
+	if last := args[len(args)-1]; last != "2001:db8::/120" {
+		t.Errorf("target passed as %q, want the prefix unexpanded", last)
+	}
+
+	if args := buildArgs("192.0.2.0/24", &scanner.ScanConfig{Ports: "22"}, false); slices.Contains(args, "-6") {
+		t.Errorf("args %v have -6 for an IPv4 target", args)
+	}
+}
+
+func TestBuildArgsOpenOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		openOnly bool
+		states   []string
+		wantOpen bool
+		wantV    bool
+	}{
+		{"open only", true, nil, true, false},
+		{"all port states", false, nil, false, false},
+		{"down hosts reported", false, []string{"up", "down"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildArgs("192.0.2.0/24", &scanner.ScanConfig{OpenOnly: tt.openOnly, ReportedStates: tt.states}, false)
+			if got := slices.Contains(args, "--open"); got != tt.wantOpen {
+				t.Errorf("--open given = %t, want %t (args %v)", got, tt.wantOpen, args)
+			}
+			if got := slices.Contains(args, "-v"); got != tt.wantV {
+				t.Errorf("-v given = %t, want %t (args %v)", got, tt.wantV, args)
+			}
+		})
+	}
+}
+
+// parseFixture parses testdata/name, keeping hosts in every state
+func parseFixture(t *testing.T, name string) []*models.Host {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts, _, err := NewParser().parseNmapXML(data, &scanner.ScanConfig{ReportedStates: scanner.HostStates})
+	if err != nil {
+		t.Fatalf("parseNmapXML(%s) error = %v", name, err)
+	}
+	return hosts
+}
+
+// portSummary describes each port of a host with its service details
+func portSummary(host *models.Host) []string {
+	var ports []string
+	for _, port := range host.Ports {
+		ports = append(ports, fmt.Sprintf("%d/%s %s %s|%s|%s|%s",
+			port.Number, port.Protocol, port.State, port.Service, port.Product, port.Version, port.ExtraInfo))
+	}
+	return ports
+}
+
+func TestParseServices(t *testing.T) {
+	hosts := parseFixture(t, "services.xml")
+	if len(hosts) != 1 {
+		t.Fatalf("parsed %d hosts, want 1", len(hosts))
+	}
+
+	want := []string{
+		"22/tcp open ssh|OpenSSH|8.9p1 Ubuntu 3ubuntu0.6|Ubuntu Linux; protocol 2.0",
+		"80/tcp open http|nginx|1.18.0|Ubuntu",
+		"5432/tcp open postgresql|PostgreSQL DB|9.6.0 or later|",
+		"9999/tcp filtered abyss|||",
+	}
+	if got := portSummary(hosts[0]); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ports =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if hosts[0].Hostname != "web01.example.internal" {
+		t.Errorf("hostname = %q", hosts[0].Hostname)
+	}
+}
+
+func TestParseTruncatedOutput(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "truncated.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, _, err := NewParser().parseNmapXML(data, &scanner.ScanConfig{})
+	if !errors.Is(err, scanner.ErrTruncatedOutput) {
+		t.Fatalf("parseNmapXML() error = %v, want ErrTruncatedOutput", err)
+	}
+	var got []string
+	for _, host := range hosts {
+		got = append(got, fmt.Sprintf("%s %d ports", host.IPAddress, len(host.Ports)))
+	}
+	if want := []string{"192.0.2.1 1 ports", "192.0.2.2 2 ports"}; !slices.Equal(got, want) {
+		t.Errorf("hosts before the cut = %v, want %v", got, want)
+	}
+
+	graphs, err := NewParser().ParseRaw(data)
+	if !errors.Is(err, scanner.ErrTruncatedOutput) || len(graphs) != 2 {
+		t.Errorf("ParseRaw() = %d hosts, %v; want 2 and ErrTruncatedOutput", len(graphs), err)
+	}
+}
+
+func TestParseUDPPortStates(t *testing.T) {
+	hosts := parseFixture(t, "udp.xml")
+	if len(hosts) != 1 {
+		t.Fatalf("parsed %d hosts, want 1", len(hosts))
+	}
+
+	// Unanswered UDP ports stay open|filtered rather than being read as open
+	want := []string{
+		"53/udp open domain|ISC BIND|9.18.18|",
+		"123/udp open|filtered ntp|||",
+		"161/udp open|filtered snmp|||",
+		"500/udp closed isakmp|||",
+	}
+	if got := portSummary(hosts[0]); !slices.Equal(got, want) {
+		t.Errorf("ports = %v, want %v", got, want)
+	}
+	if got := hosts[0].Ports[1].Reason; got != "no-response" {
+		t.Errorf("open|filtered port reason = %q, want no-response", got)
+	}
+}
+
+func TestParseHostAddresses(t *testing.T) {
+	hosts := parseFixture(t, "dualstack.xml")
+	if len(hosts) != 2 {
+		t.Fatalf("parsed %d hosts, want 2", len(hosts))
+	}
+
+	tests := []struct {
+		name                  string
+		host                  *models.Host
+		ip, ipv6, mac, vendor string
+	}{
+		{"dual stack", hosts[0], "192.0.2.20", "2001:db8::20", "00:1A:2B:3C:4D:5E", "Dell"},
+		{"IPv6 only", hosts[1], "2001:db8::30", "2001:db8::30", "00:1A:2B:3C:4D:5F", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.host
+			if h.IPAddress != tt.ip || h.IPv6Address != tt.ipv6 || h.MACAddress != tt.mac || h.Vendor != tt.vendor {
+				t.Errorf("addresses = %q, %q, %q, %q; want %q, %q, %q, %q",
+					h.IPAddress, h.IPv6Address, h.MACAddress, h.Vendor, tt.ip, tt.ipv6, tt.mac, tt.vendor)
+			}
+		})
+	}
+}
+
+func TestParseHostScripts(t *testing.T) {
+	hosts := parseFixture(t, "smb.xml")
+	if len(hosts) == 0 || len(hosts[0].HostScripts) != 1 {
+		t.Fatalf("parsed %d hosts without the smb-os-discovery host script", len(hosts))
+	}
+
+	script := hosts[0].HostScripts[0]
+	if script.ID != "smb-os-discovery" {
+		t.Errorf("host script = %q, want smb-os-discovery", script.ID)
+	}
+	if !strings.HasPrefix(script.Output, "OS: Windows Server 2019 Standard 17763") {
+		t.Errorf("output = %q, want it trimmed", script.Output)
+	}
+	want := map[string]string{
+		"os":         "Windows Server 2019 Standard 17763",
+		"server":     "DC01", // NUL terminator dropped
+		"fqdn":       "DC01.corp.example.com",
+		"domain_dns": "corp.example.com",
+		"workgroup":  "CORP",
+	}
+	for key, value := range want {
+		if got := script.Elements[key]; got != value {
+			t.Errorf("element %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestParseSMBNames(t *testing.T) {
+	hosts := parseFixture(t, "smb.xml")
+	if len(hosts) != 3 {
+		t.Fatalf("parsed %d hosts, want 3", len(hosts))
+	}
+
+	tests := []struct {
+		name                           string
+		host                           *models.Host
+		netbios, domain, workgroup, os string
+	}{
+		{"domain joined", hosts[0], "DC01", "corp.example.com", "CORP", "Windows Server 2019 Standard 17763"},
+		// OS detection takes precedence over the SMB-reported OS
+		{"workgroup", hosts[1], "FILESRV", "", "WORKGROUP", "Microsoft Windows 10 1809 - 21H2"},
+		{"nbstat only", hosts[2], "PRINTSRV", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.host
+			if h.NetBIOSName != tt.netbios || h.Domain != tt.domain || h.Workgroup != tt.workgroup || h.OS != tt.os {
+				t.Errorf("names = %q, %q, %q, %q; want %q, %q, %q, %q",
+					h.NetBIOSName, h.Domain, h.Workgroup, h.OS, tt.netbios, tt.domain, tt.workgroup, tt.os)
+			}
+		})
+	}
+}
+
+func TestParseLoadBalancerHint(t *testing.T) {
+	want := map[string]bool{
+		"192.0.2.60": true,  // Two interleaved counters
+		"192.0.2.61": true,  // ipidseq could not fit one pattern
+		"192.0.2.62": false, // One incrementing counter
+		"192.0.2.63": false, // Byte-swapped counter of a single machine
+		"192.0.2.64": false,
+	}
+
+	hosts := parseFixture(t, "ipidseq.xml")
+	if len(hosts) != len(want) {
+		t.Fatalf("parsed %d hosts, want %d", len(hosts), len(want))
+	}
+	for _, host := range hosts {
+		if host.LoadBalanced != want[host.IPAddress] {
+			t.Errorf("host %s LoadBalanced = %t, want %t", host.IPAddress, host.LoadBalanced, want[host.IPAddress])
+		}
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "uptime.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanned := time.Date(2023, 11, 14, 22, 0, 0, 0, time.UTC)
+	parser := NewParser()
+	parser.SetClock(clock.NewMock(scanned))
+
+	hosts, _, err := parser.parseNmapXML(data, &scanner.ScanConfig{})
+	if err != nil {
+		t.Fatalf("parseNmapXML() error = %v", err)
+	}
+	if len(hosts) != 3 {
+		t.Fatalf("parsed %d hosts, want 3", len(hosts))
+	}
+
+	// lastboot is printed in the scanner's local time zone
+	printed, _ := time.ParseInLocation(time.ANSIC, "Sat Oct  8 22:13:20 2022", time.Local)
+	tests := []struct {
+		name     string
+		host     *models.Host
+		seconds  int64
+		lastBoot time.Time
+	}{
+		{"printed boot time", hosts[0], 34560000, printed},
+		{"boot time derived", hosts[1], 3600, scanned.Add(-time.Hour)},
+		{"no uptime", hosts[2], 0, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lastBoot time.Time
+			if tt.host.LastBoot != nil {
+				lastBoot = *tt.host.LastBoot
+			}
+			if tt.host.UptimeSeconds != tt.seconds || !lastBoot.Equal(tt.lastBoot) {
+				t.Errorf("uptime = %d, last boot %v; want %d, %v", tt.host.UptimeSeconds, lastBoot, tt.seconds, tt.lastBoot)
+			}
+		})
+	}
+}
+
+func TestParsePortReasons(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string
+	}{
+		{"services.xml", []string{"22 syn-ack 63", "80 syn-ack 63", "5432 syn-ack 63", "9999 no-response 0"}},
+		{"udp.xml", []string{"53 udp-response 64", "123 no-response 0", "161 no-response 0", "500 port-unreach 64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			var got []string
+			for _, port := range parseFixture(t, tt.fixture)[0].Ports {
+				got = append(got, fmt.Sprintf("%d %s %d", port.Number, port.Reason, port.ReasonTTL))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("port reasons = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildArgsVulnMode(t *testing.T) {
+	scripts, err := scanner.ModeScripts(scanner.ModeVuln, []string{"vulners", "http-enum", "ssl-*"})
+	if err != nil {
+		t.Fatalf("ModeScripts() error = %v", err)
+	}
+	args := buildArgs("192.0.2.80", &scanner.ScanConfig{Scripts: scripts}, false)
+	if got := flagValue(args, "--script"); got != "vulners,http-enum,ssl-*" {
+		t.Errorf("--script %q, want the configured vuln scripts (args %v)", got, args)
+	}
+
+	if args := buildArgs("192.0.2.80", &scanner.ScanConfig{}, false); slices.Contains(args, "--script") {
+		t.Errorf("default mode args %v run scripts", args)
+	}
+}
+
+func TestParseVulnerabilities(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "vulns.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graphs, err := NewParser().ParseRaw(data)
+	if err != nil || len(graphs) != 1 {
+		t.Fatalf("ParseRaw() = %d hosts, %v; want 1", len(graphs), err)
+	}
+
+	// The rows SaveScanGraph stores for each port
+	want := map[int][]string{
+		22: {"CVE-2023-38408 critical", "CVE-2016-10009 high"},
+		80: {" low Exposed path /admin/: Possible admin folder", " low Exposed path /robots.txt: Robots file"},
+		// ssl-ccs-injection reported the port not vulnerable
+		443: {"CVE-2014-0160 high"},
+	}
+	for _, port := range graphs[0].Ports {
+		var got []string
+		for _, vuln := range port.Vulnerabilities {
+			row := vuln.CVE + " " + vuln.Severity
+			if vuln.CVE == "" {
+				row += " " + vuln.Description
+			}
+			got = append(got, row)
+		}
+		if !slices.Equal(got, want[port.Number]) {
+			t.Errorf("port %d vulnerabilities = %q, want %q", port.Number, got, want[port.Number])
+		}
+		if !slices.Equal(port.Port.Vulnerabilities, port.Vulnerabilities) {
+			t.Errorf("port %d vulnerabilities differ between the port and its graph", port.Number)
+		}
+	}
+
+	heartbleed := graphs[0].Ports[2].Vulnerabilities[0]
+	if !strings.HasPrefix(heartbleed.Description, "ssl-heartbleed: The Heartbleed Bug") || !strings.Contains(heartbleed.ReferenceLinks, "secadv_20140407") {
+		t.Errorf("heartbleed = %q, refs %q", heartbleed.Description, heartbleed.ReferenceLinks)
+	}
+}
+
+func TestParseMaxHosts(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "overcap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		maxHosts int
+		want     []string
+		found    int
+		capped   bool
+	}{
+		// The down host is not reported, so it does not count against the cap
+		{"over the cap", 2, []string{"192.0.2.1", "192.0.2.3"}, 4, true},
+		{"at the cap", 4, []string{"192.0.2.1", "192.0.2.3", "192.0.2.4", "192.0.2.5"}, 4, false},
+		{"no cap", 0, []string{"192.0.2.1", "192.0.2.3", "192.0.2.4", "192.0.2.5"}, 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, found, err := NewParser().parseNmapXML(data, &scanner.ScanConfig{MaxHosts: tt.maxHosts})
+			if got := errors.Is(err, scanner.ErrMaxHostsExceeded); got != tt.capped || (!tt.capped && err != nil) {
+				t.Fatalf("parseNmapXML() error = %v, want capped %t", err, tt.capped)
+			}
+			var got []string
+			for _, host := range hosts {
+				got = append(got, host.IPAddress)
+			}
+			if !slices.Equal(got, tt.want) || found != tt.found {
+				t.Errorf("kept %v of %d hosts, want %v of %d", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestParseReportedStates(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "hoststates.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		states []string
+		want   []string
+	}{
+		{"default", nil, []string{"192.0.2.20 up", "192.0.2.23 up"}},
+		{"up", []string{"up"}, []string{"192.0.2.20 up", "192.0.2.23 up"}},
+		{"up and down", []string{"up", "down"}, []string{"192.0.2.20 up", "192.0.2.21 down", "192.0.2.22 down", "192.0.2.23 up"}},
+		{"down only", []string{"down"}, []string{"192.0.2.21 down", "192.0.2.22 down"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, found, err := NewParser().parseNmapXML(data, &scanner.ScanConfig{ReportedStates: tt.states})
+			if err != nil {
+				t.Fatalf("parseNmapXML() error = %v", err)
+			}
+			var got []string
+			for _, host := range hosts {
+				got = append(got, host.IPAddress+" "+host.Status)
+			}
+			if !slices.Equal(got, tt.want) || found != len(tt.want) {
+				t.Errorf("reported %v (%d found), want %v", got, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfigReportedStates(t *testing.T) {
+	tests := []struct {
+		name     string
+		openOnly bool
+		states   []string
+		wantErr  bool
+	}{
+		{"default", true, nil, false},
+		{"down hosts with all ports", false, []string{"up", "down"}, false},
+		{"down hosts with --open", true, []string{"up", "down"}, true},
+		{"unknown state", false, []string{"up", "asleep"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Scanner{}).ValidateConfig(&scanner.ScanConfig{Ports: "22", OpenOnly: tt.openOnly, ReportedStates: tt.states})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildArgsDNS(t *testing.T) {
+	tests := []struct {
+		name        string
+		servers     []string
+		noDNS       bool
+		wantServers string
+		wantNoDNS   bool
+	}{
+		{"system resolver", nil, false, "", false},
+		{"one server", []string{"10.0.0.53"}, false, "10.0.0.53", false},
+		{"split-horizon servers", []string{"10.0.0.53", "2001:db8::53"}, false, "10.0.0.53,2001:db8::53", false},
+		{"no DNS", nil, true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildArgs("192.0.2.0/24", &scanner.ScanConfig{Ports: "22", DNSServers: tt.servers, NoDNS: tt.noDNS}, false)
+			if got := flagValue(args, "--dns-servers"); got != tt.wantServers {
+				t.Errorf("--dns-servers %q, want %q (args %v)", got, tt.wantServers, args)
+			}
+			if got := slices.Contains(args, "-n"); got != tt.wantNoDNS {
+				t.Errorf("-n given = %t, want %t (args %v)", got, tt.wantNoDNS, args)
+			}
+			if args[len(args)-1] != "192.0.2.0/24" {
+				t.Errorf("args %v do not end with the target", args)
+			}
+		})
+	}
+}
+
+func TestValidateConfigDNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []string
+		noDNS   bool
+		wantErr bool
+	}{
+		{"IPv4 server", []string{"10.0.0.53"}, false, false},
+		{"IPv6 server", []string{"2001:db8::53"}, false, false},
+		{"no DNS", nil, true, false},
+		{"hostname", []string{"ns1.example.com"}, false, true},
+		{"server with port", []string{"10.0.0.53:53"}, false, true},
+		{"CIDR", []string{"10.0.0.0/24"}, false, true},
+		{"one invalid among valid", []string{"10.0.0.53", "10.0.0.300"}, false, true},
+		{"empty entry", []string{""}, false, true},
+		{"servers with no DNS", []string{"10.0.0.53"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Scanner{}).ValidateConfig(&scanner.ScanConfig{Ports: "22", DNSServers: tt.servers, NoDNS: tt.noDNS})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fixtureRunner answers with a fixture, failing with err, if set, or with
+// the context error once the context ends when wait is set
+type fixtureRunner struct {
+	output []byte
+	err    error
+	wait   bool
+}
+
+func (r *fixtureRunner) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+func (r *fixtureRunner) Output(ctx context.Context, _ string, _ ...string) ([]byte, error) {
+	if r.wait {
+		<-ctx.Done()
+		return r.output, ctx.Err()
+	}
+	return r.output, r.err
+}
+
+func TestScanCompleteness(t *testing.T) {
+	truncated, err := os.ReadFile(filepath.Join("testdata", "truncated.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	services, err := os.ReadFile(filepath.Join("testdata", "services.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The partial output reaches 192.0.2.2, the third of 16 addresses
+	tests := []struct {
+		name         string
+		target       string
+		runner       *fixtureRunner
+		cancel       bool
+		wantStatus   string
+		wantComplete bool
+		wantCoverage float64
+	}{
+		{"timeout", "192.0.2.0/28", &fixtureRunner{output: truncated, wait: true}, false, scanner.StatusTimeout, false, 3.0 / 16},
+		{"cancelled", "192.0.2.0/28", &fixtureRunner{output: truncated, wait: true}, true, scanner.StatusCancelled, false, 3.0 / 16},
+		{"failed", "192.0.2.0/28", &fixtureRunner{err: errors.New("exit status 1")}, false, scanner.StatusFailed, false, 0},
+		{"truncated output", "192.0.2.0/28", &fixtureRunner{output: truncated}, false, scanner.StatusCompletedWithErrors, false, 3.0 / 16},
+		{"completed", "192.0.2.10", &fixtureRunner{output: services}, false, scanner.StatusCompleted, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScannerWithRunner(tt.runner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			result, _ := s.Scan(ctx, tt.target, &scanner.ScanConfig{Ports: "1-1000"})
+			if result == nil {
+				t.Fatal("Scan() returned no result")
+			}
+			if result.Status != tt.wantStatus || result.Complete != tt.wantComplete || result.Coverage != tt.wantCoverage {
+				t.Errorf("Scan() = %s, complete %t, coverage %v; want %s, %t, %v",
+					result.Status, result.Complete, result.Coverage, tt.wantStatus, tt.wantComplete, tt.wantCoverage)
+			}
+		})
+	}
+}
+
+// scanTypeArgs returns the scan type flags in args, in order
+func scanTypeArgs(args []string) []string {
+	var types []string
+	for _, arg := range args {
+		if arg == "-sS" || arg == "-sT" || arg == "-sU" {
+			types = append(types, arg)
+		}
+	}
+	return types
+}
+
+func TestBuildArgsProtocol(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        scanner.ScanConfig
+		connect       bool
+		wantTypes     []string
+		wantIntensity string
+	}{
+		{"default SYN scan", scanner.ScanConfig{}, false, nil, ""},
+		{"tcp", scanner.ScanConfig{Protocol: scanner.ProtocolTCP}, false, nil, ""},
+		{"tcp without root", scanner.ScanConfig{Protocol: scanner.ProtocolTCP}, true, []string{"-sT"}, ""},
+		{"udp", scanner.ScanConfig{Protocol: scanner.ProtocolUDP}, false, []string{"-sU"}, "0"},
+		{"older UDP flag", scanner.ScanConfig{UDP: true}, false, []string{"-sU"}, "0"},
+		{"udp with an intensity", scanner.ScanConfig{Protocol: scanner.ProtocolUDP, VersionIntensity: 5}, false, []string{"-sU"}, "5"},
+		{"both", scanner.ScanConfig{Protocol: scanner.ProtocolBoth}, false, []string{"-sU", "-sS"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Ports = "53,161"
+			args := buildArgs("192.0.2.0/24", &tt.config, tt.connect)
+			if got := scanTypeArgs(args); !slices.Equal(got, tt.wantTypes) {
+				t.Errorf("scan types %v, want %v (args %v)", got, tt.wantTypes, args)
+			}
+			if got := flagValue(args, "--version-intensity"); got != tt.wantIntensity {
+				t.Errorf("--version-intensity %q, want %q", got, tt.wantIntensity)
+			}
+			if !slices.Contains(args, "-sV") {
+				t.Errorf("args %v lack service detection", args)
+			}
+		})
+	}
+}
+
+// unprivilegedRunner is a runner whose commands do not run as root
+type unprivilegedRunner struct {
+	fixtureRunner
+}
+
+func (unprivilegedRunner) Privileged(context.Context) (bool, error) {
+	return false, nil
+}
+
+func TestProtocolValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      scanner.ScanConfig
+		wantErr     bool
+		wantConnect bool
+		wantRefused bool
+	}{
+		{"tcp falls back to a connect scan", scanner.ScanConfig{Protocol: scanner.ProtocolTCP}, false, true, false},
+		{"udp needs root", scanner.ScanConfig{Protocol: scanner.ProtocolUDP}, false, false, true},
+		{"both needs root", scanner.ScanConfig{Protocol: scanner.ProtocolBoth}, false, false, true},
+		{"UDP flag with tcp", scanner.ScanConfig{UDP: true, Protocol: scanner.ProtocolTCP}, true, false, false},
+		{"unknown protocol", scanner.ScanConfig{Protocol: "sctp"}, true, false, false},
+	}
+
+	s := &Scanner{path: "/usr/bin/nmap", runner: &unprivilegedRunner{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Ports = "53"
+			if err := s.ValidateConfig(&tt.config); (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateConfig() error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			connect, err := s.connectScan(context.Background(), &tt.config)
+			if refused := errors.Is(err, scanner.ErrUnprivileged); refused != tt.wantRefused || connect != tt.wantConnect {
+				t.Errorf("connectScan() = %t, %v; want connect %t, refused %t", connect, err, tt.wantConnect, tt.wantRefused)
+			}
+		})
+	}
+}
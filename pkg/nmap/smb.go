@@ -0,0 +1,62 @@
+package nmap
+
+import (
+	"regexp"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// Host scripts carrying Windows naming information
+const (
+	scriptSMBOSDiscovery = "smb-os-discovery"
+	scriptNBStat         = "nbstat"
+)
+
+var nbstatNameRegex = regexp.MustCompile(`NetBIOS name: ([^,\s]+)`)
+
+// applySMBInfo copies the NetBIOS name, domain, workgroup and SMB-reported
+// OS from smb-os-discovery and nbstat results into the host fields.
+// smb-os-discovery takes precedence over nbstat.
+func applySMBInfo(host *models.Host) {
+	for _, script := range host.HostScripts {
+		if script.ID != scriptSMBOSDiscovery {
+			continue
+		}
+
+		host.NetBIOSName = script.Elements["server"]
+		if host.NetBIOSName == "" {
+			host.NetBIOSName = script.Elements["fqdn"]
+		}
+
+		// Domain members report a DNS domain; standalone hosts only a workgroup
+		if dnsDomain := script.Elements["domain_dns"]; dnsDomain != "" {
+			host.Domain = dnsDomain
+		} else if script.Elements["workgroup"] == "" {
+			host.Domain = script.Elements["domain"]
+		}
+		host.Workgroup = script.Elements["workgroup"]
+		if host.Workgroup == "" {
+			host.Workgroup = script.Elements["domain"]
+		}
+
+		if host.OS == "" {
+			host.OS = script.Elements["os"]
+		}
+	}
+
+	if host.NetBIOSName != "" {
+		return
+	}
+	for _, script := range host.HostScripts {
+		if script.ID != scriptNBStat {
+			continue
+		}
+
+		host.NetBIOSName = script.Elements["server_name"]
+		if host.NetBIOSName == "" {
+			if m := nbstatNameRegex.FindStringSubmatch(script.Output); m != nil {
+				host.NetBIOSName = m[1]
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package nmap
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/netrecon/toolkit/internal/models"
+)
+
+// Port scripts whose results are stored as vulnerabilities. Scripts built
+// on nmap's vulns library, such as ssl-heartbleed, are recognized by the
+// shape of their output instead of by name.
+const (
+	scriptVulners  = "vulners"
+	scriptHTTPEnum = "http-enum"
+)
+
+// scriptNameRegex matches script names, categories and wildcard patterns
+// such as ssl-*, but not file paths or boolean expressions
+var scriptNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.*-]*$`)
+
+// httpEnumLineRegex matches an http-enum result such as "/admin/: Possible admin folder"
+var httpEnumLineRegex = regexp.MustCompile(`^(/\S*):\s*(.+)$`)
+
+// portVulnerabilities collects the vulnerabilities reported by scripts run
+// against a port
+func (s *Scanner) portVulnerabilities(port NmapPort) []*models.Vulnerability {
+	var vulns []*models.Vulnerability
+	seen := make(map[string]bool)
+	add := func(vuln *models.Vulnerability) {
+		key := vuln.CVE
+		if key == "" {
+			key = vuln.Description
+		}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		vuln.CreatedAt = s.clock.Now()
+		vulns = append(vulns, vuln)
+	}
+
+	for _, script := range port.Scripts {
+		switch script.ID {
+		case scriptVulners:
+			for _, vuln := range vulnersVulnerabilities(script) {
+				add(vuln)
+			}
+		case scriptHTTPEnum:
+			for _, vuln := range httpEnumVulnerabilities(script) {
+				add(vuln)
+			}
+		default:
+			for _, vuln := range vulnsLibVulnerabilities(script) {
+				add(vuln)
+			}
+		}
+	}
+	return vulns
+}
+
+// vulnersVulnerabilities reads the CVEs vulners matched against each
+// detected CPE. Exploit and advisory entries repeat those CVEs and are skipped.
+func vulnersVulnerabilities(script NmapScript) []*models.Vulnerability {
+	var vulns []*models.Vulnerability
+	for _, cpe := range script.Tables {
+		for _, entry := range cpe.Tables {
+			fields := tableElements(entry)
+			id := fields["id"]
+			if !strings.EqualFold(fields["type"], "cve") || id == "" {
+				continue
+			}
+			cvss, _ := strconv.ParseFloat(fields["cvss"], 64)
+			vulns = append(vulns, &models.Vulnerability{
+				CVE:            id,
+				Severity:       cvssSeverity(cvss),
+				Description:    fmt.Sprintf("%s (CVSS %s) affects %s", id, fields["cvss"], cpe.Key),
+				ReferenceLinks: "https://vulners.com/cve/" + id,
+			})
+		}
+	}
+	return vulns
+}
+
+// vulnsLibVulnerabilities reads scripts built on nmap's vulns library,
+// which report each vulnerability as a table with a state. Only those
+// found vulnerable are kept.
+func vulnsLibVulnerabilities(script NmapScript) []*models.Vulnerability {
+	var vulns []*models.Vulnerability
+	for _, table := range script.Tables {
+		fields := tableElements(table)
+		if !vulnerableState(fields["state"]) {
+			continue
+		}
+
+		vuln := &models.Vulnerability{Severity: riskFactorSeverity(fields["risk_factor"])}
+		var description []string
+		if fields["title"] != "" {
+			description = append(description, fields["title"])
+		}
+		for _, sub := range table.Tables {
+			values := tableValues(sub)
+			switch sub.Key {
+			case "ids":
+				for _, id := range values {
+					if cve, ok := strings.CutPrefix(id, "CVE:"); ok && vuln.CVE == "" {
+						vuln.CVE = cve
+					}
+				}
+			case "description":
+				description = append(description, values...)
+			case "refs":
+				vuln.ReferenceLinks = strings.Join(values, "\n")
+			}
+		}
+		if vuln.CVE == "" && strings.HasPrefix(table.Key, "CVE-") {
+			vuln.CVE = table.Key
+		}
+		vuln.Description = fmt.Sprintf("%s: %s", script.ID, strings.Join(description, "\n"))
+		vulns = append(vulns, vuln)
+	}
+	return vulns
+}
+
+// httpEnumVulnerabilities reports each path http-enum found as a low
+// severity exposure. The script only prints text, one path per line.
+func httpEnumVulnerabilities(script NmapScript) []*models.Vulnerability {
+	var vulns []*models.Vulnerability
+	for _, line := range strings.Split(script.Output, "\n") {
+		m := httpEnumLineRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		vulns = append(vulns, &models.Vulnerability{
+			Severity:    "low",
+			Description: fmt.Sprintf("Exposed path %s: %s", m[1], strings.TrimSpace(m[2])),
+		})
+	}
+	return vulns
+}
+
+// vulnerableState reports whether a vulns library state, such as
+// "VULNERABLE (Exploitable)" or "LIKELY VULNERABLE", confirms the issue
+func vulnerableState(state string) bool {
+	state = strings.ToUpper(state)
+	return strings.Contains(state, "VULNERABLE") && !strings.HasPrefix(state, "NOT ")
+}
+
+// tableElements returns the keyed elements of a script table
+func tableElements(table NmapTable) map[string]string {
+	fields := make(map[string]string, len(table.Elements))
+	for _, elem := range table.Elements {
+		if elem.Key != "" {
+			fields[elem.Key] = strings.TrimSpace(elem.Value)
+		}
+	}
+	return fields
+}
+
+// tableValues returns the non-empty unkeyed elements of a script table
+func tableValues(table NmapTable) []string {
+	var values []string
+	for _, elem := range table.Elements {
+		if value := strings.TrimSpace(elem.Value); elem.Key == "" && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// cvssSeverity rates a CVSS score on the CVSS v3 qualitative scale. Scores
+// below 4, none included, are stored as low.
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// riskFactorSeverity converts a vulns library risk factor, treating a
+// missing one as medium
+func riskFactorSeverity(risk string) string {
+	switch strings.ToLower(risk) {
+	case "critical":
+		return "critical"
+	case "high":
+		return "high"
+	case "low", "none":
+		return "low"
+	default:
+		return "medium"
+	}
+}
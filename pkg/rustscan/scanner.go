@@ -0,0 +1,362 @@
+package rustscan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/netrecon/toolkit/internal/clock"
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+	"github.com/netrecon/toolkit/internal/services"
+)
+
+// DefaultBatchSize is the number of ports probed at once when
+// ScanConfig.Threads is unset, RustScan's own default
+const DefaultBatchSize = 4500
+
+// MaxBatchSize is the largest batch size accepted, one socket per port
+const MaxBatchSize = 65535
+
+// MaxListedPorts limits port specifications other than a single range,
+// which are passed to RustScan as one comma separated list
+const MaxListedPorts = 10000
+
+// Scanner implements the RustScan scanner
+type Scanner struct {
+	path   string
+	clock  clock.Clock
+	runner scanner.CommandRunner
+}
+
+// NewScanner creates a new RustScan scanner running the local binary
+func NewScanner() (*Scanner, error) {
+	return NewScannerWithRunner(scanner.LocalRunner{})
+}
+
+// NewScannerWithRunner creates a RustScan scanner that executes through
+// runner, for example on a remote jump host
+func NewScannerWithRunner(runner scanner.CommandRunner) (*Scanner, error) {
+	path, err := runner.LookPath("rustscan")
+	if err != nil {
+		return nil, fmt.Errorf("rustscan not found in PATH: %w", err)
+	}
+
+	return &Scanner{path: path, clock: clock.Real{}, runner: runner}, nil
+}
+
+// NewParser creates a scanner that only parses RustScan output, for use
+// where the binary is not installed; Scan is not available
+func NewParser() *Scanner {
+	return &Scanner{clock: clock.Real{}}
+}
+
+// SetClock replaces the clock used for scan timestamps
+func (s *Scanner) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// GetName returns the scanner name
+func (s *Scanner) GetName() string {
+	return "rustscan"
+}
+
+// Capabilities reports the features available through RustScan
+func (s *Scanner) Capabilities() scanner.Capabilities {
+	// Greppable output lists open TCP ports only; RustScan hands service
+	// and OS detection to nmap, which -g turns off
+	return scanner.Capabilities{
+		TCP:  true,
+		IPv6: true,
+	}
+}
+
+// BinaryPath returns the path of the rustscan binary
+func (s *Scanner) BinaryPath() string {
+	return s.path
+}
+
+var versionRegex = regexp.MustCompile(`(?i)\brustscan\s+v?(\d\S*)`)
+
+// Version runs "rustscan --version" and returns the reported version
+func (s *Scanner) Version(ctx context.Context) (string, error) {
+	output, err := s.runner.Output(ctx, s.path, "--version")
+	if err != nil {
+		return "", fmt.Errorf("failed to run rustscan --version: %w", err)
+	}
+	// RustScan prints "rustscan 2.1.1" rather than "version 2.1.1"
+	if m := versionRegex.FindSubmatch(output); m != nil {
+		return string(m[1]), nil
+	}
+	if version := scanner.ParseVersionOutput(output); version != "" {
+		return version, nil
+	}
+	return "", fmt.Errorf("unrecognized rustscan version output")
+}
+
+// ValidateConfig validates the RustScan configuration
+func (s *Scanner) ValidateConfig(config *scanner.ScanConfig) error {
+	if config.Ports != "" {
+		if err := scanner.ValidatePortSpec(config.Ports); err != nil {
+			return err
+		}
+		if _, err := portArgs(config.Ports); err != nil {
+			return err
+		}
+	}
+
+	if config.Threads < 0 || config.Threads > MaxBatchSize {
+		return fmt.Errorf("invalid batch size: %d (must be between 0 and %d)", config.Threads, MaxBatchSize)
+	}
+
+	if err := config.ValidateProtocol(); err != nil {
+		return err
+	}
+	if config.ScansUDP() {
+		return fmt.Errorf("rustscan scans TCP only; use nmap for UDP")
+	}
+
+	if len(config.Scripts) > 0 {
+		return fmt.Errorf("rustscan cannot run scripts; use nmap")
+	}
+
+	// Only hosts with open ports are reported, so every host is up
+	if err := scanner.ValidateReportedStates(config.ReportedStates); err != nil {
+		return err
+	}
+
+	if err := scanner.ValidateExclusions(config.Exclude); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Scan performs a RustScan scan
+func (s *Scanner) Scan(ctx context.Context, target string, config *scanner.ScanConfig) (*scanner.ScanResult, error) {
+	if err := s.ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	args, err := buildArgs(target, config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	startTime := s.clock.Now()
+	output, err := s.runner.Output(ctx, s.path, args...)
+	endTime := s.clock.Now()
+
+	result := &scanner.ScanResult{
+		Target:     target,
+		Scanner:    s.GetName(),
+		Status:     scanner.StatusCompleted,
+		StartTime:  startTime.Format(time.RFC3339),
+		EndTime:    endTime.Format(time.RFC3339),
+		DurationMs: endTime.Sub(startTime).Milliseconds(),
+		Command:    strings.Join(append([]string{s.path}, args...), " "),
+		RawOutput:  string(output),
+	}
+
+	if err != nil {
+		result.Status = scanner.StatusForError(ctx)
+		result.Error = err.Error()
+		if result.Status != scanner.StatusFailed {
+			// Keep the hosts RustScan wrote before it was stopped
+			result.Hosts, _, _ = s.parseGreppable(output, config.MaxHosts)
+		}
+		result.MarkCompleteness(scanner.TargetProgress(target, result.Hosts))
+		return result, err
+	}
+
+	hosts, found, parseErr := s.parseGreppable(output, config.MaxHosts)
+	switch {
+	case errors.Is(parseErr, scanner.ErrMaxHostsExceeded):
+		result.Status = scanner.StatusCompletedWithErrors
+		result.Hosts = hosts
+		result.Error = fmt.Sprintf("results truncated to %d of %d hosts: %v", len(hosts), found, parseErr)
+		result.MarkCompleteness(float64(len(hosts)), float64(found))
+	default:
+		result.Hosts = hosts
+		result.MarkCompleteness(1, 1)
+	}
+
+	return result, nil
+}
+
+// buildArgs builds the rustscan command line. Targets are passed as one
+// comma separated address list, and -g prints only the open ports found,
+// without handing them to nmap. RustScan cannot skip addresses, so the
+// do-not-scan list is subtracted from the targets beforehand.
+func buildArgs(target string, config *scanner.ScanConfig) ([]string, error) {
+	targets := scanner.SubtractExclusions(scanner.SplitTargets(target), config.Exclude)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("every target of %s is on the do-not-scan list", target)
+	}
+	args := []string{"-a", strings.Join(targets, ","), "--accessible", "-g"}
+
+	if config.Ports != "" {
+		ports, err := portArgs(config.Ports)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, ports...)
+	}
+
+	batchSize := config.Threads
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	args = append(args, "-b", strconv.Itoa(batchSize))
+
+	// Additional arguments
+	if config.Arguments != "" {
+		args = append(args, strings.Fields(config.Arguments)...)
+	}
+
+	return args, nil
+}
+
+// portArgs converts a port specification into RustScan's port flags: -r for
+// a single range, otherwise -p with every port listed, as RustScan accepts
+// either a range or a list but not a mix of both
+func portArgs(ports string) ([]string, error) {
+	ranges, count, err := scanner.ParsePortRanges(ports)
+	if err != nil {
+		return nil, err
+	}
+	if len(ranges) == 1 && ranges[0].Low != ranges[0].High {
+		return []string{"-r", ranges[0].String()}, nil
+	}
+	if count > MaxListedPorts {
+		return nil, fmt.Errorf("rustscan takes one port range or a list of at most %d ports, got %d ports in %d ranges",
+			MaxListedPorts, count, len(ranges))
+	}
+
+	var listed []string
+	for _, r := range ranges {
+		for port := r.Low; port <= r.High; port++ {
+			listed = append(listed, strconv.Itoa(port))
+		}
+	}
+	return []string{"-p", strings.Join(listed, ",")}, nil
+}
+
+// greppableLine matches RustScan's greppable output, one host per line such
+// as "192.168.1.1 -> [22,80,443]"
+var greppableLine = regexp.MustCompile(`^(\S+)\s+->\s+\[([\d,\s]*)\]$`)
+
+// parseGreppable parses RustScan greppable output and returns the hosts
+// along with the number of distinct hosts found. When more than maxHosts are
+// present only the first maxHosts are kept and scanner.ErrMaxHostsExceeded
+// is returned with them.
+func (s *Scanner) parseGreppable(output []byte, maxHosts int) ([]*models.Host, int, error) {
+	hostMap := make(map[string]*models.Host)
+	var order []*models.Host
+	var limitErr error
+
+	err := s.eachLine(output, func(ip string, ports []int) {
+		host, exists := hostMap[ip]
+		if !exists {
+			if maxHosts > 0 && len(order) >= maxHosts {
+				limitErr = fmt.Errorf("%w: more than %d hosts", scanner.ErrMaxHostsExceeded, maxHosts)
+				hostMap[ip] = nil
+				return
+			}
+			host = &models.Host{
+				ID:        uuid.New(),
+				IPAddress: ip,
+				Status:    "up",
+				CreatedAt: s.clock.Now(),
+			}
+			hostMap[ip] = host
+			order = append(order, host)
+		}
+		if host == nil {
+			return
+		}
+
+		for _, number := range ports {
+			host.Ports = append(host.Ports, s.newPort(host.ID, number))
+		}
+	})
+	if err != nil {
+		return order, len(hostMap), err
+	}
+
+	return order, len(hostMap), limitErr
+}
+
+// ParseRaw rebuilds hosts and their ports from stored RustScan output
+func (s *Scanner) ParseRaw(data []byte) ([]*models.HostGraph, error) {
+	hostMap := make(map[string]*models.HostGraph)
+	var hosts []*models.HostGraph
+
+	err := s.eachLine(data, func(ip string, ports []int) {
+		host, exists := hostMap[ip]
+		if !exists {
+			host = &models.HostGraph{Host: &models.Host{
+				ID:        uuid.New(),
+				IPAddress: ip,
+				Status:    "up",
+				CreatedAt: s.clock.Now(),
+			}}
+			hostMap[ip] = host
+			hosts = append(hosts, host)
+		}
+
+		for _, number := range ports {
+			host.Ports = append(host.Ports, &models.PortGraph{Port: s.newPort(host.ID, number)})
+		}
+	})
+
+	return hosts, err
+}
+
+// eachLine calls fn with the address and open ports of every host line in
+// greppable output. Other lines, such as warnings about the file limit,
+// are skipped.
+func (s *Scanner) eachLine(output []byte, fn func(ip string, ports []int)) error {
+	lines := bufio.NewScanner(bytes.NewReader(output))
+	for lines.Scan() {
+		m := greppableLine.FindStringSubmatch(strings.TrimSpace(lines.Text()))
+		if m == nil {
+			continue
+		}
+
+		var ports []int
+		for _, field := range strings.Split(m[2], ",") {
+			number, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				continue
+			}
+			ports = append(ports, number)
+		}
+		fn(m[1], ports)
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read rustscan output: %w", err)
+	}
+	return nil
+}
+
+// newPort creates an open TCP port, the only kind greppable output lists
+func (s *Scanner) newPort(hostID uuid.UUID, number int) *models.Port {
+	return &models.Port{
+		ID:        uuid.New(),
+		HostID:    hostID,
+		Number:    number,
+		Protocol:  "tcp",
+		State:     "open",
+		CreatedAt: s.clock.Now(),
+
+		GuessedService: services.ResolveService(number, "tcp"),
+	}
+}
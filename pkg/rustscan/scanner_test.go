@@ -0,0 +1,123 @@
+package rustscan
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/netrecon/toolkit/internal/models"
+	"github.com/netrecon/toolkit/internal/scanner"
+)
+
+// greppableOutput is rustscan -g output with the warnings RustScan prints
+// ahead of the results and a host reported twice, as happens when its
+// ports are found in different batches
+const greppableOutput = `[!] File limit is lower than default batch size. Consider upping with --ulimit.
+[~] The config file is expected to be at "/root/.rustscan.toml"
+192.0.2.1 -> [22,80]
+2001:db8::10 -> [443]
+192.0.2.2 -> [3306]
+192.0.2.1 -> [443, 8080]
+`
+
+// hostPorts maps each host address to its port numbers in order
+func hostPorts(hosts []*models.Host) map[string]string {
+	summary := make(map[string]string)
+	for _, host := range hosts {
+		var ports []string
+		for _, port := range host.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s %s %s", port.Number, port.Protocol, port.State, port.GuessedService))
+		}
+		summary[host.IPAddress] = strings.Join(ports, ", ")
+	}
+	return summary
+}
+
+func TestParseGreppable(t *testing.T) {
+	hosts, found, err := NewParser().parseGreppable([]byte(greppableOutput), 0)
+	if err != nil {
+		t.Fatalf("parseGreppable() error = %v", err)
+	}
+	if found != 3 || len(hosts) != 3 {
+		t.Fatalf("parsed %d hosts (%d found), want 3", len(hosts), found)
+	}
+
+	var order []string
+	for _, host := range hosts {
+		order = append(order, host.IPAddress)
+		if host.Status != "up" {
+			t.Errorf("host %s status = %q, want up", host.IPAddress, host.Status)
+		}
+		for _, port := range host.Ports {
+			if port.HostID != host.ID {
+				t.Errorf("port %d of %s belongs to host %s", port.Number, host.IPAddress, port.HostID)
+			}
+		}
+	}
+	if want := []string{"192.0.2.1", "2001:db8::10", "192.0.2.2"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hosts = %v, want %v in the order found", order, want)
+	}
+
+	want := map[string]string{
+		"192.0.2.1":    "22/tcp open ssh, 80/tcp open http, 443/tcp open https, 8080/tcp open http-proxy",
+		"2001:db8::10": "443/tcp open https",
+		"192.0.2.2":    "3306/tcp open mysql",
+	}
+	got := hostPorts(hosts)
+	for ip, ports := range want {
+		if got[ip] != ports {
+			t.Errorf("host %s ports = %q, want %q", ip, got[ip], ports)
+		}
+	}
+}
+
+func TestParseGreppableMaxHosts(t *testing.T) {
+	hosts, found, err := NewParser().parseGreppable([]byte(greppableOutput), 2)
+	if !errors.Is(err, scanner.ErrMaxHostsExceeded) {
+		t.Fatalf("parseGreppable() error = %v, want ErrMaxHostsExceeded", err)
+	}
+	if len(hosts) != 2 || found != 3 {
+		t.Fatalf("kept %d of %d hosts, want 2 of 3", len(hosts), found)
+	}
+	// The second line of a kept host still adds its ports
+	if got := len(hosts[0].Ports); got != 4 {
+		t.Errorf("kept host has %d ports, want 4", got)
+	}
+}
+
+func TestParseRaw(t *testing.T) {
+	graphs, err := NewParser().ParseRaw([]byte(greppableOutput))
+	if err != nil {
+		t.Fatalf("ParseRaw() error = %v", err)
+	}
+	if len(graphs) != 3 {
+		t.Fatalf("ParseRaw() returned %d hosts, want 3", len(graphs))
+	}
+	if got := len(graphs[0].Ports); got != 4 {
+		t.Errorf("host %s has %d ports, want 4", graphs[0].IPAddress, got)
+	}
+}
+
+func TestPortArgs(t *testing.T) {
+	tests := []struct {
+		ports   string
+		want    []string
+		wantErr bool
+	}{
+		{"1-1000", []string{"-r", "1-1000"}, false},
+		{"22", []string{"-p", "22"}, false},
+		{"22,80,8000-8002", []string{"-p", "22,80,8000,8001,8002"}, false},
+		{"1-5000,6000-20000", nil, true}, // Too many ports to list
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			got, err := portArgs(tt.ports)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("portArgs(%q) = %v, %v; want %v, error %t", tt.ports, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}